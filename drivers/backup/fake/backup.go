@@ -0,0 +1,459 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type backupServer struct {
+	api.UnimplementedBackupServer
+	s *Server
+}
+
+// createBackup stores a new Pending backup, s must be locked
+func (s *Server) createBackup(req *api.BackupCreateRequest, schedule *api.BackupInfo_BackupSchedule) (*api.Metadata, error) {
+	if _, err := s.get(kindBackupLocation, req.GetOrgId(), req.GetBackupLocation(), req.GetBackupLocationRef().GetUid()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid backup location: %v", err)
+	}
+	if _, err := s.get(kindCluster, req.GetOrgId(), req.GetCluster(), ""); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cluster: %v", err)
+	}
+	return s.create(kindBackup, req.CreateMetadata, func(meta *api.Metadata) interface{} {
+		return &api.BackupObject{
+			Metadata: meta,
+			BackupInfo: &api.BackupInfo{
+				BackupLocation:    req.GetBackupLocation(),
+				BackupLocationRef: req.GetBackupLocationRef(),
+				Cluster:           req.GetCluster(),
+				ClusterRef:        req.GetClusterRef(),
+				Namespaces:        req.GetNamespaces(),
+				LabelSelectors:    req.GetLabelSelectors(),
+				NsLabelSelectors:  req.GetNsLabelSelectors(),
+				PreExecRule:       req.GetPreExecRule(),
+				PostExecRule:      req.GetPostExecRule(),
+				IncludeResources:  req.GetIncludeResources(),
+				ResourceTypes:     req.GetResourceTypes(),
+				BackupSchedule:    schedule,
+				BackupPath:        fmt.Sprintf("%s/%s", meta.GetName(), meta.GetUid()),
+				Stage:             api.BackupInfo_Initial,
+				Status:            &api.BackupInfo_StatusInfo{Status: api.BackupInfo_StatusInfo_Pending},
+			},
+		}
+	})
+}
+
+// advanceBackup moves the backup one step further and returns false once it is gone, s must be locked
+func (s *Server) advanceBackup(r *record) bool {
+	backup := r.obj.(*api.BackupObject)
+	current := backup.GetStatus()
+	switch current.GetStatus() {
+	case api.BackupInfo_StatusInfo_Pending:
+		backup.Status = &api.BackupInfo_StatusInfo{Status: api.BackupInfo_StatusInfo_InProgress}
+		backup.Stage = api.BackupInfo_Volumes
+	case api.BackupInfo_StatusInfo_InProgress:
+		outcome, ok := s.backupOutcomes[r.meta.GetName()]
+		if !ok {
+			outcome = &api.BackupInfo_StatusInfo{Status: api.BackupInfo_StatusInfo_Success}
+		}
+		backup.Status = proto.Clone(outcome).(*api.BackupInfo_StatusInfo)
+		backup.Stage = api.BackupInfo_Final
+	case api.BackupInfo_StatusInfo_DeletePending:
+		if !s.holdDeletions {
+			backup.Status = &api.BackupInfo_StatusInfo{Status: api.BackupInfo_StatusInfo_Deleting}
+		}
+	case api.BackupInfo_StatusInfo_Deleting:
+		delete(s.objects[kindBackup], key(r.meta.GetOrgId(), r.meta.GetName()))
+		return false
+	}
+	r.meta.LastUpdateTime = types.TimestampNow()
+	return true
+}
+
+// deleteBackup starts the deletion of the backup, s must be locked
+func (s *Server) deleteBackup(r *record) {
+	backup := r.obj.(*api.BackupObject)
+	switch backup.GetStatus().GetStatus() {
+	case api.BackupInfo_StatusInfo_DeletePending, api.BackupInfo_StatusInfo_Deleting:
+	default:
+		backup.Status = &api.BackupInfo_StatusInfo{Status: api.BackupInfo_StatusInfo_DeletePending}
+	}
+}
+
+func (b *backupServer) Create(ctx context.Context, req *api.BackupCreateRequest) (*api.BackupCreateResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	if _, err := b.s.createBackup(req, nil); err != nil {
+		return nil, err
+	}
+	return &api.BackupCreateResponse{}, nil
+}
+
+func (b *backupServer) Update(ctx context.Context, req *api.BackupUpdateRequest) (*api.BackupUpdateResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	r, err := b.s.get(kindBackup, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	backup := r.obj.(*api.BackupObject)
+	backup.CloudCredential = req.GetCloudCredential()
+	backup.CloudCredentialRef = req.GetCloudCredentialRef()
+	return &api.BackupUpdateResponse{}, nil
+}
+
+func (b *backupServer) Enumerate(ctx context.Context, req *api.BackupEnumerateRequest) (*api.BackupEnumerateResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	records, total := page(b.s.list(kindBackup, req.GetOrgId()), req.EnumerateOptions)
+	resp := &api.BackupEnumerateResponse{TotalCount: total}
+	for _, r := range records {
+		resp.Backups = append(resp.Backups, proto.Clone(r.obj.(*api.BackupObject)).(*api.BackupObject))
+	}
+	resp.Complete = req.GetObjectIndex()+uint64(len(records)) >= total
+	return resp, nil
+}
+
+func (b *backupServer) Inspect(ctx context.Context, req *api.BackupInspectRequest) (*api.BackupInspectResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	r, err := b.s.get(kindBackup, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	if !b.s.advanceBackup(r) {
+		return nil, notFound(kindBackup, req.GetOrgId(), req.GetName())
+	}
+	return &api.BackupInspectResponse{Backup: proto.Clone(r.obj.(*api.BackupObject)).(*api.BackupObject)}, nil
+}
+
+func (b *backupServer) Delete(ctx context.Context, req *api.BackupDeleteRequest) (*api.BackupDeleteResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	r, err := b.s.get(kindBackup, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	b.s.deleteBackup(r)
+	return &api.BackupDeleteResponse{}, nil
+}
+
+type restoreServer struct {
+	api.UnimplementedRestoreServer
+	s *Server
+}
+
+func (rs *restoreServer) Create(ctx context.Context, req *api.RestoreCreateRequest) (*api.RestoreCreateResponse, error) {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+	backup, err := rs.s.get(kindBackup, req.GetOrgId(), req.GetBackup(), req.GetBackupRef().GetUid())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid backup: %v", err)
+	}
+	if _, err := rs.s.get(kindCluster, req.GetOrgId(), req.GetCluster(), ""); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cluster: %v", err)
+	}
+	_, err = rs.s.create(kindRestore, req.CreateMetadata, func(meta *api.Metadata) interface{} {
+		return &api.RestoreObject{
+			Metadata: meta,
+			RestoreInfo: &api.RestoreInfo{
+				Backup:              req.GetBackup(),
+				BackupRef:           req.GetBackupRef(),
+				BackupLocation:      backup.obj.(*api.BackupObject).GetBackupLocation(),
+				Cluster:             req.GetCluster(),
+				NamespaceMapping:    req.GetNamespaceMapping(),
+				StorageClassMapping: req.GetStorageClassMapping(),
+				ReplacePolicy:       req.GetReplacePolicy(),
+				IncludeResources:    req.GetIncludeResources(),
+				Status:              &api.RestoreInfo_StatusInfo{Status: api.RestoreInfo_StatusInfo_Pending},
+			},
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.RestoreCreateResponse{}, nil
+}
+
+// advanceRestore moves the restore one step further and returns false once it is gone, s must be locked
+func (s *Server) advanceRestore(r *record) bool {
+	restore := r.obj.(*api.RestoreObject)
+	switch restore.GetStatus().GetStatus() {
+	case api.RestoreInfo_StatusInfo_Pending:
+		restore.Status = &api.RestoreInfo_StatusInfo{Status: api.RestoreInfo_StatusInfo_InProgress}
+	case api.RestoreInfo_StatusInfo_InProgress:
+		outcome, ok := s.restoreOutcomes[r.meta.GetName()]
+		if !ok {
+			outcome = &api.RestoreInfo_StatusInfo{Status: api.RestoreInfo_StatusInfo_Success}
+		}
+		restore.Status = proto.Clone(outcome).(*api.RestoreInfo_StatusInfo)
+	case api.RestoreInfo_StatusInfo_Deleting:
+		delete(s.objects[kindRestore], key(r.meta.GetOrgId(), r.meta.GetName()))
+		return false
+	}
+	r.meta.LastUpdateTime = types.TimestampNow()
+	return true
+}
+
+func (rs *restoreServer) Enumerate(ctx context.Context, req *api.RestoreEnumerateRequest) (*api.RestoreEnumerateResponse, error) {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+	records, total := page(rs.s.list(kindRestore, req.GetOrgId()), req.EnumerateOptions)
+	resp := &api.RestoreEnumerateResponse{TotalCount: total}
+	for _, r := range records {
+		resp.Restores = append(resp.Restores, proto.Clone(r.obj.(*api.RestoreObject)).(*api.RestoreObject))
+	}
+	resp.Complete = req.GetObjectIndex()+uint64(len(records)) >= total
+	return resp, nil
+}
+
+func (rs *restoreServer) Inspect(ctx context.Context, req *api.RestoreInspectRequest) (*api.RestoreInspectResponse, error) {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+	r, err := rs.s.get(kindRestore, req.GetOrgId(), req.GetName(), "")
+	if err != nil {
+		return nil, err
+	}
+	if !rs.s.advanceRestore(r) {
+		return nil, notFound(kindRestore, req.GetOrgId(), req.GetName())
+	}
+	return &api.RestoreInspectResponse{Restore: proto.Clone(r.obj.(*api.RestoreObject)).(*api.RestoreObject)}, nil
+}
+
+func (rs *restoreServer) Delete(ctx context.Context, req *api.RestoreDeleteRequest) (*api.RestoreDeleteResponse, error) {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+	r, err := rs.s.get(kindRestore, req.GetOrgId(), req.GetName(), "")
+	if err != nil {
+		return nil, err
+	}
+	r.obj.(*api.RestoreObject).Status = &api.RestoreInfo_StatusInfo{Status: api.RestoreInfo_StatusInfo_Deleting}
+	return &api.RestoreDeleteResponse{}, nil
+}
+
+type backupScheduleServer struct {
+	api.UnimplementedBackupScheduleServer
+	s *Server
+}
+
+// policyType returns the key the backups of a schedule with the given policy are listed under
+func policyType(policy *api.SchedulePolicyInfo) string {
+	switch {
+	case policy.GetDaily() != nil:
+		return "daily"
+	case policy.GetWeekly() != nil:
+		return "weekly"
+	case policy.GetMonthly() != nil:
+		return "monthly"
+	}
+	return "interval"
+}
+
+func (b *backupScheduleServer) Create(ctx context.Context, req *api.BackupScheduleCreateRequest) (*api.BackupScheduleCreateResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	if _, err := b.s.get(kindSchedulePolicy, req.GetOrgId(), req.GetSchedulePolicy(), req.GetSchedulePolicyRef().GetUid()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid schedule policy: %v", err)
+	}
+	if _, err := b.s.get(kindBackupLocation, req.GetOrgId(), req.GetBackupLocation(), req.GetBackupLocationRef().GetUid()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid backup location: %v", err)
+	}
+	_, err := b.s.create(kindBackupSchedule, req.CreateMetadata, func(meta *api.Metadata) interface{} {
+		return &api.BackupScheduleObject{
+			Metadata: meta,
+			BackupScheduleInfo: &api.BackupScheduleInfo{
+				SchedulePolicy:    req.GetSchedulePolicy(),
+				SchedulePolicyRef: req.GetSchedulePolicyRef(),
+				ReclaimPolicy:     req.GetReclaimPolicy(),
+				BackupLocation:    req.GetBackupLocation(),
+				BackupLocationRef: req.GetBackupLocationRef(),
+				Cluster:           req.GetCluster(),
+				Namespaces:        req.GetNamespaces(),
+				LabelSelectors:    req.GetLabelSelectors(),
+				NsLabelSelectors:  req.GetNsLabelSelectors(),
+				PreExecRule:       req.GetPreExecRule(),
+				PostExecRule:      req.GetPostExecRule(),
+				IncludeResources:  req.GetIncludeResources(),
+				ResourceTypes:     req.GetResourceTypes(),
+				BackupStatus:      make(map[string]*api.BackupScheduleInfo_StatusInfoList),
+			},
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.BackupScheduleCreateResponse{}, nil
+}
+
+func (b *backupScheduleServer) Update(ctx context.Context, req *api.BackupScheduleUpdateRequest) (*api.BackupScheduleUpdateResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	r, err := b.s.get(kindBackupSchedule, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	schedule := r.obj.(*api.BackupScheduleObject)
+	schedule.Suspend = req.GetSuspend()
+	if req.GetSchedulePolicy() != "" {
+		schedule.SchedulePolicy = req.GetSchedulePolicy()
+		schedule.SchedulePolicyRef = req.GetSchedulePolicyRef()
+	}
+	return &api.BackupScheduleUpdateResponse{}, nil
+}
+
+// syncScheduleStatus updates the statuses of the backups of the schedule from the backups, s must be locked
+func (s *Server) syncScheduleStatus(schedule *api.BackupScheduleObject) {
+	for _, list := range schedule.GetBackupStatus() {
+		for _, info := range list.GetStatus() {
+			r, err := s.get(kindBackup, schedule.GetOrgId(), info.GetBackupName(), "")
+			if err != nil {
+				// the backup was deleted
+				info.Status = api.BackupScheduleInfo_StatusInfo_Deleting
+				continue
+			}
+			backupStatus := r.obj.(*api.BackupObject).GetStatus()
+			// the backup and backup schedule statuses share their values up to DeletePending
+			info.Status = api.BackupScheduleInfo_StatusInfo_Status(backupStatus.GetStatus())
+			if backupStatus.GetStatus() > api.BackupInfo_StatusInfo_DeletePending {
+				info.Status = api.BackupScheduleInfo_StatusInfo_Failed
+			}
+			info.Reason = backupStatus.GetReason()
+			if info.FinishTime == nil && info.Status != api.BackupScheduleInfo_StatusInfo_Pending &&
+				info.Status != api.BackupScheduleInfo_StatusInfo_InProgress {
+				info.FinishTime = types.TimestampNow()
+			}
+		}
+	}
+}
+
+func (b *backupScheduleServer) Enumerate(ctx context.Context, req *api.BackupScheduleEnumerateRequest) (*api.BackupScheduleEnumerateResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	resp := &api.BackupScheduleEnumerateResponse{}
+	for _, r := range b.s.list(kindBackupSchedule, req.GetOrgId()) {
+		schedule := r.obj.(*api.BackupScheduleObject)
+		b.s.syncScheduleStatus(schedule)
+		resp.BackupSchedules = append(resp.BackupSchedules, proto.Clone(schedule).(*api.BackupScheduleObject))
+	}
+	return resp, nil
+}
+
+func (b *backupScheduleServer) Inspect(ctx context.Context, req *api.BackupScheduleInspectRequest) (*api.BackupScheduleInspectResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	r, err := b.s.get(kindBackupSchedule, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	schedule := r.obj.(*api.BackupScheduleObject)
+	b.s.syncScheduleStatus(schedule)
+	return &api.BackupScheduleInspectResponse{BackupSchedule: proto.Clone(schedule).(*api.BackupScheduleObject)}, nil
+}
+
+// Delete removes the schedule and, if requested, starts the deletion of its backups
+func (b *backupScheduleServer) Delete(ctx context.Context, req *api.BackupScheduleDeleteRequest) (*api.BackupScheduleDeleteResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	r, err := b.s.get(kindBackupSchedule, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetDeleteBackups() {
+		for _, list := range r.obj.(*api.BackupScheduleObject).GetBackupStatus() {
+			for _, info := range list.GetStatus() {
+				if backup, err := b.s.get(kindBackup, req.GetOrgId(), info.GetBackupName(), ""); err == nil {
+					b.s.deleteBackup(backup)
+				}
+			}
+		}
+	}
+	delete(b.s.objects[kindBackupSchedule], key(req.GetOrgId(), req.GetName()))
+	return &api.BackupScheduleDeleteResponse{}, nil
+}
+
+// TriggerSchedule creates the next backup of the given backup schedule, the way its schedule policy
+// would, deletes the backups beyond the retain count of the policy and returns the name of the backup
+func (s *Server) TriggerSchedule(orgID, scheduleName string) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+	r, err := s.get(kindBackupSchedule, orgID, scheduleName, "")
+	if err != nil {
+		return "", err
+	}
+	schedule := r.obj.(*api.BackupScheduleObject)
+	if schedule.GetSuspend() {
+		return "", fmt.Errorf("backup schedule %s is suspended", scheduleName)
+	}
+	policy, err := s.get(kindSchedulePolicy, orgID, schedule.GetSchedulePolicy(), "")
+	if err != nil {
+		return "", err
+	}
+	listKey := policyType(policy.obj.(*api.SchedulePolicyObject).SchedulePolicyInfo)
+	list, ok := schedule.BackupStatus[listKey]
+	if !ok {
+		list = &api.BackupScheduleInfo_StatusInfoList{}
+		schedule.BackupStatus[listKey] = list
+	}
+
+	name := fmt.Sprintf("%s-%s-%d", scheduleName, listKey, len(list.Status)+1)
+	meta, err := s.createBackup(&api.BackupCreateRequest{
+		CreateMetadata:    &api.CreateMetadata{Name: name, OrgId: orgID, Owner: r.meta.GetOwner()},
+		BackupLocation:    schedule.GetBackupLocation(),
+		BackupLocationRef: schedule.GetBackupLocationRef(),
+		Cluster:           schedule.GetCluster(),
+		Namespaces:        schedule.GetNamespaces(),
+		LabelSelectors:    schedule.GetLabelSelectors(),
+		NsLabelSelectors:  schedule.GetNsLabelSelectors(),
+		PreExecRule:       schedule.GetPreExecRule(),
+		PostExecRule:      schedule.GetPostExecRule(),
+		IncludeResources:  schedule.GetIncludeResources(),
+		ResourceTypes:     schedule.GetResourceTypes(),
+	}, &api.BackupInfo_BackupSchedule{Name: scheduleName, Uid: r.meta.GetUid()})
+	if err != nil {
+		return "", err
+	}
+	list.Status = append(list.Status, &api.BackupScheduleInfo_StatusInfo{
+		BackupName: name,
+		CreateTime: meta.GetCreateTime(),
+		Status:     api.BackupScheduleInfo_StatusInfo_Pending,
+	})
+
+	// the oldest backups beyond the retain count of the policy are deleted
+	retain := policyRetain(policy.obj.(*api.SchedulePolicyObject).SchedulePolicyInfo)
+	if retain > 0 {
+		var retained []*record
+		for _, info := range list.Status {
+			backup, err := s.get(kindBackup, orgID, info.GetBackupName(), "")
+			if err != nil {
+				continue
+			}
+			switch backup.obj.(*api.BackupObject).GetStatus().GetStatus() {
+			case api.BackupInfo_StatusInfo_DeletePending, api.BackupInfo_StatusInfo_Deleting:
+			default:
+				retained = append(retained, backup)
+			}
+		}
+		for i := 0; i < len(retained)-int(retain); i++ {
+			s.deleteBackup(retained[i])
+		}
+	}
+	return name, nil
+}
+
+// policyRetain returns the number of backups the schedules with the given policy retain
+func policyRetain(policy *api.SchedulePolicyInfo) int64 {
+	switch {
+	case policy.GetDaily() != nil:
+		return policy.GetDaily().GetRetain()
+	case policy.GetWeekly() != nil:
+		return policy.GetWeekly().GetRetain()
+	case policy.GetMonthly() != nil:
+		return policy.GetMonthly().GetRetain()
+	}
+	return policy.GetInterval().GetRetain()
+}
@@ -0,0 +1,416 @@
+package fake
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/proto"
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// licenseFeatureName is the feature an activated license grants
+const licenseFeatureName = "BackupNodeCount"
+
+type healthServer struct {
+	api.UnimplementedHealthServer
+	s *Server
+}
+
+func (h *healthServer) Status(ctx context.Context, req *api.HealthStatusRequest) (*api.HealthStatusResponse, error) {
+	return &api.HealthStatusResponse{}, nil
+}
+
+type versionServer struct {
+	api.UnimplementedVersionServer
+	s *Server
+}
+
+func (v *versionServer) Get(ctx context.Context, req *api.VersionGetRequest) (*api.VersionGetResponse, error) {
+	v.s.Lock()
+	defer v.s.Unlock()
+	return &api.VersionGetResponse{Version: proto.Clone(v.s.version).(*api.VersionInfo)}, nil
+}
+
+type organizationServer struct {
+	api.UnimplementedOrganizationServer
+	s *Server
+}
+
+func (o *organizationServer) Create(ctx context.Context, req *api.OrganizationCreateRequest) (*api.OrganizationCreateResponse, error) {
+	o.s.Lock()
+	defer o.s.Unlock()
+	// organizations are not part of an org themselves
+	var cm *api.CreateMetadata
+	if req.CreateMetadata != nil {
+		cm = proto.Clone(req.CreateMetadata).(*api.CreateMetadata)
+		cm.OrgId = ""
+	}
+	_, err := o.s.create(kindOrganization, cm, func(meta *api.Metadata) interface{} {
+		return &api.OrganizationObject{Metadata: meta}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.OrganizationCreateResponse{}, nil
+}
+
+func (o *organizationServer) Enumerate(ctx context.Context, req *api.OrganizationEnumerateRequest) (*api.OrganizationEnumerateResponse, error) {
+	o.s.Lock()
+	defer o.s.Unlock()
+	resp := &api.OrganizationEnumerateResponse{}
+	for _, r := range o.s.list(kindOrganization, "") {
+		resp.Organizations = append(resp.Organizations, proto.Clone(r.obj.(*api.OrganizationObject)).(*api.OrganizationObject))
+	}
+	return resp, nil
+}
+
+func (o *organizationServer) Inspect(ctx context.Context, req *api.OrganizationInspectRequest) (*api.OrganizationInspectResponse, error) {
+	o.s.Lock()
+	defer o.s.Unlock()
+	r, err := o.s.get(kindOrganization, "", req.GetName(), "")
+	if err != nil {
+		return nil, err
+	}
+	return &api.OrganizationInspectResponse{Organization: proto.Clone(r.obj.(*api.OrganizationObject)).(*api.OrganizationObject)}, nil
+}
+
+type cloudCredentialServer struct {
+	api.UnimplementedCloudCredentialServer
+	s *Server
+}
+
+func (c *cloudCredentialServer) Create(ctx context.Context, req *api.CloudCredentialCreateRequest) (*api.CloudCredentialCreateResponse, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	_, err := c.s.create(kindCloudCredential, req.CreateMetadata, func(meta *api.Metadata) interface{} {
+		return &api.CloudCredentialObject{Metadata: meta, CloudCredentialInfo: req.GetCloudCredential()}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.CloudCredentialCreateResponse{}, nil
+}
+
+func (c *cloudCredentialServer) Update(ctx context.Context, req *api.CloudCredentialUpdateRequest) (*api.CloudCredentialUpdateResponse, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	r, err := c.s.get(kindCloudCredential, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	r.obj.(*api.CloudCredentialObject).CloudCredentialInfo = req.GetCloudCredential()
+	return &api.CloudCredentialUpdateResponse{}, nil
+}
+
+func (c *cloudCredentialServer) Enumerate(ctx context.Context, req *api.CloudCredentialEnumerateRequest) (*api.CloudCredentialEnumerateResponse, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	resp := &api.CloudCredentialEnumerateResponse{}
+	for _, r := range c.s.list(kindCloudCredential, req.GetOrgId()) {
+		resp.CloudCredentials = append(resp.CloudCredentials, proto.Clone(r.obj.(*api.CloudCredentialObject)).(*api.CloudCredentialObject))
+	}
+	return resp, nil
+}
+
+func (c *cloudCredentialServer) Inspect(ctx context.Context, req *api.CloudCredentialInspectRequest) (*api.CloudCredentialInspectResponse, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	r, err := c.s.get(kindCloudCredential, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	return &api.CloudCredentialInspectResponse{CloudCredential: proto.Clone(r.obj.(*api.CloudCredentialObject)).(*api.CloudCredentialObject)}, nil
+}
+
+func (c *cloudCredentialServer) Delete(ctx context.Context, req *api.CloudCredentialDeleteRequest) (*api.CloudCredentialDeleteResponse, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	if err := c.s.remove(kindCloudCredential, req.GetOrgId(), req.GetName(), req.GetUid()); err != nil {
+		return nil, err
+	}
+	return &api.CloudCredentialDeleteResponse{}, nil
+}
+
+type clusterServer struct {
+	api.UnimplementedClusterServer
+	s *Server
+}
+
+func (c *clusterServer) Create(ctx context.Context, req *api.ClusterCreateRequest) (*api.ClusterCreateResponse, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	_, err := c.s.create(kindCluster, req.CreateMetadata, func(meta *api.Metadata) interface{} {
+		return &api.ClusterObject{
+			Metadata: meta,
+			ClusterInfo: &api.ClusterInfo{
+				PxConfig:              req.GetPxConfig(),
+				Kubeconfig:            req.GetKubeconfig(),
+				CloudCredential:       req.GetCloudCredential(),
+				CloudCredentialRef:    req.GetCloudCredentialRef(),
+				PlatformCredentialRef: req.GetPlatformCredentialRef(),
+				Status:                &api.ClusterInfo_StatusInfo{Status: api.ClusterInfo_StatusInfo_Online},
+			},
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.ClusterCreateResponse{}, nil
+}
+
+func (c *clusterServer) Update(ctx context.Context, req *api.ClusterUpdateRequest) (*api.ClusterUpdateResponse, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	r, err := c.s.get(kindCluster, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	info := r.obj.(*api.ClusterObject).ClusterInfo
+	info.PxConfig = req.GetPxConfig()
+	info.Kubeconfig = req.GetKubeconfig()
+	info.CloudCredential = req.GetCloudCredential()
+	info.CloudCredentialRef = req.GetCloudCredentialRef()
+	return &api.ClusterUpdateResponse{}, nil
+}
+
+func (c *clusterServer) Enumerate(ctx context.Context, req *api.ClusterEnumerateRequest) (*api.ClusterEnumerateResponse, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	resp := &api.ClusterEnumerateResponse{}
+	for _, r := range c.s.list(kindCluster, req.GetOrgId()) {
+		resp.Clusters = append(resp.Clusters, proto.Clone(r.obj.(*api.ClusterObject)).(*api.ClusterObject))
+	}
+	return resp, nil
+}
+
+func (c *clusterServer) Inspect(ctx context.Context, req *api.ClusterInspectRequest) (*api.ClusterInspectResponse, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	r, err := c.s.get(kindCluster, req.GetOrgId(), req.GetName(), "")
+	if err != nil {
+		return nil, err
+	}
+	return &api.ClusterInspectResponse{Cluster: proto.Clone(r.obj.(*api.ClusterObject)).(*api.ClusterObject)}, nil
+}
+
+func (c *clusterServer) Delete(ctx context.Context, req *api.ClusterDeleteRequest) (*api.ClusterDeleteResponse, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	if err := c.s.remove(kindCluster, req.GetOrgId(), req.GetName(), ""); err != nil {
+		return nil, err
+	}
+	return &api.ClusterDeleteResponse{}, nil
+}
+
+type backupLocationServer struct {
+	api.UnimplementedBackupLocationServer
+	s *Server
+}
+
+func (b *backupLocationServer) Create(ctx context.Context, req *api.BackupLocationCreateRequest) (*api.BackupLocationCreateResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	if req.GetBackupLocation() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "backup location info is required")
+	}
+	_, err := b.s.create(kindBackupLocation, req.CreateMetadata, func(meta *api.Metadata) interface{} {
+		info := proto.Clone(req.GetBackupLocation()).(*api.BackupLocationInfo)
+		info.Status = &api.BackupLocationInfo_StatusInfo{Status: api.BackupLocationInfo_StatusInfo_Valid}
+		return &api.BackupLocationObject{Metadata: meta, BackupLocationInfo: info}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.BackupLocationCreateResponse{}, nil
+}
+
+func (b *backupLocationServer) Update(ctx context.Context, req *api.BackupLocationUpdateRequest) (*api.BackupLocationUpdateResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	r, err := b.s.get(kindBackupLocation, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	info := proto.Clone(req.GetBackupLocation()).(*api.BackupLocationInfo)
+	info.Status = r.obj.(*api.BackupLocationObject).BackupLocationInfo.GetStatus()
+	r.obj.(*api.BackupLocationObject).BackupLocationInfo = info
+	return &api.BackupLocationUpdateResponse{}, nil
+}
+
+func (b *backupLocationServer) Enumerate(ctx context.Context, req *api.BackupLocationEnumerateRequest) (*api.BackupLocationEnumerateResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	resp := &api.BackupLocationEnumerateResponse{}
+	for _, r := range b.s.list(kindBackupLocation, req.GetOrgId()) {
+		resp.BackupLocations = append(resp.BackupLocations, proto.Clone(r.obj.(*api.BackupLocationObject)).(*api.BackupLocationObject))
+	}
+	return resp, nil
+}
+
+func (b *backupLocationServer) Inspect(ctx context.Context, req *api.BackupLocationInspectRequest) (*api.BackupLocationInspectResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	r, err := b.s.get(kindBackupLocation, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	return &api.BackupLocationInspectResponse{BackupLocation: proto.Clone(r.obj.(*api.BackupLocationObject)).(*api.BackupLocationObject)}, nil
+}
+
+func (b *backupLocationServer) Delete(ctx context.Context, req *api.BackupLocationDeleteRequest) (*api.BackupLocationDeleteResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	if err := b.s.remove(kindBackupLocation, req.GetOrgId(), req.GetName(), req.GetUid()); err != nil {
+		return nil, err
+	}
+	return &api.BackupLocationDeleteResponse{}, nil
+}
+
+func (b *backupLocationServer) Validate(ctx context.Context, req *api.BackupLocationValidateRequest) (*api.BackupLocationValidateResponse, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+	if _, err := b.s.get(kindBackupLocation, req.GetOrgId(), req.GetName(), req.GetUid()); err != nil {
+		return nil, err
+	}
+	return &api.BackupLocationValidateResponse{}, nil
+}
+
+type schedulePolicyServer struct {
+	api.UnimplementedSchedulePolicyServer
+	s *Server
+}
+
+func (p *schedulePolicyServer) Create(ctx context.Context, req *api.SchedulePolicyCreateRequest) (*api.SchedulePolicyCreateResponse, error) {
+	p.s.Lock()
+	defer p.s.Unlock()
+	_, err := p.s.create(kindSchedulePolicy, req.CreateMetadata, func(meta *api.Metadata) interface{} {
+		return &api.SchedulePolicyObject{Metadata: meta, SchedulePolicyInfo: req.GetSchedulePolicy()}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.SchedulePolicyCreateResponse{}, nil
+}
+
+func (p *schedulePolicyServer) Update(ctx context.Context, req *api.SchedulePolicyUpdateRequest) (*api.SchedulePolicyUpdateResponse, error) {
+	p.s.Lock()
+	defer p.s.Unlock()
+	r, err := p.s.get(kindSchedulePolicy, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	r.obj.(*api.SchedulePolicyObject).SchedulePolicyInfo = req.GetSchedulePolicy()
+	return &api.SchedulePolicyUpdateResponse{}, nil
+}
+
+func (p *schedulePolicyServer) Enumerate(ctx context.Context, req *api.SchedulePolicyEnumerateRequest) (*api.SchedulePolicyEnumerateResponse, error) {
+	p.s.Lock()
+	defer p.s.Unlock()
+	resp := &api.SchedulePolicyEnumerateResponse{}
+	for _, r := range p.s.list(kindSchedulePolicy, req.GetOrgId()) {
+		resp.SchedulePolicies = append(resp.SchedulePolicies, proto.Clone(r.obj.(*api.SchedulePolicyObject)).(*api.SchedulePolicyObject))
+	}
+	return resp, nil
+}
+
+func (p *schedulePolicyServer) Inspect(ctx context.Context, req *api.SchedulePolicyInspectRequest) (*api.SchedulePolicyInspectResponse, error) {
+	p.s.Lock()
+	defer p.s.Unlock()
+	r, err := p.s.get(kindSchedulePolicy, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	return &api.SchedulePolicyInspectResponse{SchedulePolicy: proto.Clone(r.obj.(*api.SchedulePolicyObject)).(*api.SchedulePolicyObject)}, nil
+}
+
+func (p *schedulePolicyServer) Delete(ctx context.Context, req *api.SchedulePolicyDeleteRequest) (*api.SchedulePolicyDeleteResponse, error) {
+	p.s.Lock()
+	defer p.s.Unlock()
+	if err := p.s.remove(kindSchedulePolicy, req.GetOrgId(), req.GetName(), req.GetUid()); err != nil {
+		return nil, err
+	}
+	return &api.SchedulePolicyDeleteResponse{}, nil
+}
+
+type rulesServer struct {
+	api.UnimplementedRulesServer
+	s *Server
+}
+
+func (r *rulesServer) Create(ctx context.Context, req *api.RuleCreateRequest) (*api.RuleCreateResponse, error) {
+	r.s.Lock()
+	defer r.s.Unlock()
+	_, err := r.s.create(kindRule, req.CreateMetadata, func(meta *api.Metadata) interface{} {
+		return &api.RuleObject{Metadata: meta, RulesInfo: req.GetRulesInfo()}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.RuleCreateResponse{}, nil
+}
+
+func (r *rulesServer) Update(ctx context.Context, req *api.RuleUpdateRequest) (*api.RuleUpdateResponse, error) {
+	r.s.Lock()
+	defer r.s.Unlock()
+	rec, err := r.s.get(kindRule, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	rec.obj.(*api.RuleObject).RulesInfo = req.GetRulesInfo()
+	return &api.RuleUpdateResponse{}, nil
+}
+
+func (r *rulesServer) Enumerate(ctx context.Context, req *api.RuleEnumerateRequest) (*api.RuleEnumerateResponse, error) {
+	r.s.Lock()
+	defer r.s.Unlock()
+	resp := &api.RuleEnumerateResponse{}
+	for _, rec := range r.s.list(kindRule, req.GetOrgId()) {
+		resp.Rules = append(resp.Rules, proto.Clone(rec.obj.(*api.RuleObject)).(*api.RuleObject))
+	}
+	return resp, nil
+}
+
+func (r *rulesServer) Inspect(ctx context.Context, req *api.RuleInspectRequest) (*api.RuleInspectResponse, error) {
+	r.s.Lock()
+	defer r.s.Unlock()
+	rec, err := r.s.get(kindRule, req.GetOrgId(), req.GetName(), req.GetUid())
+	if err != nil {
+		return nil, err
+	}
+	return &api.RuleInspectResponse{Rule: proto.Clone(rec.obj.(*api.RuleObject)).(*api.RuleObject)}, nil
+}
+
+func (r *rulesServer) Delete(ctx context.Context, req *api.RuleDeleteRequest) (*api.RuleDeleteResponse, error) {
+	r.s.Lock()
+	defer r.s.Unlock()
+	if err := r.s.remove(kindRule, req.GetOrgId(), req.GetName(), req.GetUid()); err != nil {
+		return nil, err
+	}
+	return &api.RuleDeleteResponse{}, nil
+}
+
+type licenseServer struct {
+	api.UnimplementedLicenseServer
+	s *Server
+}
+
+// Activate grants the backup node count feature to the org, whatever the activation is
+func (l *licenseServer) Activate(ctx context.Context, req *api.LicenseActivateRequest) (*api.LicenseActivateResponse, error) {
+	l.s.Lock()
+	defer l.s.Unlock()
+	l.s.licenses[req.GetOrgId()] = &api.LicenseResponseInfo{
+		FeatureInfo: []*api.LicenseResponseInfo_FeatureInfo{{Name: licenseFeatureName}},
+	}
+	return &api.LicenseActivateResponse{}, nil
+}
+
+func (l *licenseServer) Inspect(ctx context.Context, req *api.LicenseInspectRequest) (*api.LicenseInspectResponse, error) {
+	l.s.Lock()
+	defer l.s.Unlock()
+	info, ok := l.s.licenses[req.GetOrgId()]
+	if !ok {
+		return &api.LicenseInspectResponse{LicenseRespInfo: &api.LicenseResponseInfo{}}, nil
+	}
+	return &api.LicenseInspectResponse{LicenseRespInfo: proto.Clone(info).(*api.LicenseResponseInfo)}, nil
+}
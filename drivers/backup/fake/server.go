@@ -0,0 +1,274 @@
+// Package fake provides an in-memory px-backup gRPC server, so the backup driver and the helpers built on
+// it can be exercised without a PX-Backup and Keycloak install.
+//
+// Backups and restores move one step further every time they are inspected: a backup is Pending after
+// its creation, InProgress on the first inspect and reaches its outcome, Success unless another one was
+// set with SetBackupOutcome, on the second one. Deleted backups go through DeletePending and Deleting
+// before they are gone, and stay in DeletePending while deletions are held with HoldDeletions.
+package fake
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pborman/uuid"
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	kindOrganization    = "organization"
+	kindCloudCredential = "cloudcredential"
+	kindCluster         = "cluster"
+	kindBackupLocation  = "backuplocation"
+	kindBackup          = "backup"
+	kindRestore         = "restore"
+	kindSchedulePolicy  = "schedulepolicy"
+	kindBackupSchedule  = "backupschedule"
+	kindRule            = "rule"
+)
+
+// record is a stored object along with its metadata
+type record struct {
+	meta *api.Metadata
+	obj  interface{}
+	// seq orders the records of a kind by creation
+	seq int
+}
+
+// injectedError is returned by the next calls of a method
+type injectedError struct {
+	err   error
+	times int
+}
+
+// Server is an in-memory px-backup gRPC server
+type Server struct {
+	sync.Mutex
+	objects map[string]map[string]*record
+	seq     int
+
+	errors          map[string][]*injectedError
+	backupOutcomes  map[string]*api.BackupInfo_StatusInfo
+	restoreOutcomes map[string]*api.RestoreInfo_StatusInfo
+	holdDeletions   bool
+	licenses        map[string]*api.LicenseResponseInfo
+	version         *api.VersionInfo
+
+	grpcServer *grpc.Server
+	listener   net.Listener
+}
+
+// NewServer returns a server without any object
+func NewServer() *Server {
+	return &Server{
+		objects:         make(map[string]map[string]*record),
+		errors:          make(map[string][]*injectedError),
+		backupOutcomes:  make(map[string]*api.BackupInfo_StatusInfo),
+		restoreOutcomes: make(map[string]*api.RestoreInfo_StatusInfo),
+		licenses:        make(map[string]*api.LicenseResponseInfo),
+		version:         &api.VersionInfo{Major: "2", Minor: "4", Patch: "0"},
+	}
+}
+
+// Start serves the px-backup API on a local port and returns its endpoint
+func (s *Server) Start() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	s.listener = listener
+	s.grpcServer = grpc.NewServer(grpc.UnaryInterceptor(s.intercept))
+	api.RegisterHealthServer(s.grpcServer, &healthServer{s: s})
+	api.RegisterVersionServer(s.grpcServer, &versionServer{s: s})
+	api.RegisterOrganizationServer(s.grpcServer, &organizationServer{s: s})
+	api.RegisterCloudCredentialServer(s.grpcServer, &cloudCredentialServer{s: s})
+	api.RegisterClusterServer(s.grpcServer, &clusterServer{s: s})
+	api.RegisterBackupLocationServer(s.grpcServer, &backupLocationServer{s: s})
+	api.RegisterBackupServer(s.grpcServer, &backupServer{s: s})
+	api.RegisterRestoreServer(s.grpcServer, &restoreServer{s: s})
+	api.RegisterSchedulePolicyServer(s.grpcServer, &schedulePolicyServer{s: s})
+	api.RegisterBackupScheduleServer(s.grpcServer, &backupScheduleServer{s: s})
+	api.RegisterRulesServer(s.grpcServer, &rulesServer{s: s})
+	api.RegisterLicenseServer(s.grpcServer, &licenseServer{s: s})
+	go s.grpcServer.Serve(listener)
+	return listener.Addr().String(), nil
+}
+
+// Stop stops serving the API
+func (s *Server) Stop() {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+}
+
+// InjectError makes the next given number of calls of a method, e.g. Backup/Inspect, fail with the given
+// error. A negative number makes all the calls fail until ClearErrors is called.
+func (s *Server) InjectError(method string, err error, times int) {
+	s.Lock()
+	defer s.Unlock()
+	s.errors[method] = append(s.errors[method], &injectedError{err: err, times: times})
+}
+
+// ClearErrors removes all the injected errors
+func (s *Server) ClearErrors() {
+	s.Lock()
+	defer s.Unlock()
+	s.errors = make(map[string][]*injectedError)
+}
+
+// SetBackupOutcome sets the status the backup with the given name ends up in, e.g. PartialSuccess or
+// Failed with the given reason. It applies to backups created later on as well.
+func (s *Server) SetBackupOutcome(name string, outcome api.BackupInfo_StatusInfo_Status, reason string) {
+	s.Lock()
+	defer s.Unlock()
+	s.backupOutcomes[name] = &api.BackupInfo_StatusInfo{Status: outcome, Reason: reason}
+}
+
+// SetRestoreOutcome sets the status the restore with the given name ends up in
+func (s *Server) SetRestoreOutcome(name string, outcome api.RestoreInfo_StatusInfo_Status, reason string) {
+	s.Lock()
+	defer s.Unlock()
+	s.restoreOutcomes[name] = &api.RestoreInfo_StatusInfo{Status: outcome, Reason: reason}
+}
+
+// HoldDeletions keeps the deleted backups in DeletePending, like a locked bucket does, until released
+func (s *Server) HoldDeletions(hold bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.holdDeletions = hold
+}
+
+// SetVersion sets the version the server reports
+func (s *Server) SetVersion(version *api.VersionInfo) {
+	s.Lock()
+	defer s.Unlock()
+	s.version = version
+}
+
+// intercept returns the injected errors before calling the handlers
+func (s *Server) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.nextError(strings.TrimPrefix(info.FullMethod, "/")); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) nextError(method string) error {
+	s.Lock()
+	defer s.Unlock()
+	injected := s.errors[method]
+	if len(injected) == 0 {
+		return nil
+	}
+	next := injected[0]
+	if next.times > 0 {
+		next.times--
+		if next.times == 0 {
+			s.errors[method] = injected[1:]
+		}
+	}
+	return next.err
+}
+
+func key(orgID, name string) string {
+	return orgID + "/" + name
+}
+
+func notFound(kind, orgID, name string) error {
+	return status.Errorf(codes.NotFound, "%s %s not found in org %s", kind, name, orgID)
+}
+
+// create stores a new object of the given kind and returns its metadata, s must be locked
+func (s *Server) create(kind string, cm *api.CreateMetadata, obj func(*api.Metadata) interface{}) (*api.Metadata, error) {
+	if cm == nil || cm.GetName() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name of the %s is required", kind)
+	}
+	objects, ok := s.objects[kind]
+	if !ok {
+		objects = make(map[string]*record)
+		s.objects[kind] = objects
+	}
+	k := key(cm.GetOrgId(), cm.GetName())
+	if _, ok := objects[k]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "%s %s already exists in org %s", kind, cm.GetName(), cm.GetOrgId())
+	}
+	uid := cm.GetUid()
+	if uid == "" {
+		uid = uuid.New()
+	}
+	now := types.TimestampNow()
+	meta := &api.Metadata{
+		Name:            cm.GetName(),
+		Uid:             uid,
+		Owner:           cm.GetOwner(),
+		OrgId:           cm.GetOrgId(),
+		CreateTime:      now,
+		LastUpdateTime:  now,
+		Labels:          cm.GetLabels(),
+		CreateTimeInSec: now.GetSeconds(),
+		Ownership:       cm.GetOwnership(),
+	}
+	s.seq++
+	objects[k] = &record{meta: meta, obj: obj(meta), seq: s.seq}
+	return meta, nil
+}
+
+// get returns the object of the given kind with the given name and, if set, uid, s must be locked
+func (s *Server) get(kind, orgID, name, uid string) (*record, error) {
+	r, ok := s.objects[kind][key(orgID, name)]
+	if !ok || (uid != "" && r.meta.GetUid() != uid) {
+		return nil, notFound(kind, orgID, name)
+	}
+	return r, nil
+}
+
+// list returns the objects of the given kind in an org in creation order, s must be locked
+func (s *Server) list(kind, orgID string) []*record {
+	var records []*record
+	for _, r := range s.objects[kind] {
+		if r.meta.GetOrgId() == orgID {
+			records = append(records, r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].seq < records[j].seq })
+	return records
+}
+
+// remove deletes the object of the given kind, s must be locked
+func (s *Server) remove(kind, orgID, name, uid string) error {
+	if _, err := s.get(kind, orgID, name, uid); err != nil {
+		return err
+	}
+	delete(s.objects[kind], key(orgID, name))
+	return nil
+}
+
+// page returns the records the enumerate options select along with the number of matching records
+func page(records []*record, opts *api.EnumerateOptions) ([]*record, uint64) {
+	if filter := opts.GetNameFilter(); filter != "" {
+		var filtered []*record
+		for _, r := range records {
+			if strings.Contains(r.meta.GetName(), filter) {
+				filtered = append(filtered, r)
+			}
+		}
+		records = filtered
+	}
+	total := uint64(len(records))
+	start := opts.GetObjectIndex()
+	if start > total {
+		start = total
+	}
+	end := total
+	if max := opts.GetMaxObjects(); max > 0 && start+max < total {
+		end = start + max
+	}
+	return records[start:end], total
+}
@@ -0,0 +1,350 @@
+package portworx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"github.com/portworx/torpedo/drivers/backup"
+	"github.com/portworx/torpedo/drivers/backup/fake"
+	"github.com/portworx/torpedo/drivers/objectstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testOrgID          = "default"
+	testCluster        = "source-cluster"
+	testBackupLocation = "backup-location"
+	testTimeout        = 5 * time.Second
+	testRetryInterval  = 10 * time.Millisecond
+)
+
+// newTestDriver returns a driver connected to a fake px-backup with an org, a cluster and a backup location
+func newTestDriver(t *testing.T) (*portworx, *fake.Server) {
+	server := fake.NewServer()
+	endpoint, err := server.Start()
+	require.NoError(t, err)
+	t.Cleanup(server.Stop)
+	t.Setenv(backup_api_endpoint, endpoint)
+
+	p := &portworx{}
+	require.NoError(t, p.testAndSetEndpoint(""))
+
+	ctx := context.Background()
+	_, err = p.CreateOrganization(ctx, &api.OrganizationCreateRequest{
+		CreateMetadata: &api.CreateMetadata{Name: testOrgID},
+	})
+	require.NoError(t, err)
+	_, err = p.CreateCluster(ctx, &api.ClusterCreateRequest{
+		CreateMetadata: &api.CreateMetadata{Name: testCluster, OrgId: testOrgID},
+	})
+	require.NoError(t, err)
+	_, err = p.CreateBackupLocation(ctx, &api.BackupLocationCreateRequest{
+		CreateMetadata: &api.CreateMetadata{Name: testBackupLocation, OrgId: testOrgID},
+		BackupLocation: &api.BackupLocationInfo{Path: "bucket", Type: api.BackupLocationInfo_S3},
+	})
+	require.NoError(t, err)
+	return p, server
+}
+
+func createTestBackup(t *testing.T, p *portworx, name string) {
+	_, err := p.CreateBackup(context.Background(), &api.BackupCreateRequest{
+		CreateMetadata: &api.CreateMetadata{Name: name, OrgId: testOrgID},
+		BackupLocation: testBackupLocation,
+		Cluster:        testCluster,
+		Namespaces:     []string{"mysql"},
+	})
+	require.NoError(t, err)
+}
+
+func TestBackupStatusTransitions(t *testing.T) {
+	p, server := newTestDriver(t)
+	ctx := context.Background()
+
+	_, err := p.CreateBackup(ctx, &api.BackupCreateRequest{
+		CreateMetadata: &api.CreateMetadata{Name: "no-cluster", OrgId: testOrgID},
+		BackupLocation: testBackupLocation,
+		Cluster:        "missing",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	createTestBackup(t, p, "backup1")
+	uid, err := p.GetBackupUID(ctx, "backup1", testOrgID)
+	require.NoError(t, err)
+	req := &api.BackupInspectRequest{Name: "backup1", OrgId: testOrgID, Uid: uid}
+	for _, expected := range []api.BackupInfo_StatusInfo_Status{
+		api.BackupInfo_StatusInfo_InProgress,
+		api.BackupInfo_StatusInfo_Success,
+		api.BackupInfo_StatusInfo_Success,
+	} {
+		resp, err := p.InspectBackup(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, expected, resp.GetBackup().GetStatus().GetStatus())
+	}
+
+	server.HoldDeletions(true)
+	_, err = p.DeleteBackup(ctx, &api.BackupDeleteRequest{Name: "backup1", OrgId: testOrgID, Uid: uid})
+	require.NoError(t, err)
+	require.NoError(t, p.WaitForDeletePending(ctx, "backup1", testOrgID, testTimeout, testRetryInterval))
+	resp, err := p.InspectBackup(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, api.BackupInfo_StatusInfo_DeletePending, resp.GetBackup().GetStatus().GetStatus())
+
+	server.HoldDeletions(false)
+	resp, err = p.InspectBackup(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, api.BackupInfo_StatusInfo_Deleting, resp.GetBackup().GetStatus().GetStatus())
+	_, err = p.InspectBackup(ctx, req)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetBackupUIDPagination(t *testing.T) {
+	p, _ := newTestDriver(t)
+	backupCount := enumerateBatchSize + 5
+	for i := 0; i < backupCount; i++ {
+		createTestBackup(t, p, fmt.Sprintf("backup-%d", i))
+	}
+	uid, err := p.GetBackupUID(context.Background(), fmt.Sprintf("backup-%d", backupCount-1), testOrgID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, uid)
+
+	_, err = p.GetBackupUID(context.Background(), "missing", testOrgID)
+	assert.Error(t, err)
+}
+
+func TestCheckBackupSuccess(t *testing.T) {
+	p, server := newTestDriver(t)
+	ctx := context.Background()
+
+	createTestBackup(t, p, "succeeded")
+	assert.NoError(t, backup.CheckBackupSuccess(ctx, p, "succeeded", testOrgID, testTimeout, testRetryInterval))
+
+	server.SetBackupOutcome("failed", api.BackupInfo_StatusInfo_Failed, "volume snapshot failed")
+	createTestBackup(t, p, "failed")
+	err := backup.CheckBackupSuccess(ctx, p, "failed", testOrgID, testTimeout, testRetryInterval)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "volume snapshot failed")
+
+	server.SetBackupOutcome("partial", api.BackupInfo_StatusInfo_PartialSuccess, "one volume failed")
+	createTestBackup(t, p, "partial")
+	err = backup.CheckBackupSuccess(ctx, p, "partial", testOrgID, 100*time.Millisecond, testRetryInterval)
+	assert.Error(t, err)
+
+	createTestBackup(t, p, "flaky")
+	server.InjectError("Backup/Inspect", status.Error(codes.Unavailable, "px-backup restarting"), 1)
+	err = backup.CheckBackupSuccess(ctx, p, "flaky", testOrgID, testTimeout, testRetryInterval)
+	assert.Error(t, err, "inspect errors are not retried")
+	assert.NoError(t, backup.CheckBackupSuccess(ctx, p, "flaky", testOrgID, testTimeout, testRetryInterval))
+}
+
+func TestWaitForBackupCompletion(t *testing.T) {
+	p, server := newTestDriver(t)
+	ctx := context.Background()
+
+	createTestBackup(t, p, "backup1")
+	server.InjectError("Backup/Inspect", status.Error(codes.Unavailable, "px-backup restarting"), 2)
+	assert.NoError(t, p.WaitForBackupCompletion(ctx, "backup1", testOrgID, testTimeout, testRetryInterval))
+
+	server.SetBackupOutcome("backup2", api.BackupInfo_StatusInfo_Aborted, "aborted by user")
+	createTestBackup(t, p, "backup2")
+	err := p.WaitForBackupCompletion(ctx, "backup2", testOrgID, testTimeout, testRetryInterval)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "aborted by user")
+}
+
+func TestGetOrdinalScheduleBackupName(t *testing.T) {
+	p, server := newTestDriver(t)
+	ctx := context.Background()
+
+	_, err := p.CreateSchedulePolicy(ctx, &api.SchedulePolicyCreateRequest{
+		CreateMetadata: &api.CreateMetadata{Name: "every-15m", OrgId: testOrgID},
+		SchedulePolicy: p.CreateIntervalSchedulePolicy(5, 15, 2),
+	})
+	require.NoError(t, err)
+	_, err = p.CreateBackupSchedule(ctx, &api.BackupScheduleCreateRequest{
+		CreateMetadata: &api.CreateMetadata{Name: "mysql-schedule", OrgId: testOrgID},
+		SchedulePolicy: "every-15m",
+		BackupLocation: testBackupLocation,
+		Cluster:        testCluster,
+		Namespaces:     []string{"mysql"},
+	})
+	require.NoError(t, err)
+
+	_, err = backup.GetOrdinalScheduleBackupName(ctx, p, "mysql-schedule", 1, testOrgID)
+	assert.Error(t, err, "no backups yet")
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		name, err := server.TriggerSchedule(testOrgID, "mysql-schedule")
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+	for i, expected := range names {
+		name, err := backup.GetOrdinalScheduleBackupName(ctx, p, "mysql-schedule", i+1, testOrgID)
+		require.NoError(t, err)
+		assert.Equal(t, expected, name)
+	}
+	_, err = backup.GetOrdinalScheduleBackupName(ctx, p, "mysql-schedule", 0, testOrgID)
+	assert.Error(t, err)
+	_, err = backup.GetOrdinalScheduleBackupName(ctx, p, "mysql-schedule", 4, testOrgID)
+	assert.Error(t, err)
+
+	require.NoError(t, backup.CheckBackupSuccess(ctx, p, names[0], testOrgID, testTimeout, testRetryInterval))
+	resp, err := p.InspectBackupSchedule(ctx, &api.BackupScheduleInspectRequest{Name: "mysql-schedule", OrgId: testOrgID})
+	require.NoError(t, err)
+	statuses := resp.GetBackupSchedule().GetBackupStatus()["interval"].GetStatus()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, api.BackupScheduleInfo_StatusInfo_Success, statuses[0].GetStatus())
+	assert.Equal(t, api.BackupScheduleInfo_StatusInfo_Pending, statuses[1].GetStatus())
+
+	server.HoldDeletions(true)
+	_, err = p.DeleteBackupSchedule(ctx, &api.BackupScheduleDeleteRequest{Name: "mysql-schedule", OrgId: testOrgID, DeleteBackups: true})
+	require.NoError(t, err)
+	require.NoError(t, p.WaitForDeletePending(ctx, names[1], testOrgID, testTimeout, testRetryInterval))
+}
+
+// fakeBucket holds the objects of the backups the way px-backup writes them
+type fakeBucket struct {
+	objectstore.DefaultDriver
+	objects map[string]bool
+	// paths are the backup paths by backup name
+	paths       map[string]string
+	lockMode    string
+	retainUntil time.Time
+}
+
+func (b *fakeBucket) ListBuckets() ([]string, error) {
+	return []string{"bucket"}, nil
+}
+
+func (b *fakeBucket) CheckConnection() error {
+	return nil
+}
+
+func (b *fakeBucket) ListFilesInBucket(bucketName string) ([]string, error) {
+	var keys []string
+	for key := range b.objects {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (b *fakeBucket) GetObjectMetadata(bucketName, key string) (*objectstore.ObjectMetadata, error) {
+	if !b.objects[key] {
+		return nil, fmt.Errorf("object %s not found", key)
+	}
+	return &objectstore.ObjectMetadata{Key: key, RetentionMode: b.lockMode, RetainUntil: b.retainUntil}, nil
+}
+
+// runSchedule triggers the schedule, waits for the backup to succeed and writes its objects
+func runSchedule(t *testing.T, p *portworx, server *fake.Server, bucket *fakeBucket, scheduleName string) string {
+	ctx := context.Background()
+	name, err := server.TriggerSchedule(testOrgID, scheduleName)
+	require.NoError(t, err)
+	require.NoError(t, backup.CheckBackupSuccess(ctx, p, name, testOrgID, testTimeout, testRetryInterval))
+	resp, err := p.InspectBackup(ctx, &api.BackupInspectRequest{Name: name, OrgId: testOrgID})
+	require.NoError(t, err)
+	bucket.paths[name] = resp.GetBackup().GetBackupPath()
+	bucket.objects[bucket.paths[name]+"/resources.json"] = true
+	return name
+}
+
+// finishDeletions completes the pending deletions of the given backups and removes their objects unless locked
+func finishDeletions(t *testing.T, p *portworx, bucket *fakeBucket, names ...string) {
+	for _, name := range names {
+		var err error
+		for status.Code(err) != codes.NotFound {
+			_, err = p.InspectBackup(context.Background(), &api.BackupInspectRequest{Name: name, OrgId: testOrgID})
+		}
+		if bucket.lockMode == "" {
+			delete(bucket.objects, bucket.paths[name]+"/resources.json")
+		}
+	}
+}
+
+func createTestSchedule(t *testing.T, p *portworx, retain int64) string {
+	ctx := context.Background()
+	_, err := p.CreateSchedulePolicy(ctx, &api.SchedulePolicyCreateRequest{
+		CreateMetadata: &api.CreateMetadata{Name: "every-15m", OrgId: testOrgID},
+		SchedulePolicy: p.CreateIntervalSchedulePolicy(retain, 15, 0),
+	})
+	require.NoError(t, err)
+	_, err = p.CreateBackupSchedule(ctx, &api.BackupScheduleCreateRequest{
+		CreateMetadata: &api.CreateMetadata{Name: "mysql-schedule", OrgId: testOrgID},
+		SchedulePolicy: "every-15m",
+		BackupLocation: testBackupLocation,
+		Cluster:        testCluster,
+		Namespaces:     []string{"mysql"},
+	})
+	require.NoError(t, err)
+	return "mysql-schedule"
+}
+
+func TestRetentionValidator(t *testing.T) {
+	p, server := newTestDriver(t)
+	ctx := context.Background()
+	bucket := &fakeBucket{objects: make(map[string]bool), paths: make(map[string]string)}
+	schedule := createTestSchedule(t, p, 2)
+	validator := backup.NewRetentionValidator(p, bucket, "bucket", testOrgID, schedule, 2, false)
+
+	var names []string
+	for i := 0; i < 4; i++ {
+		names = append(names, runSchedule(t, p, server, bucket, schedule))
+		require.NoError(t, validator.Validate(ctx))
+	}
+	finishDeletions(t, p, bucket, names[:2]...)
+	require.NoError(t, validator.Validate(ctx))
+
+	// px-backup forgets an expired backup but leaves its objects behind
+	names = append(names, runSchedule(t, p, server, bucket, schedule))
+	finishDeletions(t, p, bucket, names[2])
+	bucket.objects[bucket.paths[names[2]]+"/resources.json"] = true
+	err := validator.Validate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("expired backup [%s] was deleted from px-backup but bucket [bucket] still has objects", names[2]))
+
+	// the objects of a retained backup are missing
+	delete(bucket.objects, bucket.paths[names[2]]+"/resources.json")
+	delete(bucket.objects, bucket.paths[names[3]]+"/resources.json")
+	err = validator.Validate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("backup [%s] is retained but bucket [bucket] has no object", names[3]))
+
+	err = backup.NewRetentionValidator(p, bucket, "bucket", testOrgID, schedule, 1, false).Validate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "px-backup retains 2 backups")
+}
+
+func TestRetentionValidatorLockedBucket(t *testing.T) {
+	p, server := newTestDriver(t)
+	ctx := context.Background()
+	bucket := &fakeBucket{objects: make(map[string]bool), paths: make(map[string]string), lockMode: "COMPLIANCE", retainUntil: time.Now().Add(time.Hour)}
+	schedule := createTestSchedule(t, p, 1)
+	validator := backup.NewRetentionValidator(p, bucket, "bucket", testOrgID, schedule, 1, true)
+
+	// px-backup keeps the expired backups of locked buckets in DeletePending until their retention expires
+	server.HoldDeletions(true)
+	var names []string
+	for i := 0; i < 3; i++ {
+		names = append(names, runSchedule(t, p, server, bucket, schedule))
+		require.NoError(t, validator.Validate(ctx))
+	}
+	require.NoError(t, p.WaitForDeletePending(ctx, names[0], testOrgID, testTimeout, testRetryInterval))
+
+	server.HoldDeletions(false)
+	finishDeletions(t, p, bucket, names[0])
+	err := validator.Validate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("expired backup [%s] was deleted from px-backup before the retention of its objects expired", names[0]))
+
+	bucket.lockMode = ""
+	names = append(names, runSchedule(t, p, server, bucket, schedule))
+	err = backup.NewRetentionValidator(p, bucket, "bucket", testOrgID, schedule, 1, true).Validate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not locked in bucket [bucket]")
+}
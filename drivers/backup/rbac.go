@@ -0,0 +1,506 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+)
+
+// AccessLevel is the access of a principal to a shared px-backup object
+type AccessLevel int
+
+const (
+	// NoAccess is the access of the principals an object is not shared with
+	NoAccess AccessLevel = iota
+	// ViewAccess lets the principal inspect the object
+	ViewAccess
+	// RestorableAccess lets the principal use the object, e.g. restore a backup
+	RestorableAccess
+	// FullAccess lets the principal delete the object
+	FullAccess
+)
+
+func (a AccessLevel) String() string {
+	switch a {
+	case ViewAccess:
+		return "View"
+	case RestorableAccess:
+		return "Restorable"
+	case FullAccess:
+		return "Full"
+	}
+	return "None"
+}
+
+// ResourceKind is the kind of a px-backup object shared in an RBAC matrix
+type ResourceKind string
+
+const (
+	CloudCredentialKind ResourceKind = "CloudCredential"
+	BackupLocationKind  ResourceKind = "BackupLocation"
+	ClusterKind         ResourceKind = "Cluster"
+	BackupKind          ResourceKind = "Backup"
+	SchedulePolicyKind  ResourceKind = "SchedulePolicy"
+	RuleKind            ResourceKind = "Rule"
+)
+
+// RBACPrincipal is a user or a group objects are shared with
+type RBACPrincipal struct {
+	Name string
+	// ID is the id objects are shared with, the Keycloak id of a user or the name of a group
+	ID    string
+	Group bool
+	// Ctx is the context the operations of the principal run with, the one of a member for a group
+	Ctx context.Context
+}
+
+// RBACResource is a px-backup object shared in an RBAC matrix
+type RBACResource struct {
+	Kind  ResourceKind
+	Name  string
+	UID   string
+	OrgID string
+}
+
+// Key returns the key of the resource in a matrix
+func (r RBACResource) Key() string {
+	return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+}
+
+// RBACGrant is the access of a principal to a resource
+type RBACGrant struct {
+	Principal RBACPrincipal
+	Access    AccessLevel
+}
+
+// RBACOperation is an operation a principal runs on a resource of a kind, allowed from the given access
+type RBACOperation struct {
+	Name      string
+	MinAccess AccessLevel
+	// Destructive operations remove the resource, so they run once all the other operations have run and
+	// only until one of them succeeds
+	Destructive bool
+	Run         func(ctx context.Context, r RBACResource) error
+}
+
+// RBACMatrix is the declarative table of the access of principals to resources. Principals have no access
+// to the resources they are not granted.
+type RBACMatrix struct {
+	Principals []RBACPrincipal
+	Resources  []RBACResource
+	// grants are the access levels by principal name and resource key
+	grants map[string]map[string]AccessLevel
+}
+
+// Grant gives the principal the access to the resource
+func (m *RBACMatrix) Grant(principal string, resource string, access AccessLevel) *RBACMatrix {
+	if m.grants == nil {
+		m.grants = make(map[string]map[string]AccessLevel)
+	}
+	if m.grants[principal] == nil {
+		m.grants[principal] = make(map[string]AccessLevel)
+	}
+	m.grants[principal][resource] = access
+	return m
+}
+
+// Access returns the access of the principal to the resource
+func (m *RBACMatrix) Access(principal string, resource string) AccessLevel {
+	return m.grants[principal][resource]
+}
+
+// RBACResult is the outcome of an operation of a principal on a resource
+type RBACResult struct {
+	Principal string
+	Resource  string
+	Access    AccessLevel
+	Operation string
+	// Allowed is true if the access of the principal allows the operation
+	Allowed bool
+	// Succeeded is true if the operation succeeded
+	Succeeded bool
+	// Skipped is true if the operation did not run because the resource was deleted before
+	Skipped bool
+	Err     error
+}
+
+// Passed returns true if the operation was skipped or succeeded exactly when allowed
+func (r RBACResult) Passed() bool {
+	return r.Skipped || r.Allowed == r.Succeeded
+}
+
+func (r RBACResult) cell() string {
+	switch {
+	case r.Skipped:
+		return "~"
+	case r.Passed() && r.Allowed:
+		return "+"
+	case r.Passed():
+		return "-"
+	case r.Allowed:
+		return "!+"
+	}
+	return "!-"
+}
+
+// RBACReport is the outcome of all the operations of an RBAC matrix
+type RBACReport struct {
+	Results    []RBACResult
+	operations []string
+}
+
+// Failures returns the operations which succeeded while denied or failed while allowed
+func (r *RBACReport) Failures() []RBACResult {
+	var failures []RBACResult
+	for _, result := range r.Results {
+		if !result.Passed() {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Err returns an error listing the failures, nil if there is none
+func (r *RBACReport) Err() error {
+	failures := r.Failures()
+	if len(failures) == 0 {
+		return nil
+	}
+	var lines []string
+	for _, f := range failures {
+		expected := "denied"
+		if f.Allowed {
+			expected = "allowed"
+		}
+		lines = append(lines, fmt.Sprintf("%s with %s access to %s: %s should be %s but got [%v]",
+			f.Principal, f.Access, f.Resource, f.Operation, expected, f.Err))
+	}
+	return fmt.Errorf("%d of %d operations of the RBAC matrix failed:\n%s\n%s", len(failures), len(r.Results), strings.Join(lines, "\n"), r)
+}
+
+// String returns the matrix of the outcomes by principal and resource. + is allowed and succeeded, - is denied
+// and failed, !+ is allowed but failed, !- is denied but succeeded and ~ is skipped.
+func (r *RBACReport) String() string {
+	type row struct {
+		principal, resource string
+		access              AccessLevel
+		cells               map[string]string
+	}
+	var rows []*row
+	byKey := make(map[string]*row)
+	for _, result := range r.Results {
+		key := result.Principal + "\x00" + result.Resource
+		if byKey[key] == nil {
+			byKey[key] = &row{principal: result.Principal, resource: result.Resource, access: result.Access, cells: make(map[string]string)}
+			rows = append(rows, byKey[key])
+		}
+		byKey[key].cells[result.Operation] = result.cell()
+	}
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "PRINCIPAL\tRESOURCE\tACCESS\t%s\n", strings.Join(r.operations, "\t"))
+	for _, row := range rows {
+		var cells []string
+		for _, operation := range r.operations {
+			cells = append(cells, row.cells[operation])
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row.principal, row.resource, row.access, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RBACEngine shares the resources of RBAC matrices with their principals, then runs every operation of every
+// principal on every resource and verifies it succeeds exactly when the access of the principal allows it
+type RBACEngine struct {
+	operations map[ResourceKind][]RBACOperation
+	// order is the order of the operations in the reports
+	order []string
+	share func(r RBACResource, grants []RBACGrant) error
+}
+
+// NewRBACEngine returns an engine sharing the resources with the admin context, with the inspect and delete
+// operations of all the kinds registered
+func NewRBACEngine(d Driver, adminCtx context.Context) *RBACEngine {
+	e := &RBACEngine{
+		operations: make(map[ResourceKind][]RBACOperation),
+		share: func(r RBACResource, grants []RBACGrant) error {
+			return ShareResource(adminCtx, d, r, grants)
+		},
+	}
+	operations := defaultRBACOperations(d)
+	for _, kind := range []ResourceKind{CloudCredentialKind, BackupLocationKind, ClusterKind, BackupKind, SchedulePolicyKind, RuleKind} {
+		for _, op := range operations[kind] {
+			e.RegisterOperation(kind, op)
+		}
+	}
+	return e
+}
+
+// RegisterOperation adds an operation run on all the resources of the kind
+func (e *RBACEngine) RegisterOperation(kind ResourceKind, op RBACOperation) {
+	e.operations[kind] = append(e.operations[kind], op)
+	for _, name := range e.order {
+		if name == op.Name {
+			return
+		}
+	}
+	e.order = append(e.order, op.Name)
+	sort.SliceStable(e.order, func(i, j int) bool {
+		return rbacOperationRank(e.order[i]) < rbacOperationRank(e.order[j])
+	})
+}
+
+// rbacOperationRank orders the columns of the reports with the deletions last
+func rbacOperationRank(name string) int {
+	if strings.HasPrefix(name, "Delete") {
+		return 1
+	}
+	return 0
+}
+
+// Run shares the resources of the matrix and verifies the operations of its principals
+func (e *RBACEngine) Run(m *RBACMatrix) (*RBACReport, error) {
+	for _, resource := range m.Resources {
+		var grants []RBACGrant
+		for _, principal := range m.Principals {
+			if access := m.Access(principal.Name, resource.Key()); access != NoAccess {
+				grants = append(grants, RBACGrant{Principal: principal, Access: access})
+			}
+		}
+		if len(grants) == 0 {
+			continue
+		}
+		if err := e.share(resource, grants); err != nil {
+			return nil, fmt.Errorf("failed to share %s. Err: %v", resource.Key(), err)
+		}
+	}
+
+	report := &RBACReport{operations: e.order}
+	run := func(principal RBACPrincipal, resource RBACResource, op RBACOperation) RBACResult {
+		access := m.Access(principal.Name, resource.Key())
+		err := op.Run(principal.Ctx, resource)
+		return RBACResult{
+			Principal: principal.Name,
+			Resource:  resource.Key(),
+			Access:    access,
+			Operation: op.Name,
+			Allowed:   access >= op.MinAccess,
+			Succeeded: err == nil,
+			Err:       err,
+		}
+	}
+	for _, resource := range m.Resources {
+		for _, principal := range m.Principals {
+			for _, op := range e.operations[resource.Kind] {
+				if !op.Destructive {
+					report.Results = append(report.Results, run(principal, resource, op))
+				}
+			}
+		}
+	}
+	// resources are deleted in the reverse order of the matrix, so the ones using others go first, and by the
+	// principals denied to first
+	for i := len(m.Resources) - 1; i >= 0; i-- {
+		resource := m.Resources[i]
+		for _, op := range e.operations[resource.Kind] {
+			if !op.Destructive {
+				continue
+			}
+			principals := append([]RBACPrincipal(nil), m.Principals...)
+			sort.SliceStable(principals, func(i, j int) bool {
+				return m.Access(principals[i].Name, resource.Key()) < m.Access(principals[j].Name, resource.Key())
+			})
+			deletedBy := ""
+			for _, principal := range principals {
+				if deletedBy != "" {
+					report.Results = append(report.Results, RBACResult{
+						Principal: principal.Name,
+						Resource:  resource.Key(),
+						Access:    m.Access(principal.Name, resource.Key()),
+						Operation: op.Name,
+						Allowed:   m.Access(principal.Name, resource.Key()) >= op.MinAccess,
+						Skipped:   true,
+						Err:       fmt.Errorf("deleted by %s", deletedBy),
+					})
+					continue
+				}
+				result := run(principal, resource, op)
+				if result.Succeeded {
+					deletedBy = principal.Name
+				}
+				report.Results = append(report.Results, result)
+			}
+		}
+	}
+	return report, nil
+}
+
+// ShareResource gives the principals of the grants access to the resource, replacing the access of the
+// principals it was shared with before. Clusters are shared by sharing all their backups.
+func ShareResource(ctx context.Context, d Driver, r RBACResource, grants []RBACGrant) error {
+	var err error
+	switch r.Kind {
+	case BackupKind:
+		_, err = d.UpdateBackupShare(ctx, &api.BackupShareUpdateRequest{
+			OrgId:       r.OrgID,
+			Name:        r.Name,
+			Uid:         r.UID,
+			Backupshare: backupShare(grants),
+		})
+	case ClusterKind:
+		_, err = d.ClusterUpdateBackupShare(ctx, &api.ClusterBackupShareUpdateRequest{
+			OrgId:          r.OrgID,
+			Name:           r.Name,
+			Uid:            r.UID,
+			AddBackupShare: backupShare(grants),
+		})
+	case CloudCredentialKind:
+		_, err = d.UpdateOwnershipCloudCredential(ctx, &api.CloudCredentialOwnershipUpdateRequest{
+			OrgId:     r.OrgID,
+			Name:      r.Name,
+			Uid:       r.UID,
+			Ownership: ownership(grants),
+		})
+	case BackupLocationKind:
+		_, err = d.UpdateOwnershipBackupLocation(ctx, &api.BackupLocationOwnershipUpdateRequest{
+			OrgId:     r.OrgID,
+			Name:      r.Name,
+			Uid:       r.UID,
+			Ownership: ownership(grants),
+		})
+	case SchedulePolicyKind:
+		_, err = d.UpdateOwnershiSchedulePolicy(ctx, &api.SchedulePolicyOwnershipUpdateRequest{
+			OrgId:     r.OrgID,
+			Name:      r.Name,
+			Uid:       r.UID,
+			Ownership: ownership(grants),
+		})
+	case RuleKind:
+		_, err = d.UpdateOwnershipRule(ctx, &api.RuleOwnershipUpdateRequest{
+			OrgId:     r.OrgID,
+			Name:      r.Name,
+			Uid:       r.UID,
+			Ownership: ownership(grants),
+		})
+	default:
+		return fmt.Errorf("sharing objects of kind [%s] is not supported", r.Kind)
+	}
+	return err
+}
+
+func backupShare(grants []RBACGrant) *api.BackupShare {
+	share := &api.BackupShare{}
+	for _, grant := range grants {
+		config := &api.BackupShare_AccessConfig{Id: grant.Principal.ID, Access: api.BackupShare_AccessType(grant.Access)}
+		if grant.Principal.Group {
+			share.Groups = append(share.Groups, config)
+		} else {
+			share.Collaborators = append(share.Collaborators, config)
+		}
+	}
+	return share
+}
+
+// ownership maps view, restorable and full access to read, write and admin ownership
+func ownership(grants []RBACGrant) *api.Ownership {
+	ownership := &api.Ownership{}
+	for _, grant := range grants {
+		config := &api.Ownership_AccessConfig{Id: grant.Principal.ID, Access: api.Ownership_AccessType(grant.Access)}
+		if grant.Principal.Group {
+			ownership.Groups = append(ownership.Groups, config)
+		} else {
+			ownership.Collaborators = append(ownership.Collaborators, config)
+		}
+	}
+	return ownership
+}
+
+// defaultRBACOperations returns the operations of every kind which only need the driver
+func defaultRBACOperations(d Driver) map[ResourceKind][]RBACOperation {
+	return map[ResourceKind][]RBACOperation{
+		CloudCredentialKind: {
+			{Name: "Inspect", MinAccess: ViewAccess, Run: func(ctx context.Context, r RBACResource) error {
+				_, err := d.InspectCloudCredential(ctx, &api.CloudCredentialInspectRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+				return err
+			}},
+			{Name: "Delete", MinAccess: FullAccess, Destructive: true, Run: func(ctx context.Context, r RBACResource) error {
+				_, err := d.DeleteCloudCredential(ctx, &api.CloudCredentialDeleteRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+				return err
+			}},
+		},
+		BackupLocationKind: {
+			{Name: "Inspect", MinAccess: ViewAccess, Run: func(ctx context.Context, r RBACResource) error {
+				_, err := d.InspectBackupLocation(ctx, &api.BackupLocationInspectRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+				return err
+			}},
+			{Name: "Delete", MinAccess: FullAccess, Destructive: true, Run: func(ctx context.Context, r RBACResource) error {
+				_, err := d.DeleteBackupLocation(ctx, &api.BackupLocationDeleteRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+				return err
+			}},
+		},
+		SchedulePolicyKind: {
+			{Name: "Inspect", MinAccess: ViewAccess, Run: func(ctx context.Context, r RBACResource) error {
+				_, err := d.InspectSchedulePolicy(ctx, &api.SchedulePolicyInspectRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+				return err
+			}},
+			{Name: "Delete", MinAccess: FullAccess, Destructive: true, Run: func(ctx context.Context, r RBACResource) error {
+				_, err := d.DeleteSchedulePolicy(ctx, &api.SchedulePolicyDeleteRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+				return err
+			}},
+		},
+		RuleKind: {
+			{Name: "Inspect", MinAccess: ViewAccess, Run: func(ctx context.Context, r RBACResource) error {
+				_, err := d.InspectRule(ctx, &api.RuleInspectRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+				return err
+			}},
+			{Name: "Delete", MinAccess: FullAccess, Destructive: true, Run: func(ctx context.Context, r RBACResource) error {
+				_, err := d.DeleteRule(ctx, &api.RuleDeleteRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+				return err
+			}},
+		},
+		BackupKind: {
+			{Name: "Inspect", MinAccess: ViewAccess, Run: func(ctx context.Context, r RBACResource) error {
+				_, err := d.InspectBackup(ctx, &api.BackupInspectRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+				return err
+			}},
+			{Name: "Delete", MinAccess: FullAccess, Destructive: true, Run: func(ctx context.Context, r RBACResource) error {
+				_, err := d.DeleteBackup(ctx, &api.BackupDeleteRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+				return err
+			}},
+		},
+		ClusterKind: {
+			{Name: "InspectBackups", MinAccess: ViewAccess, Run: func(ctx context.Context, r RBACResource) error {
+				return inspectClusterBackups(ctx, d, r)
+			}},
+		},
+	}
+}
+
+// inspectClusterBackups inspects every backup of the cluster visible to the context, and fails if there is none
+func inspectClusterBackups(ctx context.Context, d Driver, r RBACResource) error {
+	resp, err := d.EnumerateBackup(ctx, &api.BackupEnumerateRequest{
+		OrgId:            r.OrgID,
+		EnumerateOptions: &api.EnumerateOptions{ClusterNameFilter: r.Name, ClusterUidFilter: r.UID},
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.GetBackups()) == 0 {
+		return fmt.Errorf("no backup of cluster [%s] is visible", r.Name)
+	}
+	for _, backup := range resp.GetBackups() {
+		if _, err := d.InspectBackup(ctx, &api.BackupInspectRequest{OrgId: r.OrgID, Name: backup.GetName(), Uid: backup.GetUid()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
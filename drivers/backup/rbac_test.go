@@ -0,0 +1,129 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type principalKey struct{}
+
+// fakeACL enforces the access shared through the engine, except for the principals it lets bypass it
+type fakeACL struct {
+	access  map[string]map[string]AccessLevel
+	bypass  map[string]bool
+	deleted map[string]bool
+	shared  []string
+}
+
+func newTestRBACEngine(acl *fakeACL) *RBACEngine {
+	e := &RBACEngine{
+		operations: make(map[ResourceKind][]RBACOperation),
+		share: func(r RBACResource, grants []RBACGrant) error {
+			for _, grant := range grants {
+				if acl.access[grant.Principal.Name] == nil {
+					acl.access[grant.Principal.Name] = make(map[string]AccessLevel)
+				}
+				acl.access[grant.Principal.Name][r.Key()] = grant.Access
+				acl.shared = append(acl.shared, fmt.Sprintf("%s:%s=%s", r.Key(), grant.Principal.Name, grant.Access))
+			}
+			return nil
+		},
+	}
+	check := func(min AccessLevel) func(ctx context.Context, r RBACResource) error {
+		return func(ctx context.Context, r RBACResource) error {
+			principal := ctx.Value(principalKey{}).(string)
+			if acl.deleted[r.Key()] {
+				return fmt.Errorf("%s not found", r.Key())
+			}
+			if !acl.bypass[principal] && acl.access[principal][r.Key()] < min {
+				return fmt.Errorf("%s doesn't have permission", principal)
+			}
+			if min == FullAccess {
+				acl.deleted[r.Key()] = true
+			}
+			return nil
+		}
+	}
+	e.RegisterOperation(BackupKind, RBACOperation{Name: "Delete", MinAccess: FullAccess, Destructive: true, Run: check(FullAccess)})
+	e.RegisterOperation(BackupKind, RBACOperation{Name: "Inspect", MinAccess: ViewAccess, Run: check(ViewAccess)})
+	e.RegisterOperation(BackupKind, RBACOperation{Name: "Restore", MinAccess: RestorableAccess, Run: check(RestorableAccess)})
+	e.RegisterOperation(BackupLocationKind, RBACOperation{Name: "Inspect", MinAccess: ViewAccess, Run: check(ViewAccess)})
+	return e
+}
+
+func testPrincipal(name string) RBACPrincipal {
+	return RBACPrincipal{Name: name, ID: name, Ctx: context.WithValue(context.Background(), principalKey{}, name)}
+}
+
+func TestRBACEngine(t *testing.T) {
+	acl := &fakeACL{access: make(map[string]map[string]AccessLevel), bypass: make(map[string]bool), deleted: make(map[string]bool)}
+	e := newTestRBACEngine(acl)
+	m := &RBACMatrix{
+		Principals: []RBACPrincipal{testPrincipal("viewer"), testPrincipal("restorer"), testPrincipal("owner"), testPrincipal("stranger")},
+		Resources: []RBACResource{
+			{Kind: BackupLocationKind, Name: "bl"},
+			{Kind: BackupKind, Name: "bkp"},
+		},
+	}
+	m.Grant("viewer", "Backup/bkp", ViewAccess).
+		Grant("restorer", "Backup/bkp", RestorableAccess).
+		Grant("owner", "Backup/bkp", FullAccess).
+		Grant("owner", "BackupLocation/bl", ViewAccess)
+
+	report, err := e.Run(m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BackupLocation/bl:owner=View", "Backup/bkp:viewer=View", "Backup/bkp:restorer=Restorable", "Backup/bkp:owner=Full"}, acl.shared)
+	assert.NoError(t, report.Err())
+	// 4 principals x (1 location operation + 3 backup operations)
+	assert.Len(t, report.Results, 16)
+	assert.Equal(t, `PRINCIPAL  RESOURCE           ACCESS      Inspect  Restore  Delete
+viewer     BackupLocation/bl  None        -
+restorer   BackupLocation/bl  None        -
+owner      BackupLocation/bl  View        +
+stranger   BackupLocation/bl  None        -
+viewer     Backup/bkp         View        +        -        -
+restorer   Backup/bkp         Restorable  +        +        -
+owner      Backup/bkp         Full        +        +        +
+stranger   Backup/bkp         None        -        -        -
+`, report.String())
+
+	// a principal bypassing the access checks deletes the backup before the owner
+	acl = &fakeACL{access: make(map[string]map[string]AccessLevel), bypass: map[string]bool{"stranger": true}, deleted: make(map[string]bool)}
+	e = newTestRBACEngine(acl)
+	report, err = e.Run(m)
+	require.NoError(t, err)
+	err = report.Err()
+	require.Error(t, err)
+	failures := report.Failures()
+	require.Len(t, failures, 4)
+	for _, failure := range failures {
+		assert.Equal(t, "stranger", failure.Principal)
+		assert.False(t, failure.Allowed)
+		assert.True(t, failure.Succeeded)
+	}
+	assert.Contains(t, err.Error(), "stranger with None access to Backup/bkp: Delete should be denied but got [<nil>]")
+	lines := strings.Split(report.String(), "\n")
+	assert.Equal(t, "owner      Backup/bkp         Full        +        +        ~", lines[7])
+	assert.Equal(t, "stranger   Backup/bkp         None        !-       !-       !-", lines[8])
+}
+
+func TestShareRequests(t *testing.T) {
+	grants := []RBACGrant{
+		{Principal: RBACPrincipal{Name: "user", ID: "user-id"}, Access: RestorableAccess},
+		{Principal: RBACPrincipal{Name: "admins", ID: "admins", Group: true}, Access: FullAccess},
+	}
+	assert.Equal(t, &api.BackupShare{
+		Collaborators: []*api.BackupShare_AccessConfig{{Id: "user-id", Access: api.BackupShare_Restorable}},
+		Groups:        []*api.BackupShare_AccessConfig{{Id: "admins", Access: api.BackupShare_FullAccess}},
+	}, backupShare(grants))
+	assert.Equal(t, &api.Ownership{
+		Collaborators: []*api.Ownership_AccessConfig{{Id: "user-id", Access: api.Ownership_Write}},
+		Groups:        []*api.Ownership_AccessConfig{{Id: "admins", Access: api.Ownership_Admin}},
+	}, ownership(grants))
+}
@@ -0,0 +1,170 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"github.com/portworx/torpedo/drivers/objectstore"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const retentionEnumerateBatchSize = 100
+
+// RetentionValidator checks, over the lifetime of a backup schedule, that px-backup and the bucket of the
+// backup location retain as many backups as the retain count of the schedule policy. It remembers the
+// backups it has seen, so the objects of the expired ones can be checked after px-backup forgets them.
+type RetentionValidator struct {
+	driver   Driver
+	store    objectstore.Driver
+	bucket   string
+	orgID    string
+	schedule string
+	retain   int64
+	locked   bool
+
+	// seen are the backups of the schedule seen so far by name
+	seen map[string]*retainedBackup
+}
+
+// retainedBackup is a backup of the schedule seen by the validator
+type retainedBackup struct {
+	path string
+	// retainUntil is the time until which the objects of the backup are locked, zero if they are not
+	retainUntil time.Time
+}
+
+// NewRetentionValidator returns a validator of the backups of the given schedule, stored in the given
+// bucket. If the bucket has object lock enabled, expired backups stay in the bucket until their retention
+// expires, whatever the lock mode.
+func NewRetentionValidator(d Driver, store objectstore.Driver, bucket, orgID, scheduleName string, retain int64, locked bool) *RetentionValidator {
+	return &RetentionValidator{
+		driver:   d,
+		store:    store,
+		bucket:   bucket,
+		orgID:    orgID,
+		schedule: scheduleName,
+		retain:   retain,
+		locked:   locked,
+		seen:     make(map[string]*retainedBackup),
+	}
+}
+
+// Validate checks the backups of the schedule retained by px-backup and in the bucket at this point of the
+// schedule lifetime, and returns all the violations found
+func (v *RetentionValidator) Validate(ctx context.Context) error {
+	backups, err := v.scheduleBackups(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := v.store.ListFilesInBucket(v.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to list the objects of bucket [%s]. Err: %v", v.bucket, err)
+	}
+	now := time.Now()
+
+	var violations []string
+	var retained []string
+	for _, backup := range backups {
+		seen, ok := v.seen[backup.GetName()]
+		if !ok {
+			seen = &retainedBackup{path: backup.GetBackupPath()}
+			v.seen[backup.GetName()] = seen
+		}
+		status := backup.GetStatus().GetStatus()
+		if status == api.BackupInfo_StatusInfo_DeletePending || status == api.BackupInfo_StatusInfo_Deleting {
+			continue
+		}
+		retained = append(retained, backup.GetName())
+		if status != api.BackupInfo_StatusInfo_Success && status != api.BackupInfo_StatusInfo_PartialSuccess {
+			continue
+		}
+		objects := objectsUnder(keys, seen.path)
+		if len(objects) == 0 {
+			violations = append(violations, fmt.Sprintf("backup [%s] is retained but bucket [%s] has no object under [%s]", backup.GetName(), v.bucket, seen.path))
+			continue
+		}
+		if v.locked && seen.retainUntil.IsZero() {
+			md, err := v.store.GetObjectMetadata(v.bucket, objects[0])
+			if err != nil {
+				return err
+			}
+			if md.RetentionMode == "" || !md.RetainUntil.After(now) {
+				violations = append(violations, fmt.Sprintf("object [%s] of backup [%s] is not locked in bucket [%s]", objects[0], backup.GetName(), v.bucket))
+			}
+			seen.retainUntil = md.RetainUntil
+		}
+	}
+	if int64(len(retained)) > v.retain {
+		violations = append(violations, fmt.Sprintf("px-backup retains %d backups %v of schedule [%s] but its policy retains %d", len(retained), retained, v.schedule, v.retain))
+	}
+
+	inPxBackup := make(map[string]bool)
+	for _, backup := range backups {
+		inPxBackup[backup.GetName()] = true
+	}
+	for name, seen := range v.seen {
+		if inPxBackup[name] {
+			continue
+		}
+		present := len(objectsUnder(keys, seen.path)) > 0
+		switch {
+		case seen.retainUntil.After(now) && !present:
+			violations = append(violations, fmt.Sprintf("objects of expired backup [%s] were deleted from bucket [%s] before their retention expired at %v", name, v.bucket, seen.retainUntil))
+		case seen.retainUntil.After(now):
+			violations = append(violations, fmt.Sprintf("expired backup [%s] was deleted from px-backup before the retention of its objects expired at %v", name, seen.retainUntil))
+		case present:
+			violations = append(violations, fmt.Sprintf("expired backup [%s] was deleted from px-backup but bucket [%s] still has objects under [%s]", name, v.bucket, seen.path))
+		}
+	}
+
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return fmt.Errorf("retention of schedule [%s] is violated:\n%s", v.schedule, strings.Join(violations, "\n"))
+	}
+	log.Infof("Schedule [%s] retains %d backups %v out of %d seen", v.schedule, len(retained), retained, len(v.seen))
+	return nil
+}
+
+// scheduleBackups returns the backups of the schedule known to px-backup in creation order
+func (v *RetentionValidator) scheduleBackups(ctx context.Context) ([]*api.BackupObject, error) {
+	var backups []*api.BackupObject
+	req := &api.BackupEnumerateRequest{
+		OrgId:            v.orgID,
+		EnumerateOptions: &api.EnumerateOptions{MaxObjects: retentionEnumerateBatchSize},
+	}
+	for {
+		resp, err := v.driver.EnumerateBackup(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to enumerate the backups of org [%s]. Err: %v", v.orgID, err)
+		}
+		for _, backup := range resp.GetBackups() {
+			if backup.GetBackupSchedule().GetName() == v.schedule {
+				backups = append(backups, backup)
+			}
+		}
+		req.EnumerateOptions.ObjectIndex += uint64(len(resp.GetBackups()))
+		if len(resp.GetBackups()) == 0 || req.EnumerateOptions.ObjectIndex >= resp.GetTotalCount() {
+			break
+		}
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].GetCreateTime().GetSeconds() < backups[j].GetCreateTime().GetSeconds()
+	})
+	return backups, nil
+}
+
+// objectsUnder returns the keys stored under the given path
+func objectsUnder(keys []string, path string) []string {
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	var objects []string
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, key)
+		}
+	}
+	return objects
+}
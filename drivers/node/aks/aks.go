@@ -1,10 +1,12 @@
 package aks
 
 import (
-	"github.com/portworx/torpedo/pkg/log"
+	"fmt"
 	"os"
 	"time"
 
+	"github.com/portworx/torpedo/pkg/log"
+
 	"github.com/libopenstorage/cloudops"
 	"github.com/libopenstorage/cloudops/azure"
 	"github.com/portworx/torpedo/drivers/node"
@@ -19,6 +21,7 @@ const (
 type aks struct {
 	ssh.SSH
 	ops           cloudops.Ops
+	compute       computeOps
 	instanceGroup string
 }
 
@@ -42,6 +45,8 @@ func (a *aks) Init(nodeOpts node.InitOptions) error {
 	}
 	a.ops = ops
 
+	a.compute = newComputeOps()
+
 	return nil
 }
 
@@ -77,6 +82,85 @@ func (a *aks) GetZones() ([]string, error) {
 	return []string{""}, nil
 }
 
+// SetClusterVersion upgrades the control plane and then the node pool to the given version
+func (a *aks) SetClusterVersion(version string, timeout time.Duration) error {
+	// the azure driver of cloudops does not support upgrades, the managed cluster is updated directly
+	err := a.compute.SetClusterVersion(version, timeout)
+	if err != nil {
+		log.Errorf("failed to set version for cluster. Error: %v", err)
+		return err
+	}
+	log.Infof("Cluster version set successfully. Setting up node pool version now ...")
+
+	err = a.compute.SetAgentPoolVersion(a.instanceGroup, version, timeout)
+	if err != nil {
+		log.Errorf("failed to set version for node pool %s. Error: %v", a.instanceGroup, err)
+		return err
+	}
+	log.Infof("Node pool version set successfully.")
+
+	return nil
+}
+
+// DeleteNode deletes the scale set instance of the node. The node pool shrinks by one instance, it is not
+// replaced until the node pool is scaled back with SetASGClusterSize
+func (a *aks) DeleteNode(n node.Node, timeout time.Duration) error {
+	log.Infof("Deleting instance of node %s", n.Name)
+	if err := a.compute.DeleteInstance(n.Name, timeout); err != nil {
+		return &node.ErrFailedToDeleteNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to delete instance due to: %v", err),
+		}
+	}
+	return nil
+}
+
+// RebootNode restarts the scale set instance of the node
+func (a *aks) RebootNode(n node.Node, options node.RebootNodeOpts) error {
+	log.Infof("Restarting instance of node %s", n.Name)
+	if err := a.compute.RestartInstance(n.Name); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to restart instance due to: %v", err),
+		}
+	}
+	return nil
+}
+
+// ShutdownNode powers off the scale set instance of the node
+func (a *aks) ShutdownNode(n node.Node, options node.ShutdownNodeOpts) error {
+	log.Infof("Powering off instance of node %s", n.Name)
+	if err := a.compute.PowerOffInstance(n.Name); err != nil {
+		return &node.ErrFailedToShutdownNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to power off instance due to: %v", err),
+		}
+	}
+	return nil
+}
+
+// PowerOnVM starts the scale set instance of the node
+func (a *aks) PowerOnVM(n node.Node) error {
+	log.Infof("Starting instance of node %s", n.Name)
+	if err := a.compute.StartInstance(n.Name); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to start instance due to: %v", err),
+		}
+	}
+	return nil
+}
+
+// PowerOffVM powers off the scale set instance of the node
+func (a *aks) PowerOffVM(n node.Node) error {
+	return a.ShutdownNode(n, node.ShutdownNodeOpts{})
+}
+
+// GetNodeState returns the power state of the scale set instance of the node, e.g. running or stopped
+func (a *aks) GetNodeState(n node.Node) (string, error) {
+	return a.compute.InstancePowerState(n.Name)
+}
+
 func init() {
 	a := &aks{
 		SSH: *ssh.New(),
@@ -0,0 +1,132 @@
+package aks
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libopenstorage/cloudops"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOps serves the node pool size, the operations the driver does not use are left nil
+type fakeOps struct {
+	cloudops.Ops
+	size int64
+}
+
+func (f *fakeOps) GetInstanceGroupSize(instanceGroupID string) (int64, error) {
+	return f.size, nil
+}
+
+func (f *fakeOps) SetInstanceGroupSize(instanceGroupID string, count int64, timeout time.Duration) error {
+	f.size = count
+	return nil
+}
+
+// fakeCompute records the calls and keeps the power state of the instances of the nodes
+type fakeCompute struct {
+	calls []string
+	state map[string]string
+	err   error
+}
+
+func (f *fakeCompute) set(nodeName, state string) error {
+	if _, ok := f.state[nodeName]; !ok {
+		return fmt.Errorf("instance of node %s not found", nodeName)
+	}
+	f.state[nodeName] = state
+	return nil
+}
+
+func (f *fakeCompute) RestartInstance(nodeName string) error { return f.set(nodeName, "running") }
+
+func (f *fakeCompute) PowerOffInstance(nodeName string) error { return f.set(nodeName, "stopped") }
+
+func (f *fakeCompute) StartInstance(nodeName string) error { return f.set(nodeName, "running") }
+
+func (f *fakeCompute) DeleteInstance(nodeName string, timeout time.Duration) error {
+	f.calls = append(f.calls, "DeleteInstance "+nodeName)
+	if err := f.set(nodeName, "deleted"); err != nil {
+		return err
+	}
+	delete(f.state, nodeName)
+	return nil
+}
+
+func (f *fakeCompute) InstancePowerState(nodeName string) (string, error) {
+	state, ok := f.state[nodeName]
+	if !ok {
+		return "", fmt.Errorf("instance of node %s not found", nodeName)
+	}
+	return state, nil
+}
+
+func (f *fakeCompute) SetClusterVersion(version string, timeout time.Duration) error {
+	f.calls = append(f.calls, "SetClusterVersion "+version)
+	return f.err
+}
+
+func (f *fakeCompute) SetAgentPoolVersion(agentPool, version string, timeout time.Duration) error {
+	f.calls = append(f.calls, fmt.Sprintf("SetAgentPoolVersion %s %s", agentPool, version))
+	return f.err
+}
+
+func TestParseProviderID(t *testing.T) {
+	subscriptionID, resourceGroup, scaleSet, instanceID, err := parseProviderID("azure:///subscriptions/0000-1111/resourceGroups/mc_px_px-aks_eastus/providers/Microsoft.Compute/virtualMachineScaleSets/aks-nodepool1-12345678-vmss/virtualMachines/3")
+	require.NoError(t, err)
+	assert.Equal(t, "0000-1111", subscriptionID)
+	assert.Equal(t, "mc_px_px-aks_eastus", resourceGroup)
+	assert.Equal(t, "aks-nodepool1-12345678-vmss", scaleSet)
+	assert.Equal(t, "3", instanceID)
+
+	// nodes of availability set pools are plain VMs, not scale set instances
+	_, _, _, _, err = parseProviderID("azure:///subscriptions/0000-1111/resourceGroups/mc_px/providers/Microsoft.Compute/virtualMachines/aks-agentpool-0")
+	assert.Error(t, err)
+}
+
+func TestPowerOperations(t *testing.T) {
+	a := &aks{compute: &fakeCompute{state: map[string]string{"node1": "running"}}}
+	n := node.Node{Name: "node1"}
+
+	require.NoError(t, a.PowerOffVM(n))
+	state, err := a.GetNodeState(n)
+	require.NoError(t, err)
+	assert.Equal(t, "stopped", state)
+	require.NoError(t, a.PowerOnVM(n))
+	require.NoError(t, a.RebootNode(n, node.RebootNodeOpts{}))
+	state, err = a.GetNodeState(n)
+	require.NoError(t, err)
+	assert.Equal(t, "running", state)
+
+	err = a.ShutdownNode(node.Node{Name: "node2"}, node.ShutdownNodeOpts{})
+	assert.IsType(t, &node.ErrFailedToShutdownNode{}, err)
+}
+
+func TestDeleteNodeAndUpgrade(t *testing.T) {
+	compute := &fakeCompute{state: map[string]string{"node1": "running"}}
+	ops := &fakeOps{size: 3}
+	a := &aks{ops: ops, compute: compute, instanceGroup: "nodepool1"}
+
+	require.NoError(t, a.DeleteNode(node.Node{Name: "node1"}, time.Minute))
+	assert.IsType(t, &node.ErrFailedToDeleteNode{}, a.DeleteNode(node.Node{Name: "node1"}, time.Minute))
+	require.NoError(t, a.SetClusterVersion("1.26.3", time.Minute))
+	assert.Equal(t, []string{
+		"DeleteInstance node1",
+		"DeleteInstance node1",
+		"SetClusterVersion 1.26.3",
+		"SetAgentPoolVersion nodepool1 1.26.3",
+	}, compute.calls)
+
+	compute.err = fmt.Errorf("upgrade in progress")
+	compute.calls = nil
+	assert.Error(t, a.SetClusterVersion("1.26.3", time.Minute))
+	assert.Equal(t, []string{"SetClusterVersion 1.26.3"}, compute.calls)
+
+	require.NoError(t, a.SetASGClusterSize(5, time.Minute))
+	size, err := a.GetASGClusterSize()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), size)
+}
@@ -0,0 +1,246 @@
+package aks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-12-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2019-02-01/containerservice"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/portworx/sched-ops/k8s/core"
+)
+
+const (
+	envSubscriptionID     = "AZURE_SUBSCRIPTION_ID"
+	envResourceGroupName  = "AZURE_RESOURCE_GROUP_NAME"
+	envManagedClusterName = "AZURE_MANAGED_CLUSTER_NAME"
+
+	powerStatePrefix = "PowerState/"
+	operationTimeout = 15 * time.Minute
+)
+
+// vmssProviderID matches the provider ID of a node of a VMSS node pool
+var vmssProviderID = regexp.MustCompile(`(?i)^azure:///subscriptions/([^/]+)/resourceGroups/([^/]+)/providers/Microsoft.Compute/virtualMachineScaleSets/([^/]+)/virtualMachines/([^/]+)$`)
+
+// computeOps are the operations on the instances and the cluster cloudops does not provide for Azure
+type computeOps interface {
+	// RestartInstance restarts the scale set instance of the given node
+	RestartInstance(nodeName string) error
+	// PowerOffInstance powers off the scale set instance of the given node without shutting it down
+	PowerOffInstance(nodeName string) error
+	// StartInstance starts the scale set instance of the given node
+	StartInstance(nodeName string) error
+	// DeleteInstance deletes the scale set instance of the given node
+	DeleteInstance(nodeName string, timeout time.Duration) error
+	// InstancePowerState returns the power state of the scale set instance of the given node, e.g. running or stopped
+	InstancePowerState(nodeName string) (string, error)
+	// SetClusterVersion upgrades the control plane of the cluster to the given version
+	SetClusterVersion(version string, timeout time.Duration) error
+	// SetAgentPoolVersion upgrades the given node pool to the given version
+	SetAgentPoolVersion(agentPool, version string, timeout time.Duration) error
+}
+
+type azureCompute struct {
+	// subscriptionID is the subscription of the cluster. The instances use the subscription in their provider ID
+	subscriptionID string
+	resourceGroup  string
+	managedCluster string
+
+	authorizerOnce sync.Once
+	authorizer     autorest.Authorizer
+	authorizerErr  error
+}
+
+func newComputeOps() computeOps {
+	return &azureCompute{
+		subscriptionID: os.Getenv(envSubscriptionID),
+		resourceGroup:  os.Getenv(envResourceGroupName),
+		managedCluster: os.Getenv(envManagedClusterName),
+	}
+}
+
+// getAuthorizer creates the authorizer from the environment on first use, so the driver initializes without
+// azure credentials when no compute operation runs
+func (c *azureCompute) getAuthorizer() (autorest.Authorizer, error) {
+	c.authorizerOnce.Do(func() {
+		c.authorizer, c.authorizerErr = auth.NewAuthorizerFromEnvironment()
+		if c.authorizerErr != nil {
+			c.authorizerErr = fmt.Errorf("failed to create azure authorizer: %v", c.authorizerErr)
+		}
+	})
+	return c.authorizer, c.authorizerErr
+}
+
+func (c *azureCompute) vmsClient(subscriptionID string) (compute.VirtualMachineScaleSetVMsClient, error) {
+	client := compute.NewVirtualMachineScaleSetVMsClient(subscriptionID)
+	authorizer, err := c.getAuthorizer()
+	client.Authorizer = authorizer
+	return client, err
+}
+
+// clusterClients returns the clients of the managed cluster, which need the subscription, resource group and
+// name of the cluster from the environment
+func (c *azureCompute) clusterClients() (containerservice.ManagedClustersClient, containerservice.AgentPoolsClient, error) {
+	clusters := containerservice.NewManagedClustersClient(c.subscriptionID)
+	agentPools := containerservice.NewAgentPoolsClient(c.subscriptionID)
+	if len(c.subscriptionID) == 0 || len(c.resourceGroup) == 0 || len(c.managedCluster) == 0 {
+		return clusters, agentPools, fmt.Errorf("env vars %s, %s and %s are required to upgrade the cluster",
+			envSubscriptionID, envResourceGroupName, envManagedClusterName)
+	}
+	authorizer, err := c.getAuthorizer()
+	clusters.Authorizer = authorizer
+	agentPools.Authorizer = authorizer
+	return clusters, agentPools, err
+}
+
+// parseProviderID returns the subscription, resource group, scale set and instance ID of a VMSS instance from its
+// provider ID
+func parseProviderID(providerID string) (string, string, string, string, error) {
+	match := vmssProviderID.FindStringSubmatch(providerID)
+	if match == nil {
+		return "", "", "", "", fmt.Errorf("invalid Azure scale set provider ID [%s]", providerID)
+	}
+	return match[1], match[2], match[3], match[4], nil
+}
+
+// getInstance returns the scale set VMs client of the subscription of the instance of the given node, and the
+// resource group, scale set and instance ID of the instance
+func (c *azureCompute) getInstance(nodeName string) (compute.VirtualMachineScaleSetVMsClient, string, string, string, error) {
+	var vms compute.VirtualMachineScaleSetVMsClient
+	k8sNode, err := core.Instance().GetNodeByName(nodeName)
+	if err != nil {
+		return vms, "", "", "", err
+	}
+	subscriptionID, resourceGroup, scaleSet, instanceID, err := parseProviderID(k8sNode.Spec.ProviderID)
+	if err != nil {
+		return vms, "", "", "", err
+	}
+	vms, err = c.vmsClient(subscriptionID)
+	return vms, resourceGroup, scaleSet, instanceID, err
+}
+
+func (c *azureCompute) RestartInstance(nodeName string) error {
+	vms, resourceGroup, scaleSet, instanceID, err := c.getInstance(nodeName)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	future, err := vms.Restart(ctx, resourceGroup, scaleSet, instanceID)
+	if err != nil {
+		return err
+	}
+	return future.WaitForCompletionRef(ctx, vms.Client)
+}
+
+func (c *azureCompute) PowerOffInstance(nodeName string) error {
+	vms, resourceGroup, scaleSet, instanceID, err := c.getInstance(nodeName)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	skipShutdown := true
+	future, err := vms.PowerOff(ctx, resourceGroup, scaleSet, instanceID, &skipShutdown)
+	if err != nil {
+		return err
+	}
+	return future.WaitForCompletionRef(ctx, vms.Client)
+}
+
+func (c *azureCompute) StartInstance(nodeName string) error {
+	vms, resourceGroup, scaleSet, instanceID, err := c.getInstance(nodeName)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	future, err := vms.Start(ctx, resourceGroup, scaleSet, instanceID)
+	if err != nil {
+		return err
+	}
+	return future.WaitForCompletionRef(ctx, vms.Client)
+}
+
+func (c *azureCompute) DeleteInstance(nodeName string, timeout time.Duration) error {
+	vms, resourceGroup, scaleSet, instanceID, err := c.getInstance(nodeName)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	future, err := vms.Delete(ctx, resourceGroup, scaleSet, instanceID)
+	if err != nil {
+		return err
+	}
+	return future.WaitForCompletionRef(ctx, vms.Client)
+}
+
+func (c *azureCompute) InstancePowerState(nodeName string) (string, error) {
+	vms, resourceGroup, scaleSet, instanceID, err := c.getInstance(nodeName)
+	if err != nil {
+		return "", err
+	}
+	view, err := vms.GetInstanceView(context.Background(), resourceGroup, scaleSet, instanceID)
+	if err != nil {
+		return "", err
+	}
+	if view.Statuses != nil {
+		for _, status := range *view.Statuses {
+			if status.Code != nil && strings.HasPrefix(*status.Code, powerStatePrefix) {
+				return strings.TrimPrefix(*status.Code, powerStatePrefix), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("power state of node %s not found in instance view", nodeName)
+}
+
+func (c *azureCompute) SetClusterVersion(version string, timeout time.Duration) error {
+	clusters, _, err := c.clusterClients()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cluster, err := clusters.Get(ctx, c.resourceGroup, c.managedCluster)
+	if err != nil {
+		return err
+	}
+	if cluster.ManagedClusterProperties == nil {
+		return fmt.Errorf("properties of managed cluster %s not found", c.managedCluster)
+	}
+	cluster.KubernetesVersion = &version
+	future, err := clusters.CreateOrUpdate(ctx, c.resourceGroup, c.managedCluster, cluster)
+	if err != nil {
+		return err
+	}
+	return future.WaitForCompletionRef(ctx, clusters.Client)
+}
+
+func (c *azureCompute) SetAgentPoolVersion(agentPool, version string, timeout time.Duration) error {
+	_, agentPools, err := c.clusterClients()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	pool, err := agentPools.Get(ctx, c.resourceGroup, c.managedCluster, agentPool)
+	if err != nil {
+		return err
+	}
+	if pool.ManagedClusterAgentPoolProfileProperties == nil {
+		return fmt.Errorf("properties of node pool %s not found", agentPool)
+	}
+	pool.OrchestratorVersion = &version
+	future, err := agentPools.CreateOrUpdate(ctx, c.resourceGroup, c.managedCluster, agentPool, pool)
+	if err != nil {
+		return err
+	}
+	return future.WaitForCompletionRef(ctx, agentPools.Client)
+}
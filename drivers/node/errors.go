@@ -34,6 +34,58 @@ func (e *ErrFailedToSetNetworkErrorOnNode) Error() string {
 	return fmt.Sprintf("Failed to set packet loss on node: %v. Cause: %v", e.Node.Name, e.Cause)
 }
 
+// ErrFailedToPartitionNetwork error type when failing to apply or heal a network partition on a node
+type ErrFailedToPartitionNetwork struct {
+	Node  Node
+	Cause string
+}
+
+func (e *ErrFailedToPartitionNetwork) Error() string {
+	return fmt.Sprintf("Failed to partition network on node: %v. Cause: %v", e.Node.Name, e.Cause)
+}
+
+// ErrFailedToInjectDiskFault error type when failing to inject or revert a disk fault
+type ErrFailedToInjectDiskFault struct {
+	Node  Node
+	Drive string
+	Cause string
+}
+
+func (e *ErrFailedToInjectDiskFault) Error() string {
+	return fmt.Sprintf("Failed to inject disk fault on drive %v of node: %v. Cause: %v", e.Drive, e.Node.Name, e.Cause)
+}
+
+// ErrFailedToApplyResourcePressure error type when failing to apply or revert a resource pressure
+type ErrFailedToApplyResourcePressure struct {
+	Node  Node
+	Type  ResourcePressureType
+	Cause string
+}
+
+func (e *ErrFailedToApplyResourcePressure) Error() string {
+	return fmt.Sprintf("Failed to apply %v pressure on node: %v. Cause: %v", e.Type, e.Node.Name, e.Cause)
+}
+
+// ErrFailedToUpdateVM error type when failing to attach or detach a disk or to change a link of the VM of a node
+type ErrFailedToUpdateVM struct {
+	Node  Node
+	Cause string
+}
+
+func (e *ErrFailedToUpdateVM) Error() string {
+	return fmt.Sprintf("Failed to update VM of node: %v. Cause: %v", e.Node.Name, e.Cause)
+}
+
+// ErrFailedToUpgradeNodeOS error type when failing to install packages on a node or to reboot it into them
+type ErrFailedToUpgradeNodeOS struct {
+	Node  Node
+	Cause string
+}
+
+func (e *ErrFailedToUpgradeNodeOS) Error() string {
+	return fmt.Sprintf("Failed to upgrade OS of node: %v. Cause: %v", e.Node.Name, e.Cause)
+}
+
 // ErrFailedToCrashNode error type when failing to reboot a node after a crash
 type ErrFailedToCrashNode struct {
 	Node  Node
@@ -85,6 +137,17 @@ func (e *ErrFailedToRunCommand) Error() string {
 	return fmt.Sprintf("Failed to run command on: %v. Cause: %v", e.Addr, e.Cause)
 }
 
+// ErrFailedToCopyFile error type when failing to copy a file to or from a node
+type ErrFailedToCopyFile struct {
+	Node  Node
+	Path  string
+	Cause string
+}
+
+func (e *ErrFailedToCopyFile) Error() string {
+	return fmt.Sprintf("Failed to copy %v on node: %v. Cause: %v", e.Path, e.Node.Name, e.Cause)
+}
+
 // ErrFailedToYankDrive error type when we fail to simulate drive failure
 type ErrFailedToYankDrive struct {
 	Node  Node
@@ -0,0 +1,124 @@
+package gke
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/portworx/sched-ops/k8s/core"
+	"google.golang.org/api/compute/v1"
+)
+
+const (
+	gceProviderIDPrefix = "gce://"
+	operationDone       = "DONE"
+	operationTimeout    = 10 * time.Minute
+)
+
+// computeOps are the operations on the instances of the cluster cloudops does not provide
+type computeOps interface {
+	// ResetInstance hard resets the instance of the given node
+	ResetInstance(nodeName string) error
+	// StopInstance stops the instance of the given node
+	StopInstance(nodeName string) error
+	// StartInstance starts the instance of the given node
+	StartInstance(nodeName string) error
+	// InstanceStatus returns the status of the instance of the given node, e.g. RUNNING or TERMINATED
+	InstanceStatus(nodeName string) (string, error)
+}
+
+type gceCompute struct {
+	service *compute.Service
+}
+
+func newComputeOps() (computeOps, error) {
+	service, err := compute.NewService(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compute client: %v", err)
+	}
+	return &gceCompute{service: service}, nil
+}
+
+// parseProviderID returns the project, zone and name of an instance from a provider ID like gce://<project>/<zone>/<name>
+func parseProviderID(providerID string) (string, string, string, error) {
+	parts := strings.Split(strings.TrimPrefix(providerID, gceProviderIDPrefix), "/")
+	if !strings.HasPrefix(providerID, gceProviderIDPrefix) || len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("invalid GCE provider ID [%s]", providerID)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func (c *gceCompute) getInstance(nodeName string) (string, string, string, error) {
+	k8sNode, err := core.Instance().GetNodeByName(nodeName)
+	if err != nil {
+		return "", "", "", err
+	}
+	return parseProviderID(k8sNode.Spec.ProviderID)
+}
+
+// waitForOperation waits for the given zone operation to complete
+func (c *gceCompute) waitForOperation(project, zone string, op *compute.Operation) error {
+	deadline := time.Now().Add(operationTimeout)
+	for op.Status != operationDone {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for operation %s to complete", op.Name)
+		}
+		var err error
+		if op, err = c.service.ZoneOperations.Wait(project, zone, op.Name).Do(); err != nil {
+			return err
+		}
+	}
+	if op.Error != nil && len(op.Error.Errors) > 0 {
+		return fmt.Errorf("operation %s failed: %s", op.Name, op.Error.Errors[0].Message)
+	}
+	return nil
+}
+
+func (c *gceCompute) ResetInstance(nodeName string) error {
+	project, zone, name, err := c.getInstance(nodeName)
+	if err != nil {
+		return err
+	}
+	op, err := c.service.Instances.Reset(project, zone, name).Do()
+	if err != nil {
+		return err
+	}
+	return c.waitForOperation(project, zone, op)
+}
+
+func (c *gceCompute) StopInstance(nodeName string) error {
+	project, zone, name, err := c.getInstance(nodeName)
+	if err != nil {
+		return err
+	}
+	op, err := c.service.Instances.Stop(project, zone, name).Do()
+	if err != nil {
+		return err
+	}
+	return c.waitForOperation(project, zone, op)
+}
+
+func (c *gceCompute) StartInstance(nodeName string) error {
+	project, zone, name, err := c.getInstance(nodeName)
+	if err != nil {
+		return err
+	}
+	op, err := c.service.Instances.Start(project, zone, name).Do()
+	if err != nil {
+		return err
+	}
+	return c.waitForOperation(project, zone, op)
+}
+
+func (c *gceCompute) InstanceStatus(nodeName string) (string, error) {
+	project, zone, name, err := c.getInstance(nodeName)
+	if err != nil {
+		return "", err
+	}
+	instance, err := c.service.Instances.Get(project, zone, name).Do()
+	if err != nil {
+		return "", err
+	}
+	return instance.Status, nil
+}
@@ -1,6 +1,8 @@
 package gke
 
 import (
+	"fmt"
+
 	"github.com/libopenstorage/cloudops"
 	"github.com/libopenstorage/cloudops/gce"
 	"github.com/portworx/torpedo/drivers/node"
@@ -18,6 +20,7 @@ const (
 type gke struct {
 	ssh.SSH
 	ops           cloudops.Ops
+	compute       computeOps
 	instanceGroup string
 }
 
@@ -41,6 +44,12 @@ func (g *gke) Init(nodeOpts node.InitOptions) error {
 	}
 	g.ops = ops
 
+	compute, err := newComputeOps()
+	if err != nil {
+		return err
+	}
+	g.compute = compute
+
 	return nil
 }
 
@@ -84,15 +93,65 @@ func (g *gke) SetClusterVersion(version string, timeout time.Duration) error {
 	return nil
 }
 
-func (g *gke) DeleteNode(node node.Node, timeout time.Duration) error {
-
-	err := g.ops.DeleteInstance(node.Name, node.Zone, timeout)
+// DeleteNode deletes the instance of the node, the managed instance group of the node pool replaces it
+func (g *gke) DeleteNode(n node.Node, timeout time.Duration) error {
+	log.Infof("Deleting instance of node %s", n.Name)
+	err := g.ops.DeleteInstance(n.Name, n.Zone, timeout)
 	if err != nil {
-		return err
+		return &node.ErrFailedToDeleteNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to delete instance due to: %v", err),
+		}
+	}
+	return nil
+}
+
+// RebootNode hard resets the instance of the node
+func (g *gke) RebootNode(n node.Node, options node.RebootNodeOpts) error {
+	log.Infof("Resetting instance of node %s", n.Name)
+	if err := g.compute.ResetInstance(n.Name); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to reset instance due to: %v", err),
+		}
 	}
 	return nil
 }
 
+// ShutdownNode stops the instance of the node
+func (g *gke) ShutdownNode(n node.Node, options node.ShutdownNodeOpts) error {
+	log.Infof("Stopping instance of node %s", n.Name)
+	if err := g.compute.StopInstance(n.Name); err != nil {
+		return &node.ErrFailedToShutdownNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to stop instance due to: %v", err),
+		}
+	}
+	return nil
+}
+
+// PowerOnVM starts the stopped instance of the node
+func (g *gke) PowerOnVM(n node.Node) error {
+	log.Infof("Starting instance of node %s", n.Name)
+	if err := g.compute.StartInstance(n.Name); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to start instance due to: %v", err),
+		}
+	}
+	return nil
+}
+
+// PowerOffVM stops the instance of the node
+func (g *gke) PowerOffVM(n node.Node) error {
+	return g.ShutdownNode(n, node.ShutdownNodeOpts{})
+}
+
+// GetNodeState returns the status of the instance of the node, e.g. RUNNING or TERMINATED
+func (g *gke) GetNodeState(n node.Node) (string, error) {
+	return g.compute.InstanceStatus(n.Name)
+}
+
 func (g *gke) GetZones() ([]string, error) {
 	asgInfo, err := g.ops.InspectInstanceGroupForInstance(g.ops.InstanceID())
 	if err != nil {
@@ -0,0 +1,109 @@
+package gke
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libopenstorage/cloudops"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOps records the cloudops calls, the operations the driver does not use are left nil
+type fakeOps struct {
+	cloudops.Ops
+	calls []string
+	err   error
+}
+
+func (f *fakeOps) DeleteInstance(instanceID string, zone string, timeout time.Duration) error {
+	f.calls = append(f.calls, fmt.Sprintf("DeleteInstance %s %s", instanceID, zone))
+	return f.err
+}
+
+func (f *fakeOps) SetClusterVersion(version string, timeout time.Duration) error {
+	f.calls = append(f.calls, "SetClusterVersion "+version)
+	return f.err
+}
+
+func (f *fakeOps) SetInstanceGroupVersion(instanceGroupID string, version string, timeout time.Duration) error {
+	f.calls = append(f.calls, fmt.Sprintf("SetInstanceGroupVersion %s %s", instanceGroupID, version))
+	return f.err
+}
+
+// fakeCompute keeps the status of the instances of the nodes
+type fakeCompute struct {
+	status map[string]string
+}
+
+func (f *fakeCompute) set(nodeName, status string) error {
+	if _, ok := f.status[nodeName]; !ok {
+		return fmt.Errorf("instance of node %s not found", nodeName)
+	}
+	f.status[nodeName] = status
+	return nil
+}
+
+func (f *fakeCompute) ResetInstance(nodeName string) error { return f.set(nodeName, "RUNNING") }
+
+func (f *fakeCompute) StopInstance(nodeName string) error { return f.set(nodeName, "TERMINATED") }
+
+func (f *fakeCompute) StartInstance(nodeName string) error { return f.set(nodeName, "RUNNING") }
+
+func (f *fakeCompute) InstanceStatus(nodeName string) (string, error) {
+	status, ok := f.status[nodeName]
+	if !ok {
+		return "", fmt.Errorf("instance of node %s not found", nodeName)
+	}
+	return status, nil
+}
+
+func TestParseProviderID(t *testing.T) {
+	project, zone, name, err := parseProviderID("gce://px-project/us-east1-b/gke-px-default-pool-1a2b3c4d-x9z8")
+	require.NoError(t, err)
+	assert.Equal(t, "px-project", project)
+	assert.Equal(t, "us-east1-b", zone)
+	assert.Equal(t, "gke-px-default-pool-1a2b3c4d-x9z8", name)
+
+	for _, providerID := range []string{"", "aws:///us-east-1a/i-0123", "gce://px-project/us-east1-b"} {
+		_, _, _, err := parseProviderID(providerID)
+		assert.Error(t, err, "provider ID %s has to be rejected", providerID)
+	}
+}
+
+func TestPowerOperations(t *testing.T) {
+	g := &gke{compute: &fakeCompute{status: map[string]string{"node1": "RUNNING"}}}
+	n := node.Node{Name: "node1"}
+
+	require.NoError(t, g.PowerOffVM(n))
+	state, err := g.GetNodeState(n)
+	require.NoError(t, err)
+	assert.Equal(t, "TERMINATED", state)
+	require.NoError(t, g.PowerOnVM(n))
+	require.NoError(t, g.RebootNode(n, node.RebootNodeOpts{}))
+	state, err = g.GetNodeState(n)
+	require.NoError(t, err)
+	assert.Equal(t, "RUNNING", state)
+
+	err = g.RebootNode(node.Node{Name: "node2"}, node.RebootNodeOpts{})
+	assert.IsType(t, &node.ErrFailedToRebootNode{}, err)
+}
+
+func TestDeleteNodeAndUpgrade(t *testing.T) {
+	ops := &fakeOps{}
+	g := &gke{ops: ops, instanceGroup: "default-pool"}
+
+	require.NoError(t, g.DeleteNode(node.Node{Name: "node1", Zone: "us-east1-b"}, time.Minute))
+	require.NoError(t, g.SetClusterVersion("1.25.8-gke.500", time.Minute))
+	assert.Equal(t, []string{
+		"DeleteInstance node1 us-east1-b",
+		"SetClusterVersion 1.25.8-gke.500",
+		"SetInstanceGroupVersion default-pool 1.25.8-gke.500",
+	}, ops.calls)
+
+	ops.err = fmt.Errorf("quota exceeded")
+	err := g.DeleteNode(node.Node{Name: "node1"}, time.Minute)
+	assert.IsType(t, &node.ErrFailedToDeleteNode{}, err)
+}
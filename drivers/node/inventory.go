@@ -0,0 +1,249 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+// TopologyEventType identifies a change of the cluster topology seen by the node registry
+type TopologyEventType string
+
+const (
+	// NodeAdded is emitted when a node joins the registry
+	NodeAdded TopologyEventType = "NodeAdded"
+	// NodeRemoved is emitted when a node leaves the registry
+	NodeRemoved TopologyEventType = "NodeRemoved"
+	// NodeBecameStorage is emitted when a storage-less node gets its first pool
+	NodeBecameStorage TopologyEventType = "NodeBecameStorage"
+	// NodeBecameStorageless is emitted when a storage node loses its last pool
+	NodeBecameStorageless TopologyEventType = "NodeBecameStorageless"
+	// PoolAdded is emitted when a pool shows up on a node
+	PoolAdded TopologyEventType = "PoolAdded"
+	// PoolRemoved is emitted when a pool disappears from a node
+	PoolRemoved TopologyEventType = "PoolRemoved"
+	// PoolResized is emitted when the total size of a pool changes
+	PoolResized TopologyEventType = "PoolResized"
+	// ZoneChanged is emitted when the zone label of a node changes
+	ZoneChanged TopologyEventType = "ZoneChanged"
+	// NodeIDChanged is emitted when a node gets a volume driver node ID no other node had
+	NodeIDChanged TopologyEventType = "NodeIDChanged"
+	// NodeIDMoved is emitted when the volume driver node ID of a node moves to another node
+	NodeIDMoved TopologyEventType = "NodeIDMoved"
+)
+
+// TopologyEvent is a change of the cluster topology
+type TopologyEvent struct {
+	Type TopologyEventType
+	// Node is the name of the node the change happened on
+	Node string
+	// Pool is the UUID of the pool of the pool events
+	Pool string
+	// From and To are the previous and the current value of the changed attribute: the zone, the
+	// total size of the pool, the volume driver node ID, or the name of the node an ID moved from and to
+	From string
+	To   string
+}
+
+func (e TopologyEvent) String() string {
+	s := fmt.Sprintf("%s on node [%s]", e.Type, e.Node)
+	if e.Pool != "" {
+		s += fmt.Sprintf(" pool [%s]", e.Pool)
+	}
+	if e.From != "" || e.To != "" {
+		s += fmt.Sprintf(" from [%s] to [%s]", e.From, e.To)
+	}
+	return s
+}
+
+// NodeInventory is the topology of a node at the time the inventory was taken
+type NodeInventory struct {
+	Name            string
+	VolDriverNodeID string
+	Zone            string
+	// Pools maps the UUID of every pool of the node to its total size
+	Pools map[string]uint64
+}
+
+// IsStorage returns true if the node had pools when the inventory was taken
+func (n NodeInventory) IsStorage() bool {
+	return len(n.Pools) > 0
+}
+
+// Inventory is the topology of all the nodes of the registry, keyed by node name
+type Inventory map[string]NodeInventory
+
+type topologyHandler struct {
+	id     int
+	handle func(TopologyEvent)
+}
+
+var (
+	topologyLock     sync.Mutex
+	topologyHandlers []topologyHandler
+	nextHandlerID    int
+)
+
+// OnTopologyChange calls the handler with every topology change of the registry and returns the
+// function removing the handler
+func OnTopologyChange(handler func(TopologyEvent)) func() {
+	topologyLock.Lock()
+	defer topologyLock.Unlock()
+	id := nextHandlerID
+	nextHandlerID++
+	topologyHandlers = append(topologyHandlers, topologyHandler{id: id, handle: handler})
+	return func() {
+		topologyLock.Lock()
+		defer topologyLock.Unlock()
+		for i, h := range topologyHandlers {
+			if h.id == id {
+				topologyHandlers = append(topologyHandlers[:i:i], topologyHandlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// TakeInventory returns the current topology of the registry
+func TakeInventory() Inventory {
+	lock.RLock()
+	defer lock.RUnlock()
+	return takeInventory()
+}
+
+// takeInventory expects the caller to hold the registry lock
+func takeInventory() Inventory {
+	inventory := make(Inventory, len(nodeRegistry))
+	for _, n := range nodeRegistry {
+		inventory[n.Name] = nodeInventory(n)
+	}
+	return inventory
+}
+
+func nodeInventory(n Node) NodeInventory {
+	ni := NodeInventory{
+		Name:            n.Name,
+		VolDriverNodeID: n.VolDriverNodeID,
+		Zone:            n.Zone,
+		Pools:           make(map[string]uint64),
+	}
+	if n.StorageNode != nil {
+		for _, pool := range n.Pools {
+			if pool != nil {
+				ni.Pools[pool.Uuid] = pool.TotalSize
+			}
+		}
+	}
+	return ni
+}
+
+// DiffInventories returns the topology changes between two inventories, ordered by node name
+func DiffInventories(before, after Inventory) []TopologyEvent {
+	var events []TopologyEvent
+
+	previousOwner := make(map[string]string)
+	for name, n := range before {
+		if n.VolDriverNodeID != "" {
+			previousOwner[n.VolDriverNodeID] = name
+		}
+	}
+
+	for _, name := range sortedNames(before, after) {
+		old, existed := before[name]
+		cur, exists := after[name]
+		switch {
+		case !exists:
+			events = append(events, TopologyEvent{Type: NodeRemoved, Node: name})
+			continue
+		case !existed:
+			events = append(events, TopologyEvent{Type: NodeAdded, Node: name})
+			old = NodeInventory{Name: name, Zone: cur.Zone}
+		}
+
+		if old.Zone != cur.Zone {
+			events = append(events, TopologyEvent{Type: ZoneChanged, Node: name, From: old.Zone, To: cur.Zone})
+		}
+		if old.VolDriverNodeID != cur.VolDriverNodeID && cur.VolDriverNodeID != "" {
+			if owner, ok := previousOwner[cur.VolDriverNodeID]; ok && owner != name {
+				events = append(events, TopologyEvent{Type: NodeIDMoved, Node: name, From: owner, To: name})
+			} else {
+				events = append(events, TopologyEvent{Type: NodeIDChanged, Node: name, From: old.VolDriverNodeID, To: cur.VolDriverNodeID})
+			}
+		}
+		events = append(events, diffPools(name, old, cur)...)
+		if !old.IsStorage() && cur.IsStorage() {
+			events = append(events, TopologyEvent{Type: NodeBecameStorage, Node: name})
+		} else if old.IsStorage() && !cur.IsStorage() {
+			events = append(events, TopologyEvent{Type: NodeBecameStorageless, Node: name})
+		}
+	}
+	return events
+}
+
+func diffPools(name string, old, cur NodeInventory) []TopologyEvent {
+	var events []TopologyEvent
+	uuids := make([]string, 0, len(old.Pools)+len(cur.Pools))
+	for uuid := range old.Pools {
+		uuids = append(uuids, uuid)
+	}
+	for uuid := range cur.Pools {
+		if _, ok := old.Pools[uuid]; !ok {
+			uuids = append(uuids, uuid)
+		}
+	}
+	sort.Strings(uuids)
+
+	for _, uuid := range uuids {
+		oldSize, existed := old.Pools[uuid]
+		curSize, exists := cur.Pools[uuid]
+		switch {
+		case !exists:
+			events = append(events, TopologyEvent{Type: PoolRemoved, Node: name, Pool: uuid})
+		case !existed:
+			events = append(events, TopologyEvent{Type: PoolAdded, Node: name, Pool: uuid})
+		case oldSize != curSize:
+			events = append(events, TopologyEvent{
+				Type: PoolResized,
+				Node: name,
+				Pool: uuid,
+				From: fmt.Sprintf("%d", oldSize),
+				To:   fmt.Sprintf("%d", curSize),
+			})
+		}
+	}
+	return events
+}
+
+func sortedNames(inventories ...Inventory) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, inventory := range inventories {
+		for name := range inventory {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// emitTopologyEvents calls the handlers with the given events
+func emitTopologyEvents(events []TopologyEvent) {
+	if len(events) == 0 {
+		return
+	}
+	topologyLock.Lock()
+	handlers := append([]topologyHandler(nil), topologyHandlers...)
+	topologyLock.Unlock()
+
+	for _, event := range events {
+		log.Debugf("Node registry topology change: %s", event)
+		for _, h := range handlers {
+			h.handle(event)
+		}
+	}
+}
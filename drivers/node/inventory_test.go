@@ -0,0 +1,86 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffInventories(t *testing.T) {
+	before := Inventory{
+		"node1": {Name: "node1", VolDriverNodeID: "id1", Zone: "a", Pools: map[string]uint64{"p1": 100}},
+		"node2": {Name: "node2", VolDriverNodeID: "id2", Zone: "b", Pools: map[string]uint64{"p2": 100, "p3": 50}},
+		"node3": {Name: "node3", VolDriverNodeID: "id3", Zone: "c", Pools: map[string]uint64{}},
+		"node4": {Name: "node4", VolDriverNodeID: "id4", Zone: "c", Pools: map[string]uint64{"p4": 100}},
+	}
+	after := Inventory{
+		"node1": {Name: "node1", VolDriverNodeID: "id1", Zone: "b", Pools: map[string]uint64{"p1": 200}},
+		"node2": {Name: "node2", VolDriverNodeID: "id2", Zone: "b", Pools: map[string]uint64{}},
+		"node3": {Name: "node3", VolDriverNodeID: "id3", Zone: "c", Pools: map[string]uint64{"p5": 100}},
+		"node5": {Name: "node5", VolDriverNodeID: "id4", Zone: "c", Pools: map[string]uint64{"p4": 100}},
+	}
+
+	assert.Equal(t, []TopologyEvent{
+		{Type: ZoneChanged, Node: "node1", From: "a", To: "b"},
+		{Type: PoolResized, Node: "node1", Pool: "p1", From: "100", To: "200"},
+		{Type: PoolRemoved, Node: "node2", Pool: "p2"},
+		{Type: PoolRemoved, Node: "node2", Pool: "p3"},
+		{Type: NodeBecameStorageless, Node: "node2"},
+		{Type: PoolAdded, Node: "node3", Pool: "p5"},
+		{Type: NodeBecameStorage, Node: "node3"},
+		{Type: NodeRemoved, Node: "node4"},
+		{Type: NodeAdded, Node: "node5"},
+		{Type: NodeIDMoved, Node: "node5", From: "node4", To: "node5"},
+		{Type: PoolAdded, Node: "node5", Pool: "p4"},
+		{Type: NodeBecameStorage, Node: "node5"},
+	}, DiffInventories(before, after))
+
+	assert.Empty(t, DiffInventories(before, before))
+}
+
+func TestRegistryTopologyEvents(t *testing.T) {
+	CleanupRegistry()
+	defer CleanupRegistry()
+
+	var events []TopologyEvent
+	stop := OnTopologyChange(func(e TopologyEvent) { events = append(events, e) })
+	defer stop()
+
+	require.NoError(t, AddNode(Node{Name: "node1", Zone: "a"}))
+	n, err := GetNodeByName("node1")
+	require.NoError(t, err)
+	n.VolDriverNodeID = "id1"
+	n.StorageNode = &api.StorageNode{Pools: []*api.StoragePool{{Uuid: "p1", TotalSize: 100}}}
+	require.NoError(t, UpdateNode(n))
+	assert.Equal(t, []TopologyEvent{
+		{Type: NodeAdded, Node: "node1"},
+		{Type: NodeIDChanged, Node: "node1", To: "id1"},
+		{Type: PoolAdded, Node: "node1", Pool: "p1"},
+		{Type: NodeBecameStorage, Node: "node1"},
+	}, events)
+
+	// replacing the node with one parsed again by the scheduler keeps its volume driver fields and only
+	// reports what differs from the replaced one
+	events = nil
+	require.NoError(t, ReplaceNodes([]Node{{Name: "node1", Zone: "b"}, {Name: "node2", Zone: "b"}}))
+	assert.Equal(t, []TopologyEvent{
+		{Type: ZoneChanged, Node: "node1", From: "a", To: "b"},
+		{Type: NodeAdded, Node: "node2"},
+	}, events)
+	n, err = GetNodeByName("node1")
+	require.NoError(t, err)
+	assert.Equal(t, "id1", n.VolDriverNodeID)
+	assert.Equal(t, "p1", n.Pools[0].Uuid)
+
+	events = nil
+	require.NoError(t, ReplaceNodes([]Node{{Name: "node1", Zone: "b"}}))
+	assert.Equal(t, []TopologyEvent{{Type: NodeRemoved, Node: "node2"}}, events)
+
+	events = nil
+	stop()
+	CleanupRegistry()
+	assert.Empty(t, events)
+	assert.Empty(t, TakeInventory())
+}
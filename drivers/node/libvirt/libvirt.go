@@ -0,0 +1,355 @@
+package libvirt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/node/ssh"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	// DriverName is the name of the libvirt driver
+	DriverName = "libvirt"
+	// DefaultURI is the libvirt connection used when LIBVIRT_URI is not set
+	DefaultURI = "qemu:///system"
+)
+
+const (
+	libvirtURI       = "LIBVIRT_URI"
+	libvirtDomainMap = "LIBVIRT_DOMAIN_MAP"
+)
+
+const (
+	// VMReadyTimeout Timeout for checking domain state
+	VMReadyTimeout = 3 * time.Minute
+	// VMReadyRetryInterval interval for retry when checking domain state
+	VMReadyRetryInterval = 5 * time.Second
+
+	domainStateRunning = "running"
+	domainStateShutOff = "shut off"
+)
+
+// virshFunc runs virsh with the given arguments and returns its output
+type virshFunc func(args ...string) (string, error)
+
+// libvirt ssh driver mapping nodes to the domains of a libvirt hypervisor
+type libvirt struct {
+	ssh.SSH
+	uri string
+	// domains maps node names to domain names
+	domains map[string]string
+	lock    sync.Mutex
+	virsh   virshFunc
+	// session is the virsh shell runVirsh runs the commands in
+	session *virshSession
+}
+
+func (l *libvirt) String() string {
+	return DriverName
+}
+
+// Init initializes the libvirt driver for ssh
+func (l *libvirt) Init(nodeOpts node.InitOptions) error {
+	log.Infof("Using the libvirt node driver")
+
+	l.uri = DefaultURI
+	if uri := os.Getenv(libvirtURI); len(uri) != 0 {
+		l.uri = uri
+	}
+	if l.virsh == nil {
+		l.virsh = l.runVirsh
+	}
+	domainMap, err := parseDomainMap(os.Getenv(libvirtDomainMap))
+	if err != nil {
+		return err
+	}
+	if err := l.mapDomains(node.GetNodes(), domainMap); err != nil {
+		return err
+	}
+	return l.SSH.Init(nodeOpts)
+}
+
+// mapDomains maps each node to its domain, named like the node unless the domain map says otherwise
+func (l *libvirt) mapDomains(nodes []node.Node, domainMap map[string]string) error {
+	out, err := l.virsh("list", "--all", "--name")
+	if err != nil {
+		return fmt.Errorf("failed to list domains of %s: %v", l.uri, err)
+	}
+	existing := make(map[string]bool)
+	for _, domain := range strings.Fields(out) {
+		existing[domain] = true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.domains = make(map[string]string)
+	for _, n := range nodes {
+		domain, ok := domainMap[n.Name]
+		if !ok {
+			domain = n.Name
+		}
+		if !existing[domain] {
+			log.Warnf("Domain %s of node %s not found in %s", domain, n.Name, l.uri)
+			continue
+		}
+		l.domains[n.Name] = domain
+	}
+	return nil
+}
+
+// parseDomainMap parses a list of <node>=<domain> pairs separated by commas
+func parseDomainMap(value string) (map[string]string, error) {
+	domainMap := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid entry [%s] in %s, expected <node>=<domain>", pair, libvirtDomainMap)
+		}
+		domainMap[parts[0]] = parts[1]
+	}
+	return domainMap, nil
+}
+
+// runVirsh runs virsh with the given arguments in the virsh shell of the driver
+func (l *libvirt) runVirsh(args ...string) (string, error) {
+	l.lock.Lock()
+	if l.session == nil {
+		l.session = newVirshSession("virsh", l.uri)
+	}
+	session := l.session
+	l.lock.Unlock()
+	return session.run(args...)
+}
+
+func (l *libvirt) getDomain(n node.Node) (string, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	domain, ok := l.domains[n.Name]
+	if !ok {
+		return "", fmt.Errorf("could not fetch domain for node: %s", n.Name)
+	}
+	return domain, nil
+}
+
+func (l *libvirt) domainState(domain string) (string, error) {
+	return l.virsh("domstate", domain)
+}
+
+// TestConnection tests the connection to the given node
+func (l *libvirt) TestConnection(n node.Node, options node.ConnectionOpts) error {
+	log.Infof("Testing libvirt driver connection by checking state of the domain of node %s", n.Name)
+	domain, err := l.getDomain(n)
+	if err != nil {
+		return err
+	}
+	t := func() (interface{}, bool, error) {
+		state, err := l.domainState(domain)
+		if err != nil || state != domainStateRunning {
+			return nil, true, &node.ErrFailedToTestConnection{
+				Node:  n,
+				Cause: fmt.Sprintf("Failed to test connection to domain: %s Current Status: %v, error: %v", domain, state, err),
+			}
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, VMReadyTimeout, VMReadyRetryInterval); err != nil {
+		return err
+	}
+	// Check if domain is not just running but also usable
+	_, err = l.RunCommand(n, "hostname", node.ConnectionOpts{
+		Timeout:         VMReadyTimeout,
+		TimeBeforeRetry: VMReadyRetryInterval,
+	})
+	return err
+}
+
+// AddMachine adds the domain of the given name to the domains known by the driver
+func (l *libvirt) AddMachine(machineName string) error {
+	log.Infof("Adding domain: %s", machineName)
+	if _, err := l.virsh("dominfo", machineName); err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.domains[machineName] = machineName
+	return nil
+}
+
+// RebootNode resets the domain of the node when forced, otherwise asks its guest to reboot
+func (l *libvirt) RebootNode(n node.Node, options node.RebootNodeOpts) error {
+	domain, err := l.getDomain(n)
+	if err != nil {
+		return err
+	}
+	action := "reboot"
+	if options.Force {
+		action = "reset"
+	}
+	log.Infof("Rebooting domain: %s using virsh %s", domain, action)
+	if _, err := l.virsh(action, domain); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to reboot domain %s. cause %v", domain, err),
+		}
+	}
+	return nil
+}
+
+// ShutdownNode destroys the domain of the node when forced, otherwise asks its guest to shut down
+func (l *libvirt) ShutdownNode(n node.Node, options node.ShutdownNodeOpts) error {
+	domain, err := l.getDomain(n)
+	if err != nil {
+		return err
+	}
+	action := "shutdown"
+	if options.Force {
+		action = "destroy"
+	}
+	log.Infof("Shutting down domain: %s using virsh %s", domain, action)
+	if _, err := l.virsh(action, domain); err != nil {
+		return &node.ErrFailedToShutdownNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to shutdown domain %s. cause %v", domain, err),
+		}
+	}
+	return nil
+}
+
+// PowerOnVM starts the domain of the node
+func (l *libvirt) PowerOnVM(n node.Node) error {
+	domain, err := l.getDomain(n)
+	if err != nil {
+		return err
+	}
+	if err := l.powerOnDomain(domain); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to power on domain %s. cause %v", domain, err),
+		}
+	}
+	return nil
+}
+
+// PowerOnVMByName starts the domain of the given name
+func (l *libvirt) PowerOnVMByName(vmName string) error {
+	return l.powerOnDomain(vmName)
+}
+
+func (l *libvirt) powerOnDomain(domain string) error {
+	state, err := l.domainState(domain)
+	if err != nil {
+		return err
+	}
+	if state == domainStateRunning {
+		log.Warnf("Domain is already running: %s", domain)
+		return nil
+	}
+	log.Infof("Powering on domain: %s", domain)
+	_, err = l.virsh("start", domain)
+	return err
+}
+
+// PowerOffVM hard powers off the domain of the node if not already off
+func (l *libvirt) PowerOffVM(n node.Node) error {
+	domain, err := l.getDomain(n)
+	if err != nil {
+		return err
+	}
+	state, err := l.domainState(domain)
+	if err != nil {
+		return err
+	}
+	if state == domainStateShutOff {
+		log.Warnf("Domain is already shut off: %s", domain)
+		return nil
+	}
+	log.Infof("Powering off domain: %s", domain)
+	if _, err := l.virsh("destroy", domain); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to power off domain %s. cause %v", domain, err),
+		}
+	}
+	return nil
+}
+
+// GetNodeState returns the state of the domain of the node, e.g. running or shut off
+func (l *libvirt) GetNodeState(n node.Node) (string, error) {
+	domain, err := l.getDomain(n)
+	if err != nil {
+		return "", err
+	}
+	return l.domainState(domain)
+}
+
+// AttachDiskToVM attaches the given disk image or block device to the domain of the node
+func (l *libvirt) AttachDiskToVM(n node.Node, diskPath string, target string) error {
+	domain, err := l.getDomain(n)
+	if err != nil {
+		return err
+	}
+	log.Infof("Attaching disk %s to domain %s as %s", diskPath, domain, target)
+	if _, err := l.virsh("attach-disk", domain, diskPath, target, "--live"); err != nil {
+		return &node.ErrFailedToUpdateVM{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to attach disk %s to domain %s. cause %v", diskPath, domain, err),
+		}
+	}
+	return nil
+}
+
+// DetachDiskFromVM detaches the disk of the given target from the domain of the node
+func (l *libvirt) DetachDiskFromVM(n node.Node, target string) error {
+	domain, err := l.getDomain(n)
+	if err != nil {
+		return err
+	}
+	log.Infof("Detaching disk %s from domain %s", target, domain)
+	if _, err := l.virsh("detach-disk", domain, target, "--live"); err != nil {
+		return &node.ErrFailedToUpdateVM{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to detach disk %s from domain %s. cause %v", target, domain, err),
+		}
+	}
+	return nil
+}
+
+// SetVMLinkState sets the link of the given interface of the domain of the node up or down. The
+// interface is either the device name on the host, e.g. vnet0, or the MAC address of the NIC.
+func (l *libvirt) SetVMLinkState(n node.Node, iface string, up bool) error {
+	domain, err := l.getDomain(n)
+	if err != nil {
+		return err
+	}
+	state := "down"
+	if up {
+		state = "up"
+	}
+	log.Infof("Setting link %s of domain %s %s", iface, domain, state)
+	if _, err := l.virsh("domif-setlink", domain, iface, state); err != nil {
+		return &node.ErrFailedToUpdateVM{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to set link %s of domain %s %s. cause %v", iface, domain, state, err),
+		}
+	}
+	return nil
+}
+
+func init() {
+	l := &libvirt{
+		SSH:     *ssh.New(),
+		domains: make(map[string]string),
+	}
+
+	node.Register(DriverName, l)
+}
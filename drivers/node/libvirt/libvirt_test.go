@@ -0,0 +1,108 @@
+package libvirt
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVirsh records the virsh calls and keeps the state of the domains
+type fakeVirsh struct {
+	calls  []string
+	states map[string]string
+}
+
+func (f *fakeVirsh) run(args ...string) (string, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+	switch args[0] {
+	case "list":
+		var names []string
+		for name := range f.states {
+			names = append(names, name)
+		}
+		return strings.Join(names, "\n"), nil
+	case "domstate":
+		return f.states[args[1]], nil
+	case "start":
+		f.states[args[1]] = domainStateRunning
+	case "destroy":
+		f.states[args[1]] = domainStateShutOff
+	case "dominfo":
+		if _, ok := f.states[args[1]]; !ok {
+			return "", fmt.Errorf("failed to get domain '%s'", args[1])
+		}
+	}
+	return "", nil
+}
+
+func TestParseDomainMap(t *testing.T) {
+	domainMap, err := parseDomainMap("node1=lab-node1, node2=lab-node2")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"node1": "lab-node1", "node2": "lab-node2"}, domainMap)
+
+	domainMap, err = parseDomainMap("")
+	require.NoError(t, err)
+	assert.Empty(t, domainMap)
+
+	_, err = parseDomainMap("node1")
+	assert.Error(t, err)
+}
+
+func TestPowerOperations(t *testing.T) {
+	fake := &fakeVirsh{states: map[string]string{"lab-node1": domainStateRunning, "node2": domainStateShutOff}}
+	l := &libvirt{virsh: fake.run}
+	nodes := []node.Node{{Name: "node1"}, {Name: "node2"}, {Name: "node3"}}
+	require.NoError(t, l.mapDomains(nodes, map[string]string{"node1": "lab-node1"}))
+	assert.Equal(t, map[string]string{"node1": "lab-node1", "node2": "node2"}, l.domains)
+
+	require.NoError(t, l.PowerOffVM(nodes[0]))
+	state, err := l.GetNodeState(nodes[0])
+	require.NoError(t, err)
+	assert.Equal(t, domainStateShutOff, state)
+	require.NoError(t, l.PowerOnVM(nodes[0]))
+	require.NoError(t, l.PowerOnVM(nodes[1]))
+	assert.Equal(t, domainStateRunning, fake.states["node2"])
+
+	require.NoError(t, l.RebootNode(nodes[0], node.RebootNodeOpts{Force: true}))
+	require.NoError(t, l.AttachDiskToVM(nodes[0], "/var/lib/libvirt/images/px.qcow2", "vdb"))
+	require.NoError(t, l.DetachDiskFromVM(nodes[0], "vdb"))
+	require.NoError(t, l.SetVMLinkState(nodes[0], "vnet0", false))
+	assert.Contains(t, fake.calls, "reset lab-node1")
+	assert.Contains(t, fake.calls, "attach-disk lab-node1 /var/lib/libvirt/images/px.qcow2 vdb --live")
+	assert.Contains(t, fake.calls, "detach-disk lab-node1 vdb --live")
+	assert.Contains(t, fake.calls, "domif-setlink lab-node1 vnet0 down")
+
+	assert.Error(t, l.PowerOffVM(nodes[2]))
+	assert.Error(t, l.AddMachine("node3"))
+}
+
+// TestTestDriver runs the power operations against the built-in test driver of libvirt
+func TestTestDriver(t *testing.T) {
+	if _, err := exec.LookPath("virsh"); err != nil {
+		t.Skip("virsh is not installed")
+	}
+	// the test driver resets its state for every connection, so all the calls have to share one virsh shell
+	l := &libvirt{uri: "test:///default"}
+	l.virsh = l.runVirsh
+	defer func() {
+		if l.session != nil {
+			l.session.stop()
+		}
+	}()
+	n := node.Node{Name: "test"}
+	require.NoError(t, l.mapDomains([]node.Node{n}, nil))
+
+	require.NoError(t, l.PowerOffVM(n))
+	state, err := l.GetNodeState(n)
+	require.NoError(t, err)
+	assert.Equal(t, domainStateShutOff, state)
+	require.NoError(t, l.PowerOnVM(n))
+	state, err = l.GetNodeState(n)
+	require.NoError(t, err)
+	assert.Equal(t, domainStateRunning, state)
+}
@@ -0,0 +1,152 @@
+package libvirt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+const (
+	// virshBeginMarker and virshEndMarker are echoed around each command to delimit its output
+	virshBeginMarker = "torpedo-virsh-begin"
+	virshEndMarker   = "torpedo-virsh-end"
+	// virshErrorPrefix prefixes the errors virsh prints when a command fails
+	virshErrorPrefix = "error:"
+)
+
+// virshPrompts are printed by the virsh shell before reading each command
+var virshPrompts = []string{"virsh # ", "virsh > "}
+
+// virshSession is a virsh shell kept open for all the calls, so a single connection is used and the state of
+// drivers which keep it per connection, e.g. test:///default, persists between calls
+type virshSession struct {
+	binary string
+	uri    string
+	lock   sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	output *bufio.Reader
+}
+
+func newVirshSession(binary, uri string) *virshSession {
+	return &virshSession{binary: binary, uri: uri}
+}
+
+// start starts the virsh shell. Its stdout and stderr share a pipe so errors stay in order with the output
+func (s *virshSession) start() error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(s.binary, "-q", "-c", s.uri)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		r.Close()
+		w.Close()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return fmt.Errorf("failed to start virsh shell for %s: %v", s.uri, err)
+	}
+	w.Close()
+	s.cmd = cmd
+	s.stdin = stdin
+	s.output = bufio.NewReader(r)
+	return nil
+}
+
+// stop closes the virsh shell, the next call starts a new one
+func (s *virshSession) stop() {
+	if s.cmd == nil {
+		return
+	}
+	s.stdin.Close()
+	s.cmd.Process.Kill()
+	s.cmd.Wait()
+	s.cmd = nil
+}
+
+// run runs virsh with the given arguments in the shell and returns its output
+func (s *virshSession) run(args ...string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.cmd == nil {
+		if err := s.start(); err != nil {
+			return "", err
+		}
+	}
+
+	var quoted []string
+	for _, arg := range args {
+		quoted = append(quoted, virshQuote(arg))
+	}
+	line := strings.Join(quoted, " ")
+	if _, err := fmt.Fprintf(s.stdin, "echo %s\n%s\necho %s\n", virshBeginMarker, line, virshEndMarker); err != nil {
+		s.stop()
+		return "", fmt.Errorf("virsh %s failed: %v", strings.Join(args, " "), err)
+	}
+
+	var out, errs []string
+	begun := false
+	for {
+		text, err := s.output.ReadString('\n')
+		if err != nil {
+			s.stop()
+			return "", fmt.Errorf("virsh %s failed: virsh shell exited: %v", strings.Join(args, " "), err)
+		}
+		text = strings.TrimRight(trimVirshPrompts(text), "\r\n")
+		// the shell may echo the commands it reads
+		if text == line || text == "echo "+virshBeginMarker || text == "echo "+virshEndMarker {
+			continue
+		}
+		if text == virshBeginMarker {
+			begun = true
+			continue
+		}
+		if text == virshEndMarker {
+			break
+		}
+		if !begun {
+			continue
+		}
+		if strings.HasPrefix(text, virshErrorPrefix) {
+			errs = append(errs, strings.TrimSpace(strings.TrimPrefix(text, virshErrorPrefix)))
+			continue
+		}
+		out = append(out, text)
+	}
+	if len(errs) > 0 {
+		return "", fmt.Errorf("virsh %s failed: %s", strings.Join(args, " "), strings.Join(errs, ": "))
+	}
+	return strings.TrimSpace(strings.Join(out, "\n")), nil
+}
+
+// trimVirshPrompts removes the prompts printed in front of the output
+func trimVirshPrompts(text string) string {
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, prompt := range virshPrompts {
+			if strings.HasPrefix(text, prompt) {
+				text = strings.TrimPrefix(text, prompt)
+				trimmed = true
+			}
+		}
+	}
+	return text
+}
+
+// virshQuote quotes the argument for the virsh shell when it contains whitespace or special characters
+func virshQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t'\"\\;#") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
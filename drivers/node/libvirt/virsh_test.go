@@ -0,0 +1,57 @@
+package libvirt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVirshShell mimics the virsh shell: it prints a prompt, echoes the commands it reads, prints errors
+// on stderr and keeps the state of the domain for the lifetime of the process
+const fakeVirshShell = `#!/bin/sh
+state=running
+while printf 'virsh # ' && read -r line; do
+	echo "$line"
+	eval "set -- $line"
+	case "$1" in
+	echo) shift; echo "$@" ;;
+	domstate) echo "$state"; echo ;;
+	destroy) state="shut off"; echo "Domain '$2' destroyed" ;;
+	attach-disk) echo "$3" ;;
+	*) echo "error: unknown command: '$1'" >&2 ;;
+	esac
+done
+`
+
+func TestVirshSession(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "virsh")
+	require.NoError(t, os.WriteFile(binary, []byte(fakeVirshShell), 0755))
+	session := newVirshSession(binary, "test:///default")
+	defer session.stop()
+
+	out, err := session.run("domstate", "test")
+	require.NoError(t, err)
+	assert.Equal(t, "running", out)
+
+	out, err = session.run("destroy", "test")
+	require.NoError(t, err)
+	assert.Equal(t, "Domain 'test' destroyed", out)
+	out, err = session.run("domstate", "test")
+	require.NoError(t, err)
+	assert.Equal(t, "shut off", out, "the state persists in the shell between calls")
+
+	out, err = session.run("attach-disk", "test", "/var/lib/libvirt/images/px disk.qcow2", "vdb")
+	require.NoError(t, err)
+	assert.Equal(t, "/var/lib/libvirt/images/px disk.qcow2", out, "arguments with spaces are quoted")
+
+	_, err = session.run("undefine", "test")
+	assert.EqualError(t, err, "virsh undefine test failed: unknown command: 'undefine'")
+
+	session.stop()
+	out, err = session.run("domstate", "test")
+	require.NoError(t, err)
+	assert.Equal(t, "running", out, "a new shell is started once the previous one is stopped")
+}
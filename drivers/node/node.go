@@ -115,6 +115,145 @@ type TestConnectionOpts struct {
 	ConnectionOpts
 }
 
+// RunCommandOpts provide additional options for running a command on many nodes
+type RunCommandOpts struct {
+	// Concurrency bounds the number of nodes the command runs on at the same time, 10 when zero
+	Concurrency int
+	ConnectionOpts
+}
+
+// CommandResult is the result of a command run on a node
+type CommandResult struct {
+	Node   Node
+	Output string
+	Err    error
+}
+
+var (
+	// PxMgmtPorts are the ports of the Portworx management API
+	PxMgmtPorts = []int{9001}
+	// PxDataPorts are the ports Portworx replicates volume data on
+	PxDataPorts = []int{9002, 9003}
+	// PxKvdbPorts are the peer and client ports of the Portworx internal kvdb
+	PxKvdbPorts = []int{9018, 9019}
+	// APIServerPorts are the ports of the kubernetes API server
+	APIServerPorts = []int{6443}
+)
+
+// NetworkPartitionOpts provide additional options for network partition operations
+type NetworkPartitionOpts struct {
+	// Ports restricts the partition to the given tcp, udp and sctp destination ports. All traffic is blocked when empty
+	Ports []int
+	// OneWay only blocks the traffic from the first group to the second one, making the partition asymmetric
+	OneWay bool
+	// BandwidthKbit limits the bandwidth between the groups to the given rate instead of blocking the traffic
+	BandwidthKbit int
+	// HealAfter heals the partition automatically once it expires. The partition stays until healed when zero
+	HealAfter time.Duration
+	ConnectionOpts
+}
+
+// NetworkPartition is a network partition applied on a set of nodes
+type NetworkPartition struct {
+	// ID identifies the rules of the partition on the nodes
+	ID string
+	// Nodes are the nodes the partition rules are applied on
+	Nodes []Node
+	// Heal removes the partition rules from all the nodes. It is safe to call it more than once
+	Heal func() error
+}
+
+// DiskFaultType identifies the device-mapper target used to inject a disk fault
+type DiskFaultType string
+
+const (
+	// DiskFaultFlakey fails all the I/O of the drive for DownInterval every UpInterval
+	DiskFaultFlakey DiskFaultType = "flakey"
+	// DiskFaultDelay delays all the I/O of the drive by Delay
+	DiskFaultDelay DiskFaultType = "delay"
+	// DiskFaultError fails all the I/O of the drive
+	DiskFaultError DiskFaultType = "error"
+	// DiskFaultReadOnly fails the writes of the drive while reads keep working
+	DiskFaultReadOnly DiskFaultType = "readonly"
+)
+
+// WrappedDrivePathPrefix prefixes the paths of the device-mapper linear devices created by WrapDrive
+const WrappedDrivePathPrefix = "/dev/mapper/torpedo-"
+
+// DiskFaultOpts provide additional options for disk fault injection
+type DiskFaultOpts struct {
+	Type DiskFaultType
+	// UpInterval is the time a flakey drive works before failing again
+	UpInterval time.Duration
+	// DownInterval is the time a flakey drive fails before working again
+	DownInterval time.Duration
+	// Delay is the latency added to the I/O of a delayed drive
+	Delay time.Duration
+	// Duration reverts the fault automatically once it expires. The fault stays until reverted when zero
+	Duration time.Duration
+	ConnectionOpts
+}
+
+// DiskFault is a disk fault injected on a drive of a node
+type DiskFault struct {
+	Node  Node
+	Drive string
+	Type  DiskFaultType
+	// Revert restores the original device-mapper table of the drive. It is safe to call it more than once
+	Revert func() error
+}
+
+// ResourcePressureType identifies the resource a node is put under pressure on
+type ResourcePressureType string
+
+const (
+	// PressureCPU burns the CPU cores of the node
+	PressureCPU ResourcePressureType = "cpu"
+	// PressureMemory allocates the memory of the node, up to OOM
+	PressureMemory ResourcePressureType = "memory"
+	// PressureDiskFill fills a filesystem of the node
+	PressureDiskFill ResourcePressureType = "diskFill"
+	// PressureClockSkew shifts the system clock of the node
+	PressureClockSkew ResourcePressureType = "clockSkew"
+)
+
+// ResourcePressureOpts provide additional options for resource pressure operations
+type ResourcePressureOpts struct {
+	Type ResourcePressureType
+	// CPUCores is the number of cores to burn, all the cores when zero
+	CPUCores int
+	// MemoryPercent is the percentage of the total memory to allocate. 100 or more runs the node out of memory
+	MemoryPercent int
+	// Path is on the filesystem to fill, /var/lib/osd when empty
+	Path string
+	// FillPercent is the usage percentage the filesystem is filled up to
+	FillPercent int
+	// ClockSkew is added to the system clock, a negative skew moves it back
+	ClockSkew time.Duration
+	// Duration bounds the pressure. The node reverts it by itself once it expires
+	Duration time.Duration
+	ConnectionOpts
+}
+
+// ResourcePressure is a resource pressure applied on a node
+type ResourcePressure struct {
+	ID   string
+	Node Node
+	Type ResourcePressureType
+	// Revert stops the pressure and undoes its side effects. It is safe to call it more than once
+	Revert func() error
+}
+
+// OSUpgradeOpts provide additional options for node OS upgrade operations
+type OSUpgradeOpts struct {
+	// Packages are installed with the package manager of the node, e.g. a kernel package. At least one
+	// package is required
+	Packages []string
+	// RebootTimeout is how long the node has to come back after rebooting into the upgrade
+	RebootTimeout time.Duration
+	ConnectionOpts
+}
+
 var (
 	nodeDrivers = make(map[string]Driver)
 )
@@ -149,6 +288,16 @@ type Driver interface {
 	// RunCommand runs the given command on the node and returns the output
 	RunCommand(node Node, command string, options ConnectionOpts) (string, error)
 
+	// RunCommandOnNodes runs the given command on all the given nodes in parallel. The results are in the
+	// order of the nodes, the error is set if the command failed on any node
+	RunCommandOnNodes(nodes []Node, command string, options RunCommandOpts) ([]CommandResult, error)
+
+	// CopyToNode copies the given local file or directory to the given path on the node
+	CopyToNode(n Node, localPath, remotePath string, options ConnectionOpts) error
+
+	// CopyFromNode copies the given file or directory on the node to the given local path
+	CopyFromNode(n Node, remotePath, localPath string, options ConnectionOpts) error
+
 	// RunCommandWithNoRetry runs the given command on the node but with no retry
 	RunCommandWithNoRetry(node Node, command string, options ConnectionOpts) (string, error)
 
@@ -195,6 +344,15 @@ type Driver interface {
 	// PowerOffVM powers VM
 	PowerOffVM(node Node) error
 
+	// AttachDiskToVM attaches the given disk image or block device to the VM of the node as the given target, e.g. vdb
+	AttachDiskToVM(node Node, diskPath string, target string) error
+
+	// DetachDiskFromVM detaches the disk of the given target from the VM of the node
+	DetachDiskFromVM(node Node, target string) error
+
+	// SetVMLinkState sets the link of the given network interface of the VM of the node up or down
+	SetVMLinkState(node Node, iface string, up bool) error
+
 	// SystemctlUnitExist checks if a given service exists in a node
 	SystemctlUnitExist(n Node, service string, options SystemctlOpts) (bool, error)
 
@@ -215,6 +373,43 @@ type Driver interface {
 	// delayInMilliseconds => 1 to 1000
 	InjectNetworkError(nodes []Node, errorInjectionType string, operationType string, dropPercentage int, delayInMilliseconds int) error
 
+	// PartitionNetwork isolates groupA from groupB using firewall rules on the nodes of both groups.
+	// The returned partition records the heal action, which also runs once opts.HealAfter expires
+	PartitionNetwork(groupA, groupB []Node, opts NetworkPartitionOpts) (*NetworkPartition, error)
+
+	// PartitionNetworkFromAddresses isolates the given nodes from the given addresses, e.g. the API server
+	PartitionNetworkFromAddresses(nodes []Node, addresses []string, opts NetworkPartitionOpts) (*NetworkPartition, error)
+
+	// HealNetworkPartitions heals all the network partitions that are still applied
+	HealNetworkPartitions() error
+
+	// WrapDrive creates a device-mapper linear device on top of the given raw drive, e.g. a SCSI, NVMe, virtio
+	// or cloud drive, and returns its path. The drive has to be wrapped before Portworx takes it, so that
+	// Portworx uses the returned device and faults can be injected on it with InjectDiskFault
+	WrapDrive(n Node, drive string, options ConnectionOpts) (string, error)
+
+	// CanInjectDiskFault returns true if the given drive is a device-mapper linear device, e.g. an LVM logical
+	// volume or a drive wrapped by WrapDrive, so faults can be injected on it with InjectDiskFault. Faults
+	// cannot be injected on raw drives since Portworx keeps them open.
+	CanInjectDiskFault(n Node, drive string, options ConnectionOpts) (bool, error)
+
+	// InjectDiskFault swaps the device-mapper table of the given drive with a faulty one. The returned
+	// fault records the revert action, which also runs once opts.Duration expires
+	InjectDiskFault(n Node, drive string, opts DiskFaultOpts) (*DiskFault, error)
+
+	// RevertDiskFaults reverts all the disk faults that are still injected
+	RevertDiskFaults() error
+
+	// ApplyResourcePressure runs a bounded CPU, memory, disk fill or clock skew pressure on the given node
+	ApplyResourcePressure(n Node, opts ResourcePressureOpts) (*ResourcePressure, error)
+
+	// RevertResourcePressures reverts all the resource pressures that are still applied
+	RevertResourcePressures() error
+
+	// UpgradeNodeOS installs the given packages with the apt, yum, dnf or zypper package manager of the node,
+	// reboots the node into them and returns the kernel version it runs after the reboot
+	UpgradeNodeOS(n Node, opts OSUpgradeOpts) (string, error)
+
 	// GetDeviceMapperCount return devicemapper count
 	GetDeviceMapperCount(Node, time.Duration) (int, error)
 
@@ -280,6 +475,27 @@ func (d *notSupportedDriver) CrashNode(node Node, options CrashNodeOpts) error {
 	}
 }
 
+func (d *notSupportedDriver) RunCommandOnNodes(nodes []Node, command string, options RunCommandOpts) ([]CommandResult, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RunCommandOnNodes()",
+	}
+}
+
+func (d *notSupportedDriver) CopyToNode(n Node, localPath, remotePath string, options ConnectionOpts) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "CopyToNode()",
+	}
+}
+
+func (d *notSupportedDriver) CopyFromNode(n Node, remotePath, localPath string, options ConnectionOpts) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "CopyFromNode()",
+	}
+}
+
 func (d *notSupportedDriver) RunCommand(node Node, command string, options ConnectionOpts) (string, error) {
 	return "", &errors.ErrNotSupported{
 		Type:      "Function",
@@ -422,6 +638,27 @@ func (d *notSupportedDriver) SystemctlUnitExist(node Node, service string, optio
 	}
 }
 
+func (d *notSupportedDriver) AttachDiskToVM(node Node, diskPath string, target string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "AttachDiskToVM()",
+	}
+}
+
+func (d *notSupportedDriver) DetachDiskFromVM(node Node, target string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "DetachDiskFromVM()",
+	}
+}
+
+func (d *notSupportedDriver) SetVMLinkState(node Node, iface string, up bool) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "SetVMLinkState()",
+	}
+}
+
 func (d *notSupportedDriver) AddMachine(machineName string) error {
 	return &errors.ErrNotSupported{
 		Type:      "Function",
@@ -465,6 +702,76 @@ func (d *notSupportedDriver) InjectNetworkError(nodes []Node, errorInjectionType
 	}
 }
 
+func (d *notSupportedDriver) PartitionNetwork(groupA, groupB []Node, opts NetworkPartitionOpts) (*NetworkPartition, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "PartitionNetwork()",
+	}
+}
+
+func (d *notSupportedDriver) PartitionNetworkFromAddresses(nodes []Node, addresses []string, opts NetworkPartitionOpts) (*NetworkPartition, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "PartitionNetworkFromAddresses()",
+	}
+}
+
+func (d *notSupportedDriver) HealNetworkPartitions() error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "HealNetworkPartitions()",
+	}
+}
+
+func (d *notSupportedDriver) WrapDrive(n Node, drive string, options ConnectionOpts) (string, error) {
+	return "", &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "WrapDrive()",
+	}
+}
+
+func (d *notSupportedDriver) CanInjectDiskFault(n Node, drive string, options ConnectionOpts) (bool, error) {
+	return false, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "CanInjectDiskFault()",
+	}
+}
+
+func (d *notSupportedDriver) InjectDiskFault(n Node, drive string, opts DiskFaultOpts) (*DiskFault, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "InjectDiskFault()",
+	}
+}
+
+func (d *notSupportedDriver) RevertDiskFaults() error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RevertDiskFaults()",
+	}
+}
+
+func (d *notSupportedDriver) ApplyResourcePressure(n Node, opts ResourcePressureOpts) (*ResourcePressure, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "ApplyResourcePressure()",
+	}
+}
+
+func (d *notSupportedDriver) RevertResourcePressures() error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RevertResourcePressures()",
+	}
+}
+
+func (d *notSupportedDriver) UpgradeNodeOS(n Node, opts OSUpgradeOpts) (string, error) {
+	return "", &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "UpgradeNodeOS()",
+	}
+}
+
 func (d *notSupportedDriver) RebalanceWorkerPool() error {
 	return &errors.ErrNotSupported{
 		Type:      "Function",
@@ -17,21 +17,29 @@ func AddNode(n Node) error {
 	if n.uuid != "" {
 		return fmt.Errorf("UUID should not be set to add new node")
 	}
+	var events []TopologyEvent
+	defer func() { emitTopologyEvents(events) }()
 	lock.Lock()
 	defer lock.Unlock()
+	before := takeInventory()
 	n.uuid = uuid.New()
 	nodeRegistry[n.uuid] = n
+	events = DiffInventories(before, takeInventory())
 	return nil
 }
 
 // UpdateNode updates a given node if it exists in the node collection
 func UpdateNode(n Node) error {
+	var events []TopologyEvent
+	defer func() { emitTopologyEvents(events) }()
 	lock.Lock()
 	defer lock.Unlock()
 	if _, ok := nodeRegistry[n.uuid]; !ok {
 		return fmt.Errorf("node to be updated does not exist")
 	}
+	before := takeInventory()
 	nodeRegistry[n.uuid] = n
+	events = DiffInventories(before, takeInventory())
 	return nil
 }
 
@@ -40,9 +48,47 @@ func DeleteNode(n Node) error {
 	if n.uuid == "" {
 		return fmt.Errorf("UUID should be set to delete existing node")
 	}
+	var events []TopologyEvent
+	defer func() { emitTopologyEvents(events) }()
 	lock.Lock()
 	defer lock.Unlock()
+	before := takeInventory()
 	delete(nodeRegistry, n.uuid)
+	events = DiffInventories(before, takeInventory())
+	return nil
+}
+
+// ReplaceNodes replaces the node collection with the given nodes and emits the topology changes between the
+// collection before and after, instead of the removal and the addition of every node. A given node keeps the
+// volume driver fields of the node with the same name it replaces until the volume driver refreshes them.
+func ReplaceNodes(nodes []Node) error {
+	for _, n := range nodes {
+		if n.uuid != "" {
+			return fmt.Errorf("UUID should not be set to add new node %s", n.Name)
+		}
+	}
+	var events []TopologyEvent
+	defer func() { emitTopologyEvents(events) }()
+	lock.Lock()
+	defer lock.Unlock()
+	before := takeInventory()
+	previous := make(map[string]Node, len(nodeRegistry))
+	for _, n := range nodeRegistry {
+		previous[n.Name] = n
+	}
+	nodeRegistry = make(map[string]Node, len(nodes))
+	for _, n := range nodes {
+		if old, ok := previous[n.Name]; ok {
+			n.StorageNode = old.StorageNode
+			n.VolDriverNodeID = old.VolDriverNodeID
+			n.IsStorageDriverInstalled = old.IsStorageDriverInstalled
+			n.IsMetadataNode = old.IsMetadataNode
+			n.StoragePools = old.StoragePools
+		}
+		n.uuid = uuid.New()
+		nodeRegistry[n.uuid] = n
+	}
+	events = DiffInventories(before, takeInventory())
 	return nil
 }
 
@@ -203,6 +249,11 @@ func GetNodeByIP(nodeIP string) (Node, error) {
 
 // CleanupRegistry removes entry of all nodes from registry
 func CleanupRegistry() {
+	var events []TopologyEvent
+	defer func() { emitTopologyEvents(events) }()
+	lock.Lock()
+	defer lock.Unlock()
+	events = DiffInventories(takeInventory(), Inventory{})
 	nodeRegistry = make(map[string]Node)
 }
 
@@ -0,0 +1,154 @@
+package redfish
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	systemsPath   = "/redfish/v1/Systems"
+	resetAction   = "#ComputerSystem.Reset"
+	clientTimeout = 30 * time.Second
+)
+
+// Redfish reset types of the ComputerSystem.Reset action
+const (
+	resetOn               = "On"
+	resetForceOff         = "ForceOff"
+	resetGracefulShutdown = "GracefulShutdown"
+	resetForceRestart     = "ForceRestart"
+	resetGracefulRestart  = "GracefulRestart"
+	resetPowerCycle       = "PowerCycle"
+)
+
+// Redfish power states of a ComputerSystem
+const (
+	powerStateOn  = "On"
+	powerStateOff = "Off"
+)
+
+// client talks to the Redfish service of the BMC of one server
+type client struct {
+	endpoint string
+	username string
+	password string
+	// system is the path of the ComputerSystem of the server, discovered when not configured
+	system string
+	http   *http.Client
+}
+
+type odataID struct {
+	ID string `json:"@odata.id"`
+}
+
+type systemCollection struct {
+	Members []odataID `json:"Members"`
+}
+
+type computerSystem struct {
+	PowerState string `json:"PowerState"`
+	Actions    map[string]struct {
+		Target string `json:"target"`
+	} `json:"Actions"`
+}
+
+func newClient(bmc bmcConfig) *client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: bmc.Insecure}
+	return &client{
+		endpoint: strings.TrimRight(bmc.Endpoint, "/"),
+		username: bmc.Username,
+		password: bmc.Password,
+		system:   bmc.System,
+		http:     &http.Client{Transport: transport, Timeout: clientTimeout},
+	}
+}
+
+func (c *client) do(method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, c.endpoint+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s returned %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// getSystem returns the path of the ComputerSystem, the first member of the system collection unless configured
+func (c *client) getSystem() (string, error) {
+	if c.system != "" {
+		return c.system, nil
+	}
+	var systems systemCollection
+	if err := c.do(http.MethodGet, systemsPath, nil, &systems); err != nil {
+		return "", err
+	}
+	if len(systems.Members) == 0 {
+		return "", fmt.Errorf("no system found in %s%s", c.endpoint, systemsPath)
+	}
+	c.system = systems.Members[0].ID
+	return c.system, nil
+}
+
+func (c *client) getComputerSystem() (string, *computerSystem, error) {
+	system, err := c.getSystem()
+	if err != nil {
+		return "", nil, err
+	}
+	var cs computerSystem
+	if err := c.do(http.MethodGet, system, nil, &cs); err != nil {
+		return "", nil, err
+	}
+	return system, &cs, nil
+}
+
+// powerState returns the power state of the server, e.g. On or Off
+func (c *client) powerState() (string, error) {
+	_, cs, err := c.getComputerSystem()
+	if err != nil {
+		return "", err
+	}
+	return cs.PowerState, nil
+}
+
+// reset runs the ComputerSystem.Reset action of the server with the given reset type
+func (c *client) reset(resetType string) error {
+	system, cs, err := c.getComputerSystem()
+	if err != nil {
+		return err
+	}
+	target := system + "/Actions/ComputerSystem.Reset"
+	if action, ok := cs.Actions[resetAction]; ok && action.Target != "" {
+		target = action.Target
+	}
+	return c.do(http.MethodPost, target, map[string]string{"ResetType": resetType}, nil)
+}
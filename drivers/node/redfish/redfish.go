@@ -0,0 +1,296 @@
+package redfish
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/node/ssh"
+	"github.com/portworx/torpedo/pkg/log"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DriverName is the name of the redfish driver
+	DriverName = "redfish"
+)
+
+const (
+	redfishConfig = "REDFISH_CONFIG"
+)
+
+const (
+	// PowerStateTimeout Timeout for a server to reach the requested power state
+	PowerStateTimeout = 5 * time.Minute
+	// PowerStateRetryInterval interval for retry when checking power state
+	PowerStateRetryInterval = 10 * time.Second
+	// NodeReadyTimeout Timeout for a powered on server to accept ssh commands
+	NodeReadyTimeout = 15 * time.Minute
+	// NodeReadyRetryInterval interval for retry when checking a powered on server
+	NodeReadyRetryInterval = 30 * time.Second
+)
+
+// bmcConfig is the BMC of one node. Credentials and TLS settings default to the ones of the config file.
+type bmcConfig struct {
+	// Node is the name of the node
+	Node string `yaml:"node"`
+	// Endpoint is the base URL of the BMC, e.g. https://10.0.0.1
+	Endpoint string `yaml:"endpoint"`
+	// System is the path of the ComputerSystem, e.g. /redfish/v1/Systems/1. The first system is used when empty.
+	System   string `yaml:"system"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Insecure bool   `yaml:"insecure"`
+}
+
+// config is the node-to-BMC mapping read from the file set in REDFISH_CONFIG
+type config struct {
+	Username string      `yaml:"username"`
+	Password string      `yaml:"password"`
+	Insecure bool        `yaml:"insecure"`
+	BMCs     []bmcConfig `yaml:"bmcs"`
+}
+
+// redfish ssh driver controlling the power of bare-metal servers out-of-band through their BMC
+type redfish struct {
+	ssh.SSH
+	lock sync.Mutex
+	// clients maps node names to the Redfish client of their BMC
+	clients map[string]*client
+}
+
+func (r *redfish) String() string {
+	return DriverName
+}
+
+// Init initializes the redfish driver for ssh
+func (r *redfish) Init(nodeOpts node.InitOptions) error {
+	log.Infof("Using the redfish node driver")
+
+	path := os.Getenv(redfishConfig)
+	if len(path) == 0 {
+		return fmt.Errorf("Redfish config file not provided as env var: %s", redfishConfig)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	r.setClients(cfg)
+	return r.SSH.Init(nodeOpts)
+}
+
+// loadConfig reads the node-to-BMC mapping and applies the defaults of the file to every BMC
+func loadConfig(path string) (*config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redfish config %s: %v", path, err)
+	}
+	cfg := &config{}
+	if err := yaml.UnmarshalStrict(content, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse redfish config %s: %v", path, err)
+	}
+	for i := range cfg.BMCs {
+		bmc := &cfg.BMCs[i]
+		if bmc.Node == "" || bmc.Endpoint == "" {
+			return nil, fmt.Errorf("node and endpoint are required for every bmc of redfish config %s", path)
+		}
+		if bmc.Username == "" {
+			bmc.Username = cfg.Username
+		}
+		if bmc.Password == "" {
+			bmc.Password = cfg.Password
+		}
+		bmc.Insecure = bmc.Insecure || cfg.Insecure
+	}
+	return cfg, nil
+}
+
+func (r *redfish) setClients(cfg *config) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.clients = make(map[string]*client)
+	for _, bmc := range cfg.BMCs {
+		r.clients[bmc.Node] = newClient(bmc)
+	}
+}
+
+func (r *redfish) getClient(nodeName string) (*client, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	c, ok := r.clients[nodeName]
+	if !ok {
+		return nil, fmt.Errorf("could not fetch BMC for node: %s", nodeName)
+	}
+	return c, nil
+}
+
+// waitForPowerState waits until the server reports the given power state
+func (r *redfish) waitForPowerState(c *client, state string) error {
+	t := func() (interface{}, bool, error) {
+		current, err := c.powerState()
+		if err != nil {
+			return nil, true, err
+		}
+		if current != state {
+			return nil, true, fmt.Errorf("power state of %s is %s, waiting for %s", c.endpoint, current, state)
+		}
+		return nil, false, nil
+	}
+	_, err := task.DoRetryWithTimeout(t, PowerStateTimeout, PowerStateRetryInterval)
+	return err
+}
+
+// TestConnection tests the connection to the given node
+func (r *redfish) TestConnection(n node.Node, options node.ConnectionOpts) error {
+	log.Infof("Testing redfish driver connection by checking power state of node %s", n.Name)
+	c, err := r.getClient(n.Name)
+	if err != nil {
+		return err
+	}
+	if err := r.waitForPowerState(c, powerStateOn); err != nil {
+		return &node.ErrFailedToTestConnection{
+			Node:  n,
+			Cause: fmt.Sprintf("Failed to test connection to BMC %s: %v", c.endpoint, err),
+		}
+	}
+	// Check if server is not just powered on but also usable
+	_, err = r.RunCommand(n, "hostname", node.ConnectionOpts{
+		Timeout:         NodeReadyTimeout,
+		TimeBeforeRetry: NodeReadyRetryInterval,
+	})
+	return err
+}
+
+// RebootNode restarts the server of the node through its BMC. A forced reboot cuts the power
+// without letting the OS shut down.
+func (r *redfish) RebootNode(n node.Node, options node.RebootNodeOpts) error {
+	c, err := r.getClient(n.Name)
+	if err != nil {
+		return err
+	}
+	resetType := resetGracefulRestart
+	if options.Force {
+		resetType = resetForceRestart
+	}
+	log.Infof("Rebooting node %s using Redfish reset %s", n.Name, resetType)
+	if err := c.reset(resetType); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to reboot server %s. cause %v", c.endpoint, err),
+		}
+	}
+	return nil
+}
+
+// CrashNode power cycles the server of the node, simulating a hard power loss the OS cannot react to
+func (r *redfish) CrashNode(n node.Node, options node.CrashNodeOpts) error {
+	c, err := r.getClient(n.Name)
+	if err != nil {
+		return err
+	}
+	log.Infof("Crashing node %s using Redfish reset %s", n.Name, resetPowerCycle)
+	if err := c.reset(resetPowerCycle); err != nil {
+		return &node.ErrFailedToCrashNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to power cycle server %s. cause %v", c.endpoint, err),
+		}
+	}
+	return nil
+}
+
+// ShutdownNode shuts down the server of the node through its BMC. A forced shutdown cuts the power.
+func (r *redfish) ShutdownNode(n node.Node, options node.ShutdownNodeOpts) error {
+	c, err := r.getClient(n.Name)
+	if err != nil {
+		return err
+	}
+	resetType := resetGracefulShutdown
+	if options.Force {
+		resetType = resetForceOff
+	}
+	log.Infof("Shutting down node %s using Redfish reset %s", n.Name, resetType)
+	if err := c.reset(resetType); err != nil {
+		return &node.ErrFailedToShutdownNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to shutdown server %s. cause %v", c.endpoint, err),
+		}
+	}
+	return nil
+}
+
+// PowerOnVM powers on the server of the node and waits for its BMC to report it on
+func (r *redfish) PowerOnVM(n node.Node) error {
+	if err := r.PowerOnVMByName(n.Name); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to power on server. cause %v", err),
+		}
+	}
+	return nil
+}
+
+// PowerOnVMByName powers on the server of the node of the given name
+func (r *redfish) PowerOnVMByName(vmName string) error {
+	c, err := r.getClient(vmName)
+	if err != nil {
+		return err
+	}
+	state, err := c.powerState()
+	if err != nil {
+		return err
+	}
+	if state == powerStateOn {
+		log.Warnf("Server is already powered on: %s", vmName)
+		return nil
+	}
+	log.Infof("Powering on server of node %s", vmName)
+	if err := c.reset(resetOn); err != nil {
+		return err
+	}
+	return r.waitForPowerState(c, powerStateOn)
+}
+
+// PowerOffVM forces the server of the node off and waits for its BMC to report it off
+func (r *redfish) PowerOffVM(n node.Node) error {
+	c, err := r.getClient(n.Name)
+	if err != nil {
+		return err
+	}
+	state, err := c.powerState()
+	if err != nil {
+		return err
+	}
+	if state == powerStateOff {
+		log.Warnf("Server is already powered off: %s", n.Name)
+		return nil
+	}
+	log.Infof("Powering off server of node %s", n.Name)
+	if err := c.reset(resetForceOff); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to power off server %s. cause %v", c.endpoint, err),
+		}
+	}
+	return r.waitForPowerState(c, powerStateOff)
+}
+
+// GetNodeState returns the Redfish power state of the server of the node, e.g. On or Off
+func (r *redfish) GetNodeState(n node.Node) (string, error) {
+	c, err := r.getClient(n.Name)
+	if err != nil {
+		return "", err
+	}
+	return c.powerState()
+}
+
+func init() {
+	r := &redfish{
+		SSH:     *ssh.New(),
+		clients: make(map[string]*client),
+	}
+
+	node.Register(DriverName, r)
+}
@@ -0,0 +1,126 @@
+package redfish
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockBMC serves the subset of the Redfish API the driver uses for a single system
+type mockBMC struct {
+	sync.Mutex
+	powerState string
+	resets     []string
+}
+
+func (m *mockBMC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.Lock()
+	defer m.Unlock()
+	if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == systemsPath:
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Members": []map[string]string{{"@odata.id": "/redfish/v1/Systems/1"}},
+		})
+	case r.Method == http.MethodGet && r.URL.Path == "/redfish/v1/Systems/1":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"PowerState": m.powerState,
+			"Actions": map[string]interface{}{
+				resetAction: map[string]string{"target": "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"},
+			},
+		})
+	case r.Method == http.MethodPost && r.URL.Path == "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset":
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.resets = append(m.resets, body["ResetType"])
+		switch body["ResetType"] {
+		case resetOn, resetForceRestart, resetGracefulRestart, resetPowerCycle:
+			m.powerState = powerStateOn
+		case resetForceOff, resetGracefulShutdown:
+			m.powerState = powerStateOff
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redfish.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+username: admin
+password: secret
+bmcs:
+- node: node1
+  endpoint: https://10.0.0.1
+  insecure: true
+- node: node2
+  endpoint: https://10.0.0.2
+  system: /redfish/v1/Systems/System.Embedded.1
+  username: root
+`), 0600))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.BMCs, 2)
+	assert.Equal(t, bmcConfig{Node: "node1", Endpoint: "https://10.0.0.1", Username: "admin", Password: "secret", Insecure: true}, cfg.BMCs[0])
+	assert.Equal(t, "root", cfg.BMCs[1].Username)
+	assert.Equal(t, "/redfish/v1/Systems/System.Embedded.1", cfg.BMCs[1].System)
+
+	require.NoError(t, os.WriteFile(path, []byte("bmcs:\n- node: node1\n"), 0600))
+	_, err = loadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestPowerOperations(t *testing.T) {
+	bmc := &mockBMC{powerState: powerStateOn}
+	server := httptest.NewTLSServer(bmc)
+	defer server.Close()
+
+	r := &redfish{}
+	r.setClients(&config{BMCs: []bmcConfig{{Node: "node1", Endpoint: server.URL, Username: "admin", Password: "secret", Insecure: true}}})
+	n := node.Node{Name: "node1"}
+
+	require.NoError(t, r.PowerOffVM(n))
+	state, err := r.GetNodeState(n)
+	require.NoError(t, err)
+	assert.Equal(t, powerStateOff, state)
+	require.NoError(t, r.PowerOffVM(n))
+
+	require.NoError(t, r.PowerOnVM(n))
+	state, err = r.GetNodeState(n)
+	require.NoError(t, err)
+	assert.Equal(t, powerStateOn, state)
+
+	require.NoError(t, r.RebootNode(n, node.RebootNodeOpts{Force: true}))
+	require.NoError(t, r.CrashNode(n, node.CrashNodeOpts{}))
+	require.NoError(t, r.ShutdownNode(n, node.ShutdownNodeOpts{}))
+	assert.Equal(t, []string{resetForceOff, resetOn, resetForceRestart, resetPowerCycle, resetGracefulShutdown}, bmc.resets)
+
+	_, err = r.GetNodeState(node.Node{Name: "node2"})
+	assert.Error(t, err)
+}
+
+func TestUnauthorized(t *testing.T) {
+	server := httptest.NewTLSServer(&mockBMC{powerState: powerStateOn})
+	defer server.Close()
+
+	r := &redfish{}
+	r.setClients(&config{BMCs: []bmcConfig{{Node: "node1", Endpoint: server.URL, Username: "admin", Password: "wrong", Insecure: true}}})
+	_, err := r.GetNodeState(node.Node{Name: "node1"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,211 @@
+package ssh
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+	"github.com/portworx/torpedo/pkg/revertutils"
+)
+
+const (
+	// dmNameCmd prints the device-mapper name of the given drive and fails if it is not a device-mapper device
+	dmNameCmd = "sudo dmsetup info -c --noheadings -o name $(readlink -f %s) 2>/dev/null"
+	// diskFaultRevertKeyPrefix prefixes the revert registry keys of the disk faults
+	diskFaultRevertKeyPrefix = "diskfault/"
+	// dmCreateLinearCmd creates a device-mapper linear device spanning the whole given drive unless it exists already
+	dmCreateLinearCmd = "sudo dmsetup info %[1]s >/dev/null 2>&1 || sudo dmsetup create %[1]s --table \"0 $(sudo blockdev --getsz $(readlink -f %[2]s)) linear $(readlink -f %[2]s) 0\""
+	// dmTableCmd prints the device-mapper table of the given device
+	dmTableCmd = "sudo dmsetup table %s"
+	// dmReloadCmd swaps the table of a device. The device is suspended without flushing so the
+	// swap does not hang on I/O the faulty table cannot complete
+	dmReloadCmd = "printf '%%s\\n' %s | sudo dmsetup load %s && sudo dmsetup suspend --noflush --nolockfs %s && sudo dmsetup resume %s"
+	// selfRevertCmd injects the fault and reverts it on the node itself once the given seconds pass
+	selfRevertCmd = "%s && (nohup setsid sh -c \"sleep %d; %s\" >/dev/null 2>&1 &)"
+
+	defaultFlakeyUpInterval   = 1 * time.Minute
+	defaultFlakeyDownInterval = 15 * time.Second
+	defaultDiskDelay          = 500 * time.Millisecond
+	// readOnlyDownInterval keeps a read-only drive failing its writes for a year, i.e. until reverted
+	readOnlyDownInterval = 365 * 24 * time.Hour
+)
+
+// WrapDrive creates a device-mapper linear device on top of the given drive
+func (s *SSH) WrapDrive(n node.Node, drive string, options node.ConnectionOpts) (string, error) {
+	wrapped, cmd := wrapDriveCmd(drive)
+	if _, err := s.doCmd(n, options, cmd, false); err != nil {
+		return "", &node.ErrFailedToInjectDiskFault{
+			Node:  n,
+			Drive: drive,
+			Cause: fmt.Sprintf("failed to wrap drive with a device-mapper linear device: %v", err),
+		}
+	}
+	log.Infof("Wrapped drive [%s] on node [%s] with [%s]", drive, n.Name, wrapped)
+	return wrapped, nil
+}
+
+// CanInjectDiskFault returns true if the given drive is a device-mapper device with only linear targets
+func (s *SSH) CanInjectDiskFault(n node.Node, drive string, options node.ConnectionOpts) (bool, error) {
+	name, err := s.doCmd(n, options, fmt.Sprintf(dmNameCmd, drive), true)
+	if err != nil {
+		return false, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return false, nil
+	}
+	table, err := s.doCmd(n, options, fmt.Sprintf(dmTableCmd, name), false)
+	if err != nil {
+		return false, err
+	}
+	_, err = faultTable(table, node.DiskFaultOpts{Type: node.DiskFaultError})
+	return err == nil, nil
+}
+
+// InjectDiskFault swaps the device-mapper table of the given drive with a faulty one
+func (s *SSH) InjectDiskFault(n node.Node, drive string, opts node.DiskFaultOpts) (*node.DiskFault, error) {
+	key := fmt.Sprintf("%s%s/%s", diskFaultRevertKeyPrefix, n.Name, drive)
+	for _, action := range revertutils.Pending() {
+		if action.Key == key {
+			return nil, &node.ErrFailedToInjectDiskFault{Node: n, Drive: drive, Cause: "a fault is already injected on the drive"}
+		}
+	}
+
+	name, err := s.doCmd(n, opts.ConnectionOpts, fmt.Sprintf(dmNameCmd, drive), false)
+	if err != nil {
+		return nil, &node.ErrFailedToInjectDiskFault{
+			Node:  n,
+			Drive: drive,
+			Cause: fmt.Sprintf("drive is not a device-mapper device: %v", err),
+		}
+	}
+	name = strings.TrimSpace(name)
+	table, err := s.doCmd(n, opts.ConnectionOpts, fmt.Sprintf(dmTableCmd, name), false)
+	if err != nil {
+		return nil, &node.ErrFailedToInjectDiskFault{Node: n, Drive: drive, Cause: err.Error()}
+	}
+	faulty, err := faultTable(table, opts)
+	if err != nil {
+		return nil, &node.ErrFailedToInjectDiskFault{Node: n, Drive: drive, Cause: err.Error()}
+	}
+	revertCmd := reloadTableCmd(name, table)
+
+	fault := &node.DiskFault{Node: n, Drive: drive, Type: opts.Type}
+	var (
+		lock     sync.Mutex
+		reverted bool
+		timer    *time.Timer
+	)
+	fault.Revert = func() error {
+		lock.Lock()
+		defer lock.Unlock()
+		if reverted {
+			return nil
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if _, err := s.doCmd(n, opts.ConnectionOpts, revertCmd, false); err != nil {
+			return &node.ErrFailedToInjectDiskFault{
+				Node:  n,
+				Drive: drive,
+				Cause: fmt.Sprintf("failed to revert %s fault: %v", opts.Type, err),
+			}
+		}
+		reverted = true
+		revertutils.Resolve(key)
+		log.Infof("Reverted %s fault on drive [%s] of node [%s]", opts.Type, drive, n.Name)
+		return nil
+	}
+
+	cmd := reloadTableCmd(name, faulty)
+	if opts.Duration > 0 {
+		// the node reverts the fault as well in case torpedo cannot reach it anymore
+		cmd = fmt.Sprintf(selfRevertCmd, cmd, int(opts.Duration.Seconds()), strings.ReplaceAll(revertCmd, `"`, `\"`))
+	}
+	log.Infof("Injecting %s fault on drive [%s] of node [%s]", opts.Type, drive, n.Name)
+	if _, err := s.doCmd(n, opts.ConnectionOpts, cmd, false); err != nil {
+		return nil, &node.ErrFailedToInjectDiskFault{Node: n, Drive: drive, Cause: err.Error()}
+	}
+
+	revertutils.Register(key, fmt.Sprintf("%s fault on drive [%s] of node [%s]", opts.Type, drive, n.Name), fault.Revert)
+
+	if opts.Duration > 0 {
+		lock.Lock()
+		timer = time.AfterFunc(opts.Duration, func() {
+			if err := fault.Revert(); err != nil {
+				log.Errorf("Failed to revert disk fault on drive [%s] of node [%s] after %v: %v", drive, n.Name, opts.Duration, err)
+			}
+		})
+		lock.Unlock()
+	}
+	return fault, nil
+}
+
+// RevertDiskFaults reverts all the disk faults that are still injected
+func (s *SSH) RevertDiskFaults() error {
+	return revertFailuresError("revert disk faults", revertutils.ReplayPrefix(diskFaultRevertKeyPrefix))
+}
+
+// wrapDriveCmd returns the path of the device-mapper linear device wrapping the given drive and the command creating it
+func wrapDriveCmd(drive string) (string, string) {
+	wrapped := node.WrappedDrivePathPrefix + path.Base(drive)
+	return wrapped, fmt.Sprintf(dmCreateLinearCmd, path.Base(wrapped), drive)
+}
+
+// reloadTableCmd returns the command loading the given table into the given device
+func reloadTableCmd(name, table string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(table), "\n") {
+		lines = append(lines, fmt.Sprintf("'%s'", strings.TrimSpace(line)))
+	}
+	return fmt.Sprintf(dmReloadCmd, strings.Join(lines, " "), name, name, name)
+}
+
+// faultTable converts the linear segments of a device-mapper table into segments of the fault target
+func faultTable(table string, opts node.DiskFaultOpts) (string, error) {
+	var faulty []string
+	for _, line := range strings.Split(strings.TrimSpace(table), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return "", fmt.Errorf("invalid device-mapper table line [%s]", line)
+		}
+		if fields[2] != "linear" || len(fields) != 5 {
+			return "", fmt.Errorf("only linear device-mapper targets are supported, found [%s]", fields[2])
+		}
+		start, length, dev, offset := fields[0], fields[1], fields[3], fields[4]
+
+		switch opts.Type {
+		case node.DiskFaultFlakey:
+			up, down := opts.UpInterval, opts.DownInterval
+			if up == 0 {
+				up = defaultFlakeyUpInterval
+			}
+			if down == 0 {
+				down = defaultFlakeyDownInterval
+			}
+			faulty = append(faulty, fmt.Sprintf("%s %s flakey %s %s %d %d",
+				start, length, dev, offset, int(up.Seconds()), int(down.Seconds())))
+		case node.DiskFaultReadOnly:
+			faulty = append(faulty, fmt.Sprintf("%s %s flakey %s %s 0 %d 1 error_writes",
+				start, length, dev, offset, int(readOnlyDownInterval.Seconds())))
+		case node.DiskFaultDelay:
+			delay := opts.Delay
+			if delay == 0 {
+				delay = defaultDiskDelay
+			}
+			faulty = append(faulty, fmt.Sprintf("%s %s delay %s %s %s",
+				start, length, dev, offset, strconv.FormatInt(delay.Milliseconds(), 10)))
+		case node.DiskFaultError:
+			faulty = append(faulty, fmt.Sprintf("%s %s error", start, length))
+		default:
+			return "", fmt.Errorf("unsupported disk fault type [%s]", opts.Type)
+		}
+	}
+	return strings.Join(faulty, "\n"), nil
+}
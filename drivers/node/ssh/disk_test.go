@@ -0,0 +1,46 @@
+package ssh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultTable(t *testing.T) {
+	table := "0 2097152 linear 259:1 0\n2097152 1048576 linear 259:2 2048\n"
+
+	faulty, err := faultTable(table, node.DiskFaultOpts{Type: node.DiskFaultFlakey, UpInterval: 30 * time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, "0 2097152 flakey 259:1 0 30 15\n2097152 1048576 flakey 259:2 2048 30 15", faulty)
+
+	faulty, err = faultTable(table, node.DiskFaultOpts{Type: node.DiskFaultDelay, Delay: 2 * time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, "0 2097152 delay 259:1 0 2000\n2097152 1048576 delay 259:2 2048 2000", faulty)
+
+	faulty, err = faultTable(table, node.DiskFaultOpts{Type: node.DiskFaultError})
+	require.NoError(t, err)
+	assert.Equal(t, "0 2097152 error\n2097152 1048576 error", faulty)
+
+	faulty, err = faultTable("0 2097152 linear 8:16 0", node.DiskFaultOpts{Type: node.DiskFaultReadOnly})
+	require.NoError(t, err)
+	assert.Equal(t, "0 2097152 flakey 8:16 0 0 31536000 1 error_writes", faulty)
+
+	_, err = faultTable("0 2097152 crypt aes-xts-plain64 key 0 8:16 0", node.DiskFaultOpts{Type: node.DiskFaultError})
+	assert.Error(t, err, "only linear targets can be swapped")
+
+	_, err = faultTable(table, node.DiskFaultOpts{Type: "unknown"})
+	assert.Error(t, err, "unknown fault types are rejected")
+}
+
+func TestWrapDriveCmd(t *testing.T) {
+	wrapped, cmd := wrapDriveCmd("/dev/nvme1n1")
+	assert.Equal(t, "/dev/mapper/torpedo-nvme1n1", wrapped)
+	assert.Equal(t, `sudo dmsetup info torpedo-nvme1n1 >/dev/null 2>&1 || `+
+		`sudo dmsetup create torpedo-nvme1n1 --table "0 $(sudo blockdev --getsz $(readlink -f /dev/nvme1n1)) linear $(readlink -f /dev/nvme1n1) 0"`, cmd)
+
+	wrapped, _ = wrapDriveCmd("/dev/disk/by-id/virtio-data1")
+	assert.Equal(t, "/dev/mapper/torpedo-virtio-data1", wrapped, "symlinked drives are named after the link")
+}
@@ -0,0 +1,392 @@
+package ssh
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+	"github.com/portworx/torpedo/pkg/revertutils"
+)
+
+const (
+	// partitionChainPrefix prefixes the iptables chain holding the rules of a partition
+	partitionChainPrefix = "TORPEDO-"
+	// partitionRateCeil is the rate of the traffic that is not shaped by a bandwidth limited partition
+	partitionRateCeil = "100gbit"
+	// partitionQdiscHandle is the handle of the htb root qdisc added by bandwidth limited partitions on
+	// interfaces that only have the default root qdisc of the kernel
+	partitionQdiscHandle = "7470:"
+	// detectInterfaceCmd prints the interface the traffic to the given address is routed through
+	detectInterfaceCmd = "ip -o route get %s | sed -n 's/.* dev \\([^ ]*\\).*/\\1/p'"
+	// iptablesCheckCmd fails when the iptables binary is missing. On nftables hosts iptables-nft provides it
+	iptablesCheckCmd = "command -v %s"
+	// selfHealCmd applies the partition and heals it on the node itself once the given seconds pass
+	selfHealCmd = "%s && (nohup setsid sh -c 'sleep %d; %s' >/dev/null 2>&1 &)"
+	// partitionRevertKeyPrefix prefixes the revert registry keys of the network partitions
+	partitionRevertKeyPrefix = "partition/"
+)
+
+// partitionPortProtocols are the protocols whose traffic to the given ports a partition blocks
+var partitionPortProtocols = []string{"tcp", "udp", "sctp"}
+
+// partitionTarget describes the rules a partition applies on a single node
+type partitionTarget struct {
+	node  node.Node
+	peers []string
+	// outbound blocks the traffic from the node to the peers
+	outbound bool
+	// inbound blocks the traffic from the peers to the node
+	inbound bool
+	// interfaces are the interfaces shaped by a bandwidth limited partition, filled in when applied
+	interfaces []string
+}
+
+// PartitionNetwork isolates groupA from groupB
+func (s *SSH) PartitionNetwork(groupA, groupB []node.Node, opts node.NetworkPartitionOpts) (*node.NetworkPartition, error) {
+	if len(groupA) == 0 || len(groupB) == 0 {
+		return nil, fmt.Errorf("both node groups are required to partition the network")
+	}
+	addrA, addrB := nodeAddresses(groupA), nodeAddresses(groupB)
+	var targets []*partitionTarget
+	for _, n := range groupA {
+		targets = append(targets, &partitionTarget{node: n, peers: addrB, outbound: true, inbound: !opts.OneWay})
+	}
+	for _, n := range groupB {
+		targets = append(targets, &partitionTarget{node: n, peers: addrA, outbound: !opts.OneWay, inbound: true})
+	}
+	return s.applyNetworkPartition(targets, opts)
+}
+
+// PartitionNetworkFromAddresses isolates the given nodes from the given addresses
+func (s *SSH) PartitionNetworkFromAddresses(nodes []node.Node, addresses []string, opts node.NetworkPartitionOpts) (*node.NetworkPartition, error) {
+	if len(nodes) == 0 || len(addresses) == 0 {
+		return nil, fmt.Errorf("nodes and addresses are required to partition the network")
+	}
+	var targets []*partitionTarget
+	for _, n := range nodes {
+		targets = append(targets, &partitionTarget{node: n, peers: addresses, outbound: true, inbound: !opts.OneWay})
+	}
+	return s.applyNetworkPartition(targets, opts)
+}
+
+// HealNetworkPartitions heals all the network partitions that are still applied
+func (s *SSH) HealNetworkPartitions() error {
+	return revertFailuresError("heal network partitions", revertutils.ReplayPrefix(partitionRevertKeyPrefix))
+}
+
+func (s *SSH) applyNetworkPartition(targets []*partitionTarget, opts node.NetworkPartitionOpts) (*node.NetworkPartition, error) {
+	id := strings.ToUpper(strings.Split(uuid.New().String(), "-")[0])
+	chain := partitionChainPrefix + id
+	opts.ConnectionOpts = partitionConnectionOpts(opts.ConnectionOpts)
+
+	partition := &node.NetworkPartition{ID: id}
+	var (
+		lock   sync.Mutex
+		healed bool
+		timer  *time.Timer
+	)
+	partition.Heal = func() error {
+		lock.Lock()
+		defer lock.Unlock()
+		if healed {
+			return nil
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		for _, t := range targets {
+			cmd := healPartitionCmd(chain, t)
+			if err := s.runPartitionCmd(t.node, opts.ConnectionOpts, cmd); err != nil {
+				return &node.ErrFailedToPartitionNetwork{
+					Node:  t.node,
+					Cause: fmt.Sprintf("failed to heal partition [%s]: %v", id, err),
+				}
+			}
+		}
+		healed = true
+		revertutils.Resolve(partitionRevertKey(id))
+		log.Infof("Healed network partition [%s]", id)
+		return nil
+	}
+
+	for _, t := range targets {
+		var cmd string
+		peerIfaces, err := s.peerInterfaces(t, opts)
+		if err == nil {
+			cmd = partitionCmd(chain, t, opts, peerIfaces)
+		}
+		if err == nil && opts.HealAfter > 0 {
+			// the node heals itself as well in case torpedo cannot reach it anymore, e.g. when the
+			// partition blocks the API server the debug pods are exec'ed through
+			cmd = fmt.Sprintf(selfHealCmd, cmd, int(opts.HealAfter.Seconds()), healPartitionCmd(chain, t))
+		}
+		if err == nil {
+			log.Infof("Applying network partition [%s] on node [%s] against %v", id, t.node.Name, t.peers)
+			err = s.runPartitionCmd(t.node, opts.ConnectionOpts, cmd)
+		}
+		partition.Nodes = append(partition.Nodes, t.node)
+		if err != nil {
+			if healErr := partition.Heal(); healErr != nil {
+				log.Errorf("Failed to heal partially applied partition [%s]: %v", id, healErr)
+			}
+			return nil, &node.ErrFailedToPartitionNetwork{
+				Node:  t.node,
+				Cause: err.Error(),
+			}
+		}
+	}
+
+	revertutils.Register(partitionRevertKey(id), fmt.Sprintf("network partition [%s]", id), partition.Heal)
+
+	if opts.HealAfter > 0 {
+		lock.Lock()
+		timer = time.AfterFunc(opts.HealAfter, func() {
+			if err := partition.Heal(); err != nil {
+				log.Errorf("Failed to heal network partition [%s] after %v: %v", id, opts.HealAfter, err)
+			}
+		})
+		lock.Unlock()
+	}
+	return partition, nil
+}
+
+// peerInterfaces returns the interface the node of the target routes the traffic to each of its peers through.
+// Only bandwidth limited partitions shape interfaces, so other partitions need none.
+func (s *SSH) peerInterfaces(t *partitionTarget, opts node.NetworkPartitionOpts) (map[string]string, error) {
+	ifaces := make(map[string]string)
+	if opts.BandwidthKbit == 0 || !t.outbound {
+		return ifaces, nil
+	}
+	for _, peer := range t.peers {
+		iface, err := s.detectInterface(t.node, opts.ConnectionOpts, peer)
+		if err != nil {
+			return nil, err
+		}
+		ifaces[peer] = iface
+	}
+	return ifaces, nil
+}
+
+// partitionCmd returns the command applying the partition rules of the target on its node. The command starts
+// from a clean chain or tc class, so it can be retried after a partial failure.
+func partitionCmd(chain string, t *partitionTarget, opts node.NetworkPartitionOpts, peerIfaces map[string]string) string {
+	var cmds []string
+	if opts.BandwidthKbit > 0 {
+		// tc only shapes the egress traffic, the peers shape the other direction
+		if !t.outbound {
+			return "true"
+		}
+		minor, prio := partitionClass(chain)
+		for _, peer := range t.peers {
+			if iface := peerIfaces[peer]; !containsString(t.interfaces, iface) {
+				t.interfaces = append(t.interfaces, iface)
+			}
+		}
+		for _, iface := range t.interfaces {
+			// the partition adds its own class and filters under the htb root qdisc of the interface, adding
+			// the root qdisc when the interface has the default one of the kernel. $3 is the handle of the root.
+			// Other root qdiscs, e.g. netem, are not replaced.
+			cmds = append(cmds,
+				fmt.Sprintf("set -- $(sudo tc qdisc show dev %s root)", iface),
+				fmt.Sprintf("if [ \"$2\" != htb ]; then { [ -z \"$3\" ] || [ \"$3\" = 0: ] || { echo \"%s has a $2 root qdisc\" >&2; false; }; } && "+
+					"sudo tc qdisc add dev %s root handle %s htb default 1 && "+
+					"sudo tc class add dev %s parent %s classid %s1 htb rate %s && set -- qdisc htb %s; fi",
+					iface, iface, partitionQdiscHandle, iface, partitionQdiscHandle, partitionQdiscHandle, partitionRateCeil, partitionQdiscHandle),
+				// removing the class and filters of an earlier attempt
+				fmt.Sprintf("(sudo tc filter del dev %s parent $3 prio %d 2>/dev/null; sudo tc class del dev %s classid $3%s 2>/dev/null; true)",
+					iface, prio, iface, minor),
+				fmt.Sprintf("sudo tc class add dev %s parent $3 classid $3%s htb rate %dkbit", iface, minor, opts.BandwidthKbit))
+			for _, peer := range t.peers {
+				if peerIfaces[peer] != iface {
+					continue
+				}
+				match := fmt.Sprintf("match %s dst %s", ipFamily(peer), hostPrefix(peer))
+				if len(opts.Ports) == 0 {
+					cmds = append(cmds, fmt.Sprintf("sudo tc filter add dev %s parent $3 protocol all prio %d u32 %s flowid $3%s",
+						iface, prio, match, minor))
+				}
+				for _, port := range opts.Ports {
+					cmds = append(cmds, fmt.Sprintf("sudo tc filter add dev %s parent $3 protocol all prio %d u32 %s match %s dport %d 0xffff flowid $3%s",
+						iface, prio, match, ipFamily(peer), port, minor))
+				}
+			}
+		}
+		return strings.Join(cmds, " && ")
+	}
+
+	for _, bin := range iptablesBinaries(t.peers) {
+		cmds = append(cmds,
+			fmt.Sprintf(iptablesCheckCmd, bin),
+			fmt.Sprintf("(sudo %s -w -N %s 2>/dev/null || sudo %s -w -F %s)", bin, chain, bin, chain),
+			fmt.Sprintf("(sudo %s -w -C INPUT -j %s 2>/dev/null || sudo %s -w -I INPUT -j %s)", bin, chain, bin, chain),
+			fmt.Sprintf("(sudo %s -w -C OUTPUT -j %s 2>/dev/null || sudo %s -w -I OUTPUT -j %s)", bin, chain, bin, chain))
+	}
+	for _, peer := range t.peers {
+		bin := iptablesBinary(peer)
+		var portMatches []string
+		for _, port := range opts.Ports {
+			for _, proto := range partitionPortProtocols {
+				portMatches = append(portMatches, fmt.Sprintf(" -p %s --dport %d", proto, port))
+			}
+		}
+		if len(portMatches) == 0 {
+			portMatches = []string{""}
+		}
+		for _, portMatch := range portMatches {
+			if t.outbound {
+				cmds = append(cmds, fmt.Sprintf("sudo %s -w -A %s -d %s%s -j DROP", bin, chain, peer, portMatch))
+			}
+			if t.inbound {
+				cmds = append(cmds, fmt.Sprintf("sudo %s -w -A %s -s %s%s -j DROP", bin, chain, peer, portMatch))
+			}
+		}
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// healPartitionCmd returns the command removing the partition rules of the target from its node.
+// It succeeds when the rules were already removed so a partition can be healed more than once.
+func healPartitionCmd(chain string, t *partitionTarget) string {
+	var cmds []string
+	for _, bin := range iptablesBinaries(t.peers) {
+		cmds = append(cmds,
+			fmt.Sprintf("sudo %s -w -D INPUT -j %s 2>/dev/null", bin, chain),
+			fmt.Sprintf("sudo %s -w -D OUTPUT -j %s 2>/dev/null", bin, chain),
+			fmt.Sprintf("sudo %s -w -F %s 2>/dev/null", bin, chain),
+			fmt.Sprintf("sudo %s -w -X %s 2>/dev/null", bin, chain))
+	}
+	minor, prio := partitionClass(chain)
+	for _, iface := range t.interfaces {
+		// only the class and filters of the partition are removed, and the root qdisc when the partition
+		// added it and no other class is left under it
+		cmds = append(cmds, fmt.Sprintf("set -- $(sudo tc qdisc show dev %s root)", iface),
+			fmt.Sprintf("[ \"$2\" != htb ] || { sudo tc filter del dev %s parent $3 prio %d 2>/dev/null; "+
+				"sudo tc class del dev %s classid $3%s 2>/dev/null; "+
+				"[ \"$3\" != %s ] || [ -n \"$(sudo tc class show dev %s | grep -v \" %s1 \")\" ] || sudo tc qdisc del dev %s root 2>/dev/null; }",
+				iface, prio, iface, minor, partitionQdiscHandle, iface, partitionQdiscHandle, iface))
+	}
+	cmds = append(cmds, "true")
+	return strings.Join(cmds, "; ")
+}
+
+// partitionClass returns the minor of the htb class and the priority of the tc filters of the partition with
+// the given chain, so partitions shaping the same interface do not share them
+func partitionClass(chain string) (string, int) {
+	h := fnv.New32a()
+	h.Write([]byte(chain))
+	n := h.Sum32()%0xff00 + 0x10
+	return fmt.Sprintf("%x", n), int(n)
+}
+
+func (s *SSH) runPartitionCmd(n node.Node, opts node.ConnectionOpts, cmd string) error {
+	t := func() (interface{}, bool, error) {
+		out, err := s.doCmd(n, opts, cmd, false)
+		return out, true, err
+	}
+	_, err := task.DoRetryWithTimeout(t, opts.Timeout, opts.TimeBeforeRetry)
+	return err
+}
+
+// detectInterface returns the interface the node routes the traffic to the given address through
+func (s *SSH) detectInterface(n node.Node, opts node.ConnectionOpts, address string) (string, error) {
+	out, err := s.doCmd(n, opts, fmt.Sprintf(detectInterfaceCmd, address), false)
+	if err != nil {
+		return "", err
+	}
+	iface := strings.TrimSpace(out)
+	if iface == "" {
+		return "", fmt.Errorf("no route to %s on node %s", address, n.Name)
+	}
+	return iface, nil
+}
+
+func partitionRevertKey(id string) string {
+	return partitionRevertKeyPrefix + id
+}
+
+// revertFailuresError joins the failures of a replay of the revert registry into a single error
+func revertFailuresError(op string, failures []*revertutils.Failure) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	var errs []string
+	for _, f := range failures {
+		errs = append(errs, f.Error())
+	}
+	return fmt.Errorf("failed to %s: %s", op, strings.Join(errs, "; "))
+}
+
+func partitionConnectionOpts(opts node.ConnectionOpts) node.ConnectionOpts {
+	if opts.Timeout == 0 {
+		opts.Timeout = 1 * time.Minute
+	}
+	if opts.TimeBeforeRetry == 0 {
+		opts.TimeBeforeRetry = 10 * time.Second
+	}
+	return opts
+}
+
+// nodeAddresses returns the sorted addresses of the given nodes
+func nodeAddresses(nodes []node.Node) []string {
+	var addresses []string
+	for _, n := range nodes {
+		for _, addr := range n.Addresses {
+			if !containsString(addresses, addr) {
+				addresses = append(addresses, addr)
+			}
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+func iptablesBinaries(addresses []string) []string {
+	var bins []string
+	for _, addr := range addresses {
+		if bin := iptablesBinary(addr); !containsString(bins, bin) {
+			bins = append(bins, bin)
+		}
+	}
+	sort.Strings(bins)
+	return bins
+}
+
+func iptablesBinary(address string) string {
+	if strings.Contains(address, ":") {
+		return "ip6tables"
+	}
+	return "iptables"
+}
+
+func ipFamily(address string) string {
+	if strings.Contains(address, ":") {
+		return "ip6"
+	}
+	return "ip"
+}
+
+func hostPrefix(address string) string {
+	if strings.Contains(address, "/") {
+		return address
+	}
+	if strings.Contains(address, ":") {
+		return address + "/128"
+	}
+	return address + "/32"
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
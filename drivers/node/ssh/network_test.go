@@ -0,0 +1,86 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionCmd(t *testing.T) {
+	target := &partitionTarget{node: node.Node{Name: "n1"}, peers: []string{"10.0.0.2", "fd00::2"}, outbound: true, inbound: true}
+	cmd := partitionCmd("TORPEDO-AB", target, node.NetworkPartitionOpts{}, nil)
+	assert.Equal(t, strings.Join([]string{
+		"command -v ip6tables",
+		"(sudo ip6tables -w -N TORPEDO-AB 2>/dev/null || sudo ip6tables -w -F TORPEDO-AB)",
+		"(sudo ip6tables -w -C INPUT -j TORPEDO-AB 2>/dev/null || sudo ip6tables -w -I INPUT -j TORPEDO-AB)",
+		"(sudo ip6tables -w -C OUTPUT -j TORPEDO-AB 2>/dev/null || sudo ip6tables -w -I OUTPUT -j TORPEDO-AB)",
+		"command -v iptables",
+		"(sudo iptables -w -N TORPEDO-AB 2>/dev/null || sudo iptables -w -F TORPEDO-AB)",
+		"(sudo iptables -w -C INPUT -j TORPEDO-AB 2>/dev/null || sudo iptables -w -I INPUT -j TORPEDO-AB)",
+		"(sudo iptables -w -C OUTPUT -j TORPEDO-AB 2>/dev/null || sudo iptables -w -I OUTPUT -j TORPEDO-AB)",
+		"sudo iptables -w -A TORPEDO-AB -d 10.0.0.2 -j DROP",
+		"sudo iptables -w -A TORPEDO-AB -s 10.0.0.2 -j DROP",
+		"sudo ip6tables -w -A TORPEDO-AB -d fd00::2 -j DROP",
+		"sudo ip6tables -w -A TORPEDO-AB -s fd00::2 -j DROP",
+	}, " && "), cmd)
+
+	oneWay := &partitionTarget{node: node.Node{Name: "n1"}, peers: []string{"10.0.0.2"}, inbound: true}
+	cmd = partitionCmd("TORPEDO-AB", oneWay, node.NetworkPartitionOpts{Ports: []int{9001}}, nil)
+	assert.Contains(t, cmd, "sudo iptables -w -A TORPEDO-AB -s 10.0.0.2 -p tcp --dport 9001 -j DROP")
+	assert.Contains(t, cmd, "sudo iptables -w -A TORPEDO-AB -s 10.0.0.2 -p udp --dport 9001 -j DROP")
+	assert.Contains(t, cmd, "sudo iptables -w -A TORPEDO-AB -s 10.0.0.2 -p sctp --dport 9001 -j DROP")
+	assert.NotContains(t, cmd, "-d 10.0.0.2", "an inbound only target does not block outbound traffic")
+
+	shaped := &partitionTarget{node: node.Node{Name: "n1"}, peers: []string{"10.0.0.2", "10.0.0.3", "10.1.0.2"}, outbound: true}
+	opts := node.NetworkPartitionOpts{BandwidthKbit: 512}
+	minor, prio := partitionClass("TORPEDO-AB")
+	cmd = partitionCmd("TORPEDO-AB", shaped, opts, map[string]string{"10.0.0.2": "eth0", "10.0.0.3": "eth0", "10.1.0.2": "eth1"})
+	assert.Equal(t, strings.Join([]string{
+		"set -- $(sudo tc qdisc show dev eth0 root)",
+		`if [ "$2" != htb ]; then { [ -z "$3" ] || [ "$3" = 0: ] || { echo "eth0 has a $2 root qdisc" >&2; false; }; } && ` +
+			"sudo tc qdisc add dev eth0 root handle 7470: htb default 1 && " +
+			"sudo tc class add dev eth0 parent 7470: classid 7470:1 htb rate 100gbit && set -- qdisc htb 7470:; fi",
+		fmt.Sprintf("(sudo tc filter del dev eth0 parent $3 prio %d 2>/dev/null; sudo tc class del dev eth0 classid $3%s 2>/dev/null; true)", prio, minor),
+		fmt.Sprintf("sudo tc class add dev eth0 parent $3 classid $3%s htb rate 512kbit", minor),
+		fmt.Sprintf("sudo tc filter add dev eth0 parent $3 protocol all prio %d u32 match ip dst 10.0.0.2/32 flowid $3%s", prio, minor),
+		fmt.Sprintf("sudo tc filter add dev eth0 parent $3 protocol all prio %d u32 match ip dst 10.0.0.3/32 flowid $3%s", prio, minor),
+		"set -- $(sudo tc qdisc show dev eth1 root)",
+	}, " && ")+" && ", cmd[:strings.Index(cmd, "set -- $(sudo tc qdisc show dev eth1 root)")+len("set -- $(sudo tc qdisc show dev eth1 root) && ")],
+		"the filters of an interface are added right after its root qdisc is looked up")
+	assert.NotContains(t, cmd, "tc qdisc del", "existing root qdiscs are never deleted")
+	assert.True(t, strings.HasSuffix(cmd, fmt.Sprintf("sudo tc filter add dev eth1 parent $3 protocol all prio %d u32 match ip dst 10.1.0.2/32 flowid $3%s", prio, minor)))
+	assert.Equal(t, []string{"eth0", "eth1"}, shaped.interfaces)
+
+	otherMinor, otherPrio := partitionClass("TORPEDO-CD")
+	assert.NotEqual(t, minor, otherMinor, "partitions shaping the same interface use their own class")
+	assert.NotEqual(t, prio, otherPrio, "partitions shaping the same interface use their own filter priority")
+	assert.NotEqual(t, "1", minor, "the class of the partition is not the default class")
+
+	inbound := &partitionTarget{node: node.Node{Name: "n2"}, peers: []string{"10.0.0.1"}, inbound: true}
+	assert.Equal(t, "true", partitionCmd("TORPEDO-AB", inbound, opts, nil), "tc only shapes the egress traffic")
+}
+
+func TestHealPartitionCmd(t *testing.T) {
+	minor, prio := partitionClass("TORPEDO-AB")
+	target := &partitionTarget{peers: []string{"10.0.0.2"}, interfaces: []string{"eth0"}}
+	assert.Equal(t, strings.Join([]string{
+		"sudo iptables -w -D INPUT -j TORPEDO-AB 2>/dev/null",
+		"sudo iptables -w -D OUTPUT -j TORPEDO-AB 2>/dev/null",
+		"sudo iptables -w -F TORPEDO-AB 2>/dev/null",
+		"sudo iptables -w -X TORPEDO-AB 2>/dev/null",
+		"set -- $(sudo tc qdisc show dev eth0 root)",
+		fmt.Sprintf(`[ "$2" != htb ] || { sudo tc filter del dev eth0 parent $3 prio %d 2>/dev/null; `+
+			"sudo tc class del dev eth0 classid $3%s 2>/dev/null; "+
+			`[ "$3" != 7470: ] || [ -n "$(sudo tc class show dev eth0 | grep -v " 7470:1 ")" ] || sudo tc qdisc del dev eth0 root 2>/dev/null; }`, prio, minor),
+		"true",
+	}, "; "), healPartitionCmd("TORPEDO-AB", target))
+	assert.NotContains(t, healPartitionCmd("TORPEDO-AB", target), "'", "the heal command runs in single quotes on self heal")
+
+	target = &partitionTarget{peers: []string{"fd00::2"}}
+	cmd := healPartitionCmd("TORPEDO-AB", target)
+	assert.True(t, strings.HasPrefix(cmd, "sudo ip6tables -w -D INPUT -j TORPEDO-AB"))
+	assert.True(t, strings.HasSuffix(cmd, "; true"), "healing succeeds when the rules are already removed")
+}
@@ -0,0 +1,160 @@
+package ssh
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+	"github.com/portworx/torpedo/pkg/revertutils"
+)
+
+const (
+	// pressureTagPrefix prefixes the tag every process of a pressure carries in its command line
+	pressureTagPrefix = "torpedo-pressure-"
+	// runScriptCmd runs a script as root without nesting its quotes in the command
+	runScriptCmd = "echo %s | base64 -d | sudo sh"
+	// pressureBackgroundCmd runs the given command detached from the session so it outlives the exec or ssh call
+	pressureBackgroundCmd = "nohup setsid %s >/dev/null 2>&1 &\n"
+
+	defaultPressurePath        = "/var/lib/osd"
+	defaultPressureMemoryPct   = 90
+	defaultPressureFillPercent = 95
+)
+
+// ApplyResourcePressure runs a bounded resource pressure on the given node
+func (s *SSH) ApplyResourcePressure(n node.Node, opts node.ResourcePressureOpts) (*node.ResourcePressure, error) {
+	if opts.Duration <= 0 {
+		return nil, &node.ErrFailedToApplyResourcePressure{Node: n, Type: opts.Type, Cause: "a pressure duration is required"}
+	}
+	id := strings.Split(uuid.New().String(), "-")[0]
+	tag := pressureTagPrefix + id
+	applyScript, revertScript, err := pressureScripts(tag, opts)
+	if err != nil {
+		return nil, &node.ErrFailedToApplyResourcePressure{Node: n, Type: opts.Type, Cause: err.Error()}
+	}
+
+	pressure := &node.ResourcePressure{ID: id, Node: n, Type: opts.Type}
+	var (
+		lock     sync.Mutex
+		reverted bool
+		timer    *time.Timer
+	)
+	pressure.Revert = func() error {
+		lock.Lock()
+		defer lock.Unlock()
+		if reverted {
+			return nil
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if _, err := s.doCmd(n, opts.ConnectionOpts, scriptCmd(revertScript), false); err != nil {
+			return &node.ErrFailedToApplyResourcePressure{
+				Node:  n,
+				Type:  opts.Type,
+				Cause: fmt.Sprintf("failed to revert pressure [%s]: %v", id, err),
+			}
+		}
+		reverted = true
+		revertutils.Resolve(pressureTagPrefix + id)
+		log.Infof("Reverted %s pressure [%s] on node [%s]", opts.Type, id, n.Name)
+		return nil
+	}
+
+	log.Infof("Applying %s pressure [%s] on node [%s] for %v", opts.Type, id, n.Name, opts.Duration)
+	if _, err := s.doCmd(n, opts.ConnectionOpts, scriptCmd(applyScript), false); err != nil {
+		if revertErr := pressure.Revert(); revertErr != nil {
+			log.Errorf("Failed to revert partially applied pressure [%s]: %v", id, revertErr)
+		}
+		return nil, &node.ErrFailedToApplyResourcePressure{Node: n, Type: opts.Type, Cause: err.Error()}
+	}
+
+	revertutils.Register(pressureTagPrefix+id, fmt.Sprintf("%s pressure [%s] on node [%s]", opts.Type, id, n.Name), pressure.Revert)
+
+	// the node reverts the pressure by itself, reverting it here as well only resolves it in the revert registry
+	lock.Lock()
+	timer = time.AfterFunc(opts.Duration, func() {
+		if err := pressure.Revert(); err != nil {
+			log.Errorf("Failed to revert %s pressure [%s] on node [%s]: %v", opts.Type, id, n.Name, err)
+		}
+	})
+	lock.Unlock()
+	return pressure, nil
+}
+
+// RevertResourcePressures reverts all the resource pressures that are still applied
+func (s *SSH) RevertResourcePressures() error {
+	return revertFailuresError("revert resource pressures", revertutils.ReplayPrefix(pressureTagPrefix))
+}
+
+// pressureScripts returns the scripts applying and reverting the given pressure. Every process the
+// apply script starts carries the tag in its command line and stops by itself once the duration
+// expires. The revert script kills the tagged processes and only undoes the side effects the
+// node did not undo by itself yet.
+func pressureScripts(tag string, opts node.ResourcePressureOpts) (string, string, error) {
+	seconds := int(opts.Duration.Seconds())
+	// the brackets keep the pattern from matching the command line of the shell running pkill
+	killTagged := fmt.Sprintf("pkill -f '[%s]%s'", tag[:1], tag[1:])
+
+	switch opts.Type {
+	case node.PressureCPU:
+		cores := "$(nproc)"
+		if opts.CPUCores > 0 {
+			cores = fmt.Sprintf("%d", opts.CPUCores)
+		}
+		apply := fmt.Sprintf("for i in $(seq %s); do\n", cores) +
+			fmt.Sprintf(pressureBackgroundCmd, fmt.Sprintf("timeout %d sh -c 'while :; do :; done' %s", seconds, tag)) +
+			"done\n"
+		return apply, killTagged + "\ntrue\n", nil
+
+	case node.PressureMemory:
+		percent := opts.MemoryPercent
+		if percent <= 0 {
+			percent = defaultPressureMemoryPct
+		}
+		// tail buffers its whole input until EOF, the sleep holds the pipe open so the memory stays allocated
+		apply := fmt.Sprintf("kb=$(( $(awk '/MemTotal/ {print $2}' /proc/meminfo) * %d / 100 ))\n", percent) +
+			fmt.Sprintf(pressureBackgroundCmd, fmt.Sprintf("timeout %d sh -c \"(head -c ${kb}K /dev/zero; sleep %d) | tail\" %s", seconds, seconds, tag))
+		return apply, killTagged + "\ntrue\n", nil
+
+	case node.PressureDiskFill:
+		dir := opts.Path
+		if dir == "" {
+			dir = defaultPressurePath
+		}
+		percent := opts.FillPercent
+		if percent <= 0 {
+			percent = defaultPressureFillPercent
+		}
+		file := fmt.Sprintf("%s/.%s", strings.TrimRight(dir, "/"), tag)
+		apply := fmt.Sprintf("kb=$(df -Pk %s | awk 'NR==2 {print int($2 * %d / 100 - $3)}')\n", dir, percent) +
+			"[ \"$kb\" -gt 0 ] || exit 0\n" +
+			fmt.Sprintf("fallocate -l ${kb}K %s || dd if=/dev/zero of=%s bs=1K count=$kb\n", file, file) +
+			fmt.Sprintf(pressureBackgroundCmd, fmt.Sprintf("sh -c 'sleep %d; rm -f %s' %s", seconds, file, tag))
+		return apply, fmt.Sprintf("%s\nrm -f %s\n", killTagged, file), nil
+
+	case node.PressureClockSkew:
+		skew := int(opts.ClockSkew.Seconds())
+		if skew == 0 {
+			return "", "", fmt.Errorf("a clock skew is required")
+		}
+		shift := "date -s @$(( $(date +%%s) + (%d) )) >/dev/null"
+		restore := "timedatectl set-ntp true 2>/dev/null"
+		apply := "timedatectl set-ntp false 2>/dev/null\n" +
+			fmt.Sprintf(shift, skew) + "\n" +
+			fmt.Sprintf(pressureBackgroundCmd, fmt.Sprintf("sh -c 'sleep %d; %s; %s' %s", seconds, fmt.Sprintf(shift, -skew), restore, tag))
+		// the clock is only shifted back while the node did not do it by itself
+		revert := fmt.Sprintf("if %s; then\n%s\n%s\nfi\ntrue\n", killTagged, fmt.Sprintf(shift, -skew), restore)
+		return apply, revert, nil
+	}
+	return "", "", fmt.Errorf("unsupported resource pressure type [%s]", opts.Type)
+}
+
+func scriptCmd(script string) string {
+	return fmt.Sprintf(runScriptCmd, base64.StdEncoding.EncodeToString([]byte(script)))
+}
@@ -12,6 +12,7 @@ import (
 	volumedriver "github.com/portworx/torpedo/drivers/volume"
 	"github.com/portworx/torpedo/drivers/volume/portworx/schedops"
 	"github.com/portworx/torpedo/pkg/log"
+	"github.com/portworx/torpedo/pkg/revertutils"
 	ssh_pkg "golang.org/x/crypto/ssh"
 	"io/ioutil"
 	appsv1_api "k8s.io/api/apps/v1"
@@ -22,6 +23,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -39,6 +41,10 @@ const (
 const (
 	defaultTimeout        = 5 * time.Minute
 	execPodDaemonSetLabel = "debug"
+	// defaultConcurrency is the number of nodes a command runs on at the same time
+	defaultConcurrency = 10
+	// keepAliveRequest checks a pooled ssh connection is still usable
+	keepAliveRequest = "keepalive@openssh.com"
 )
 
 // SSH ssh node driver
@@ -58,6 +64,12 @@ var (
 	k8sCore = core.Instance()
 )
 
+// connectionPool keeps one ssh connection per node so the commands run on a node share it
+var connectionPool = struct {
+	sync.Mutex
+	clients map[string]*ssh_pkg.Client
+}{clients: make(map[string]*ssh_pkg.Client)}
+
 func (s *SSH) String() string {
 	return DriverName
 }
@@ -356,6 +368,15 @@ func (s *SSH) InjectNetworkError(nodes []node.Node, errorInjectionType string, o
 				Cause: err.Error(),
 			}
 		}
+		key := "netem/" + n.Name
+		if operationType == "delete" {
+			revertutils.Resolve(key)
+			continue
+		}
+		errNode := n
+		revertutils.Register(key, fmt.Sprintf("network %s on node [%s]", errorInjectionType, n.Name), func() error {
+			return s.InjectNetworkError([]node.Node{errNode}, errorInjectionType, "delete", dropPercentage, delayInMilliseconds)
+		})
 	}
 	return nil
 }
@@ -429,6 +450,10 @@ func (s *SSH) YankDrive(n node.Node, driveNameToFail string, options node.Connec
 			Cause: fmt.Sprintf("failed to yank drive %v due to: %v", driveNameToFail, err),
 		}
 	}
+	drive := "/" + driveNameToFail
+	revertutils.Register(yankRevertKey(n, drive), fmt.Sprintf("yanked drive [%s] on node [%s]", drive, n.Name), func() error {
+		return s.RecoverDrive(n, drive, bus, options)
+	})
 	return bus, nil
 }
 
@@ -442,9 +467,14 @@ func (s *SSH) RecoverDrive(n node.Node, driveNameToRecover string, driveUUIDToRe
 			Cause: fmt.Sprintf("Unable to rescan the drive (%v): %v", driveNameToRecover, err),
 		}
 	}
+	revertutils.Resolve(yankRevertKey(n, "/"+strings.Trim(driveNameToRecover, "/")))
 	return nil
 }
 
+func yankRevertKey(n node.Node, drive string) string {
+	return fmt.Sprintf("yank/%s%s", n.Name, drive)
+}
+
 // RunCommand runs given command on given node
 func (s *SSH) RunCommand(n node.Node, command string, options node.ConnectionOpts) (string, error) {
 	t := func() (interface{}, bool, error) {
@@ -466,6 +496,39 @@ func (s *SSH) RunCommand(n node.Node, command string, options node.ConnectionOpt
 	return output.(string), nil
 }
 
+// RunCommandOnNodes runs given command on given nodes in parallel
+func (s *SSH) RunCommandOnNodes(nodes []node.Node, command string, options node.RunCommandOpts) ([]node.CommandResult, error) {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	results := make([]node.CommandResult, len(nodes))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, n node.Node) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			output, err := s.RunCommand(n, command, options.ConnectionOpts)
+			results[i] = node.CommandResult{Node: n, Output: output, Err: err}
+		}(i, n)
+	}
+	wg.Wait()
+
+	var failed []string
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result.Node.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return results, fmt.Errorf("command [%s] failed on nodes %v", command, failed)
+	}
+	return results, nil
+}
+
 // RunCommandWithNoRetry runs given command on given node but with no retries
 func (s *SSH) RunCommandWithNoRetry(n node.Node, command string, options node.ConnectionOpts) (string, error) {
 	if s.IsUsingSSH() {
@@ -688,8 +751,37 @@ func (s *SSH) getConnection(n node.Node, options node.ConnectionOpts) (*ssh_pkg.
 		return nil, fmt.Errorf("no address available to connect")
 	}
 
-	addr, err := s.getConnectionOnUsableAddr(n, options)
-	return addr, err
+	connectionPool.Lock()
+	client, ok := connectionPool.clients[n.Name]
+	connectionPool.Unlock()
+	if ok {
+		if _, _, err := client.SendRequest(keepAliveRequest, true, nil); err == nil {
+			return client, nil
+		}
+		log.Debugf("Pooled ssh connection to node %s is broken, reconnecting", n.Name)
+		connectionPool.Lock()
+		// only the caller dropping the broken connection from the pool closes it
+		if connectionPool.clients[n.Name] == client {
+			delete(connectionPool.clients, n.Name)
+			client.Close()
+		}
+		connectionPool.Unlock()
+	}
+
+	client, err := s.getConnectionOnUsableAddr(n, options)
+	if err != nil {
+		return nil, err
+	}
+	connectionPool.Lock()
+	defer connectionPool.Unlock()
+	if pooled, ok := connectionPool.clients[n.Name]; ok {
+		// another caller reconnected meanwhile and may already use its connection, so keep it and close
+		// the new one nobody else has seen
+		client.Close()
+		return pooled, nil
+	}
+	connectionPool.clients[n.Name] = client
+	return client, nil
 }
 
 func (s *SSH) getConnectionOnUsableAddr(n node.Node, options node.ConnectionOpts) (*ssh_pkg.Client, error) {
@@ -0,0 +1,296 @@
+package ssh
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// untarCmd extracts the tar stream of stdin into the given directory of the node
+	untarCmd = "mkdir -p %s && tar -xf - -C %s"
+	// tarCmd writes the given file or directory of the node as a tar stream to stdout
+	tarCmd = "tar -cf - -C %s %s"
+)
+
+// CopyToNode copies the given local file or directory to the given path on the node
+func (s *SSH) CopyToNode(n node.Node, localPath, remotePath string, options node.ConnectionOpts) error {
+	log.Debugf("Copying [%s] to [%s] on node %s", localPath, remotePath, n.Name)
+	var err error
+	if s.IsUsingSSH() {
+		err = s.copyToNodeSFTP(n, localPath, remotePath, options)
+	} else {
+		err = s.copyToNodeUsingPod(n, localPath, remotePath)
+	}
+	if err != nil {
+		return &node.ErrFailedToCopyFile{
+			Node:  n,
+			Path:  remotePath,
+			Cause: err.Error(),
+		}
+	}
+	return nil
+}
+
+// CopyFromNode copies the given file or directory on the node to the given local path
+func (s *SSH) CopyFromNode(n node.Node, remotePath, localPath string, options node.ConnectionOpts) error {
+	log.Debugf("Copying [%s] on node %s to [%s]", remotePath, n.Name, localPath)
+	var err error
+	if s.IsUsingSSH() {
+		err = s.copyFromNodeSFTP(n, remotePath, localPath, options)
+	} else {
+		err = s.copyFromNodeUsingPod(n, remotePath, localPath)
+	}
+	if err != nil {
+		return &node.ErrFailedToCopyFile{
+			Node:  n,
+			Path:  remotePath,
+			Cause: err.Error(),
+		}
+	}
+	return nil
+}
+
+func (s *SSH) copyToNodeSFTP(n node.Node, localPath, remotePath string, options node.ConnectionOpts) error {
+	connection, err := s.getConnection(n, options)
+	if err != nil {
+		return err
+	}
+	client, err := sftp.NewClient(connection)
+	if err != nil {
+		return fmt.Errorf("failed to start sftp session: %v", err)
+	}
+	defer client.Close()
+
+	return filepath.Walk(localPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		dst := path.Join(remotePath, filepath.ToSlash(rel))
+		if info.IsDir() {
+			return client.MkdirAll(dst)
+		}
+		if err := client.MkdirAll(path.Dir(dst)); err != nil {
+			return err
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		f, err := client.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %v", dst, err)
+		}
+		defer f.Close()
+		if _, err := io.Copy(f, src); err != nil {
+			return fmt.Errorf("failed to write %s: %v", dst, err)
+		}
+		return client.Chmod(dst, info.Mode().Perm())
+	})
+}
+
+func (s *SSH) copyFromNodeSFTP(n node.Node, remotePath, localPath string, options node.ConnectionOpts) error {
+	connection, err := s.getConnection(n, options)
+	if err != nil {
+		return err
+	}
+	client, err := sftp.NewClient(connection)
+	if err != nil {
+		return fmt.Errorf("failed to start sftp session: %v", err)
+	}
+	defer client.Close()
+
+	walker := client.Walk(remotePath)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), remotePath), "/")
+		dst := filepath.Join(localPath, filepath.FromSlash(rel))
+		info := walker.Stat()
+		if info.IsDir() {
+			if err := os.MkdirAll(dst, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := copyRemoteFile(client, walker.Path(), dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyRemoteFile(client *sftp.Client, src, dst string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	f, err := client.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", src, err)
+	}
+	defer f.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := f.WriteTo(out); err != nil {
+		return fmt.Errorf("failed to read %s: %v", src, err)
+	}
+	return nil
+}
+
+// copyToNodeUsingPod streams a tar archive of the local path into the debug pod of the node,
+// the way kubectl cp does
+func (s *SSH) copyToNodeUsingPod(n node.Node, localPath, remotePath string) error {
+	pod, err := s.getDebugPod(n)
+	if err != nil {
+		return err
+	}
+	var archive bytes.Buffer
+	if err := writeTar(&archive, localPath, path.Base(remotePath)); err != nil {
+		return err
+	}
+	dir := path.Dir(remotePath)
+	return s.execInDebugPod(pod, fmt.Sprintf(untarCmd, dir, dir), &archive, io.Discard)
+}
+
+// copyFromNodeUsingPod streams a tar archive of the remote path out of the debug pod of the node
+func (s *SSH) copyFromNodeUsingPod(n node.Node, remotePath, localPath string) error {
+	pod, err := s.getDebugPod(n)
+	if err != nil {
+		return err
+	}
+	var archive bytes.Buffer
+	cmd := fmt.Sprintf(tarCmd, path.Dir(remotePath), path.Base(remotePath))
+	if err := s.execInDebugPod(pod, cmd, nil, &archive); err != nil {
+		return err
+	}
+	return readTar(&archive, path.Base(remotePath), localPath)
+}
+
+func (s *SSH) getDebugPod(n node.Node) (*v1.Pod, error) {
+	pods, err := k8sCore.GetPodsByNode(n.Name, s.execPodNamespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods.Items {
+		if pod.Labels["name"] == execPodDaemonSetLabel && k8sCore.IsPodReady(pod) {
+			return &pod, nil
+		}
+	}
+	return nil, fmt.Errorf("debug pod not found in node %v", n.Name)
+}
+
+func (s *SSH) execInDebugPod(pod *v1.Pod, cmd string, stdin io.Reader, stdout io.Writer) error {
+	var stderr bytes.Buffer
+	err := k8sCore.RunCommandInPodEx(&core.RunCommandInPodExRequest{
+		Command:   []string{"nsenter", "--mount=/hostproc/1/ns/mnt", "/bin/bash", "-c", cmd},
+		PODName:   pod.Name,
+		Namespace: pod.Namespace,
+		Stdin:     stdin,
+		Stdout:    stdout,
+		Stderr:    &stderr,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to run [%s] in pod %s: %v %s", cmd, pod.Name, err, stderr.String())
+	}
+	return nil
+}
+
+// writeTar writes the given local file or directory to a tar archive, named name in the archive
+func writeTar(w io.Writer, localPath, name string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(localPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(name, filepath.ToSlash(rel))
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// readTar extracts the entries of a tar archive under name into the given local path. Entries
+// outside of name are rejected, like kubectl cp does, so a node cannot write anywhere locally.
+func readTar(r io.Reader, name, localPath string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(path.Clean(header.Name), name)
+		if path.Clean(header.Name) != name && !strings.HasPrefix(rel, "/") {
+			return fmt.Errorf("unexpected entry %s in archive of %s", header.Name, name)
+		}
+		dst := filepath.Join(localPath, filepath.FromSlash(rel))
+		if dst != filepath.Clean(localPath) && !strings.HasPrefix(dst, filepath.Clean(localPath)+string(os.PathSeparator)) {
+			return fmt.Errorf("entry %s is outside of %s", header.Name, localPath)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dst, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			log.Warnf("Skipping %s of unsupported type %c", header.Name, header.Typeflag)
+		}
+	}
+}
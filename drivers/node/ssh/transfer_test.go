@@ -0,0 +1,46 @@
+package ssh
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "logs", "px"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "logs", "px", "px.log"), []byte("px is operational"), 0640))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "config"), []byte("kvdb"), 0600))
+
+	var archive bytes.Buffer
+	require.NoError(t, writeTar(&archive, src, "diags"))
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	require.NoError(t, readTar(&archive, "diags", dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "logs", "px", "px.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "px is operational", string(content))
+	info, err := os.Stat(filepath.Join(dst, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestReadTarRejectsEntriesOutsideOfPath(t *testing.T) {
+	for _, name := range []string{"diags/../../etc/passwd", "other/file", "diagsx/file"} {
+		var archive bytes.Buffer
+		tw := tar.NewWriter(&archive)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: 1}))
+		_, err := tw.Write([]byte("x"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+
+		err = readTar(&archive, "diags", t.TempDir())
+		assert.Error(t, err, "entry %s has to be rejected", name)
+	}
+}
@@ -0,0 +1,104 @@
+package ssh
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	bootIDCmd        = "cat /proc/sys/kernel/random/boot_id"
+	kernelVersionCmd = "uname -r"
+
+	defaultOSUpgradeRebootTimeout = 15 * time.Minute
+	bootIDRetryInterval           = 10 * time.Second
+)
+
+// packageName matches the package names, versions and globs package managers accept, so they need no quoting
+var packageName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+:~=*-]*$`)
+
+// UpgradeNodeOS installs the given packages on the node, reboots the node into them and returns its running kernel
+func (s *SSH) UpgradeNodeOS(n node.Node, opts node.OSUpgradeOpts) (string, error) {
+	script, err := osUpgradeScript(opts.Packages)
+	if err != nil {
+		return "", &node.ErrFailedToUpgradeNodeOS{Node: n, Cause: err.Error()}
+	}
+	bootID, err := s.doCmd(n, opts.ConnectionOpts, bootIDCmd, false)
+	if err != nil {
+		return "", &node.ErrFailedToUpgradeNodeOS{Node: n, Cause: err.Error()}
+	}
+	bootID = strings.TrimSpace(bootID)
+	kernel, err := s.doCmd(n, opts.ConnectionOpts, kernelVersionCmd, false)
+	if err != nil {
+		return "", &node.ErrFailedToUpgradeNodeOS{Node: n, Cause: err.Error()}
+	}
+
+	log.Infof("Upgrading OS of node [%s] running kernel %s with packages %v", n.Name, strings.TrimSpace(kernel), opts.Packages)
+	if out, err := s.doCmd(n, opts.ConnectionOpts, scriptCmd(script), false); err != nil {
+		return "", &node.ErrFailedToUpgradeNodeOS{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to install packages: %v %s", err, out),
+		}
+	}
+
+	if err := s.RebootNode(n, node.RebootNodeOpts{ConnectionOpts: opts.ConnectionOpts}); err != nil {
+		return "", &node.ErrFailedToUpgradeNodeOS{Node: n, Cause: err.Error()}
+	}
+
+	// the node answers with the same boot ID until it actually went down
+	timeout := opts.RebootTimeout
+	if timeout == 0 {
+		timeout = defaultOSUpgradeRebootTimeout
+	}
+	t := func() (interface{}, bool, error) {
+		current, err := s.doCmd(n, opts.ConnectionOpts, bootIDCmd, false)
+		if err != nil {
+			return nil, true, err
+		}
+		if strings.TrimSpace(current) == bootID {
+			return nil, true, fmt.Errorf("node [%s] did not reboot yet", n.Name)
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, timeout, bootIDRetryInterval); err != nil {
+		return "", &node.ErrFailedToUpgradeNodeOS{
+			Node:  n,
+			Cause: fmt.Sprintf("node did not come back after reboot: %v", err),
+		}
+	}
+
+	kernel, err = s.doCmd(n, opts.ConnectionOpts, kernelVersionCmd, false)
+	if err != nil {
+		return "", &node.ErrFailedToUpgradeNodeOS{Node: n, Cause: err.Error()}
+	}
+	kernel = strings.TrimSpace(kernel)
+	log.Infof("Node [%s] is running kernel %s after OS upgrade", n.Name, kernel)
+	return kernel, nil
+}
+
+// osUpgradeScript returns the script installing the given packages with the package manager the node has
+func osUpgradeScript(packages []string) (string, error) {
+	if len(packages) == 0 {
+		return "", fmt.Errorf("no packages to install given")
+	}
+	for _, p := range packages {
+		if !packageName.MatchString(p) {
+			return "", fmt.Errorf("invalid package name [%s]", p)
+		}
+	}
+	pkgs := strings.Join(packages, " ")
+	return "set -e\n" +
+		"if command -v apt-get >/dev/null 2>&1; then\n" +
+		"export DEBIAN_FRONTEND=noninteractive\n" +
+		"apt-get -q update\n" +
+		"apt-get -y -q install " + pkgs + " -o Dpkg::Options::=--force-confdef -o Dpkg::Options::=--force-confold\n" +
+		"elif command -v dnf >/dev/null 2>&1; then\ndnf -y install " + pkgs + "\n" +
+		"elif command -v yum >/dev/null 2>&1; then\nyum -y install " + pkgs + "\n" +
+		"elif command -v zypper >/dev/null 2>&1; then\nzypper --non-interactive install " + pkgs + "\n" +
+		"else\necho 'no supported package manager found' >&2\nexit 1\nfi\n", nil
+}
@@ -0,0 +1,26 @@
+package ssh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOSUpgradeScript(t *testing.T) {
+	script, err := osUpgradeScript([]string{"linux-image-5.15.0-76-generic", "kernel-5.14.0-284.11.1.el9_2"})
+	require.NoError(t, err)
+	assert.Contains(t, script, "apt-get -y -q install linux-image-5.15.0-76-generic kernel-5.14.0-284.11.1.el9_2 -o")
+	assert.Contains(t, script, "yum -y install linux-image-5.15.0-76-generic kernel-5.14.0-284.11.1.el9_2\n")
+	assert.Contains(t, script, "zypper --non-interactive install ")
+
+	assert.Contains(t, script, "dnf -y install linux-image-5.15.0-76-generic kernel-5.14.0-284.11.1.el9_2\n")
+
+	_, err = osUpgradeScript(nil)
+	assert.Error(t, err, "upgrading all the installed packages is not supported")
+
+	for _, p := range []string{"kernel; reboot", "$(id)", "-y", ""} {
+		_, err := osUpgradeScript([]string{p})
+		assert.Error(t, err, "package %q has to be rejected", p)
+	}
+}
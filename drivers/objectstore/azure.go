@@ -0,0 +1,90 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/azureblob"
+)
+
+const (
+	// AzureDriverName is the name of the objectstore driver for Azure blob storage
+	AzureDriverName = "azure"
+
+	// azureEncryption is the algorithm Azure storage service encryption uses
+	azureEncryption = "AES256"
+)
+
+// azureProvider talks to the blob service of the storage account the tests use to create backup locations
+type azureProvider struct {
+	accountName azureblob.AccountName
+	credential  *azblob.SharedKeyCredential
+	service     azblob.ServiceURL
+}
+
+func newAzureProvider() (provider, error) {
+	accountName := getenv("AZURE_ACCOUNT_NAME")
+	accountKey := getenv("AZURE_ACCOUNT_KEY")
+	if accountName == "" || accountKey == "" {
+		return nil, fmt.Errorf("Azure credentials not provided as env vars AZURE_ACCOUNT_NAME and AZURE_ACCOUNT_KEY")
+	}
+	credential, err := azureblob.NewCredential(azureblob.AccountName(accountName), azureblob.AccountKey(accountKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shared key credential: %v", err)
+	}
+	u, err := url.Parse(fmt.Sprintf("https://%s.blob.core.windows.net/", accountName))
+	if err != nil {
+		return nil, err
+	}
+	return &azureProvider{
+		accountName: azureblob.AccountName(accountName),
+		credential:  credential,
+		service:     azblob.NewServiceURL(*u, azureblob.NewPipeline(credential, azblob.PipelineOptions{})),
+	}, nil
+}
+
+func (p *azureProvider) openBucket(ctx context.Context, bucketName string) (*blob.Bucket, error) {
+	pipeline := azureblob.NewPipeline(p.credential, azblob.PipelineOptions{})
+	return azureblob.OpenBucket(ctx, pipeline, p.accountName, bucketName, &azureblob.Options{Credential: p.credential})
+}
+
+func (p *azureProvider) listBuckets(ctx context.Context) ([]string, error) {
+	var buckets []string
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := p.service.ListContainersSegment(ctx, marker, azblob.ListContainersSegmentOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, container := range resp.ContainerItems {
+			buckets = append(buckets, container.Name)
+		}
+		marker = resp.NextMarker
+	}
+	return buckets, nil
+}
+
+func (p *azureProvider) createBucket(ctx context.Context, bucketName string, opts BucketOpts) error {
+	if opts.ObjectLock {
+		return fmt.Errorf("object lock is not supported by the %s driver", AzureDriverName)
+	}
+	_, err := p.service.NewContainerURL(bucketName).Create(ctx, azblob.Metadata{}, azblob.PublicAccessNone)
+	return err
+}
+
+func (p *azureProvider) deleteBucket(ctx context.Context, bucketName string) error {
+	_, err := p.service.NewContainerURL(bucketName).Delete(ctx, azblob.ContainerAccessConditions{})
+	return err
+}
+
+func (p *azureProvider) objectMetadata(attrs *blob.Attributes, md *ObjectMetadata) {
+	var props azblob.BlobGetPropertiesResponse
+	if !attrs.As(&props) {
+		return
+	}
+	if props.IsServerEncrypted() == "true" {
+		md.Encryption = azureEncryption
+	}
+}
@@ -0,0 +1,188 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"gocloud.dev/blob"
+)
+
+// provider is the provider specific part of a blob driver, everything else goes through gocloud blob
+type provider interface {
+	// openBucket opens the given bucket
+	openBucket(ctx context.Context, bucketName string) (*blob.Bucket, error)
+	// listBuckets returns the names of all the buckets
+	listBuckets(ctx context.Context) ([]string, error)
+	// createBucket creates the given bucket
+	createBucket(ctx context.Context, bucketName string, opts BucketOpts) error
+	// deleteBucket deletes the given empty bucket
+	deleteBucket(ctx context.Context, bucketName string) error
+	// objectMetadata sets the encryption and retention of the object from its provider specific attributes
+	objectMetadata(attrs *blob.Attributes, md *ObjectMetadata)
+}
+
+// blobDriver is an objectstore driver backed by a gocloud blob provider. The provider is created on
+// first use, so the credentials only need to be in the environment of the tests using the driver.
+type blobDriver struct {
+	DefaultDriver
+	name        string
+	newProvider func() (provider, error)
+
+	once     sync.Once
+	provider provider
+	err      error
+}
+
+func newBlobDriver(name string, newProvider func() (provider, error)) *blobDriver {
+	return &blobDriver{name: name, newProvider: newProvider}
+}
+
+func (b *blobDriver) String() string {
+	return b.name
+}
+
+func (b *blobDriver) getProvider() (provider, error) {
+	b.once.Do(func() {
+		b.provider, b.err = b.newProvider()
+		if b.err != nil {
+			b.err = fmt.Errorf("failed to initialize %s objectstore driver: %v", b.name, b.err)
+		}
+	})
+	return b.provider, b.err
+}
+
+func (b *blobDriver) openBucket(ctx context.Context, bucketName string) (*blob.Bucket, error) {
+	p, err := b.getProvider()
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := p.openBucket(ctx, bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bucket %s: %v", bucketName, err)
+	}
+	return bucket, nil
+}
+
+// ListBuckets lists the names of the buckets of the objectstore
+func (b *blobDriver) ListBuckets() ([]string, error) {
+	p, err := b.getProvider()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	buckets, err := p.listBuckets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %v", err)
+	}
+	sort.Strings(buckets)
+	return buckets, nil
+}
+
+// ListFilesInBucket lists the keys of all the objects stored in the given bucket
+func (b *blobDriver) ListFilesInBucket(bucketName string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	bucket, err := b.openBucket(ctx, bucketName)
+	if err != nil {
+		return nil, err
+	}
+	defer bucket.Close()
+	return listKeys(ctx, bucket)
+}
+
+// CheckConnection checks that the objectstore is reachable with the configured credentials
+func (b *blobDriver) CheckConnection() error {
+	p, err := b.getProvider()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if _, err := p.listBuckets(ctx); err != nil {
+		return fmt.Errorf("failed to connect to %s objectstore: %v", b.name, err)
+	}
+	return nil
+}
+
+// CreateBucket creates the given bucket
+func (b *blobDriver) CreateBucket(bucketName string, opts BucketOpts) error {
+	p, err := b.getProvider()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if err := p.createBucket(ctx, bucketName, opts); err != nil {
+		return fmt.Errorf("failed to create bucket %s: %v", bucketName, err)
+	}
+	return nil
+}
+
+// DeleteBucket deletes all the objects of the given bucket and then the bucket itself
+func (b *blobDriver) DeleteBucket(bucketName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	bucket, err := b.openBucket(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+	defer bucket.Close()
+	keys, err := listKeys(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := bucket.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete object %s from bucket %s: %v", key, bucketName, err)
+		}
+	}
+	if err := b.provider.deleteBucket(ctx, bucketName); err != nil {
+		return fmt.Errorf("failed to delete bucket %s: %v", bucketName, err)
+	}
+	return nil
+}
+
+// GetObjectMetadata returns the metadata of the given object
+func (b *blobDriver) GetObjectMetadata(bucketName, key string) (*ObjectMetadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	bucket, err := b.openBucket(ctx, bucketName)
+	if err != nil {
+		return nil, err
+	}
+	defer bucket.Close()
+	attrs, err := bucket.Attributes(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attributes of object %s in bucket %s: %v", key, bucketName, err)
+	}
+	md := &ObjectMetadata{
+		Key:         key,
+		Size:        attrs.Size,
+		ModTime:     attrs.ModTime,
+		ContentType: attrs.ContentType,
+	}
+	b.provider.objectMetadata(attrs, md)
+	return md, nil
+}
+
+// listKeys returns the keys of all the objects of the bucket, ordered by key
+func listKeys(ctx context.Context, bucket *blob.Bucket) ([]string, error) {
+	iterator := bucket.List(nil)
+	var keys []string
+	for {
+		object, err := iterator.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate bucket: %v", err)
+		}
+		keys = append(keys, object.Key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
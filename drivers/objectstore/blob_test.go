@@ -0,0 +1,85 @@
+package objectstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFileDriver(t *testing.T) *blobDriver {
+	root := t.TempDir()
+	return newBlobDriver(FileDriverName, func() (provider, error) {
+		return newFileProviderAt(root)
+	})
+}
+
+func TestFileDriverBucketLifecycle(t *testing.T) {
+	d := newTestFileDriver(t)
+	require.NoError(t, d.CheckConnection())
+
+	buckets, err := d.ListBuckets()
+	require.NoError(t, err)
+	assert.Empty(t, buckets)
+
+	require.NoError(t, d.CreateBucket("backups", BucketOpts{}))
+	require.NoError(t, d.CreateBucket("cloudsnaps", BucketOpts{}))
+	assert.Error(t, d.CreateBucket("backups", BucketOpts{}), "bucket backups already exists")
+	assert.Error(t, d.CreateBucket("locked", BucketOpts{ObjectLock: true, RetentionDays: 1}))
+	assert.Error(t, d.CreateBucket("../escape", BucketOpts{}))
+
+	buckets, err = d.ListBuckets()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backups", "cloudsnaps"}, buckets)
+
+	ctx := context.Background()
+	bucket, err := d.openBucket(ctx, "backups")
+	require.NoError(t, err)
+	require.NoError(t, bucket.WriteAll(ctx, "ns1/backup1/resources.json", []byte(`{"kind":"List"}`), nil))
+	require.NoError(t, bucket.WriteAll(ctx, "ns1/backup1/volumes.json", []byte(`[]`), nil))
+	require.NoError(t, bucket.WriteAll(ctx, "metadata", []byte("v1"), nil))
+	require.NoError(t, bucket.Close())
+
+	files, err := d.ListFilesInBucket("backups")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metadata", "ns1/backup1/resources.json", "ns1/backup1/volumes.json"}, files)
+
+	md, err := d.GetObjectMetadata("backups", "ns1/backup1/resources.json")
+	require.NoError(t, err)
+	assert.Equal(t, "ns1/backup1/resources.json", md.Key)
+	assert.Equal(t, int64(15), md.Size)
+	assert.False(t, md.ModTime.IsZero())
+	assert.Empty(t, md.Encryption)
+	assert.True(t, md.RetainUntil.IsZero())
+
+	_, err = d.GetObjectMetadata("backups", "missing")
+	assert.Error(t, err)
+
+	require.NoError(t, d.DeleteBucket("backups"))
+	buckets, err = d.ListBuckets()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cloudsnaps"}, buckets)
+
+	_, err = d.ListFilesInBucket("backups")
+	assert.Error(t, err)
+	assert.Error(t, d.DeleteBucket("backups"))
+}
+
+func TestSelect(t *testing.T) {
+	defer func() { selectedDriver = driverName }()
+
+	d, err := Get()
+	require.NoError(t, err)
+	assert.Equal(t, driverName, d.String())
+
+	require.NoError(t, Select(FileDriverName))
+	d, err = Get()
+	require.NoError(t, err)
+	assert.Equal(t, FileDriverName, d.String())
+
+	assert.Error(t, Select("unknown"))
+	d, err = Get()
+	require.NoError(t, err)
+	assert.Equal(t, FileDriverName, d.String())
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"path"
+	"sort"
+	"strings"
 
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	stork_objectstore "github.com/libopenstorage/stork/pkg/objectstore"
 	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/pkg/errors"
 	"gocloud.dev/blob"
 )
 
@@ -19,6 +23,30 @@ func (d *DefaultDriver) String() string {
 	return ""
 }
 
+// CreateBucket is not supported by default
+func (d *DefaultDriver) CreateBucket(bucketName string, opts BucketOpts) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "CreateBucket()",
+	}
+}
+
+// DeleteBucket is not supported by default
+func (d *DefaultDriver) DeleteBucket(bucketName string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "DeleteBucket()",
+	}
+}
+
+// GetObjectMetadata is not supported by default
+func (d *DefaultDriver) GetObjectMetadata(bucketName, key string) (*ObjectMetadata, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "GetObjectMetadata()",
+	}
+}
+
 // ValidateBackupsDeletedFromCloud checks it given backups are deleted from the cloud
 func (d *DefaultDriver) ValidateBackupsDeletedFromCloud(backupLocation *stork_api.BackupLocation, backupPath string) error {
 	t := func() (interface{}, bool, error) {
@@ -54,3 +82,83 @@ func (d *DefaultDriver) ValidateBackupsDeletedFromCloud(backupLocation *stork_ap
 	}
 	return nil
 }
+
+// ListCloudsnapsByCluster lists the IDs of the cloudsnaps stored in the bucket of the given cluster.
+// Portworx stores the cloudsnaps of a cluster in a bucket named after the cluster ID, so the bucket
+// is opened with the credentials of the backup location and the cluster ID as its path.
+func (d *DefaultDriver) ListCloudsnapsByCluster(backupLocation *stork_api.BackupLocation, clusterID string) ([]string, error) {
+	return listCloudsnaps(backupLocation, clusterID, "")
+}
+
+// ListCloudsnapsByVolume lists the IDs of the cloudsnaps of the given volume stored in the bucket of the given cluster
+func (d *DefaultDriver) ListCloudsnapsByVolume(backupLocation *stork_api.BackupLocation, clusterID, volumeID string) ([]string, error) {
+	if volumeID == "" {
+		return nil, fmt.Errorf("volume ID is required to list cloudsnaps of a volume")
+	}
+	return listCloudsnaps(backupLocation, clusterID, volumeID)
+}
+
+// openCloudsnapBucket opens the bucket of the given backup location
+var openCloudsnapBucket = stork_objectstore.GetBucket
+
+func listCloudsnaps(backupLocation *stork_api.BackupLocation, clusterID, volumeID string) ([]string, error) {
+	if backupLocation == nil {
+		return nil, fmt.Errorf("nil backupLocation")
+	}
+	location := backupLocation.DeepCopy()
+	if clusterID != "" {
+		location.Location.Path = clusterID
+	}
+	bucket, err := openCloudsnapBucket(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket %s: %v", location.Location.Path, err)
+	}
+	defer bucket.Close()
+
+	var prefix string
+	if volumeID != "" {
+		prefix = volumeID + "-"
+	}
+	iterator := bucket.List(&blob.ListOptions{
+		Prefix:    prefix,
+		Delimiter: "/",
+	})
+	var keys []string
+	for {
+		object, err := iterator.Next(context.TODO())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate bucket %s: %v", location.Location.Path, err)
+		}
+		keys = append(keys, object.Key)
+	}
+	return CloudsnapIDsFromKeys(location.Location.Path, volumeID, keys), nil
+}
+
+// CloudsnapIDsFromKeys converts the top level keys of a cloudsnap bucket into cloudsnap IDs.
+// A cloudsnap ID has the form <bucket>/<volumeID>-<snapID> and its objects are stored under
+// the <volumeID>-<snapID>/ prefix. Keys that do not belong to a cloudsnap of the given volume
+// (or any volume if volumeID is empty) are skipped.
+func CloudsnapIDsFromKeys(bucketName, volumeID string, keys []string) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, key := range keys {
+		name := strings.SplitN(key, "/", 2)[0]
+		dash := strings.LastIndex(name, "-")
+		if dash <= 0 || dash == len(name)-1 {
+			continue
+		}
+		if volumeID != "" && name[:dash] != volumeID {
+			continue
+		}
+		id := path.Join(bucketName, name)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
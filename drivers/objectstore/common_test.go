@@ -0,0 +1,91 @@
+package objectstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/fileblob"
+)
+
+func TestCloudsnapIDsFromKeys(t *testing.T) {
+	keys := []string{
+		"1057328-945623/",
+		"1057328-945623/chunk-0001",
+		"1057328-113245/",
+		"6631234-220011/",
+		"metadata.json",
+		"nodash/",
+		"trailing-/",
+	}
+
+	ids := CloudsnapIDsFromKeys("cluster-uuid", "", keys)
+	assert.Equal(t, []string{
+		"cluster-uuid/1057328-113245",
+		"cluster-uuid/1057328-945623",
+		"cluster-uuid/6631234-220011",
+	}, ids, "unexpected cloudsnaps for cluster")
+
+	ids = CloudsnapIDsFromKeys("cluster-uuid", "1057328", keys)
+	assert.Equal(t, []string{
+		"cluster-uuid/1057328-113245",
+		"cluster-uuid/1057328-945623",
+	}, ids, "unexpected cloudsnaps for volume 1057328")
+
+	ids = CloudsnapIDsFromKeys("cluster-uuid", "42", keys)
+	assert.Empty(t, ids, "volume 42 is not expected to have cloudsnaps")
+}
+
+func TestListCloudsnaps(t *testing.T) {
+	root := t.TempDir()
+	openBucket := openCloudsnapBucket
+	defer func() { openCloudsnapBucket = openBucket }()
+	// the fileblob driver stands in for the objectstore, with a directory per bucket
+	openCloudsnapBucket = func(location *stork_api.BackupLocation) (*blob.Bucket, error) {
+		return fileblob.OpenBucket(filepath.Join(root, location.Location.Path), nil)
+	}
+
+	ctx := context.Background()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "cluster-uuid"), 0755))
+	bucket, err := fileblob.OpenBucket(filepath.Join(root, "cluster-uuid"), nil)
+	require.NoError(t, err)
+	for _, key := range []string{
+		"1057328-945623/chunk-0001",
+		"1057328-945623/chunk-0002",
+		"1057328-113245/chunk-0001",
+		"6631234-220011/chunk-0001",
+		"metadata.json",
+	} {
+		require.NoError(t, bucket.WriteAll(ctx, key, []byte("data"), nil))
+	}
+	require.NoError(t, bucket.Close())
+
+	d := &DefaultDriver{}
+	location := &stork_api.BackupLocation{Location: stork_api.BackupLocationItem{Path: "backups"}}
+	ids, err := d.ListCloudsnapsByCluster(location, "cluster-uuid")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"cluster-uuid/1057328-113245",
+		"cluster-uuid/1057328-945623",
+		"cluster-uuid/6631234-220011",
+	}, ids)
+	assert.Equal(t, "backups", location.Location.Path, "the backup location is left as it is")
+
+	ids, err = d.ListCloudsnapsByVolume(location, "cluster-uuid", "6631234")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cluster-uuid/6631234-220011"}, ids)
+
+	ids, err = d.ListCloudsnapsByVolume(location, "cluster-uuid", "42")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	_, err = d.ListCloudsnapsByVolume(location, "cluster-uuid", "")
+	assert.Error(t, err, "volume ID is required")
+	_, err = d.ListCloudsnapsByCluster(nil, "cluster-uuid")
+	assert.Error(t, err, "backup location is required")
+}
@@ -0,0 +1,98 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/fileblob"
+)
+
+const (
+	// FileDriverName is the name of the objectstore driver storing the buckets as directories of the local filesystem
+	FileDriverName = "file"
+	// envFileRoot is the directory the file driver creates its buckets in
+	envFileRoot = "OBJECTSTORE_FILE_ROOT"
+)
+
+// fileProvider stores every bucket as a directory under root
+type fileProvider struct {
+	root string
+}
+
+func newFileProvider() (provider, error) {
+	root := os.Getenv(envFileRoot)
+	if len(root) == 0 {
+		return nil, fmt.Errorf("root directory of the buckets not provided as env var: %s", envFileRoot)
+	}
+	return newFileProviderAt(root)
+}
+
+func newFileProviderAt(root string) (provider, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	return &fileProvider{root: root}, nil
+}
+
+func (f *fileProvider) bucketPath(bucketName string) (string, error) {
+	if bucketName == "" || bucketName != filepath.Base(bucketName) || bucketName == "." || bucketName == ".." {
+		return "", fmt.Errorf("invalid bucket name [%s]", bucketName)
+	}
+	return filepath.Join(f.root, bucketName), nil
+}
+
+func (f *fileProvider) openBucket(ctx context.Context, bucketName string) (*blob.Bucket, error) {
+	dir, err := f.bucketPath(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	return fileblob.OpenBucket(dir, nil)
+}
+
+func (f *fileProvider) listBuckets(ctx context.Context) ([]string, error) {
+	entries, err := ioutil.ReadDir(f.root)
+	if err != nil {
+		return nil, err
+	}
+	var buckets []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			buckets = append(buckets, entry.Name())
+		}
+	}
+	return buckets, nil
+}
+
+func (f *fileProvider) createBucket(ctx context.Context, bucketName string, opts BucketOpts) error {
+	if opts.ObjectLock {
+		return fmt.Errorf("object lock is not supported by the %s driver", FileDriverName)
+	}
+	dir, err := f.bucketPath(bucketName)
+	if err != nil {
+		return err
+	}
+	return os.Mkdir(dir, 0755)
+}
+
+func (f *fileProvider) deleteBucket(ctx context.Context, bucketName string) error {
+	dir, err := f.bucketPath(bucketName)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	// deleting the objects leaves the directories of the keys with a / behind
+	return os.RemoveAll(dir)
+}
+
+// objectMetadata has nothing to add, files are neither encrypted nor retained
+func (f *fileProvider) objectMetadata(attrs *blob.Attributes, md *ObjectMetadata) {}
@@ -0,0 +1,111 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/gcsblob"
+	"gocloud.dev/gcp"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+const (
+	// GCSDriverName is the name of the objectstore driver for Google cloud storage
+	GCSDriverName = "gcs"
+
+	// gcsEncryption is the encryption of the objects without a KMS or customer supplied key
+	gcsEncryption = "google-managed"
+	// gcsCustomerEncryption is the encryption of the objects with a customer supplied key
+	gcsCustomerEncryption = "customer-supplied"
+	// gcsKMSEncryption is the encryption of the objects with a KMS key
+	gcsKMSEncryption = "kms"
+)
+
+// gcsProvider talks to GCS with the application default credentials
+type gcsProvider struct {
+	client  *gcp.HTTPClient
+	storage *storage.Client
+	project string
+}
+
+func newGCSProvider() (provider, error) {
+	ctx := context.Background()
+	creds, err := gcp.DefaultCredentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default credentials: %v", err)
+	}
+	project := getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		projectID, err := gcp.DefaultProjectID(creds)
+		if err != nil {
+			return nil, fmt.Errorf("project not provided as env var GOOGLE_CLOUD_PROJECT nor found in credentials: %v", err)
+		}
+		project = string(projectID)
+	}
+	client, err := gcp.NewHTTPClient(gcp.DefaultTransport(), gcp.CredentialsTokenSource(creds))
+	if err != nil {
+		return nil, err
+	}
+	storageClient, err := storage.NewClient(ctx, option.WithHTTPClient(&client.Client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %v", err)
+	}
+	return &gcsProvider{client: client, storage: storageClient, project: project}, nil
+}
+
+func (p *gcsProvider) openBucket(ctx context.Context, bucketName string) (*blob.Bucket, error) {
+	return gcsblob.OpenBucket(ctx, p.client, bucketName, nil)
+}
+
+func (p *gcsProvider) listBuckets(ctx context.Context) ([]string, error) {
+	var buckets []string
+	it := p.storage.Buckets(ctx, p.project)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, attrs.Name)
+	}
+	return buckets, nil
+}
+
+// createBucket creates the bucket with a retention policy when object lock is requested, GCS has no retention modes
+func (p *gcsProvider) createBucket(ctx context.Context, bucketName string, opts BucketOpts) error {
+	attrs := &storage.BucketAttrs{}
+	if opts.ObjectLock && opts.RetentionDays > 0 {
+		attrs.RetentionPolicy = &storage.RetentionPolicy{
+			RetentionPeriod: time.Duration(opts.RetentionDays) * 24 * time.Hour,
+		}
+	}
+	return p.storage.Bucket(bucketName).Create(ctx, p.project, attrs)
+}
+
+func (p *gcsProvider) deleteBucket(ctx context.Context, bucketName string) error {
+	return p.storage.Bucket(bucketName).Delete(ctx)
+}
+
+func (p *gcsProvider) objectMetadata(attrs *blob.Attributes, md *ObjectMetadata) {
+	var objAttrs storage.ObjectAttrs
+	if !attrs.As(&objAttrs) {
+		return
+	}
+	switch {
+	case objAttrs.KMSKeyName != "":
+		md.Encryption = gcsKMSEncryption
+		md.KMSKeyID = objAttrs.KMSKeyName
+	case objAttrs.CustomerKeySHA256 != "":
+		md.Encryption = gcsCustomerEncryption
+	default:
+		md.Encryption = gcsEncryption
+	}
+	md.RetainUntil = objAttrs.RetentionExpirationTime
+	md.LegalHold = objAttrs.TemporaryHold || objAttrs.EventBasedHold
+}
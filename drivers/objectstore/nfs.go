@@ -0,0 +1,309 @@
+package objectstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/portworx/sched-ops/k8s/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NFSDriverName is the name of the objectstore driver storing the buckets as directories of an NFS export
+	NFSDriverName = "nfs"
+	// EnvNFSServerAddr is the address of the NFS server
+	EnvNFSServerAddr = "NFS_SERVER_ADDR"
+	// EnvNFSPath is the exported path of the NFS server
+	EnvNFSPath = "NFS_PATH"
+	// EnvNFSMountOption are the mount options of the export, e.g. nfsvers=4.1
+	EnvNFSMountOption = "NFS_MOUNT_OPTION"
+	// envNFSHelperNamespace is the namespace of the helper pod mounting the export
+	envNFSHelperNamespace = "NFS_HELPER_NAMESPACE"
+
+	nfsHelperPodName    = "torpedo-nfs-helper"
+	nfsHelperImage      = "busybox"
+	nfsHelperMountPath  = "/mnt/nfs"
+	nfsHelperNamespace  = "default"
+	nfsHelperPodTimeout = 5 * time.Minute
+)
+
+// nfsRunner runs a shell command in the root directory of the export and returns its output
+type nfsRunner func(cmd string) (string, error)
+
+// nfsDriver is an objectstore driver keeping every bucket as a directory at the root of an NFS export.
+// The export is reached through a helper pod which mounts it, created on first use.
+type nfsDriver struct {
+	DefaultDriver
+
+	mu  sync.Mutex
+	run nfsRunner
+}
+
+// NFSConfig is the NFS export backing the nfs driver
+type NFSConfig struct {
+	ServerAddr  string
+	Path        string
+	MountOption string
+}
+
+// GetNFSConfig returns the NFS export configured in the environment
+func GetNFSConfig() (*NFSConfig, error) {
+	config := &NFSConfig{
+		ServerAddr:  os.Getenv(EnvNFSServerAddr),
+		Path:        os.Getenv(EnvNFSPath),
+		MountOption: os.Getenv(EnvNFSMountOption),
+	}
+	if config.ServerAddr == "" {
+		return nil, fmt.Errorf("NFS server address not provided as env var: %s", EnvNFSServerAddr)
+	}
+	if config.Path == "" {
+		config.Path = "/"
+	}
+	return config, nil
+}
+
+func (n *nfsDriver) String() string {
+	return NFSDriverName
+}
+
+func (n *nfsDriver) getRunner() (nfsRunner, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.run != nil {
+		return n.run, nil
+	}
+	config, err := GetNFSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s objectstore driver: %v", NFSDriverName, err)
+	}
+	namespace := os.Getenv(envNFSHelperNamespace)
+	if namespace == "" {
+		namespace = nfsHelperNamespace
+	}
+	if err := startNFSHelperPod(config, namespace); err != nil {
+		return nil, fmt.Errorf("failed to initialize %s objectstore driver: %v", NFSDriverName, err)
+	}
+	n.run = func(cmd string) (string, error) {
+		return core.Instance().RunCommandInPod([]string{"sh", "-c", fmt.Sprintf("cd %s && %s", nfsHelperMountPath, cmd)},
+			nfsHelperPodName, nfsHelperPodName, namespace)
+	}
+	return n.run, nil
+}
+
+// startNFSHelperPod starts the pod mounting the export, unless it already runs. A helper pod left mounting
+// another export is replaced.
+func startNFSHelperPod(config *NFSConfig, namespace string) error {
+	pod, err := core.Instance().GetPodByName(nfsHelperPodName, namespace)
+	if err == nil && !nfsHelperPodMatches(pod, config) {
+		if err := core.Instance().DeletePod(nfsHelperPodName, namespace, true); err != nil {
+			return fmt.Errorf("failed to delete pod [%s/%s] mounting another export: %v", namespace, nfsHelperPodName, err)
+		}
+		if err := core.Instance().WaitForPodDeletion(pod.UID, namespace, nfsHelperPodTimeout); err != nil {
+			return fmt.Errorf("failed to wait for the deletion of pod [%s/%s]: %v", namespace, nfsHelperPodName, err)
+		}
+		pod = nil
+	}
+	if pod == nil {
+		pod, err = core.Instance().CreatePod(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      nfsHelperPodName,
+				Namespace: namespace,
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:    nfsHelperPodName,
+					Image:   nfsHelperImage,
+					Command: []string{"sleep", "infinity"},
+					VolumeMounts: []corev1.VolumeMount{{
+						Name:      "nfs",
+						MountPath: nfsHelperMountPath,
+					}},
+				}},
+				Volumes: []corev1.Volume{{
+					Name: "nfs",
+					VolumeSource: corev1.VolumeSource{
+						NFS: &corev1.NFSVolumeSource{
+							Server: config.ServerAddr,
+							Path:   config.Path,
+						},
+					},
+				}},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create pod [%s/%s]: %v", namespace, nfsHelperPodName, err)
+		}
+	}
+	return core.Instance().ValidatePod(pod, nfsHelperPodTimeout, defaultRetryInterval)
+}
+
+// nfsHelperPodMatches returns true if the helper pod mounts the export of the config
+func nfsHelperPodMatches(pod *corev1.Pod, config *NFSConfig) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.NFS != nil {
+			return v.NFS.Server == config.ServerAddr && v.NFS.Path == config.Path
+		}
+	}
+	return false
+}
+
+// StopNFSHelper deletes the helper pod of the nfs driver, if it was started
+func StopNFSHelper() error {
+	d, err := GetDriver(NFSDriverName)
+	if err != nil {
+		return err
+	}
+	n := d.(*nfsDriver)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.run == nil {
+		return nil
+	}
+	n.run = nil
+	namespace := os.Getenv(envNFSHelperNamespace)
+	if namespace == "" {
+		namespace = nfsHelperNamespace
+	}
+	return core.Instance().DeletePod(nfsHelperPodName, namespace, true)
+}
+
+// nfsBucketPath returns the path of the bucket relative to the root of the export
+func nfsBucketPath(bucketName string) (string, error) {
+	if bucketName == "" || bucketName != filepath.Base(bucketName) || bucketName == "." || bucketName == ".." {
+		return "", fmt.Errorf("invalid bucket name [%s]", bucketName)
+	}
+	return "./" + bucketName, nil
+}
+
+// shellQuote quotes the argument for sh
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// ListBuckets lists the directories at the root of the export
+func (n *nfsDriver) ListBuckets() ([]string, error) {
+	run, err := n.getRunner()
+	if err != nil {
+		return nil, err
+	}
+	out, err := run("find . -mindepth 1 -maxdepth 1 -type d")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %v", err)
+	}
+	return parseNFSPaths(out), nil
+}
+
+// ListFilesInBucket lists the files below the directory of the bucket
+func (n *nfsDriver) ListFilesInBucket(bucketName string) ([]string, error) {
+	run, err := n.getRunner()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := nfsBucketPath(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	out, err := run(fmt.Sprintf("cd %s && find . -type f", shellQuote(dir)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of bucket %s: %v", bucketName, err)
+	}
+	return parseNFSPaths(out), nil
+}
+
+// CheckConnection checks that the export is mounted and writable
+func (n *nfsDriver) CheckConnection() error {
+	run, err := n.getRunner()
+	if err != nil {
+		return err
+	}
+	if _, err := run("test -w ."); err != nil {
+		return fmt.Errorf("failed to connect to %s objectstore: %v", NFSDriverName, err)
+	}
+	return nil
+}
+
+// CreateBucket creates the directory of the bucket
+func (n *nfsDriver) CreateBucket(bucketName string, opts BucketOpts) error {
+	if opts.ObjectLock {
+		return fmt.Errorf("object lock is not supported by the %s driver", NFSDriverName)
+	}
+	run, err := n.getRunner()
+	if err != nil {
+		return err
+	}
+	dir, err := nfsBucketPath(bucketName)
+	if err != nil {
+		return err
+	}
+	if _, err := run(fmt.Sprintf("mkdir %s", shellQuote(dir))); err != nil {
+		return fmt.Errorf("failed to create bucket %s: %v", bucketName, err)
+	}
+	return nil
+}
+
+// DeleteBucket deletes the directory of the bucket with all its files
+func (n *nfsDriver) DeleteBucket(bucketName string) error {
+	run, err := n.getRunner()
+	if err != nil {
+		return err
+	}
+	dir, err := nfsBucketPath(bucketName)
+	if err != nil {
+		return err
+	}
+	if _, err := run(fmt.Sprintf("test -d %s && rm -rf %s", shellQuote(dir), shellQuote(dir))); err != nil {
+		return fmt.Errorf("failed to delete bucket %s: %v", bucketName, err)
+	}
+	return nil
+}
+
+// GetObjectMetadata returns the size and modification time of the file
+func (n *nfsDriver) GetObjectMetadata(bucketName, key string) (*ObjectMetadata, error) {
+	run, err := n.getRunner()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := nfsBucketPath(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	out, err := run(fmt.Sprintf("cd %s && stat -c '%%s %%Y' -- %s", shellQuote(dir), shellQuote(key)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attributes of object %s in bucket %s: %v", key, bucketName, err)
+	}
+	md := &ObjectMetadata{Key: key}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected attributes [%s] of object %s in bucket %s", strings.TrimSpace(out), key, bucketName)
+	}
+	if md.Size, err = strconv.ParseInt(fields[0], 10, 64); err != nil {
+		return nil, fmt.Errorf("unexpected size [%s] of object %s in bucket %s", fields[0], key, bucketName)
+	}
+	modTime, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected modification time [%s] of object %s in bucket %s", fields[1], key, bucketName)
+	}
+	md.ModTime = time.Unix(modTime, 0)
+	return md, nil
+}
+
+// parseNFSPaths parses the output of find run in a directory into the sorted paths relative to that directory
+func parseNFSPaths(out string) []string {
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "./")
+		if line == "" || line == "." {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,85 @@
+package objectstore
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// newTestNFSDriver returns an nfs driver running its commands locally in a temporary directory standing for
+// the root of the export
+func newTestNFSDriver(t *testing.T) (*nfsDriver, string) {
+	root := t.TempDir()
+	return &nfsDriver{run: func(cmd string) (string, error) {
+		c := exec.Command("sh", "-c", cmd)
+		c.Dir = root
+		out, err := c.CombinedOutput()
+		return string(out), err
+	}}, root
+}
+
+func TestNFSDriverBucketLifecycle(t *testing.T) {
+	d, root := newTestNFSDriver(t)
+	require.NoError(t, d.CheckConnection())
+	buckets, err := d.ListBuckets()
+	require.NoError(t, err)
+	assert.Empty(t, buckets)
+
+	require.NoError(t, d.CreateBucket("backups", BucketOpts{}))
+	require.NoError(t, d.CreateBucket("it's", BucketOpts{}))
+	assert.Error(t, d.CreateBucket("backups", BucketOpts{}), "bucket backups already exists")
+	assert.Error(t, d.CreateBucket("locked", BucketOpts{ObjectLock: true, RetentionDays: 1}))
+	assert.Error(t, d.CreateBucket("../escape", BucketOpts{}))
+
+	buckets, err = d.ListBuckets()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backups", "it's"}, buckets)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "backups", "ns1", "backup1"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "backups", "ns1", "backup1", "resources.json"), []byte(`{"kind":"List"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "backups", "metadata"), []byte("v1"), 0644))
+
+	files, err := d.ListFilesInBucket("backups")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metadata", "ns1/backup1/resources.json"}, files)
+
+	md, err := d.GetObjectMetadata("backups", "ns1/backup1/resources.json")
+	require.NoError(t, err)
+	assert.Equal(t, "ns1/backup1/resources.json", md.Key)
+	assert.Equal(t, int64(15), md.Size)
+	assert.False(t, md.ModTime.IsZero())
+	assert.True(t, md.RetainUntil.IsZero())
+
+	_, err = d.GetObjectMetadata("backups", "missing")
+	assert.Error(t, err)
+
+	require.NoError(t, d.DeleteBucket("backups"))
+	buckets, err = d.ListBuckets()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"it's"}, buckets)
+
+	_, err = d.ListFilesInBucket("backups")
+	assert.Error(t, err)
+	assert.Error(t, d.DeleteBucket("backups"))
+}
+
+func TestParseNFSPaths(t *testing.T) {
+	assert.Equal(t, []string{"a/b", "c"}, parseNFSPaths("./c\n.\n./a/b\n\n"))
+	assert.Empty(t, parseNFSPaths(""))
+}
+
+func TestNFSHelperPodMatches(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+		Name:         "nfs",
+		VolumeSource: corev1.VolumeSource{NFS: &corev1.NFSVolumeSource{Server: "10.0.0.1", Path: "/"}},
+	}}}}
+	assert.True(t, nfsHelperPodMatches(pod, &NFSConfig{ServerAddr: "10.0.0.1", Path: "/"}))
+	assert.False(t, nfsHelperPodMatches(pod, &NFSConfig{ServerAddr: "10.0.0.2", Path: "/"}))
+	assert.False(t, nfsHelperPodMatches(pod, &NFSConfig{ServerAddr: "10.0.0.1", Path: "/exports"}))
+	assert.False(t, nfsHelperPodMatches(&corev1.Pod{}, &NFSConfig{ServerAddr: "10.0.0.1", Path: "/"}))
+}
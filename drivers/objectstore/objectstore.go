@@ -12,6 +12,8 @@ import (
 var (
 	objectstoredriver = make(map[string]Driver)
 	k8sStork          = stork.Instance()
+	// selectedDriver is the name of the driver Get returns
+	selectedDriver = driverName
 )
 
 const (
@@ -28,34 +30,90 @@ type Driver interface {
 	// ValidateBackupsDeletedFromCloud validates if bucket has been deleted from the cloud objectstore
 	ValidateBackupsDeletedFromCloud(backupLocation *stork_api.BackupLocation, backupPath string) error
 
-	// TODO: Actually add these per PWX-19768
-	// ListBuckets()
+	// ListCloudsnapsByCluster lists the IDs of the cloudsnaps stored in the bucket of the given cluster
+	ListCloudsnapsByCluster(backupLocation *stork_api.BackupLocation, clusterID string) ([]string, error)
+
+	// ListCloudsnapsByVolume lists the IDs of the cloudsnaps of the given volume stored in the bucket of the given cluster
+	ListCloudsnapsByVolume(backupLocation *stork_api.BackupLocation, clusterID, volumeID string) ([]string, error)
+
+	// ListBuckets lists the names of the buckets of the objectstore
 	ListBuckets() ([]string, error)
 
-	// ListFilesInBucket(bucketName string) ([]string, error)
+	// ListFilesInBucket lists the keys of all the objects stored in the given bucket
 	ListFilesInBucket(bucketName string) ([]string, error)
 
-	// CheckConnection() error
+	// CheckConnection checks that the objectstore is reachable with the configured credentials
 	CheckConnection() error
+
+	// CreateBucket creates the given bucket
+	CreateBucket(bucketName string, opts BucketOpts) error
+
+	// DeleteBucket deletes all the objects of the given bucket and then the bucket itself
+	DeleteBucket(bucketName string) error
+
+	// GetObjectMetadata returns the metadata of the given object
+	GetObjectMetadata(bucketName, key string) (*ObjectMetadata, error)
+}
+
+// BucketOpts are the options to create a bucket with
+type BucketOpts struct {
+	// ObjectLock enables object lock on the bucket, with the default retention below if RetentionDays is set
+	ObjectLock bool
+	// RetentionDays is the number of days objects are retained by default
+	RetentionDays int64
+	// RetentionMode is the object lock mode of the default retention, e.g. GOVERNANCE or COMPLIANCE
+	RetentionMode string
+}
+
+// ObjectMetadata is the metadata of an object stored in a bucket
+type ObjectMetadata struct {
+	Key         string
+	Size        int64
+	ModTime     time.Time
+	ContentType string
+	// Encryption is the server side encryption of the object, e.g. AES256 or aws:kms, empty if not encrypted
+	Encryption string
+	// KMSKeyID is the key the object is encrypted with when the encryption uses a key management service
+	KMSKeyID string
+	// RetentionMode is the object lock mode of the object, empty if it is not locked
+	RetentionMode string
+	// RetainUntil is the time until which the object cannot be deleted, zero if it is not retained
+	RetainUntil time.Time
+	// LegalHold is true if a hold prevents the object from being deleted
+	LegalHold bool
 }
 
 type objstore struct {
 	DefaultDriver
 }
 
-// Get returns the objecstore drive
+// Get returns the selected objecstore driver
 func Get() (Driver, error) {
-	d, ok := objectstoredriver[driverName]
+	return GetDriver(selectedDriver)
+}
+
+// GetDriver returns the objectstore driver with the given name
+func GetDriver(name string) (Driver, error) {
+	d, ok := objectstoredriver[name]
 	if ok {
 		return d, nil
 	}
 
 	return nil, &errors.ErrNotFound{
-		ID:   driverName,
+		ID:   name,
 		Type: "ObjectstoreDriver",
 	}
 }
 
+// Select makes Get return the objectstore driver with the given name
+func Select(name string) error {
+	if _, err := GetDriver(name); err != nil {
+		return err
+	}
+	selectedDriver = name
+	return nil
+}
+
 // Register registers the objectstore driver
 func Register(driverName string, d Driver) error {
 	if _, ok := objectstoredriver[driverName]; !ok {
@@ -83,6 +141,12 @@ func (o *objstore) ListFilesInBucket(bucketName string) ([]string, error) {
 func (o *objstore) CheckConnection() error {
 	return nil
 }
+
 func init() {
 	Register(driverName, &objstore{})
+	Register(S3DriverName, newBlobDriver(S3DriverName, newS3Provider))
+	Register(AzureDriverName, newBlobDriver(AzureDriverName, newAzureProvider))
+	Register(GCSDriverName, newBlobDriver(GCSDriverName, newGCSProvider))
+	Register(FileDriverName, newBlobDriver(FileDriverName, newFileProvider))
+	Register(NFSDriverName, &nfsDriver{})
 }
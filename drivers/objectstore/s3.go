@@ -0,0 +1,121 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/portworx/torpedo/pkg/s3utils"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/s3blob"
+)
+
+const (
+	// S3DriverName is the name of the objectstore driver for AWS S3 and S3 compatible objectstores like minio
+	S3DriverName = "s3"
+
+	objectLockEnabled   = "Enabled"
+	objectLockLegalHold = "ON"
+)
+
+// s3Provider talks to S3 with the credentials the tests use to create backup locations
+type s3Provider struct {
+	sess   *session.Session
+	client *s3.S3
+}
+
+// getenv returns the first of the given env vars that is set
+func getenv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func newS3Provider() (provider, error) {
+	id, secret, endpoint, region, disableSSL := s3utils.GetAWSDetailsFromEnv()
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:         aws.String(endpoint),
+		Credentials:      credentials.NewStaticCredentials(id, secret, ""),
+		Region:           aws.String(region),
+		DisableSSL:       aws.Bool(disableSSL),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 session: %v", err)
+	}
+	return &s3Provider{sess: sess, client: s3.New(sess)}, nil
+}
+
+func (p *s3Provider) openBucket(ctx context.Context, bucketName string) (*blob.Bucket, error) {
+	return s3blob.OpenBucket(ctx, p.sess, bucketName, nil)
+}
+
+func (p *s3Provider) listBuckets(ctx context.Context) ([]string, error) {
+	out, err := p.client.ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+	var buckets []string
+	for _, bucket := range out.Buckets {
+		buckets = append(buckets, aws.StringValue(bucket.Name))
+	}
+	return buckets, nil
+}
+
+func (p *s3Provider) createBucket(ctx context.Context, bucketName string, opts BucketOpts) error {
+	input := &s3.CreateBucketInput{Bucket: aws.String(bucketName)}
+	if opts.ObjectLock {
+		input.ObjectLockEnabledForBucket = aws.Bool(true)
+	}
+	if _, err := p.client.CreateBucketWithContext(ctx, input); err != nil {
+		return err
+	}
+	if err := p.client.WaitUntilBucketExistsWithContext(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucketName)}); err != nil {
+		return err
+	}
+	if !opts.ObjectLock || opts.RetentionDays == 0 {
+		return nil
+	}
+	_, err := p.client.PutObjectLockConfigurationWithContext(ctx, &s3.PutObjectLockConfigurationInput{
+		Bucket: aws.String(bucketName),
+		ObjectLockConfiguration: &s3.ObjectLockConfiguration{
+			ObjectLockEnabled: aws.String(objectLockEnabled),
+			Rule: &s3.ObjectLockRule{
+				DefaultRetention: &s3.DefaultRetention{
+					Days: aws.Int64(opts.RetentionDays),
+					Mode: aws.String(opts.RetentionMode),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set object lock retention of %d days in %s mode: %v", opts.RetentionDays, opts.RetentionMode, err)
+	}
+	return nil
+}
+
+func (p *s3Provider) deleteBucket(ctx context.Context, bucketName string) error {
+	if _, err := p.client.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{Bucket: aws.String(bucketName)}); err != nil {
+		return err
+	}
+	return p.client.WaitUntilBucketNotExistsWithContext(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucketName)})
+}
+
+func (p *s3Provider) objectMetadata(attrs *blob.Attributes, md *ObjectMetadata) {
+	var head s3.HeadObjectOutput
+	if !attrs.As(&head) {
+		return
+	}
+	md.Encryption = aws.StringValue(head.ServerSideEncryption)
+	md.KMSKeyID = aws.StringValue(head.SSEKMSKeyId)
+	md.RetentionMode = aws.StringValue(head.ObjectLockMode)
+	md.RetainUntil = aws.TimeValue(head.ObjectLockRetainUntilDate)
+	md.LegalHold = aws.StringValue(head.ObjectLockLegalHoldStatus) == objectLockLegalHold
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/portworx/torpedo/pkg/log"
 	"github.com/portworx/torpedo/pkg/osutils"
+	"github.com/portworx/torpedo/pkg/revertutils"
 
 	yaml2 "gopkg.in/yaml.v2"
 
@@ -365,7 +366,8 @@ func (k *K8s) RescanSpecs(specDir, storageDriver string) error {
 	return nil
 }
 
-// RefreshNodeRegistry update the k8 node list registry
+// RefreshNodeRegistry update the k8 node list registry. The registry emits the topology changes
+// between the node lists before and after the refresh
 func (k *K8s) RefreshNodeRegistry() error {
 
 	nodes, err := k8sCore.GetNodes()
@@ -373,14 +375,15 @@ func (k *K8s) RefreshNodeRegistry() error {
 		return err
 	}
 
-	node.CleanupRegistry()
-
-	for _, n := range nodes.Items {
-		if err = k.AddNewNode(n); err != nil {
+	var newNodes []node.Node
+	for _, k8sNode := range nodes.Items {
+		n := k.parseK8SNode(k8sNode)
+		if err := k.IsNodeReady(n); err != nil {
 			return err
 		}
+		newNodes = append(newNodes, n)
 	}
-	return nil
+	return node.ReplaceNodes(newNodes)
 }
 
 // ParseSpecs parses the application spec file
@@ -4091,12 +4094,22 @@ func (k *K8s) StartSchedOnNode(n node.Node) error {
 
 // EnableSchedulingOnNode enable apps to be scheduled to a given k8s worker node
 func (k *K8s) EnableSchedulingOnNode(n node.Node) error {
-	return k8sCore.UnCordonNode(n.Name, DefaultTimeout, DefaultRetryInterval)
+	if err := k8sCore.UnCordonNode(n.Name, DefaultTimeout, DefaultRetryInterval); err != nil {
+		return err
+	}
+	revertutils.Resolve("cordon/" + n.Name)
+	return nil
 }
 
 // DisableSchedulingOnNode disable apps to be scheduled to a given k8s worker node
 func (k *K8s) DisableSchedulingOnNode(n node.Node) error {
-	return k8sCore.CordonNode(n.Name, DefaultTimeout, DefaultRetryInterval)
+	if err := k8sCore.CordonNode(n.Name, DefaultTimeout, DefaultRetryInterval); err != nil {
+		return err
+	}
+	revertutils.Register("cordon/"+n.Name, fmt.Sprintf("disabled scheduling on node [%s]", n.Name), func() error {
+		return k.EnableSchedulingOnNode(n)
+	})
+	return nil
 }
 
 // IsScalable check whether scalable
@@ -10,6 +10,7 @@ import (
 	apapi "github.com/libopenstorage/autopilot-api/pkg/apis/autopilot/v1alpha1"
 	"github.com/libopenstorage/openstorage/api"
 	v1 "github.com/libopenstorage/operator/pkg/apis/core/v1"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/pborman/uuid"
 	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
@@ -150,6 +151,46 @@ func (d *DefaultDriver) DetachVolume(volumeID string) error {
 	}
 }
 
+// GenerateToken mints a token for the given identity
+func (d *DefaultDriver) GenerateToken(identity AuthIdentity, expiry time.Duration) (string, error) {
+	return "", &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "GenerateToken()",
+	}
+}
+
+// CreateVolumeWithToken creates a volume as the identity of the given token
+func (d *DefaultDriver) CreateVolumeWithToken(token, volName string, size uint64, haLevel int64, groups []string) (string, error) {
+	return "", &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "CreateVolumeWithToken()",
+	}
+}
+
+// InspectVolumeWithToken inspects a volume as the identity of the given token
+func (d *DefaultDriver) InspectVolumeWithToken(token, volumeID string) (*api.Volume, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "InspectVolumeWithToken()",
+	}
+}
+
+// CreateSnapshotWithToken creates a snapshot as the identity of the given token
+func (d *DefaultDriver) CreateSnapshotWithToken(token, volumeID, snapName string) (string, error) {
+	return "", &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "CreateSnapshotWithToken()",
+	}
+}
+
+// DeleteVolumeWithToken deletes a volume as the identity of the given token
+func (d *DefaultDriver) DeleteVolumeWithToken(token, volumeID string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "DeleteVolumeWithToken()",
+	}
+}
+
 // DeleteVolume deletes the volume specified by volumeID
 func (d *DefaultDriver) DeleteVolume(volumeID string) error {
 	return &errors.ErrNotSupported{
@@ -332,6 +373,22 @@ func (d *DefaultDriver) ValidateCreateCloudsnapUsingPxctl(name string) error {
 	}
 }
 
+// ValidateCloudsnapInObjectstore validates whether the cloudsnaps of a volume are present in the objectstore.
+func (d *DefaultDriver) ValidateCloudsnapInObjectstore(name string, params map[string]string, backupLocation *stork_api.BackupLocation) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "ValidateCloudsnapInObjectstore()",
+	}
+}
+
+// ValidateCloudsnapDeleted validates whether a cloudsnap has been deleted from the objectstore.
+func (d *DefaultDriver) ValidateCloudsnapDeleted(cloudsnapID string, params map[string]string, backupLocation *stork_api.BackupLocation) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "ValidateCloudsnapDeleted()",
+	}
+}
+
 // ValidateCreateGroupSnapshotUsingPxctl validates whether a group volumesnapshot has been created properly.
 // params are the custom volume options passed when creating the volume.
 func (d *DefaultDriver) ValidateCreateGroupSnapshotUsingPxctl() error {
@@ -574,6 +631,30 @@ func (d *DefaultDriver) GetClusterPairingInfo(kubeConfigPath, token string, isPx
 
 }
 
+// GetClusterDomains returns the cluster domains of a stretched cluster
+func (d *DefaultDriver) GetClusterDomains() (map[string]bool, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "GetClusterDomains()",
+	}
+}
+
+// ActivateClusterDomain activates the given cluster domain
+func (d *DefaultDriver) ActivateClusterDomain(name string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "ActivateClusterDomain()",
+	}
+}
+
+// DeactivateClusterDomain deactivates the given cluster domain
+func (d *DefaultDriver) DeactivateClusterDomain(name string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "DeactivateClusterDomain()",
+	}
+}
+
 // DecommissionNode decommissions the given node from the cluster
 func (d *DefaultDriver) DecommissionNode(n *node.Node) error {
 	return &errors.ErrNotSupported{
@@ -878,6 +959,22 @@ func (d *DefaultDriver) IsOperatorBasedInstall() (bool, error) {
 	}
 }
 
+// ValidateVolumeEncryption validates that the volume is encrypted at rest
+func (d *DefaultDriver) ValidateVolumeEncryption(vol *Volume, params map[string]string, marker string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "ValidateVolumeEncryption()",
+	}
+}
+
+// RotateEncryptionKey rotates the encryption key of the volume
+func (d *DefaultDriver) RotateEncryptionKey(vol *Volume, params map[string]string, secretType string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RotateEncryptionKey()",
+	}
+}
+
 // RunSecretsLogin runs secrets login using pxctl
 func (d *DefaultDriver) RunSecretsLogin(n node.Node, secretType string) error {
 	return &errors.ErrNotSupported{
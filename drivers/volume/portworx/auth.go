@@ -0,0 +1,127 @@
+package portworx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/libopenstorage/openstorage/pkg/auth"
+	pxutil "github.com/libopenstorage/operator/drivers/storage/portworx/util"
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	// pxDefaultJwtIssuer is the issuer the operator configures for self signed tokens when none is set
+	pxDefaultJwtIssuer = "operator.portworx.io"
+	// tokenIATSubtract guards minted tokens against clock drift between torpedo and the cluster
+	tokenIATSubtract = 1 * time.Minute
+)
+
+func (d *portworx) GenerateToken(identity torpedovolume.AuthIdentity, expiry time.Duration) (string, error) {
+	issuer, sharedSecret, err := d.getSelfSignedAuthConfig()
+	if err != nil {
+		return "", err
+	}
+	signature, err := auth.NewSignatureSharedSecret(sharedSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token signature, Err: %v", err)
+	}
+	claims := &auth.Claims{
+		Issuer:  issuer,
+		Subject: identity.Name,
+		Name:    identity.Name,
+		Email:   fmt.Sprintf("%s@%s", identity.Name, issuer),
+		Roles:   identity.Roles,
+		Groups:  identity.Groups,
+	}
+	token, err := auth.Token(claims, signature, &auth.Options{
+		Expiration:  time.Now().Add(expiry).Unix(),
+		IATSubtract: tokenIATSubtract,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token for %s, Err: %v", identity.Name, err)
+	}
+	log.Debugf("Generated token for [%s] with roles %v and groups %v valid for %v", identity.Name, identity.Roles, identity.Groups, expiry)
+	return token, nil
+}
+
+// getSelfSignedAuthConfig returns the issuer and the shared secret used by PX-Security to validate self signed tokens
+func (d *portworx) getSelfSignedAuthConfig() (string, string, error) {
+	issuer := pxDefaultJwtIssuer
+	secretName := pxutil.SecurityPXSharedSecretSecretName
+	stc, err := d.GetDriver()
+	if err != nil {
+		return "", "", err
+	}
+	if stc.Spec.Security == nil || !stc.Spec.Security.Enabled {
+		return "", "", fmt.Errorf("security is not enabled on StorageCluster [%s]", stc.Name)
+	}
+	if authSpec := stc.Spec.Security.Auth; authSpec != nil && authSpec.SelfSigned != nil {
+		if authSpec.SelfSigned.Issuer != nil && *authSpec.SelfSigned.Issuer != "" {
+			issuer = *authSpec.SelfSigned.Issuer
+		}
+		if authSpec.SelfSigned.SharedSecret != nil && *authSpec.SelfSigned.SharedSecret != "" {
+			secretName = *authSpec.SelfSigned.SharedSecret
+		}
+	}
+	secret, err := k8sCore.GetSecret(secretName, stc.Namespace)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get shared secret [%s] in namespace [%s], Err: %v", secretName, stc.Namespace, err)
+	}
+	sharedSecret, ok := secret.Data[pxutil.SecuritySharedSecretKey]
+	if !ok {
+		return "", "", fmt.Errorf("secret [%s] has no key [%s]", secretName, pxutil.SecuritySharedSecretKey)
+	}
+	return issuer, string(sharedSecret), nil
+}
+
+func (d *portworx) CreateVolumeWithToken(token, volName string, size uint64, haLevel int64, groups []string) (string, error) {
+	spec := &api.VolumeSpec{
+		Size:    size,
+		HaLevel: haLevel,
+		Format:  api.FSType_FS_TYPE_EXT4,
+	}
+	if len(groups) > 0 {
+		// the owner is set from the token by the volume driver, only the acls are taken from the spec
+		acls := &api.Ownership_AccessControl{Groups: make(map[string]api.Ownership_AccessType)}
+		for _, group := range groups {
+			acls.Groups[group] = api.Ownership_Read
+		}
+		spec.Ownership = &api.Ownership{Acls: acls}
+	}
+	resp, err := d.getVolDriver().Create(d.getContextWithToken(context.Background(), token),
+		&api.SdkVolumeCreateRequest{
+			Name: volName,
+			Spec: spec,
+		})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetVolumeId(), nil
+}
+
+func (d *portworx) InspectVolumeWithToken(token, volumeID string) (*api.Volume, error) {
+	resp, err := d.getVolDriver().Inspect(d.getContextWithToken(context.Background(), token),
+		&api.SdkVolumeInspectRequest{VolumeId: volumeID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetVolume(), nil
+}
+
+func (d *portworx) CreateSnapshotWithToken(token, volumeID, snapName string) (string, error) {
+	resp, err := d.getVolDriver().SnapshotCreate(d.getContextWithToken(context.Background(), token),
+		&api.SdkVolumeSnapshotCreateRequest{VolumeId: volumeID, Name: snapName})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetSnapshotId(), nil
+}
+
+func (d *portworx) DeleteVolumeWithToken(token, volumeID string) error {
+	_, err := d.getVolDriver().Delete(d.getContextWithToken(context.Background(), token),
+		&api.SdkVolumeDeleteRequest{VolumeId: volumeID})
+	return err
+}
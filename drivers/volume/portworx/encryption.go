@@ -0,0 +1,326 @@
+package portworx
+
+import (
+	"fmt"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/pborman/uuid"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	// per volume secret annotations, the cluster wide secret is used when they are not set
+	pxSecretNameParam      = "px/secret-name"
+	pxSecretNamespaceParam = "px/secret-namespace"
+	pxSecretKeyParam       = "px/secret-key"
+	clusterWideSecretName  = "px-vol-encryption"
+	clusterWideSecretKey   = "cluster-wide-secret-key"
+	vaultSecretPathPrefix  = "secret/"
+	vaultMountsPath        = "sys/internal/ui/mounts/"
+
+	pxRawDevicePath       = "/dev/pxd/pxd%s"
+	pxEncryptedDevicePath = "/dev/mapper/pxd-enc%s"
+	dmsetupTableCmd       = "dmsetup table pxd-enc%s"
+	cryptsetupIsLuksCmd   = "cryptsetup isLuks %s"
+	grepMarkerCmd         = "grep -a -c -F %s %s || true"
+)
+
+// encryptionSecret is the location of the passphrase of an encrypted volume in the secret store
+type encryptionSecret struct {
+	name      string
+	namespace string
+	key       string
+}
+
+func (d *portworx) ValidateVolumeEncryption(vol *torpedovolume.Volume, params map[string]string, marker string) error {
+	volumeName := d.schedOps.GetVolumeName(vol)
+	n, err := d.GetNodeForVolume(vol, inspectVolumeTimeout, inspectVolumeRetryInterval)
+	if err != nil {
+		return fmt.Errorf("failed to get node of encrypted volume %s, Err: %v", volumeName, err)
+	}
+	if n == nil {
+		return fmt.Errorf("encrypted volume %s is not attached on any node", volumeName)
+	}
+	opts := node.ConnectionOpts{
+		Timeout:         defaultTimeout,
+		TimeBeforeRetry: defaultRetryInterval,
+	}
+
+	volumeInspectResponse, err := d.getVolDriver().Inspect(d.getContext(), &api.SdkVolumeInspectRequest{VolumeId: volumeName})
+	if err != nil {
+		return fmt.Errorf("failed to inspect volume %s, Err: %v", volumeName, err)
+	}
+	pxVol := volumeInspectResponse.GetVolume()
+	if !pxVol.GetSpec().GetEncrypted() {
+		return fmt.Errorf("volume %s is not encrypted", volumeName)
+	}
+	volumeID := pxVol.GetId()
+
+	table, err := d.nodeDriver.RunCommand(*n, fmt.Sprintf(dmsetupTableCmd, volumeID), opts)
+	if err != nil {
+		return fmt.Errorf("failed to get device-mapper table of volume %s on node %s, Err: %v", volumeName, n.Name, err)
+	}
+	if !strings.Contains(table, " crypt ") {
+		return fmt.Errorf("device-mapper table of volume %s on node %s has no crypt target: %s", volumeName, n.Name, table)
+	}
+	log.Infof("Volume %s is mapped through dm-crypt on node %s", volumeName, n.Name)
+
+	if marker == "" {
+		return nil
+	}
+	// the marker must be visible through the decrypted device, otherwise the raw device check proves nothing
+	scanOpts := node.ConnectionOpts{
+		Timeout:         asyncTimeout,
+		TimeBeforeRetry: defaultRetryInterval,
+	}
+	out, err := d.nodeDriver.RunCommand(*n, fmt.Sprintf(grepMarkerCmd, shellQuote(marker), fmt.Sprintf(pxEncryptedDevicePath, volumeID)), scanOpts)
+	if err != nil {
+		return fmt.Errorf("failed to scan decrypted device of volume %s, Err: %v", volumeName, err)
+	}
+	if strings.TrimSpace(out) == "0" {
+		return fmt.Errorf("marker %s is not present on decrypted device of volume %s", marker, volumeName)
+	}
+	out, err = d.nodeDriver.RunCommand(*n, fmt.Sprintf(grepMarkerCmd, shellQuote(marker), fmt.Sprintf(pxRawDevicePath, volumeID)), scanOpts)
+	if err != nil {
+		return fmt.Errorf("failed to scan raw device of volume %s, Err: %v", volumeName, err)
+	}
+	if strings.TrimSpace(out) != "0" {
+		return fmt.Errorf("plaintext marker %s found on raw device of encrypted volume %s on node %s", marker, volumeName, n.Name)
+	}
+	log.Infof("Plaintext marker is not present on raw device of volume %s", volumeName)
+	return nil
+}
+
+func (d *portworx) RotateEncryptionKey(vol *torpedovolume.Volume, params map[string]string, secretType string) error {
+	volumeName := d.schedOps.GetVolumeName(vol)
+	secret := d.getEncryptionSecret(params)
+	oldKey, err := d.readEncryptionSecret(secretType, secret)
+	if err != nil {
+		return err
+	}
+
+	volumeInspectResponse, err := d.getVolDriver().Inspect(d.getContext(), &api.SdkVolumeInspectRequest{VolumeId: volumeName})
+	if err != nil {
+		return fmt.Errorf("failed to inspect volume %s, Err: %v", volumeName, err)
+	}
+	volumeID := volumeInspectResponse.GetVolume().GetId()
+
+	// the cluster wide secret, as well as a per volume secret, may be shared by other volumes. All the volumes whose
+	// passphrase is in the secret are rotated together, or the secret update would leave them unreadable.
+	volumesResponse, err := d.getVolDriver().InspectWithFilters(d.getContext(), &api.SdkVolumeInspectWithFiltersRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to list volumes, Err: %v", err)
+	}
+	var pxVols []*api.Volume
+	for _, resp := range volumesResponse.GetVolumes() {
+		if resp.GetVolume().GetSpec().GetEncrypted() && resp.GetVolume().GetSource().GetParent() == "" {
+			pxVols = append(pxVols, resp.GetVolume())
+		}
+	}
+
+	opts := node.ConnectionOpts{
+		Timeout:         defaultTimeout,
+		TimeBeforeRetry: defaultRetryInterval,
+	}
+	keyFiles := func(keys ...string) string {
+		cmd := "umask 077; "
+		for i, key := range keys {
+			cmd += fmt.Sprintf("printf '%%s' %s > /tmp/px-key.%d; ", shellQuote(key), i)
+		}
+		return cmd
+	}
+	cleanup := "; rc=$?; rm -f /tmp/px-key.*; exit $rc"
+
+	// find the volumes using the current key before changing anything, a detached volume cannot be rotated
+	// and would be left unreadable once the secret is updated
+	devices := make(map[string]node.Node)
+	for _, pxVol := range pxVols {
+		n, err := d.getAttachedNode(pxVol)
+		if err != nil {
+			return err
+		}
+		if n == nil {
+			return fmt.Errorf("encrypted volume %s is not attached, cannot tell whether it shares secret %s, refusing to rotate it",
+				pxVol.GetLocator().GetName(), secret.name)
+		}
+		device := fmt.Sprintf(pxRawDevicePath, pxVol.GetId())
+		if _, err := d.nodeDriver.RunCommand(*n, fmt.Sprintf(cryptsetupIsLuksCmd, device), opts); err != nil {
+			return fmt.Errorf("device %s of volume %s is not LUKS formatted, Err: %v", device, pxVol.GetLocator().GetName(), err)
+		}
+		cmd := keyFiles(oldKey) + fmt.Sprintf("cryptsetup luksOpen --test-passphrase --key-file /tmp/px-key.0 %s", device) + cleanup
+		if _, err := d.nodeDriver.RunCommand(*n, cmd, opts); err != nil {
+			log.Infof("Volume %s is not encrypted with secret %s, skipping it", pxVol.GetLocator().GetName(), secret.name)
+			continue
+		}
+		devices[device] = *n
+	}
+	if _, ok := devices[fmt.Sprintf(pxRawDevicePath, volumeID)]; !ok {
+		return fmt.Errorf("volume %s is not encrypted with secret %s", volumeName, secret.name)
+	}
+
+	// add the new passphrase before removing the old one so the volume stays accessible if rotation fails midway
+	newKey := uuid.New()
+	for device, n := range devices {
+		cmd := keyFiles(oldKey, newKey) + fmt.Sprintf("cryptsetup luksAddKey --key-file /tmp/px-key.0 %s /tmp/px-key.1 && "+
+			"cryptsetup luksRemoveKey %s /tmp/px-key.0 && "+
+			"cryptsetup luksOpen --test-passphrase --key-file /tmp/px-key.1 %s", device, device, device) + cleanup
+		if _, err := d.nodeDriver.RunCommand(n, cmd, opts); err != nil {
+			return fmt.Errorf("failed to rotate LUKS passphrase of device %s on node %s, Err: %v", device, n.Name, err)
+		}
+	}
+	if err := d.writeEncryptionSecret(secretType, secret, newKey); err != nil {
+		return err
+	}
+	log.Infof("Rotated encryption key of %d volumes stored in %s secret %s", len(devices), secretType, secret.name)
+	return nil
+}
+
+// getAttachedNode returns the node the given volume is attached on, or nil if it is detached
+func (d *portworx) getAttachedNode(pxVol *api.Volume) (*node.Node, error) {
+	for _, n := range node.GetStorageDriverNodes() {
+		ok, err := d.isVolumeAttachedOnNode(pxVol, n)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &n, nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *portworx) getEncryptionSecret(params map[string]string) encryptionSecret {
+	if name, ok := params[pxSecretNameParam]; ok && name != "" {
+		secret := encryptionSecret{
+			name:      name,
+			namespace: params[pxSecretNamespaceParam],
+			key:       params[pxSecretKeyParam],
+		}
+		if secret.namespace == "" {
+			secret.namespace = d.namespace
+		}
+		if secret.key == "" {
+			secret.key = name
+		}
+		return secret
+	}
+	return encryptionSecret{
+		name:      clusterWideSecretName,
+		namespace: d.namespace,
+		key:       clusterWideSecretKey,
+	}
+}
+
+func (d *portworx) readEncryptionSecret(secretType string, secret encryptionSecret) (string, error) {
+	switch secretType {
+	case scheduler.SecretK8S:
+		s, err := k8sCore.GetSecret(secret.name, secret.namespace)
+		if err != nil {
+			return "", fmt.Errorf("failed to get secret %s/%s, Err: %v", secret.namespace, secret.name, err)
+		}
+		value, ok := s.Data[secret.key]
+		if !ok {
+			return "", fmt.Errorf("secret %s/%s has no key %s", secret.namespace, secret.name, secret.key)
+		}
+		return string(value), nil
+	case scheduler.SecretVault:
+		client, err := vaultapi.NewClient(nil)
+		if err != nil {
+			return "", err
+		}
+		version := getVaultKVVersion(client)
+		s, err := client.Logical().Read(vaultSecretPath(secret.name, version))
+		if err != nil {
+			return "", fmt.Errorf("failed to read vault secret %s, Err: %v", secret.name, err)
+		}
+		data := vaultSecretData(s, version)
+		if data == nil {
+			return "", fmt.Errorf("vault secret %s not found", secret.name)
+		}
+		value, ok := data[secret.key].(string)
+		if !ok {
+			return "", fmt.Errorf("vault secret %s has no key %s", secret.name, secret.key)
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("unsupported secret type %s", secretType)
+}
+
+func (d *portworx) writeEncryptionSecret(secretType string, secret encryptionSecret, value string) error {
+	switch secretType {
+	case scheduler.SecretK8S:
+		s, err := k8sCore.GetSecret(secret.name, secret.namespace)
+		if err != nil {
+			return fmt.Errorf("failed to get secret %s/%s, Err: %v", secret.namespace, secret.name, err)
+		}
+		s.Data[secret.key] = []byte(value)
+		if _, err := k8sCore.UpdateSecret(s); err != nil {
+			return fmt.Errorf("failed to update secret %s/%s, Err: %v", secret.namespace, secret.name, err)
+		}
+		return nil
+	case scheduler.SecretVault:
+		client, err := vaultapi.NewClient(nil)
+		if err != nil {
+			return err
+		}
+		version := getVaultKVVersion(client)
+		path := vaultSecretPath(secret.name, version)
+		// writes replace the whole secret, so keep the other keys stored along with the passphrase
+		data := make(map[string]interface{})
+		if s, err := client.Logical().Read(path); err == nil && vaultSecretData(s, version) != nil {
+			data = vaultSecretData(s, version)
+		}
+		data[secret.key] = value
+		if version == 2 {
+			data = map[string]interface{}{"data": data}
+		}
+		if _, err := client.Logical().Write(path, data); err != nil {
+			return fmt.Errorf("failed to write vault secret %s, Err: %v", secret.name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported secret type %s", secretType)
+}
+
+// getVaultKVVersion returns the version of the KV secrets engine mounted at vaultSecretPathPrefix, 1 if it can't
+// be told
+func getVaultKVVersion(client *vaultapi.Client) int {
+	s, err := client.Logical().Read(vaultMountsPath + vaultSecretPathPrefix)
+	if err != nil || s == nil {
+		return 1
+	}
+	if options, ok := s.Data["options"].(map[string]interface{}); ok && options["version"] == "2" {
+		return 2
+	}
+	return 1
+}
+
+// vaultSecretPath returns the path of the given secret, version 2 of the KV secrets engine keeps it below data/
+func vaultSecretPath(name string, version int) string {
+	if version == 2 {
+		return vaultSecretPathPrefix + "data/" + name
+	}
+	return vaultSecretPathPrefix + name
+}
+
+// vaultSecretData returns the keys stored in the vault secret, version 2 of the KV secrets engine nests them in data
+func vaultSecretData(s *vaultapi.Secret, version int) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	if version == 2 {
+		data, _ := s.Data["data"].(map[string]interface{})
+		return data
+	}
+	return s.Data
+}
+
+// shellQuote quotes the given string for use as a single argument in a shell command
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,24 @@
+package portworx
+
+import (
+	"testing"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultSecret(t *testing.T) {
+	require.Equal(t, "secret/px-key", vaultSecretPath("px-key", 1))
+	require.Equal(t, "secret/data/px-key", vaultSecretPath("px-key", 2))
+
+	v1 := &vaultapi.Secret{Data: map[string]interface{}{"px-key": "passphrase"}}
+	require.Equal(t, "passphrase", vaultSecretData(v1, 1)["px-key"])
+
+	v2 := &vaultapi.Secret{Data: map[string]interface{}{
+		"data":     map[string]interface{}{"px-key": "passphrase"},
+		"metadata": map[string]interface{}{"version": 3},
+	}}
+	require.Equal(t, "passphrase", vaultSecretData(v2, 2)["px-key"])
+	require.Nil(t, vaultSecretData(nil, 2))
+	require.Nil(t, vaultSecretData(v1, 2))
+}
@@ -19,6 +19,7 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/portworx/torpedo/pkg/log"
+	"github.com/portworx/torpedo/pkg/revertutils"
 	pxapi "github.com/portworx/torpedo/porx/px/api"
 
 	"github.com/portworx/torpedo/pkg/s3utils"
@@ -34,6 +35,7 @@ import (
 	"github.com/libopenstorage/openstorage/cluster"
 	v1 "github.com/libopenstorage/operator/pkg/apis/core/v1"
 	optest "github.com/libopenstorage/operator/pkg/util/test"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/pborman/uuid"
 	"github.com/portworx/sched-ops/k8s/apiextensions"
 	"github.com/portworx/sched-ops/k8s/core"
@@ -41,6 +43,7 @@ import (
 	"github.com/portworx/sched-ops/task"
 	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/objectstore"
 	torpedok8s "github.com/portworx/torpedo/drivers/scheduler/k8s"
 	torpedovolume "github.com/portworx/torpedo/drivers/volume"
 	"github.com/portworx/torpedo/drivers/volume/portworx/schedops"
@@ -102,6 +105,11 @@ const (
 	mountGrepVolume                           = "mount | grep %s"
 )
 
+const (
+	// cloudsnapFullMetadataKey is the cloudsnap metadata key set to true on full cloudsnaps
+	cloudsnapFullMetadataKey = "full"
+)
+
 const (
 	defaultTimeout                    = 2 * time.Minute
 	defaultRetryInterval              = 10 * time.Second
@@ -189,6 +197,7 @@ type portworx struct {
 	mountAttachManager    api.OpenStorageMountAttachClient
 	volDriver             api.OpenStorageVolumeClient
 	clusterPairManager    api.OpenStorageClusterPairClient
+	clusterDomainManager  api.OpenStorageClusterDomainsClient
 	alertsManager         api.OpenStorageAlertsClient
 	csbackupManager       api.OpenStorageCloudBackupClient
 	storagePoolManager    api.OpenStoragePoolClient
@@ -1009,6 +1018,9 @@ func (d *portworx) EnterMaintenance(n node.Node) error {
 	if _, err := task.DoRetryWithTimeout(t, maintenanceOpTimeout, defaultRetryInterval); err != nil {
 		return err
 	}
+	revertutils.Register("maintenance/"+n.Name, fmt.Sprintf("maintenance mode on node [%s]", n.Name), func() error {
+		return d.ExitMaintenance(n)
+	})
 	t = func() (interface{}, bool, error) {
 		apiNode, err := d.GetDriverNode(&n)
 		if err != nil {
@@ -1055,6 +1067,7 @@ func (d *portworx) ExitMaintenance(n node.Node) error {
 	if _, err := task.DoRetryWithTimeout(t, maintenanceWaitTimeout, defaultRetryInterval); err != nil {
 		return err
 	}
+	revertutils.Resolve("maintenance/" + n.Name)
 	return nil
 }
 
@@ -1507,6 +1520,281 @@ func (d *portworx) ValidateCreateCloudsnapUsingPxctl(volumeName string) error {
 	return nil
 }
 
+func (d *portworx) ValidateCloudsnapInObjectstore(volumeName string, params map[string]string, backupLocation *stork_api.BackupLocation) error {
+	token := d.getTokenForVolume(volumeName, params)
+	ctx := d.getContextWithToken(context.Background(), token)
+	inspectResp, err := d.getVolDriver().Inspect(ctx, &api.SdkVolumeInspectRequest{VolumeId: volumeName})
+	if err != nil {
+		return fmt.Errorf("failed to inspect volume %s, Err: %v", volumeName, err)
+	}
+	srcVol := inspectResp.GetVolume()
+	backups, err := d.enumerateCloudsnaps(ctx, &api.SdkCloudBackupEnumerateWithFiltersRequest{
+		SrcVolumeId:  srcVol.GetId(),
+		All:          true,
+		StatusFilter: api.SdkCloudBackupStatusType_SdkCloudBackupStatusTypeDone,
+	})
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("no completed cloudsnaps found for volume %s", volumeName)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].GetTimestamp().AsTime().Before(backups[j].GetTimestamp().AsTime())
+	})
+
+	objectstoreDriver, err := objectstore.Get()
+	if err != nil {
+		return err
+	}
+	// cloudsnaps are incremental to the previous cloudsnap of the volume in the same bucket until a full
+	// one is taken, so a single missing object breaks the restore of all the later cloudsnaps
+	bucketBackups := make(map[string][]*api.SdkCloudBackupInfo)
+	var bucketNames []string
+	for _, backup := range backups {
+		bucketName := strings.SplitN(backup.GetId(), "/", 2)[0]
+		if _, ok := bucketBackups[bucketName]; !ok {
+			bucketNames = append(bucketNames, bucketName)
+		}
+		bucketBackups[bucketName] = append(bucketBackups[bucketName], backup)
+	}
+	for _, bucketName := range bucketNames {
+		ids, err := objectstoreDriver.ListCloudsnapsByVolume(backupLocation, bucketName, srcVol.GetId())
+		if err != nil {
+			return fmt.Errorf("failed to list cloudsnaps of volume %s in bucket %s, Err: %v", volumeName, bucketName, err)
+		}
+		stored := make(map[string]bool)
+		for _, id := range ids {
+			stored[id] = true
+		}
+		if err := validateCloudsnapChains(bucketBackups[bucketName], stored); err != nil {
+			return fmt.Errorf("cloudsnaps of volume %s in bucket %s are broken: %v", volumeName, bucketName, err)
+		}
+	}
+	log.Infof("All %d cloudsnaps of volume %s are present in the objectstore", len(backups), volumeName)
+	return d.validateCloudsnapRestore(ctx, srcVol)
+}
+
+// validateCloudsnapChains follows each of the given cloudsnaps, sorted by creation time, back to the full cloudsnap
+// it is incremental to, and fails when the chain does not reach a full cloudsnap or one of its links is not stored
+func validateCloudsnapChains(backups []*api.SdkCloudBackupInfo, stored map[string]bool) error {
+	for i, backup := range backups {
+		full := false
+		for j := i; j >= 0 && !full; j-- {
+			if !stored[backups[j].GetId()] {
+				return fmt.Errorf("cloudsnap %s in the chain of cloudsnap %s is missing from the objectstore", backups[j].GetId(), backup.GetId())
+			}
+			full = backups[j].GetMetadata()[cloudsnapFullMetadataKey] == "true"
+		}
+		if !full {
+			return fmt.Errorf("chain of cloudsnap %s does not lead back to a full cloudsnap", backup.GetId())
+		}
+	}
+	return nil
+}
+
+// validateCloudsnapRestore takes a local snapshot of the volume, backs it up with the credential of the cloudsnaps
+// of the volume, restores the cloudsnap into a new volume and compares the checksums of the snapshot and the restored
+// volume. The snapshot freezes the data, so the application can keep writing to the volume meanwhile.
+func (d *portworx) validateCloudsnapRestore(ctx context.Context, srcVol *api.Volume) error {
+	statusResp, err := d.csbackupManager.Status(ctx, &api.SdkCloudBackupStatusRequest{VolumeId: srcVol.GetId()})
+	if err != nil {
+		return fmt.Errorf("failed to get cloudsnap status of volume %s, Err: %v", srcVol.GetLocator().GetName(), err)
+	}
+	var credentialID string
+	for _, csStatus := range statusResp.GetStatuses() {
+		if csStatus.GetOptype() == api.SdkCloudBackupOpType_SdkCloudBackupOpTypeBackupOp && csStatus.GetCredentialId() != "" {
+			credentialID = csStatus.GetCredentialId()
+			break
+		}
+	}
+
+	name := fmt.Sprintf("%s-cs-verify-%d", srcVol.GetLocator().GetName(), time.Now().Unix())
+	snapResp, err := d.getVolDriver().SnapshotCreate(ctx, &api.SdkVolumeSnapshotCreateRequest{VolumeId: srcVol.GetId(), Name: name})
+	if err != nil {
+		return fmt.Errorf("failed to snapshot volume %s, Err: %v", srcVol.GetLocator().GetName(), err)
+	}
+	snapID := snapResp.GetSnapshotId()
+	defer func() {
+		if _, err := d.getVolDriver().Delete(ctx, &api.SdkVolumeDeleteRequest{VolumeId: snapID}); err != nil {
+			log.Warnf("failed to delete snapshot %s, Err: %v", name, err)
+		}
+	}()
+
+	createResp, err := d.csbackupManager.Create(ctx, &api.SdkCloudBackupCreateRequest{
+		VolumeId:     snapID,
+		CredentialId: credentialID,
+		Full:         true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create cloudsnap of snapshot %s, Err: %v", name, err)
+	}
+	backupStatus, err := d.waitForCloudsnapTask(ctx, createResp.GetTaskId(), api.SdkCloudBackupOpType_SdkCloudBackupOpTypeBackupOp)
+	if err != nil {
+		return err
+	}
+	backupID := backupStatus.GetBackupId()
+	defer func() {
+		if _, err := d.csbackupManager.Delete(ctx, &api.SdkCloudBackupDeleteRequest{BackupId: backupID, CredentialId: credentialID}); err != nil {
+			log.Warnf("failed to delete cloudsnap %s, Err: %v", backupID, err)
+		}
+	}()
+
+	restoreName := name + "-restore"
+	restoreResp, err := d.csbackupManager.Restore(ctx, &api.SdkCloudBackupRestoreRequest{
+		BackupId:          backupID,
+		RestoreVolumeName: restoreName,
+		CredentialId:      credentialID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore cloudsnap %s, Err: %v", backupID, err)
+	}
+	restoreID := restoreResp.GetRestoreVolumeId()
+	defer func() {
+		if _, err := d.getVolDriver().Delete(ctx, &api.SdkVolumeDeleteRequest{VolumeId: restoreID}); err != nil {
+			log.Warnf("failed to delete restored volume %s, Err: %v", restoreName, err)
+		}
+	}()
+	if _, err := d.waitForCloudsnapTask(ctx, restoreResp.GetTaskId(), api.SdkCloudBackupOpType_SdkCloudBackupOpTypeRestoreOp); err != nil {
+		return err
+	}
+
+	snapChecksum, err := d.getVolumeChecksum(snapID)
+	if err != nil {
+		return err
+	}
+	restoredChecksum, err := d.getVolumeChecksum(restoreID)
+	if err != nil {
+		return err
+	}
+	if snapChecksum != restoredChecksum {
+		return fmt.Errorf("checksum %s of volume %s restored from cloudsnap %s does not match checksum %s of snapshot %s",
+			restoredChecksum, restoreName, backupID, snapChecksum, name)
+	}
+	log.Infof("Restored cloudsnap %s into volume %s with checksum %s", backupID, restoreName, restoredChecksum)
+	return nil
+}
+
+// waitForCloudsnapTask waits for the cloudsnap backup or restore task to be done and returns its status
+func (d *portworx) waitForCloudsnapTask(ctx context.Context, taskID string, opType api.SdkCloudBackupOpType) (*api.SdkCloudBackupStatus, error) {
+	t := func() (interface{}, bool, error) {
+		statusResp, err := d.csbackupManager.Status(ctx, &api.SdkCloudBackupStatusRequest{TaskId: taskID})
+		if err != nil {
+			return nil, true, err
+		}
+		for _, csStatus := range statusResp.GetStatuses() {
+			if csStatus.GetOptype() != opType {
+				continue
+			}
+			switch csStatus.GetStatus() {
+			case api.SdkCloudBackupStatusType_SdkCloudBackupStatusTypeDone:
+				return csStatus, false, nil
+			case api.SdkCloudBackupStatusType_SdkCloudBackupStatusTypeFailed,
+				api.SdkCloudBackupStatusType_SdkCloudBackupStatusTypeAborted:
+				return nil, false, fmt.Errorf("cloudsnap task %s ended with status %v: %v", taskID, csStatus.GetStatus(), csStatus.GetInfo())
+			}
+			return nil, true, fmt.Errorf("cloudsnap task %s is %v", taskID, csStatus.GetStatus())
+		}
+		return nil, true, fmt.Errorf("no %v status found for cloudsnap task %s", opType, taskID)
+	}
+	csStatus, err := task.DoRetryWithTimeout(t, asyncTimeout, defaultRetryInterval)
+	if err != nil {
+		return nil, err
+	}
+	return csStatus.(*api.SdkCloudBackupStatus), nil
+}
+
+// getVolumeChecksum attaches the volume on a storage node and returns the md5 checksum of its block device
+func (d *portworx) getVolumeChecksum(volumeID string) (string, error) {
+	nodes := node.GetStorageDriverNodes()
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("no storage driver node to attach volume %s on", volumeID)
+	}
+	n := nodes[0]
+	if _, err := d.GetPxctlCmdOutput(n, "host attach "+volumeID); err != nil {
+		return "", fmt.Errorf("failed to attach volume %s on node %s, Err: %v", volumeID, n.Name, err)
+	}
+	defer func() {
+		if _, err := d.GetPxctlCmdOutput(n, "host detach "+volumeID); err != nil {
+			log.Warnf("failed to detach volume %s from node %s, Err: %v", volumeID, n.Name, err)
+		}
+	}()
+	out, err := d.nodeDriver.RunCommand(n, "md5sum "+fmt.Sprintf(pxRawDevicePath, volumeID), node.ConnectionOpts{
+		Timeout:         asyncTimeout,
+		TimeBeforeRetry: defaultRetryInterval,
+		Sudo:            true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to checksum volume %s on node %s, Err: %v", volumeID, n.Name, err)
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("no checksum of volume %s in output [%s]", volumeID, out)
+	}
+	return fields[0], nil
+}
+
+func (d *portworx) ValidateCloudsnapDeleted(cloudsnapID string, params map[string]string, backupLocation *stork_api.BackupLocation) error {
+	// cloudsnap IDs have the form <bucket>/<volumeID>-<snapID>
+	parts := strings.SplitN(cloudsnapID, "/", 2)
+	if len(parts) != 2 || strings.LastIndex(parts[1], "-") <= 0 {
+		return fmt.Errorf("invalid cloudsnap ID %s", cloudsnapID)
+	}
+	bucketName := parts[0]
+	volumeID := parts[1][:strings.LastIndex(parts[1], "-")]
+	token := d.getTokenForVolume(volumeID, params)
+	ctx := d.getContextWithToken(context.Background(), token)
+
+	objectstoreDriver, err := objectstore.Get()
+	if err != nil {
+		return err
+	}
+	t := func() (interface{}, bool, error) {
+		backups, err := d.enumerateCloudsnaps(ctx, &api.SdkCloudBackupEnumerateWithFiltersRequest{
+			CloudBackupId: cloudsnapID,
+			All:           true,
+		})
+		if err != nil {
+			return nil, true, err
+		}
+		if len(backups) > 0 {
+			return nil, true, fmt.Errorf("cloudsnap %s is still listed by the volume driver", cloudsnapID)
+		}
+		// the objects are garbage collected from the bucket of the cluster asynchronously
+		ids, err := objectstoreDriver.ListCloudsnapsByCluster(backupLocation, bucketName)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, id := range ids {
+			if id == cloudsnapID {
+				return nil, true, fmt.Errorf("objects of cloudsnap %s are still present in bucket %s", cloudsnapID, bucketName)
+			}
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, asyncTimeout, defaultRetryInterval); err != nil {
+		return err
+	}
+	log.Infof("Cloudsnap %s is deleted from the objectstore", cloudsnapID)
+	return nil
+}
+
+// enumerateCloudsnaps returns all the cloudsnaps matching the given filters, following continuation tokens
+func (d *portworx) enumerateCloudsnaps(ctx context.Context, req *api.SdkCloudBackupEnumerateWithFiltersRequest) ([]*api.SdkCloudBackupInfo, error) {
+	var backups []*api.SdkCloudBackupInfo
+	for {
+		resp, err := d.csbackupManager.EnumerateWithFilters(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to enumerate cloudsnaps, Err: %v", err)
+		}
+		backups = append(backups, resp.GetBackups()...)
+		if resp.GetContinuationToken() == "" {
+			return backups, nil
+		}
+		req.ContinuationToken = resp.GetContinuationToken()
+	}
+}
+
 func (d *portworx) ValidateGetByteUsedForVolume(volumeName string, params map[string]string) (uint64, error) {
 	var token string
 	token = d.getTokenForVolume(volumeName, params)
@@ -1713,7 +2001,10 @@ func (d *portworx) StopDriver(nodes []node.Node, force bool, triggerOpts *driver
 				log.Infof("Sleeping for %v for volume driver to gracefully go down.", waitVolDriverToCrash/6)
 				time.Sleep(waitVolDriverToCrash / 6)
 			}
-
+			stoppedNode := n
+			revertutils.Register("stopdriver/"+n.Name, fmt.Sprintf("stopped volume driver on node [%s]", n.Name), func() error {
+				return d.StartDriver(stoppedNode)
+			})
 		}
 		return nil
 	}
@@ -2623,6 +2914,7 @@ func (d *portworx) testAndSetEndpoint(endpoint string, sdkport, apiport int32) e
 	d.nodeManager = api.NewOpenStorageNodeClient(conn)
 	d.mountAttachManager = api.NewOpenStorageMountAttachClient(conn)
 	d.clusterPairManager = api.NewOpenStorageClusterPairClient(conn)
+	d.clusterDomainManager = api.NewOpenStorageClusterDomainsClient(conn)
 	d.alertsManager = api.NewOpenStorageAlertsClient(conn)
 	d.csbackupManager = api.NewOpenStorageCloudBackupClient(conn)
 	d.licenseManager = pxapi.NewPortworxLicenseClient(conn)
@@ -2687,12 +2979,17 @@ func (d *portworx) StartDriver(n node.Node) error {
 	if err != nil {
 		return err
 	}
-	return d.nodeDriver.Systemctl(n, pxSystemdServiceName, node.SystemctlOpts{
+	err = d.nodeDriver.Systemctl(n, pxSystemdServiceName, node.SystemctlOpts{
 		Action: "start",
 		ConnectionOpts: node.ConnectionOpts{
 			Timeout:         startDriverTimeout,
 			TimeBeforeRetry: defaultRetryInterval,
 		}})
+	if err != nil {
+		return err
+	}
+	revertutils.Resolve("stopdriver/" + n.Name)
+	return nil
 }
 
 // UpgradeDriver upgrades PX to a specific version, based on a given Spec Generator URL
@@ -2918,6 +3215,44 @@ func (d *portworx) RestartDriver(n node.Node, triggerOpts *driver_api.TriggerOpt
 		triggerOpts)
 }
 
+// GetClusterDomains returns the cluster domains of a stretched cluster and whether each of them is active
+func (d *portworx) GetClusterDomains() (map[string]bool, error) {
+	clusterDomainManager := d.getClusterDomainManager()
+	resp, err := clusterDomainManager.Enumerate(d.getContext(), &api.SdkClusterDomainsEnumerateRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate cluster domains. Err: %v", err)
+	}
+	domains := make(map[string]bool)
+	for _, name := range resp.GetClusterDomainNames() {
+		domain, err := clusterDomainManager.Inspect(d.getContext(), &api.SdkClusterDomainInspectRequest{ClusterDomainName: name})
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect cluster domain %s. Err: %v", name, err)
+		}
+		domains[name] = domain.GetIsActive()
+	}
+	return domains, nil
+}
+
+// ActivateClusterDomain activates the given cluster domain of a stretched cluster
+func (d *portworx) ActivateClusterDomain(name string) error {
+	log.Infof("Activating cluster domain %s", name)
+	_, err := d.getClusterDomainManager().Activate(d.getContext(), &api.SdkClusterDomainActivateRequest{ClusterDomainName: name})
+	if err != nil {
+		return fmt.Errorf("failed to activate cluster domain %s. Err: %v", name, err)
+	}
+	return nil
+}
+
+// DeactivateClusterDomain deactivates the given cluster domain of a stretched cluster
+func (d *portworx) DeactivateClusterDomain(name string) error {
+	log.Infof("Deactivating cluster domain %s", name)
+	_, err := d.getClusterDomainManager().Deactivate(d.getContext(), &api.SdkClusterDomainDeactivateRequest{ClusterDomainName: name})
+	if err != nil {
+		return fmt.Errorf("failed to deactivate cluster domain %s. Err: %v", name, err)
+	}
+	return nil
+}
+
 // GetClusterPairingInfo returns cluster pair information
 func (d *portworx) GetClusterPairingInfo(kubeConfigPath, token string, isPxLBService bool, reversePair bool) (map[string]string, error) {
 	pairInfo := make(map[string]string)
@@ -3213,6 +3548,13 @@ func (d *portworx) getClusterPairManager() api.OpenStorageClusterPairClient {
 
 }
 
+func (d *portworx) getClusterDomainManager() api.OpenStorageClusterDomainsClient {
+	if d.refreshEndpoint {
+		d.setDriver()
+	}
+	return d.clusterDomainManager
+}
+
 func (d *portworx) getClusterPairManagerByAddress(addr, token string) (api.OpenStorageClusterPairClient, error) {
 	pxPort, err := d.getSDKContainerPort()
 	if err != nil {
@@ -4663,7 +5005,9 @@ func (d *portworx) AddBlockDrives(n *node.Node, drivePath []string) error {
 	eligibleDrives := make(map[string]*node.BlockDrive)
 
 	for _, drv := range blockDrives {
-		if !strings.Contains(drv.Path, "pxd") && drv.MountPoint == "" && drv.FSType == "" && drv.Type == "disk" {
+		// drives wrapped by the node driver are device-mapper devices Portworx can take as well
+		isWrapped := strings.HasPrefix(drv.Path, node.WrappedDrivePathPrefix)
+		if !strings.Contains(drv.Path, "pxd") && drv.MountPoint == "" && drv.FSType == "" && (drv.Type == "disk" || isWrapped) {
 			isPartitioned, err := isDiskPartitioned(*n, drv.Path, d)
 			if err != nil {
 				return err
@@ -277,3 +277,25 @@ func TestEstimatedVolumeSize(t *testing.T) {
 		require.Equal(t, tc.expectedResizeCount, resizeCount)
 	}
 }
+
+func TestValidateCloudsnapChains(t *testing.T) {
+	full := map[string]string{cloudsnapFullMetadataKey: "true"}
+	backups := []*api.SdkCloudBackupInfo{
+		{Id: "bucket/1-10", Metadata: full},
+		{Id: "bucket/1-11"},
+		{Id: "bucket/1-12", Metadata: full},
+		{Id: "bucket/1-13"},
+	}
+	stored := map[string]bool{"bucket/1-10": true, "bucket/1-11": true, "bucket/1-12": true, "bucket/1-13": true}
+	require.NoError(t, validateCloudsnapChains(backups, stored))
+
+	delete(stored, "bucket/1-10")
+	require.Error(t, validateCloudsnapChains(backups, stored), "the full cloudsnap of 1-11 is missing")
+	require.Error(t, validateCloudsnapChains(backups[2:], map[string]bool{"bucket/1-13": true}),
+		"the full cloudsnap of 1-13 is missing")
+	require.NoError(t, validateCloudsnapChains(backups[2:], map[string]bool{"bucket/1-12": true, "bucket/1-13": true}),
+		"1-11 is not in the chain of the later cloudsnaps")
+
+	require.Error(t, validateCloudsnapChains(backups[1:2], map[string]bool{"bucket/1-11": true}),
+		"the chain of an incremental cloudsnap has to reach a full one")
+}
@@ -8,6 +8,7 @@ import (
 	apapi "github.com/libopenstorage/autopilot-api/pkg/apis/autopilot/v1alpha1"
 	"github.com/libopenstorage/openstorage/api"
 	v1 "github.com/libopenstorage/operator/pkg/apis/core/v1"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/pkg/errors"
@@ -61,6 +62,26 @@ type DriveConfig struct {
 	Labels map[string]string
 }
 
+// Roles of the identities tokens are minted for when the volume driver runs with authorization enabled
+const (
+	// AuthRoleAdmin has access to all the resources
+	AuthRoleAdmin = "system.admin"
+	// AuthRoleUser has access to the resources it owns
+	AuthRoleUser = "system.user"
+	// AuthRoleView has read only access
+	AuthRoleView = "system.view"
+)
+
+// AuthIdentity is an identity a token is minted for when the volume driver runs with authorization enabled
+type AuthIdentity struct {
+	// Name is the subject and name of the identity
+	Name string
+	// Roles are the roles granted to the identity
+	Roles []string
+	// Groups are the groups the identity belongs to, e.g. the namespace of a namespace scoped identity
+	Groups []string
+}
+
 // Options to pass to APIs
 type Options struct {
 	ValidateReplicationUpdateTimeout time.Duration
@@ -99,6 +120,23 @@ type Driver interface {
 	// DetachVolume detaches the volume for given volumeID
 	DetachVolume(volumeID string) error
 
+	// GenerateToken mints a token for the given identity that is valid for the given duration
+	GenerateToken(identity AuthIdentity, expiry time.Duration) (string, error)
+
+	// CreateVolumeWithToken creates a volume as the identity of the given token and returns its ID. The given groups
+	// are granted read access to the volume. Errors from the volume driver are returned as is so callers can check
+	// for authorization failures.
+	CreateVolumeWithToken(token, volName string, size uint64, haLevel int64, groups []string) (string, error)
+
+	// InspectVolumeWithToken inspects the volume as the identity of the given token
+	InspectVolumeWithToken(token, volumeID string) (*api.Volume, error)
+
+	// CreateSnapshotWithToken creates a snapshot of the volume as the identity of the given token and returns its ID
+	CreateSnapshotWithToken(token, volumeID, snapName string) (string, error)
+
+	// DeleteVolumeWithToken deletes the volume as the identity of the given token
+	DeleteVolumeWithToken(token, volumeID string) error
+
 	// DeleteVolume deletes the volume for given volumeID
 	DeleteVolume(volumeID string) error
 
@@ -131,6 +169,13 @@ type Driver interface {
 	// ValidateCreateCloudsnapUsingPxctl validates whether a cloudsnap backup can be created properly(or errored expectely) using pxctl
 	ValidateCreateCloudsnapUsingPxctl(name string) error
 
+	// ValidateCloudsnapInObjectstore validates that every cloudsnap of the given volume, including the
+	// whole incremental chain, is present in the objectstore and that a cloudsnap of the volume restores its data
+	ValidateCloudsnapInObjectstore(name string, params map[string]string, backupLocation *stork_api.BackupLocation) error
+
+	// ValidateCloudsnapDeleted validates that the given cloudsnap is deleted and its objects are garbage collected from the objectstore
+	ValidateCloudsnapDeleted(cloudsnapID string, params map[string]string, backupLocation *stork_api.BackupLocation) error
+
 	// ValidateCreateGroupSnapshotUsingPxctl validates whether a groupsnap backup can be created properly (or errored expectedly) using pxctl
 	ValidateCreateGroupSnapshotUsingPxctl() error
 
@@ -266,6 +311,15 @@ type Driver interface {
 	// GetClusterPairingInfo returns cluster pairing information from remote cluster
 	GetClusterPairingInfo(kubeConfigPath, token string, isPxLBService, reversePair bool) (map[string]string, error)
 
+	// GetClusterDomains returns the cluster domains of a stretched cluster and whether each of them is active
+	GetClusterDomains() (map[string]bool, error)
+
+	// ActivateClusterDomain activates the given cluster domain of a stretched cluster
+	ActivateClusterDomain(name string) error
+
+	// DeactivateClusterDomain deactivates the given cluster domain of a stretched cluster
+	DeactivateClusterDomain(name string) error
+
 	// DecommissionNode decommissions the given node from the cluster
 	DecommissionNode(n *node.Node) error
 
@@ -342,6 +396,14 @@ type Driver interface {
 	// UpdateSharedv4FailoverStrategyUsingPxctl updates the sharedv4 failover strategy using pxctl
 	UpdateSharedv4FailoverStrategyUsingPxctl(volumeName string, strategy api.Sharedv4FailoverStrategy_Value) error
 
+	// ValidateVolumeEncryption validates that the volume is encrypted at rest on the node it is attached to
+	// and that the raw block device does not contain the given plaintext marker
+	ValidateVolumeEncryption(vol *Volume, params map[string]string, marker string) error
+
+	// RotateEncryptionKey rotates the encryption key of the volume, along with every volume sharing its secret, and
+	// stores the new key in the given secret store
+	RotateEncryptionKey(vol *Volume, params map[string]string, secretType string) error
+
 	// RunSecretsLogin runs secrets login using pxctl
 	RunSecretsLogin(n node.Node, secretType string) error
 
@@ -0,0 +1,110 @@
+package alertutils
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+// Expectation describes an alert that an operation is expected to raise
+type Expectation struct {
+	// Name identifies the expectation in reports
+	Name string
+	// ResourceType is the type of the resource the alert is raised for
+	ResourceType api.ResourceType
+	// ResourceID restricts the match to a single resource when set
+	ResourceID string
+	// MessagePattern is matched against the alert message
+	MessagePattern *regexp.Regexp
+}
+
+var (
+	// NodeDown is raised when a node goes offline or leaves the quorum
+	NodeDown = Expectation{
+		Name:           "NodeDown",
+		ResourceType:   api.ResourceType_RESOURCE_TYPE_NODE,
+		MessagePattern: regexp.MustCompile(`(?i)(down|offline|not in quorum|shut ?down|not responding)`),
+	}
+	// PoolExpand is raised when a storage pool expansion is started or completed
+	PoolExpand = Expectation{
+		Name:           "PoolExpand",
+		ResourceType:   api.ResourceType_RESOURCE_TYPE_POOL,
+		MessagePattern: regexp.MustCompile(`(?i)expan`),
+	}
+	// VolumeHAUpdate is raised when the replication level of a volume changes
+	VolumeHAUpdate = Expectation{
+		Name:           "VolumeHAUpdate",
+		ResourceType:   api.ResourceType_RESOURCE_TYPE_VOLUME,
+		MessagePattern: regexp.MustCompile(`(?i)(\bha\b|replica|replication).*(updat|increas|decreas|chang)`),
+	}
+	// DriveFailure is raised when a drive fails or goes offline
+	DriveFailure = Expectation{
+		Name:           "DriveFailure",
+		ResourceType:   api.ResourceType_RESOURCE_TYPE_DRIVE,
+		MessagePattern: regexp.MustCompile(`(?i)(fail|offline|error|not accessible)`),
+	}
+	// PoolOffline is raised when a storage pool goes offline, e.g. after one of its drives failed
+	PoolOffline = Expectation{
+		Name:           "PoolOffline",
+		ResourceType:   api.ResourceType_RESOURCE_TYPE_POOL,
+		MessagePattern: regexp.MustCompile(`(?i)(offline|down|fail)`),
+	}
+)
+
+// ForResource returns a copy of the expectation restricted to the given resource
+func (e Expectation) ForResource(resourceID string) Expectation {
+	e.ResourceID = resourceID
+	return e
+}
+
+// Matches returns true if the given alert satisfies the expectation
+func (e Expectation) Matches(alert *api.Alert) bool {
+	if alert.GetResource() != e.ResourceType {
+		return false
+	}
+	if e.ResourceID != "" && alert.GetResourceId() != e.ResourceID {
+		return false
+	}
+	return e.MessagePattern == nil || e.MessagePattern.MatchString(alert.GetMessage())
+}
+
+func (e Expectation) String() string {
+	if e.ResourceID != "" {
+		return fmt.Sprintf("%s on %v [%s]", e.Name, e.ResourceType, e.ResourceID)
+	}
+	return fmt.Sprintf("%s on %v", e.Name, e.ResourceType)
+}
+
+// Diff compares the expected alerts with the alerts actually raised. It returns the expectations
+// no alert satisfied, and the alarms (the most critical severity) no expectation accounts for.
+func Diff(expected []Expectation, alerts []*api.Alert) (missing []Expectation, unexpected []*api.Alert) {
+	for _, e := range expected {
+		found := false
+		for _, alert := range alerts {
+			if e.Matches(alert) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, e)
+		}
+	}
+	for _, alert := range alerts {
+		if alert.GetSeverity() != api.SeverityType_SEVERITY_TYPE_ALARM {
+			continue
+		}
+		covered := false
+		for _, e := range expected {
+			if e.Matches(alert) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			unexpected = append(unexpected, alert)
+		}
+	}
+	return missing, unexpected
+}
@@ -0,0 +1,54 @@
+package alertutils
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	nodeDown := &api.Alert{
+		Resource:   api.ResourceType_RESOURCE_TYPE_NODE,
+		ResourceId: "node-1",
+		Severity:   api.SeverityType_SEVERITY_TYPE_ALARM,
+		Message:    "Node node-1 is not in quorum",
+	}
+	poolExpand := &api.Alert{
+		Resource:   api.ResourceType_RESOURCE_TYPE_POOL,
+		ResourceId: "pool-1",
+		Severity:   api.SeverityType_SEVERITY_TYPE_NOTIFY,
+		Message:    "Storage pool expansion completed successfully",
+	}
+	driveFailed := &api.Alert{
+		Resource:   api.ResourceType_RESOURCE_TYPE_DRIVE,
+		ResourceId: "/dev/sdc",
+		Severity:   api.SeverityType_SEVERITY_TYPE_ALARM,
+		Message:    "Drive /dev/sdc failed",
+	}
+	alerts := []*api.Alert{nodeDown, poolExpand, driveFailed}
+
+	missing, unexpected := Diff([]Expectation{NodeDown, PoolExpand}, alerts)
+	assert.Empty(t, missing, "node down and pool expand alerts were raised")
+	assert.Equal(t, []*api.Alert{driveFailed}, unexpected, "drive failure alarm is not expected")
+
+	missing, unexpected = Diff([]Expectation{NodeDown.ForResource("node-2"), VolumeHAUpdate, DriveFailure}, alerts)
+	assert.Equal(t, []Expectation{NodeDown.ForResource("node-2"), VolumeHAUpdate}, missing,
+		"node down alert was raised for another node and no HA update alert was raised")
+	assert.Equal(t, []*api.Alert{nodeDown}, unexpected, "node down alarm of node-1 is not expected")
+
+	poolOffline := &api.Alert{
+		Resource:   api.ResourceType_RESOURCE_TYPE_POOL,
+		ResourceId: "pool-1",
+		Severity:   api.SeverityType_SEVERITY_TYPE_ALARM,
+		Message:    "Storage pool pool-1 is offline",
+	}
+	missing, unexpected = Diff([]Expectation{DriveFailure.ForResource("/dev/sdc"), PoolOffline.ForResource("pool-1")},
+		[]*api.Alert{driveFailed, poolOffline, poolExpand})
+	assert.Empty(t, missing, "drive failure and pool offline alerts were raised for the faulted drive and pool")
+	assert.Empty(t, unexpected, "drive failure and pool offline alarms are expected")
+
+	missing, unexpected = Diff(nil, []*api.Alert{poolExpand})
+	assert.Empty(t, missing, "nothing was expected")
+	assert.Empty(t, unexpected, "notifications are never reported as unexpected")
+}
@@ -0,0 +1,79 @@
+package applicationbackup
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ParseApplicationClone returns the summary of an ApplicationClone
+func ParseApplicationClone(clone *storkv1.ApplicationClone) Summary {
+	s := Summary{
+		Name:      clone.Name,
+		Namespace: clone.Namespace,
+		Status:    string(clone.Status.Status),
+	}
+	for _, volume := range clone.Status.Volumes {
+		s.add("volume", clone.Spec.SourceNamespace+"/"+volume.PersistentVolumeClaim, string(volume.Status), volume.Reason)
+	}
+	for _, resource := range clone.Status.Resources {
+		s.add(resource.Kind, resource.Name, string(resource.Status), resource.Reason)
+	}
+	sort.Strings(s.Failures)
+	return s
+}
+
+// CreateApplicationClone clones the applications of the source namespace to the destination namespace. Clones
+// can only be created in the admin namespace of stork.
+func CreateApplicationClone(
+	name string,
+	adminNamespace string,
+	sourceNamespace string,
+	destinationNamespace string,
+	replacePolicy storkv1.ApplicationCloneReplacePolicyType,
+) (*storkv1.ApplicationClone, error) {
+
+	appClone := &storkv1.ApplicationClone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: adminNamespace,
+		},
+		Spec: storkv1.ApplicationCloneSpec{
+			SourceNamespace:      sourceNamespace,
+			DestinationNamespace: destinationNamespace,
+			ReplacePolicy:        replacePolicy,
+		},
+	}
+
+	return storkops.Instance().CreateApplicationClone(appClone)
+}
+
+// WaitForAppCloneCompletion waits for the clone to reach its final stage and returns an error if it or any of
+// its volumes or resources failed
+func WaitForAppCloneCompletion(name, namespace string, timeout time.Duration) error {
+	getAppClone := func() (interface{}, bool, error) {
+		appClone, err := storkops.Instance().GetApplicationClone(name, namespace)
+		if err != nil {
+			return nil, true, err
+		}
+		if appClone.Status.Status != storkv1.ApplicationCloneStatusFailed &&
+			appClone.Status.Stage != storkv1.ApplicationCloneStageFinal {
+			return nil, true, fmt.Errorf("app clone %s in %s not complete yet, stage [%s] status [%s]. Retrying",
+				name, namespace, appClone.Status.Stage, appClone.Status.Status)
+		}
+		return ParseApplicationClone(appClone), false, nil
+	}
+	summary, err := task.DoRetryWithTimeout(getAppClone, timeout, applicationRestoreRetryInterval)
+	if err != nil {
+		return err
+	}
+	s := summary.(Summary)
+	log.Infof("App clone %s in %s cloned %d volumes and %d resources", name, namespace, s.Volumes, s.Resources)
+	return s.Err()
+}
@@ -0,0 +1,138 @@
+package applicationbackup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	applicationRestoreRetryInterval = 10 * time.Second
+)
+
+// Summary is the outcome of an ApplicationRestore or an ApplicationClone
+type Summary struct {
+	Name      string
+	Namespace string
+	Status    string
+	Reason    string
+	// Volumes and Resources are the numbers of volumes and resources restored or retained
+	Volumes   int
+	Resources int
+	// Failures are the volumes and resources which failed to be restored, with their reasons
+	Failures []string
+}
+
+// Err returns an error if the restore failed or any of its volumes or resources failed to be restored. Volumes
+// and resources retained by the replace policy are not failures.
+func (s Summary) Err() error {
+	if s.Status != string(storkv1.ApplicationRestoreStatusFailed) && len(s.Failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s/%s ended with status [%s]: %s\n%s", s.Namespace, s.Name, s.Status, s.Reason, strings.Join(s.Failures, "\n"))
+}
+
+func (s *Summary) add(kind, name, status, reason string) {
+	switch status {
+	case string(storkv1.ApplicationRestoreStatusSuccessful), string(storkv1.ApplicationRestoreStatusRetained):
+		if kind == "volume" {
+			s.Volumes++
+		} else {
+			s.Resources++
+		}
+	default:
+		s.Failures = append(s.Failures, fmt.Sprintf("%s [%s] ended with status [%s]: %s", kind, name, status, reason))
+	}
+}
+
+// ParseApplicationRestore returns the summary of an ApplicationRestore
+func ParseApplicationRestore(restore *storkv1.ApplicationRestore) Summary {
+	s := Summary{
+		Name:      restore.Name,
+		Namespace: restore.Namespace,
+		Status:    string(restore.Status.Status),
+		Reason:    restore.Status.Reason,
+	}
+	for _, volume := range restore.Status.Volumes {
+		s.add("volume", volume.SourceNamespace+"/"+volume.PersistentVolumeClaim, string(volume.Status), volume.Reason)
+	}
+	for _, resource := range restore.Status.Resources {
+		s.add(resource.Kind, resource.Namespace+"/"+resource.Name, string(resource.Status), resource.Reason)
+	}
+	sort.Strings(s.Failures)
+	return s
+}
+
+// CreateApplicationRestore restores the backup, mapping its namespaces as given. The restore is created in
+// the namespace of the backup.
+func CreateApplicationRestore(
+	name string,
+	backup *storkv1.ApplicationBackup,
+	namespaceMapping map[string]string,
+	replacePolicy storkv1.ApplicationRestoreReplacePolicyType,
+) (*storkv1.ApplicationRestore, error) {
+
+	appRestore := &storkv1.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: backup.Namespace,
+		},
+		Spec: storkv1.ApplicationRestoreSpec{
+			BackupName:       backup.Name,
+			BackupLocation:   backup.Spec.BackupLocation,
+			NamespaceMapping: namespaceMapping,
+			ReplacePolicy:    replacePolicy,
+		},
+	}
+
+	return storkops.Instance().CreateApplicationRestore(appRestore)
+}
+
+// WaitForAppRestoreCompletion waits for the restore to reach its final stage and returns an error if it or
+// any of its volumes or resources failed
+func WaitForAppRestoreCompletion(name, namespace string, timeout time.Duration) error {
+	getAppRestore := func() (interface{}, bool, error) {
+		appRestore, err := storkops.Instance().GetApplicationRestore(name, namespace)
+		if err != nil {
+			return nil, true, err
+		}
+		if appRestore.Status.Status != storkv1.ApplicationRestoreStatusFailed &&
+			appRestore.Status.Stage != storkv1.ApplicationRestoreStageFinal {
+			return nil, true, fmt.Errorf("app restore %s in %s not complete yet, stage [%s] status [%s]. Retrying",
+				name, namespace, appRestore.Status.Stage, appRestore.Status.Status)
+		}
+		return ParseApplicationRestore(appRestore), false, nil
+	}
+	summary, err := task.DoRetryWithTimeout(getAppRestore, timeout, applicationRestoreRetryInterval)
+	if err != nil {
+		return err
+	}
+	s := summary.(Summary)
+	log.Infof("App restore %s in %s restored %d volumes and %d resources", name, namespace, s.Volumes, s.Resources)
+	return s.Err()
+}
+
+// ValidateRestoredApplications waits for the restored applications to run and validates their volumes with the
+// scheduler driver
+func ValidateRestoredApplications(d scheduler.Driver, contexts []*scheduler.Context, timeout, retryInterval time.Duration) error {
+	for _, ctx := range contexts {
+		log.Infof("Validating restored app %s in namespace %s", ctx.App.Key, ctx.ScheduleOptions.Namespace)
+		err := d.ValidateVolumes(ctx, timeout, retryInterval, &scheduler.VolumeOptions{SkipClusterScopedObjects: true})
+		if err != nil {
+			return fmt.Errorf("failed to validate the volumes of restored app %s. Err: %v", ctx.App.Key, err)
+		}
+		err = d.WaitForRunning(ctx, timeout, retryInterval)
+		if err != nil {
+			return fmt.Errorf("restored app %s is not running. Err: %v", ctx.App.Key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package applicationbackup
+
+import (
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseApplicationRestore(t *testing.T) {
+	restore := &storkv1.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "mysql"},
+		Status: storkv1.ApplicationRestoreStatus{
+			Stage:  storkv1.ApplicationRestoreStageFinal,
+			Status: storkv1.ApplicationRestoreStatusPartialSuccess,
+			Volumes: []*storkv1.ApplicationRestoreVolumeInfo{
+				{SourceNamespace: "mysql", PersistentVolumeClaim: "data", Status: storkv1.ApplicationRestoreStatusSuccessful},
+				{SourceNamespace: "mysql", PersistentVolumeClaim: "logs", Status: storkv1.ApplicationRestoreStatusRetained},
+			},
+			Resources: []*storkv1.ApplicationRestoreResourceInfo{
+				{ObjectInfo: storkv1.ObjectInfo{Name: "mysql", Namespace: "mysql", GroupVersionKind: metav1.GroupVersionKind{Kind: "Deployment"}},
+					Status: storkv1.ApplicationRestoreStatusSuccessful},
+			},
+		},
+	}
+	s := ParseApplicationRestore(restore)
+	assert.Equal(t, 2, s.Volumes)
+	assert.Equal(t, 1, s.Resources)
+	assert.NoError(t, s.Err())
+
+	restore.Status.Resources = append(restore.Status.Resources, &storkv1.ApplicationRestoreResourceInfo{
+		ObjectInfo: storkv1.ObjectInfo{Name: "mysql", Namespace: "mysql", GroupVersionKind: metav1.GroupVersionKind{Kind: "Service"}},
+		Status:     storkv1.ApplicationRestoreStatusFailed,
+		Reason:     "port already allocated",
+	})
+	s = ParseApplicationRestore(restore)
+	err := s.Err()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Service [mysql/mysql] ended with status [Failed]: port already allocated")
+
+	restore.Status = storkv1.ApplicationRestoreStatus{Status: storkv1.ApplicationRestoreStatusFailed, Reason: "backup not found"}
+	err = ParseApplicationRestore(restore).Err()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mysql/restore ended with status [Failed]: backup not found")
+}
+
+func TestParseApplicationClone(t *testing.T) {
+	clone := &storkv1.ApplicationClone{
+		ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "kube-system"},
+		Spec:       storkv1.ApplicationCloneSpec{SourceNamespace: "mysql", DestinationNamespace: "mysql-clone"},
+		Status: storkv1.ApplicationCloneStatus{
+			Stage:  storkv1.ApplicationCloneStageFinal,
+			Status: storkv1.ApplicationCloneStatusPartialSuccess,
+			Volumes: []*storkv1.ApplicationCloneVolumeInfo{
+				{PersistentVolumeClaim: "data", Status: storkv1.ApplicationCloneStatusSuccessful},
+				{PersistentVolumeClaim: "logs", Status: storkv1.ApplicationCloneStatusFailed, Reason: "snapshot failed"},
+			},
+		},
+	}
+	s := ParseApplicationClone(clone)
+	assert.Equal(t, 1, s.Volumes)
+	assert.Equal(t, []string{"volume [mysql/logs] ended with status [Failed]: snapshot failed"}, s.Failures)
+	assert.Error(t, s.Err())
+}
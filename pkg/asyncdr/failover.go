@@ -0,0 +1,424 @@
+package asyncdr
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/libopenstorage/stork/pkg/storkctl"
+	"github.com/portworx/sched-ops/k8s/apps"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/pkg/log"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	appScaleTimeout       = 10 * time.Minute
+	appScaleRetryInterval = 10 * time.Second
+)
+
+// DRConfig is the asynchronous DR of the applications of a namespace from a source to a destination cluster
+type DRConfig struct {
+	Namespace string
+	// ClusterPair is the name of the cluster pairs of the namespace, from the source to the destination cluster
+	// and back
+	ClusterPair string
+	// MigrationSchedule is the migration schedule of the namespace on the source cluster, if any
+	MigrationSchedule         string
+	SourceKubeConfigPath      string
+	DestinationKubeConfigPath string
+	SetSourceKubeConfig       func() error
+	SetDestinationKubeConfig  func() error
+	// PairClusters creates the cluster pair of the namespace from the source to the destination cluster, or
+	// back when reverse is set. It leaves the clients on the cluster the pair was created on.
+	PairClusters func(reverse bool) error
+	Timeout      time.Duration
+}
+
+// AppReplicas is the state of the replicas of a deployment or a statefulset
+type AppReplicas struct {
+	Kind          string
+	Name          string
+	Replicas      int32
+	ReadyReplicas int32
+	// CurrentReplicas is the number of pods of the application
+	CurrentReplicas int32
+	// MigrationReplicas is the number of replicas stork keeps for the activation of a migrated application, -1
+	// when the application was not migrated
+	MigrationReplicas int32
+}
+
+// Key returns the key of the application
+func (a AppReplicas) Key() string {
+	return a.Kind + "/" + a.Name
+}
+
+// parseMigrationReplicas returns the replicas of the migration replicas annotation, -1 if absent
+func parseMigrationReplicas(annotations map[string]string) (int32, error) {
+	value, ok := annotations[migration.StorkMigrationReplicasAnnotation]
+	if !ok {
+		return -1, nil
+	}
+	replicas, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return -1, fmt.Errorf("invalid %s annotation [%s]: %v", migration.StorkMigrationReplicasAnnotation, value, err)
+	}
+	return int32(replicas), nil
+}
+
+// ValidateDeactivated checks that no application has replicas or pods
+func ValidateDeactivated(apps []AppReplicas) error {
+	var violations []string
+	for _, app := range apps {
+		if app.Replicas != 0 || app.CurrentReplicas != 0 {
+			violations = append(violations, fmt.Sprintf("%s has %d replicas and %d pods", app.Key(), app.Replicas, app.CurrentReplicas))
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("applications are not deactivated:\n%s", strings.Join(violations, "\n"))
+	}
+	return nil
+}
+
+// ValidateActivated checks that every migrated application runs the replicas stork kept for it, and that there
+// is at least one
+func ValidateActivated(apps []AppReplicas) error {
+	var violations []string
+	migrated := 0
+	for _, app := range apps {
+		if app.MigrationReplicas < 0 {
+			continue
+		}
+		migrated++
+		if app.Replicas != app.MigrationReplicas || app.ReadyReplicas != app.MigrationReplicas {
+			violations = append(violations, fmt.Sprintf("%s has %d replicas and %d ready, expected %d",
+				app.Key(), app.Replicas, app.ReadyReplicas, app.MigrationReplicas))
+		}
+	}
+	if migrated == 0 {
+		return fmt.Errorf("no migrated application was found")
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("applications are not activated:\n%s", strings.Join(violations, "\n"))
+	}
+	return nil
+}
+
+// ListAppReplicas returns the state of the deployments and the statefulsets of the namespace, ordered by key
+func ListAppReplicas(namespace string) ([]AppReplicas, error) {
+	var replicas []AppReplicas
+	deployments, err := apps.Instance().ListDeployments(namespace, meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments.Items {
+		app := AppReplicas{Kind: "Deployment", Name: d.Name, ReadyReplicas: d.Status.ReadyReplicas, CurrentReplicas: d.Status.Replicas}
+		if d.Spec.Replicas != nil {
+			app.Replicas = *d.Spec.Replicas
+		}
+		if app.MigrationReplicas, err = parseMigrationReplicas(d.Annotations); err != nil {
+			return nil, fmt.Errorf("%s: %v", app.Key(), err)
+		}
+		replicas = append(replicas, app)
+	}
+	statefulSets, err := apps.Instance().ListStatefulSets(namespace, meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ss := range statefulSets.Items {
+		app := AppReplicas{Kind: "StatefulSet", Name: ss.Name, ReadyReplicas: ss.Status.ReadyReplicas, CurrentReplicas: ss.Status.Replicas}
+		if ss.Spec.Replicas != nil {
+			app.Replicas = *ss.Spec.Replicas
+		}
+		if app.MigrationReplicas, err = parseMigrationReplicas(ss.Annotations); err != nil {
+			return nil, fmt.Errorf("%s: %v", app.Key(), err)
+		}
+		replicas = append(replicas, app)
+	}
+	sort.Slice(replicas, func(i, j int) bool {
+		return replicas[i].Key() < replicas[j].Key()
+	})
+	return replicas, nil
+}
+
+// waitForAppReplicas waits for the applications of the namespace to pass the validation
+func waitForAppReplicas(namespace string, validate func([]AppReplicas) error, timeout time.Duration) error {
+	t := func() (interface{}, bool, error) {
+		replicas, err := ListAppReplicas(namespace)
+		if err != nil {
+			return nil, true, err
+		}
+		if err := validate(replicas); err != nil {
+			return nil, true, fmt.Errorf("namespace %s: %v", namespace, err)
+		}
+		return nil, false, nil
+	}
+	_, err := task.DoRetryWithTimeout(t, timeout, appScaleRetryInterval)
+	return err
+}
+
+// ScaleDownApplications scales the deployments and the statefulsets of the namespace to 0, keeping their
+// replicas in the migration replicas annotation like stork does for migrated applications, and waits for their
+// pods to terminate
+func ScaleDownApplications(namespace string) error {
+	annotate := func(annotations map[string]string, replicas *int32) map[string]string {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		if _, ok := annotations[migration.StorkMigrationReplicasAnnotation]; !ok && replicas != nil {
+			annotations[migration.StorkMigrationReplicasAnnotation] = strconv.Itoa(int(*replicas))
+		}
+		return annotations
+	}
+	zero := int32(0)
+	deployments, err := apps.Instance().ListDeployments(namespace, meta_v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, d := range deployments.Items {
+		d.Annotations = annotate(d.Annotations, d.Spec.Replicas)
+		d.Spec.Replicas = &zero
+		if _, err := apps.Instance().UpdateDeployment(&d); err != nil {
+			return fmt.Errorf("failed to scale down deployment %s/%s: %v", namespace, d.Name, err)
+		}
+	}
+	statefulSets, err := apps.Instance().ListStatefulSets(namespace, meta_v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, ss := range statefulSets.Items {
+		ss.Annotations = annotate(ss.Annotations, ss.Spec.Replicas)
+		ss.Spec.Replicas = &zero
+		if _, err := apps.Instance().UpdateStatefulSet(&ss); err != nil {
+			return fmt.Errorf("failed to scale down statefulset %s/%s: %v", namespace, ss.Name, err)
+		}
+	}
+	log.Infof("Scaled down the applications of namespace %s", namespace)
+	return waitForAppReplicas(namespace, ValidateDeactivated, appScaleTimeout)
+}
+
+// runStorkctl runs a storkctl command against the cluster of the kubeconfig
+func runStorkctl(kubeConfigPath string, args ...string) error {
+	var out, errOut bytes.Buffer
+	cmd := storkctl.NewCommand(storkctl.NewFactory(), os.Stdin, &out, &errOut)
+	cmd.SetArgs(append(args, "--kubeconfig", kubeConfigPath))
+	err := cmd.Execute()
+	log.Infof("storkctl %s: %s%s", strings.Join(args, " "), out.String(), errOut.String())
+	if err != nil {
+		return fmt.Errorf("storkctl %s failed: %v", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// ActivateMigrations activates the applications migrated to the namespace like storkctl activate migrations,
+// and waits for them to run the replicas they had on the cluster they were migrated from
+func ActivateMigrations(namespace, kubeConfigPath string) error {
+	if err := runStorkctl(kubeConfigPath, "activate", "migrations", "-n", namespace); err != nil {
+		return err
+	}
+	return waitForAppReplicas(namespace, ValidateActivated, appScaleTimeout)
+}
+
+// DeactivateMigrations deactivates the applications migrated to the namespace like storkctl deactivate
+// migrations, and waits for their pods to terminate
+func DeactivateMigrations(namespace, kubeConfigPath string) error {
+	if err := runStorkctl(kubeConfigPath, "deactivate", "migrations", "-n", namespace); err != nil {
+		return err
+	}
+	return waitForAppReplicas(namespace, ValidateDeactivated, appScaleTimeout)
+}
+
+// CreateSchedulePolicy creates an interval schedule policy, unless it exists
+func CreateSchedulePolicy(name string, intervalMinutes int) (*storkapi.SchedulePolicy, error) {
+	if policy, err := storkops.Instance().GetSchedulePolicy(name); err == nil {
+		return policy, nil
+	}
+	return storkops.Instance().CreateSchedulePolicy(&storkapi.SchedulePolicy{
+		ObjectMeta: meta_v1.ObjectMeta{Name: name},
+		Policy: storkapi.SchedulePolicyItem{
+			Interval: &storkapi.IntervalPolicy{IntervalMinutes: intervalMinutes},
+		},
+	})
+}
+
+// CreateMigrationSchedule creates a schedule of migrations of the namespace through the cluster pair
+func CreateMigrationSchedule(
+	name string,
+	namespace string,
+	clusterPair string,
+	schedulePolicy string,
+	includeResources *bool,
+	startApplications *bool,
+) (*storkapi.MigrationSchedule, error) {
+
+	schedule := &storkapi.MigrationSchedule{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: storkapi.MigrationScheduleSpec{
+			Template: storkapi.MigrationTemplateSpec{
+				Spec: storkapi.MigrationSpec{
+					ClusterPair:       clusterPair,
+					IncludeResources:  includeResources,
+					StartApplications: startApplications,
+					Namespaces:        []string{namespace},
+				},
+			},
+			SchedulePolicyName: schedulePolicy,
+		},
+	}
+	return storkops.Instance().CreateMigrationSchedule(schedule)
+}
+
+// LatestScheduledMigration returns the last migration triggered by the schedule, nil if there is none
+func LatestScheduledMigration(schedule *storkapi.MigrationSchedule) *storkapi.ScheduledMigrationStatus {
+	var latest *storkapi.ScheduledMigrationStatus
+	for _, migrations := range schedule.Status.Items {
+		for _, m := range migrations {
+			if latest == nil || latest.CreationTimestamp.Before(&m.CreationTimestamp) {
+				latest = m
+			}
+		}
+	}
+	return latest
+}
+
+// ScheduledMigrationsInProgress returns the names of the migrations of the schedule which did not end
+func ScheduledMigrationsInProgress(schedule *storkapi.MigrationSchedule) []string {
+	var names []string
+	for _, migrations := range schedule.Status.Items {
+		for _, m := range migrations {
+			switch m.Status {
+			case storkapi.MigrationStatusSuccessful, storkapi.MigrationStatusFailed, storkapi.MigrationStatusPartialSuccess, storkapi.MigrationStatusPurged:
+			default:
+				names = append(names, m.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WaitForScheduledMigration waits for a migration of the schedule triggered after the given time to succeed
+func WaitForScheduledMigration(name, namespace string, after time.Time, timeout time.Duration) error {
+	t := func() (interface{}, bool, error) {
+		schedule, err := storkops.Instance().GetMigrationSchedule(name, namespace)
+		if err != nil {
+			return nil, true, err
+		}
+		latest := LatestScheduledMigration(schedule)
+		if latest == nil || latest.CreationTimestamp.Time.Before(after) {
+			return nil, true, fmt.Errorf("migration schedule %s/%s did not trigger a migration since %v", namespace, name, after)
+		}
+		if latest.Status != storkapi.MigrationStatusSuccessful {
+			return nil, true, fmt.Errorf("migration %s of schedule %s/%s is %s", latest.Name, namespace, name, latest.Status)
+		}
+		return nil, false, nil
+	}
+	_, err := task.DoRetryWithTimeout(t, timeout, migrationRetryInterval)
+	return err
+}
+
+// SuspendMigrationSchedule suspends or resumes the schedule. Once suspended, it waits for the migrations of the
+// schedule in progress to end and checks that the last one succeeded.
+func SuspendMigrationSchedule(name, namespace string, suspend bool, timeout time.Duration) error {
+	schedule, err := storkops.Instance().GetMigrationSchedule(name, namespace)
+	if err != nil {
+		return err
+	}
+	schedule.Spec.Suspend = &suspend
+	if _, err := storkops.Instance().UpdateMigrationSchedule(schedule); err != nil {
+		return fmt.Errorf("failed to update migration schedule %s/%s: %v", namespace, name, err)
+	}
+	t := func() (interface{}, bool, error) {
+		schedule, err := storkops.Instance().GetMigrationSchedule(name, namespace)
+		if err != nil {
+			return nil, true, err
+		}
+		if schedule.Spec.Suspend == nil || *schedule.Spec.Suspend != suspend {
+			return nil, true, fmt.Errorf("migration schedule %s/%s is not updated to suspend %v yet", namespace, name, suspend)
+		}
+		if !suspend {
+			return nil, false, nil
+		}
+		if inProgress := ScheduledMigrationsInProgress(schedule); len(inProgress) > 0 {
+			return nil, true, fmt.Errorf("migrations %v of schedule %s/%s are in progress", inProgress, namespace, name)
+		}
+		if latest := LatestScheduledMigration(schedule); latest == nil || latest.Status != storkapi.MigrationStatusSuccessful {
+			return nil, false, fmt.Errorf("the last migration of schedule %s/%s did not succeed: %+v", namespace, name, latest)
+		}
+		return nil, false, nil
+	}
+	_, err = task.DoRetryWithTimeout(t, timeout, migrationRetryInterval)
+	return err
+}
+
+// Failover moves the applications of the namespace to the destination cluster: it suspends the migration
+// schedule on the source cluster, scales down the source applications and activates the migrated ones on the
+// destination cluster. It leaves the clients on the destination cluster.
+func Failover(cfg DRConfig) error {
+	log.InfoD("Failing over namespace %s to the destination cluster", cfg.Namespace)
+	if err := cfg.SetSourceKubeConfig(); err != nil {
+		return err
+	}
+	if cfg.MigrationSchedule != "" {
+		if err := SuspendMigrationSchedule(cfg.MigrationSchedule, cfg.Namespace, true, cfg.Timeout); err != nil {
+			return fmt.Errorf("failed to suspend the migrations of namespace %s: %v", cfg.Namespace, err)
+		}
+	}
+	if err := ScaleDownApplications(cfg.Namespace); err != nil {
+		return fmt.Errorf("failed to scale down the applications of namespace %s on the source cluster: %v", cfg.Namespace, err)
+	}
+	if err := cfg.SetDestinationKubeConfig(); err != nil {
+		return err
+	}
+	if err := ActivateMigrations(cfg.Namespace, cfg.DestinationKubeConfigPath); err != nil {
+		return fmt.Errorf("failed to activate the applications of namespace %s on the destination cluster: %v", cfg.Namespace, err)
+	}
+	return nil
+}
+
+// Failback moves the applications of the namespace back to the source cluster once failed over: it pairs the
+// destination cluster with the source one, migrates the applications back, deactivates them on the destination
+// cluster, activates them on the source cluster and resumes the migration schedule. It leaves the clients on the
+// source cluster and returns the migration back, which is left on the destination cluster.
+func Failback(cfg DRConfig, migrationName string) (*storkapi.Migration, error) {
+	log.InfoD("Failing back namespace %s to the source cluster", cfg.Namespace)
+	if err := cfg.PairClusters(true); err != nil {
+		return nil, fmt.Errorf("failed to pair the destination cluster with the source cluster: %v", err)
+	}
+	if err := cfg.SetDestinationKubeConfig(); err != nil {
+		return nil, err
+	}
+	includeResources, startApplications := true, false
+	mig, err := CreateMigration(migrationName, cfg.Namespace, cfg.ClusterPair, cfg.Namespace, &includeResources, &startApplications)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration %s back to the source cluster: %v", migrationName, err)
+	}
+	if err := WaitForMigration([]*storkapi.Migration{mig}); err != nil {
+		return mig, fmt.Errorf("migration %s back to the source cluster failed: %v", migrationName, err)
+	}
+	if err := DeactivateMigrations(cfg.Namespace, cfg.DestinationKubeConfigPath); err != nil {
+		return mig, fmt.Errorf("failed to deactivate the applications of namespace %s on the destination cluster: %v", cfg.Namespace, err)
+	}
+	if err := cfg.SetSourceKubeConfig(); err != nil {
+		return mig, err
+	}
+	if err := ActivateMigrations(cfg.Namespace, cfg.SourceKubeConfigPath); err != nil {
+		return mig, fmt.Errorf("failed to activate the applications of namespace %s on the source cluster: %v", cfg.Namespace, err)
+	}
+	if cfg.MigrationSchedule != "" {
+		if err := SuspendMigrationSchedule(cfg.MigrationSchedule, cfg.Namespace, false, cfg.Timeout); err != nil {
+			return mig, fmt.Errorf("failed to resume the migrations of namespace %s: %v", cfg.Namespace, err)
+		}
+	}
+	return mig, nil
+}
@@ -0,0 +1,60 @@
+package asyncdr
+
+import (
+	"testing"
+	"time"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseMigrationReplicas(t *testing.T) {
+	replicas, err := parseMigrationReplicas(map[string]string{migration.StorkMigrationReplicasAnnotation: "3"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), replicas)
+	replicas, err = parseMigrationReplicas(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(-1), replicas)
+	_, err = parseMigrationReplicas(map[string]string{migration.StorkMigrationReplicasAnnotation: "three"})
+	assert.Error(t, err)
+}
+
+func TestValidateAppReplicas(t *testing.T) {
+	apps := []AppReplicas{
+		{Kind: "Deployment", Name: "web", Replicas: 2, ReadyReplicas: 2, CurrentReplicas: 2, MigrationReplicas: 2},
+		{Kind: "StatefulSet", Name: "db", Replicas: 3, ReadyReplicas: 1, CurrentReplicas: 3, MigrationReplicas: 3},
+		{Kind: "Deployment", Name: "sidecar", Replicas: 1, ReadyReplicas: 1, CurrentReplicas: 1, MigrationReplicas: -1},
+	}
+	err := ValidateActivated(apps)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "StatefulSet/db has 3 replicas and 1 ready, expected 3")
+	assert.NotContains(t, err.Error(), "web")
+	assert.NoError(t, ValidateActivated(apps[:1]))
+	assert.Error(t, ValidateActivated(apps[2:]))
+
+	err = ValidateDeactivated(apps)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Deployment/sidecar has 1 replicas and 1 pods")
+	assert.NoError(t, ValidateDeactivated([]AppReplicas{{Kind: "Deployment", Name: "web", MigrationReplicas: 2}}))
+}
+
+func TestScheduledMigrations(t *testing.T) {
+	now := time.Now()
+	schedule := &storkapi.MigrationSchedule{}
+	assert.Nil(t, LatestScheduledMigration(schedule))
+
+	schedule.Status.Items = map[storkapi.SchedulePolicyType][]*storkapi.ScheduledMigrationStatus{
+		storkapi.SchedulePolicyTypeInterval: {
+			{Name: "interval-1", CreationTimestamp: meta_v1.NewTime(now.Add(-2 * time.Hour)), Status: storkapi.MigrationStatusSuccessful},
+			{Name: "interval-2", CreationTimestamp: meta_v1.NewTime(now), Status: storkapi.MigrationStatusInProgress},
+		},
+		storkapi.SchedulePolicyTypeDaily: {
+			{Name: "daily-1", CreationTimestamp: meta_v1.NewTime(now.Add(-time.Hour)), Status: storkapi.MigrationStatusPending},
+		},
+	}
+	assert.Equal(t, "interval-2", LatestScheduledMigration(schedule).Name)
+	assert.Equal(t, []string{"daily-1", "interval-2"}, ScheduledMigrationsInProgress(schedule))
+}
@@ -0,0 +1,208 @@
+// Package kdmputils follows the objects the KDMP data mover creates to back up volumes with generic backups,
+// and checks that the backups went through it and cleaned up after themselves.
+package kdmputils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// DataExportAPIVersion is the api version of the DataExports created by stork for generic backups
+	DataExportAPIVersion = "kdmp.portworx.com/v1alpha1"
+	// DataExportKind is the kind of the DataExports created by stork for generic backups
+	DataExportKind = "DataExport"
+	// DriverNameLabel is the label of the jobs started by the KDMP data mover
+	DriverNameLabel = "kdmp.portworx.com/driver-name"
+	// GenericDriverName is the driver of the volumes backed up by the KDMP data mover
+	GenericDriverName = "kdmp"
+
+	// StageFinal is the last stage of a DataExport
+	StageFinal = "Final"
+	// StatusSuccessful is the status of a DataExport which transferred the volume
+	StatusSuccessful = "Successful"
+	// StatusFailed is the status of a DataExport which failed to transfer the volume
+	StatusFailed = "Failed"
+)
+
+// DataExport is the state of a DataExport transferring a volume
+type DataExport struct {
+	Name      string
+	Namespace string
+	// SourceNamespace and SourceName are the namespace and the name of the claim of the transferred volume
+	SourceNamespace string
+	SourceName      string
+	Stage           string
+	Status          string
+	Reason          string
+}
+
+// Key returns the key of the DataExport
+func (d DataExport) Key() string {
+	return d.Namespace + "/" + d.Name
+}
+
+// Source returns the key of the claim of the transferred volume
+func (d DataExport) Source() string {
+	return d.SourceNamespace + "/" + d.SourceName
+}
+
+// Succeeded returns true if the DataExport transferred the volume
+func (d DataExport) Succeeded() bool {
+	return d.Stage == StageFinal && d.Status == StatusSuccessful
+}
+
+// ParseDataExport returns the state of a DataExport from its unstructured object
+func ParseDataExport(obj map[string]interface{}) DataExport {
+	str := func(fields ...string) string {
+		value, _, _ := unstructured.NestedString(obj, fields...)
+		return value
+	}
+	return DataExport{
+		Name:            str("metadata", "name"),
+		Namespace:       str("metadata", "namespace"),
+		SourceNamespace: str("spec", "source", "namespace"),
+		SourceName:      str("spec", "source", "name"),
+		Stage:           str("status", "stage"),
+		Status:          str("status", "status"),
+		Reason:          str("status", "reason"),
+	}
+}
+
+// Observer records the DataExports and the jobs of the data mover seen while the volumes of some namespaces
+// are backed up, so they can be checked once the backup is over and the objects are gone
+type Observer struct {
+	namespaces map[string]bool
+	// exports are the last states seen of the DataExports of the namespaces by key
+	exports map[string]DataExport
+	// jobs are the keys of the jobs of the data mover seen
+	jobs map[string]bool
+}
+
+// NewObserver returns an observer of the DataExports transferring volumes of the given namespaces
+func NewObserver(namespaces []string) *Observer {
+	o := &Observer{
+		namespaces: make(map[string]bool),
+		exports:    make(map[string]DataExport),
+		jobs:       make(map[string]bool),
+	}
+	for _, namespace := range namespaces {
+		o.namespaces[namespace] = true
+	}
+	return o
+}
+
+// Observe records the DataExports transferring volumes of the namespaces and the jobs of the data mover
+// currently in the cluster
+func (o *Observer) Observe(exports []unstructured.Unstructured, jobs []batchv1.Job) {
+	for _, obj := range exports {
+		export := ParseDataExport(obj.Object)
+		if o.namespaces[export.SourceNamespace] {
+			o.exports[export.Key()] = export
+		}
+	}
+	for _, job := range jobs {
+		if _, ok := job.Labels[DriverNameLabel]; ok {
+			o.jobs[job.Namespace+"/"+job.Name] = true
+		}
+	}
+}
+
+// DataExports returns the last states seen of the DataExports, ordered by key
+func (o *Observer) DataExports() []DataExport {
+	var exports []DataExport
+	for _, export := range o.exports {
+		exports = append(exports, export)
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].Key() < exports[j].Key()
+	})
+	return exports
+}
+
+// Jobs returns the keys of the jobs of the data mover seen, ordered
+func (o *Observer) Jobs() []string {
+	return sortedKeys(o.jobs)
+}
+
+// Validate checks that each volume of the backup succeeded and that the last state seen of the DataExports
+// of the volumes which did not is reported. Stork deletes the DataExports once they complete, possibly before
+// the observer sees them in their final stage or at all, so a DataExport last seen in progress whose volume
+// succeeded is not a violation.
+func (o *Observer) Validate(volumes []*api.BackupInfo_Volume) error {
+	var violations []string
+	succeeded := make(map[string]bool)
+	for _, volume := range volumes {
+		claim := volume.GetNamespace() + "/" + volume.GetPvc()
+		status := volume.GetStatus()
+		if status.GetStatus() == api.BackupInfo_StatusInfo_Success {
+			succeeded[claim] = true
+			continue
+		}
+		violations = append(violations, fmt.Sprintf("volume [%s] of claim [%s] is [%s]: %s",
+			volume.GetName(), claim, status.GetStatus(), status.GetReason()))
+	}
+	for _, export := range o.DataExports() {
+		if !export.Succeeded() && !succeeded[export.Source()] {
+			violations = append(violations, fmt.Sprintf("DataExport [%s] of claim [%s] was last seen in stage [%s] with status [%s]: %s",
+				export.Key(), export.Source(), export.Stage, export.Status, export.Reason))
+		}
+	}
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return fmt.Errorf("generic backup of namespaces %v is invalid:\n%s", sortedKeys(o.namespaces), strings.Join(violations, "\n"))
+	}
+	return nil
+}
+
+// Leftovers returns the keys of the DataExports and the jobs seen which are still in the cluster
+func (o *Observer) Leftovers(exports []unstructured.Unstructured, jobs []batchv1.Job) []string {
+	var leftovers []string
+	for _, obj := range exports {
+		export := ParseDataExport(obj.Object)
+		if _, ok := o.exports[export.Key()]; ok {
+			leftovers = append(leftovers, fmt.Sprintf("%s/%s", DataExportKind, export.Key()))
+		}
+	}
+	for _, job := range jobs {
+		key := job.Namespace + "/" + job.Name
+		if o.jobs[key] {
+			leftovers = append(leftovers, "Job/"+key)
+		}
+	}
+	sort.Strings(leftovers)
+	return leftovers
+}
+
+// ValidateVolumeDrivers checks that all the volumes of a backup were backed up by the given driver
+func ValidateVolumeDrivers(volumes []*api.BackupInfo_Volume, driver string) error {
+	if len(volumes) == 0 {
+		return fmt.Errorf("backup has no volume")
+	}
+	var violations []string
+	for _, volume := range volumes {
+		if volume.GetDriverName() != driver {
+			violations = append(violations, fmt.Sprintf("volume [%s] of claim [%s/%s] was backed up by driver [%s]",
+				volume.GetName(), volume.GetNamespace(), volume.GetPvc(), volume.GetDriverName()))
+		}
+	}
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return fmt.Errorf("volumes were expected to be backed up by driver [%s]:\n%s", driver, strings.Join(violations, "\n"))
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
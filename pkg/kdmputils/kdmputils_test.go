@@ -0,0 +1,84 @@
+package kdmputils
+
+import (
+	"testing"
+
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func dataExport(name, sourceNamespace, claim, stage, status string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": DataExportAPIVersion,
+		"kind":       DataExportKind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": sourceNamespace},
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{"namespace": sourceNamespace, "name": claim},
+		},
+		"status": map[string]interface{}{"stage": stage, "status": status},
+	}}
+}
+
+func job(namespace, name string, labels map[string]string) batchv1.Job {
+	return batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+}
+
+func backupVolume(namespace, claim string, status api.BackupInfo_StatusInfo_Status) *api.BackupInfo_Volume {
+	return &api.BackupInfo_Volume{Name: "pvc-" + claim, Namespace: namespace, Pvc: claim, DriverName: GenericDriverName,
+		Status: &api.BackupInfo_StatusInfo{Status: status}}
+}
+
+func TestObserver(t *testing.T) {
+	o := NewObserver([]string{"mysql"})
+	moverJob := job("mysql", "kopia-backup-1", map[string]string{DriverNameLabel: "kopiabackup"})
+	otherJob := job("mysql", "migration", nil)
+
+	o.Observe([]unstructured.Unstructured{
+		dataExport("export-data", "mysql", "data", "DataExportStageTransferInProgress", "InProgress"),
+		dataExport("export-other", "postgres", "data", "DataExportStageTransferInProgress", "InProgress"),
+	}, []batchv1.Job{moverJob, otherJob})
+	require.Len(t, o.DataExports(), 1)
+	// the DataExport was deleted once the backup succeeded, before it was seen in its final stage
+	assert.NoError(t, o.Validate([]*api.BackupInfo_Volume{backupVolume("mysql", "data", api.BackupInfo_StatusInfo_Success)}))
+	assert.Error(t, o.Validate([]*api.BackupInfo_Volume{backupVolume("mysql", "data", api.BackupInfo_StatusInfo_InProgress)}))
+
+	o.Observe([]unstructured.Unstructured{
+		dataExport("export-data", "mysql", "data", StageFinal, StatusSuccessful),
+		dataExport("export-logs", "mysql", "logs", StageFinal, StatusFailed),
+	}, nil)
+	require.Len(t, o.DataExports(), 2)
+	assert.Equal(t, []string{"mysql/kopia-backup-1"}, o.Jobs())
+	err := o.Validate([]*api.BackupInfo_Volume{
+		backupVolume("mysql", "data", api.BackupInfo_StatusInfo_Success),
+		backupVolume("mysql", "logs", api.BackupInfo_StatusInfo_Failed),
+		// no DataExport was seen transferring the claim, it was created and deleted between two observations
+		backupVolume("mysql", "binlog", api.BackupInfo_StatusInfo_Success),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "volume [pvc-logs] of claim [mysql/logs] is [Failed]")
+	assert.Contains(t, err.Error(), "DataExport [mysql/export-logs] of claim [mysql/logs] was last seen in stage [Final] with status [Failed]")
+	assert.NotContains(t, err.Error(), "data")
+	assert.NotContains(t, err.Error(), "binlog")
+
+	assert.Equal(t, []string{"DataExport/mysql/export-data", "Job/mysql/kopia-backup-1"}, o.Leftovers(
+		[]unstructured.Unstructured{dataExport("export-data", "mysql", "data", StageFinal, StatusSuccessful),
+			dataExport("export-other", "postgres", "data", StageFinal, StatusSuccessful)},
+		[]batchv1.Job{moverJob, otherJob}))
+	assert.Empty(t, o.Leftovers(nil, []batchv1.Job{otherJob}))
+}
+
+func TestValidateVolumeDrivers(t *testing.T) {
+	volumes := []*api.BackupInfo_Volume{
+		{Name: "pvc-1", Namespace: "mysql", Pvc: "data", DriverName: GenericDriverName},
+		{Name: "pvc-2", Namespace: "mysql", Pvc: "logs", DriverName: "pxd"},
+	}
+	assert.NoError(t, ValidateVolumeDrivers(volumes[:1], GenericDriverName))
+	err := ValidateVolumeDrivers(volumes, GenericDriverName)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "volume [pvc-2] of claim [mysql/logs] was backed up by driver [pxd]")
+	assert.Error(t, ValidateVolumeDrivers(nil, GenericDriverName))
+}
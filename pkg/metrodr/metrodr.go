@@ -0,0 +1,241 @@
+package metrodr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/libopenstorage/openstorage/api"
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/torpedo/drivers/node"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// EnvClusterDomainLabel is the env var with the node label holding the cluster domain of a node in a stretched
+	// cluster. The label is read from the labels portworx keeps for its nodes.
+	EnvClusterDomainLabel = "METRO_DR_DOMAIN_LABEL"
+	// EnvWitnessDomain is the env var with the cluster domain of the witness nodes, which only take part in the
+	// quorum of a stretched cluster and never run applications. Clusters without witness nodes leave it unset.
+	EnvWitnessDomain = "METRO_DR_WITNESS_DOMAIN"
+)
+
+// Topology is the layout of the nodes of a stretched cluster across its cluster domains
+type Topology struct {
+	// Domains are the nodes of each cluster domain which runs applications
+	Domains map[string][]node.Node
+	// WitnessDomain is the cluster domain of the witness nodes, if any
+	WitnessDomain string
+	// Witness are the witness nodes of the cluster
+	Witness []node.Node
+}
+
+// NewTopology groups the nodes by the cluster domain in the given label. Nodes without the label are not part
+// of the stretched cluster and are left out.
+func NewTopology(nodes []node.Node, nodeLabels map[string]map[string]string, domainLabel, witnessDomain string) (*Topology, error) {
+	t := &Topology{Domains: make(map[string][]node.Node), WitnessDomain: witnessDomain}
+	for _, n := range nodes {
+		domain, ok := nodeLabels[n.Name][domainLabel]
+		if !ok || domain == "" {
+			continue
+		}
+		if witnessDomain != "" && domain == witnessDomain {
+			t.Witness = append(t.Witness, n)
+			continue
+		}
+		t.Domains[domain] = append(t.Domains[domain], n)
+	}
+	if len(t.Domains) < 2 {
+		return nil, fmt.Errorf("expected nodes in at least 2 cluster domains with label %s, found domains %v",
+			domainLabel, t.DomainNames())
+	}
+	return t, nil
+}
+
+// NewTopologyFromDriverNodes returns the topology of the nodes of the volume driver, which span all the
+// clusters sharing the stretched storage cluster, from the labels the volume driver keeps for them
+func NewTopologyFromDriverNodes(driverNodes []*api.StorageNode, domainLabel, witnessDomain string) (*Topology, error) {
+	var nodes []node.Node
+	nodeLabels := make(map[string]map[string]string)
+	for _, sn := range driverNodes {
+		name := sn.SchedulerNodeName
+		if name == "" {
+			name = sn.Hostname
+		}
+		addresses := []string{sn.MgmtIp}
+		if sn.DataIp != "" && sn.DataIp != sn.MgmtIp {
+			addresses = append(addresses, sn.DataIp)
+		}
+		nodes = append(nodes, node.Node{
+			StorageNode:              sn,
+			VolDriverNodeID:          sn.Id,
+			Name:                     name,
+			Addresses:                addresses,
+			IsStorageDriverInstalled: true,
+		})
+		nodeLabels[name] = sn.NodeLabels
+	}
+	return NewTopology(nodes, nodeLabels, domainLabel, witnessDomain)
+}
+
+// DomainNames returns the sorted names of the cluster domains which run applications
+func (t *Topology) DomainNames() []string {
+	names := make([]string, 0, len(t.Domains))
+	for name := range t.Domains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SurvivingDomains returns the sorted names of the cluster domains which run applications once the given domain
+// is down
+func (t *Topology) SurvivingDomains(failedDomain string) []string {
+	surviving := make([]string, 0)
+	for _, name := range t.DomainNames() {
+		if name != failedDomain {
+			surviving = append(surviving, name)
+		}
+	}
+	return surviving
+}
+
+// DomainOf returns the cluster domain of the node with the given name
+func (t *Topology) DomainOf(nodeName string) (string, bool) {
+	for _, n := range t.Witness {
+		if n.Name == nodeName {
+			return t.WitnessDomain, true
+		}
+	}
+	for domain, nodes := range t.Domains {
+		for _, n := range nodes {
+			if n.Name == nodeName {
+				return domain, true
+			}
+		}
+	}
+	return "", false
+}
+
+// DomainsWithout returns the sorted names of the cluster domains which run applications and hold none of the
+// given nodes
+func (t *Topology) DomainsWithout(nodeNames []string) []string {
+	excluded := make(map[string]bool)
+	for _, name := range nodeNames {
+		excluded[name] = true
+	}
+	domains := make([]string, 0)
+	for _, domain := range t.DomainNames() {
+		holds := false
+		for _, n := range t.Domains[domain] {
+			if excluded[n.Name] {
+				holds = true
+				break
+			}
+		}
+		if !holds {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// HoldsAll returns true if the cluster domain holds all the given nodes which are part of the stretched cluster,
+// and there is at least one
+func (t *Topology) HoldsAll(domain string, nodeNames []string) bool {
+	found := false
+	for _, name := range nodeNames {
+		nodeDomain, ok := t.DomainOf(name)
+		if !ok {
+			continue
+		}
+		if nodeDomain != domain {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// ValidateAppNodes returns an error if any of the nodes an application runs on is not in the given cluster domains
+func (t *Topology) ValidateAppNodes(appNodes []node.Node, domains []string) error {
+	var misplaced []string
+	for _, n := range appNodes {
+		domain, _ := t.DomainOf(n.Name)
+		found := false
+		for _, d := range domains {
+			if domain == d {
+				found = true
+				break
+			}
+		}
+		if !found {
+			misplaced = append(misplaced, fmt.Sprintf("%s [%s]", n.Name, domain))
+		}
+	}
+	if len(misplaced) > 0 {
+		return fmt.Errorf("application runs on nodes out of cluster domains %v: %s", domains, strings.Join(misplaced, ", "))
+	}
+	return nil
+}
+
+// ValidateDomainStates returns an error if any of the active domains is not active or any of the inactive domains
+// is active, in the states reported by the volume driver
+func ValidateDomainStates(states map[string]bool, active, inactive []string) error {
+	for _, name := range active {
+		isActive, ok := states[name]
+		if !ok {
+			return fmt.Errorf("cluster domain %s not found in %v", name, states)
+		}
+		if !isActive {
+			return fmt.Errorf("cluster domain %s is not active", name)
+		}
+	}
+	for _, name := range inactive {
+		isActive, ok := states[name]
+		if !ok {
+			return fmt.Errorf("cluster domain %s not found in %v", name, states)
+		}
+		if isActive {
+			return fmt.Errorf("cluster domain %s is still active", name)
+		}
+	}
+	return nil
+}
+
+// ValidateSyncDRClusterPair returns an error if the cluster pair is not ready for sync DR. Both clusters of a
+// sync DR pair share a stretched storage cluster, so the pair is only made with the scheduler.
+func ValidateSyncDRClusterPair(pair *storkapi.ClusterPair) error {
+	if len(pair.Spec.Options) > 0 {
+		return fmt.Errorf("cluster pair %s/%s has storage options, sync DR pairs are only made with the scheduler",
+			pair.Namespace, pair.Name)
+	}
+	if pair.Status.SchedulerStatus != storkapi.ClusterPairStatusReady {
+		return fmt.Errorf("cluster pair %s/%s scheduler status is [%s]", pair.Namespace, pair.Name, pair.Status.SchedulerStatus)
+	}
+	if pair.Status.StorageStatus != storkapi.ClusterPairStatusNotProvided {
+		return fmt.Errorf("cluster pair %s/%s storage status is [%s], expected [%s]", pair.Namespace, pair.Name,
+			pair.Status.StorageStatus, storkapi.ClusterPairStatusNotProvided)
+	}
+	return nil
+}
+
+// NewSyncDRMigration returns a migration of the resources of the namespace through a sync DR cluster pair. The
+// volumes are not migrated, both clusters share them, and the applications are only started on the destination
+// cluster once activated.
+func NewSyncDRMigration(name, namespace, clusterPair string) *storkapi.Migration {
+	includeResources, includeVolumes, startApplications := true, false, false
+	return &storkapi.Migration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: storkapi.MigrationSpec{
+			ClusterPair:       clusterPair,
+			IncludeResources:  &includeResources,
+			IncludeVolumes:    &includeVolumes,
+			StartApplications: &startApplications,
+			Namespaces:        []string{namespace},
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package metrodr
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/openstorage/api"
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testDomainLabel = "example.com/cluster-domain"
+
+func TestNewTopology(t *testing.T) {
+	nodes := []node.Node{{Name: "a1"}, {Name: "a2"}, {Name: "b1"}, {Name: "w1"}, {Name: "master"}}
+	labels := map[string]map[string]string{
+		"a1": {testDomainLabel: "dc-a"},
+		"a2": {testDomainLabel: "dc-a"},
+		"b1": {testDomainLabel: "dc-b"},
+		"w1": {testDomainLabel: "dc-w"},
+	}
+	topology, err := NewTopology(nodes, labels, testDomainLabel, "dc-w")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dc-a", "dc-b"}, topology.DomainNames())
+	assert.Len(t, topology.Domains["dc-a"], 2)
+	require.Len(t, topology.Witness, 1)
+	assert.Equal(t, "w1", topology.Witness[0].Name)
+	assert.Equal(t, []string{"dc-b"}, topology.SurvivingDomains("dc-a"))
+
+	domain, ok := topology.DomainOf("w1")
+	assert.True(t, ok)
+	assert.Equal(t, "dc-w", domain)
+	_, ok = topology.DomainOf("master")
+	assert.False(t, ok)
+
+	assert.NoError(t, topology.ValidateAppNodes([]node.Node{{Name: "b1"}}, []string{"dc-b"}))
+	err = topology.ValidateAppNodes([]node.Node{{Name: "a2"}, {Name: "b1"}}, []string{"dc-b"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a2 [dc-a]")
+
+	assert.Equal(t, []string{"dc-b"}, topology.DomainsWithout([]string{"a2", "w1", "master"}))
+	assert.Empty(t, topology.DomainsWithout([]string{"a1", "b1"}))
+	assert.True(t, topology.HoldsAll("dc-a", []string{"a1", "a2", "master"}))
+	assert.False(t, topology.HoldsAll("dc-a", []string{"a1", "b1"}))
+	assert.False(t, topology.HoldsAll("dc-a", []string{"master"}))
+
+	// without a witness domain every labeled domain runs applications
+	topology, err = NewTopology(nodes, labels, testDomainLabel, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dc-a", "dc-b", "dc-w"}, topology.DomainNames())
+	assert.Empty(t, topology.Witness)
+
+	delete(labels, "b1")
+	_, err = NewTopology(nodes, labels, testDomainLabel, "dc-w")
+	assert.Error(t, err)
+}
+
+func TestNewTopologyFromDriverNodes(t *testing.T) {
+	driverNodes := []*api.StorageNode{
+		{Id: "id-a1", SchedulerNodeName: "a1", MgmtIp: "10.0.0.1", DataIp: "10.1.0.1", NodeLabels: map[string]string{testDomainLabel: "dc-a"}},
+		// a node of the other cluster sharing the storage cluster
+		{Id: "id-b1", SchedulerNodeName: "b1", MgmtIp: "10.0.1.1", DataIp: "10.0.1.1", NodeLabels: map[string]string{testDomainLabel: "dc-b"}},
+		{Id: "id-w1", Hostname: "w1", MgmtIp: "10.0.2.1", NodeLabels: map[string]string{testDomainLabel: "dc-w"}},
+	}
+	topology, err := NewTopologyFromDriverNodes(driverNodes, testDomainLabel, "dc-w")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dc-a", "dc-b"}, topology.DomainNames())
+	a1 := topology.Domains["dc-a"][0]
+	assert.Equal(t, "a1", a1.Name)
+	assert.Equal(t, "id-a1", a1.VolDriverNodeID)
+	assert.Equal(t, []string{"10.0.0.1", "10.1.0.1"}, a1.Addresses)
+	assert.Equal(t, []string{"10.0.1.1"}, topology.Domains["dc-b"][0].Addresses)
+	require.Len(t, topology.Witness, 1)
+	assert.Equal(t, "w1", topology.Witness[0].Name)
+}
+
+func TestValidateDomainStates(t *testing.T) {
+	states := map[string]bool{"dc-a": false, "dc-b": true}
+	assert.NoError(t, ValidateDomainStates(states, []string{"dc-b"}, []string{"dc-a"}))
+	assert.Error(t, ValidateDomainStates(states, []string{"dc-a"}, nil))
+	assert.Error(t, ValidateDomainStates(states, nil, []string{"dc-b"}))
+	assert.Error(t, ValidateDomainStates(states, []string{"dc-c"}, nil))
+}
+
+func TestValidateSyncDRClusterPair(t *testing.T) {
+	pair := &storkapi.ClusterPair{
+		ObjectMeta: metav1.ObjectMeta{Name: "remoteclusterpair", Namespace: "mysql"},
+		Status: storkapi.ClusterPairStatus{
+			SchedulerStatus: storkapi.ClusterPairStatusReady,
+			StorageStatus:   storkapi.ClusterPairStatusNotProvided,
+		},
+	}
+	assert.NoError(t, ValidateSyncDRClusterPair(pair))
+
+	pair.Status.SchedulerStatus = storkapi.ClusterPairStatusPending
+	assert.Error(t, ValidateSyncDRClusterPair(pair))
+
+	pair.Status.SchedulerStatus = storkapi.ClusterPairStatusReady
+	pair.Spec.Options = map[string]string{"ip": "10.0.0.1"}
+	assert.Error(t, ValidateSyncDRClusterPair(pair))
+}
+
+func TestNewSyncDRMigration(t *testing.T) {
+	mig := NewSyncDRMigration("mysql-metro-dr", "mysql", "remoteclusterpair")
+	assert.Equal(t, "mysql", mig.Namespace)
+	assert.Equal(t, "remoteclusterpair", mig.Spec.ClusterPair)
+	assert.Equal(t, []string{"mysql"}, mig.Spec.Namespaces)
+	assert.True(t, *mig.Spec.IncludeResources)
+	assert.False(t, *mig.Spec.IncludeVolumes)
+	assert.False(t, *mig.Spec.StartApplications)
+}
@@ -0,0 +1,262 @@
+// Package restoreutils compares the volume contents and the resources of applications captured before a
+// backup with their restored copies.
+package restoreutils
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ChecksumCommand prints the sha256 of every file below the current directory, skipping lost+found
+const ChecksumCommand = "find . -path ./lost+found -prune -o -type f -exec sha256sum {} +"
+
+const (
+	missing  = "<missing>"
+	redacted = "<redacted>"
+)
+
+// annotationPrefixes are the annotations set by the cluster or the restore, which differ between a
+// resource and its restored copy
+var annotationPrefixes = []string{
+	"kubectl.kubernetes.io/",
+	"deployment.kubernetes.io/",
+	"pv.kubernetes.io/",
+	"volume.kubernetes.io/",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"stork.libopenstorage.org/",
+}
+
+// clusterFields are the fields set by the cluster
+var clusterFields = [][]string{
+	{"status"},
+	{"metadata", "uid"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "generation"},
+	{"metadata", "managedFields"},
+	{"metadata", "selfLink"},
+	{"metadata", "ownerReferences"},
+}
+
+// Diff is a difference between an object captured before a backup and its restored copy
+type Diff struct {
+	// Object is the key of the compared object, e.g. PersistentVolumeClaim/mysql/mysql-data
+	Object string
+	// Field is the path of the differing file of a volume or of the differing field of a resource
+	Field    string
+	Expected string
+	Actual   string
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("%s: %s expected [%s] but got [%s]", d.Object, d.Field, d.Expected, d.Actual)
+}
+
+// ResourceKey returns the key of a resource in a snapshot
+func ResourceKey(kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// ParseChecksums parses the output of ChecksumCommand into the checksums of the files by path
+func ParseChecksums(out string) (map[string]string, error) {
+	checksums := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected checksum line [%s]", line)
+		}
+		// sha256sum separates the checksum from the path with a space and a mode character
+		path := strings.TrimPrefix(strings.TrimLeft(fields[1], " *"), "./")
+		checksums[path] = fields[0]
+	}
+	return checksums, nil
+}
+
+// CompareChecksums returns the files which are missing, unexpected or have different contents in the restored volume
+func CompareChecksums(object string, expected, actual map[string]string) []Diff {
+	var diffs []Diff
+	for _, path := range unionKeys(stringKeys(expected), stringKeys(actual)) {
+		e, inExpected := expected[path]
+		a, inActual := actual[path]
+		switch {
+		case !inActual:
+			a = missing
+		case !inExpected:
+			e = missing
+		case e == a:
+			continue
+		}
+		diffs = append(diffs, Diff{Object: object, Field: path, Expected: e, Actual: a})
+	}
+	return diffs
+}
+
+// NormalizeResource returns a copy of a resource converted to unstructured without the fields set by the
+// cluster, with its namespace and storage class mapped the way the restore maps them, so it can be compared
+// with its restored copy
+func NormalizeResource(obj map[string]interface{}, namespaceMapping, storageClassMapping map[string]string) map[string]interface{} {
+	normalized := runtime.DeepCopyJSON(obj)
+	for _, field := range clusterFields {
+		unstructured.RemoveNestedField(normalized, field...)
+	}
+
+	if namespace, ok, _ := unstructured.NestedString(normalized, "metadata", "namespace"); ok {
+		if mapped, ok := namespaceMapping[namespace]; ok {
+			_ = unstructured.SetNestedField(normalized, mapped, "metadata", "namespace")
+		}
+	}
+
+	annotations, _, _ := unstructured.NestedStringMap(normalized, "metadata", "annotations")
+	for annotation := range annotations {
+		for _, prefix := range annotationPrefixes {
+			if strings.HasPrefix(annotation, prefix) {
+				delete(annotations, annotation)
+			}
+		}
+	}
+
+	switch normalized["kind"] {
+	case "PersistentVolumeClaim":
+		// the restored claim is bound to a new volume
+		unstructured.RemoveNestedField(normalized, "spec", "volumeName")
+		if class, ok, _ := unstructured.NestedString(normalized, "spec", "storageClassName"); ok {
+			if mapped, ok := storageClassMapping[class]; ok {
+				_ = unstructured.SetNestedField(normalized, mapped, "spec", "storageClassName")
+			}
+		}
+		if class, ok := annotations["volume.beta.kubernetes.io/storage-class"]; ok {
+			if mapped, ok := storageClassMapping[class]; ok {
+				annotations["volume.beta.kubernetes.io/storage-class"] = mapped
+			}
+		}
+	case "Service":
+		// the restored service gets new cluster IPs and node ports
+		unstructured.RemoveNestedField(normalized, "spec", "clusterIP")
+		unstructured.RemoveNestedField(normalized, "spec", "clusterIPs")
+		unstructured.RemoveNestedField(normalized, "spec", "healthCheckNodePort")
+		if ports, ok, _ := unstructured.NestedSlice(normalized, "spec", "ports"); ok {
+			for _, port := range ports {
+				if port, ok := port.(map[string]interface{}); ok {
+					delete(port, "nodePort")
+				}
+			}
+			_ = unstructured.SetNestedSlice(normalized, ports, "spec", "ports")
+		}
+	}
+
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(normalized, "metadata", "annotations")
+	} else {
+		_ = unstructured.SetNestedStringMap(normalized, annotations, "metadata", "annotations")
+	}
+	return normalized
+}
+
+// CompareResources returns the fields which differ between a normalized resource and its normalized
+// restored copy. The values of secrets are not reported.
+func CompareResources(object string, expected, actual map[string]interface{}) []Diff {
+	var diffs []Diff
+	secret := expected["kind"] == "Secret"
+	compareValues("", expected, actual, func(field string, e, a string) {
+		if secret && (strings.HasPrefix(field, "data.") || strings.HasPrefix(field, "stringData.")) {
+			if e != missing {
+				e = redacted
+			}
+			if a != missing {
+				a = redacted
+			}
+		}
+		diffs = append(diffs, Diff{Object: object, Field: field, Expected: e, Actual: a})
+	})
+	return diffs
+}
+
+func compareValues(field string, expected, actual interface{}, report func(field, expected, actual string)) {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range unionKeys(objectKeys(e), objectKeys(a)) {
+			ev, inExpected := e[key]
+			av, inActual := a[key]
+			child := key
+			if field != "" {
+				child = field + "." + key
+			}
+			switch {
+			case !inActual:
+				report(child, format(ev), missing)
+			case !inExpected:
+				report(child, missing, format(av))
+			default:
+				compareValues(child, ev, av, report)
+			}
+		}
+		return
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(e) || i < len(a); i++ {
+			child := fmt.Sprintf("%s[%d]", field, i)
+			switch {
+			case i >= len(a):
+				report(child, format(e[i]), missing)
+			case i >= len(e):
+				report(child, missing, format(a[i]))
+			default:
+				compareValues(child, e[i], a[i], report)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		report(field, format(expected), format(actual))
+	}
+}
+
+func format(value interface{}) string {
+	return fmt.Sprintf("%v", value)
+}
+
+// unionKeys returns the keys of both maps in order
+func unionKeys(expected, actual []string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, key := range append(expected, actual...) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func stringKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func objectKeys(m map[string]interface{}) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
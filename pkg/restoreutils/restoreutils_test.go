@@ -0,0 +1,96 @@
+package restoreutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareChecksums(t *testing.T) {
+	expected, err := ParseChecksums(`
+e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  ./empty
+9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  ./data/ibdata1
+2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae *./data/ib_logfile0
+`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"empty":            "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"data/ibdata1":     "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		"data/ib_logfile0": "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+	}, expected)
+
+	_, err = ParseChecksums("not-a-checksum-line")
+	assert.Error(t, err)
+
+	actual := map[string]string{
+		"empty":        expected["empty"],
+		"data/ibdata1": "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9",
+		"data/new":     expected["empty"],
+	}
+	assert.Empty(t, CompareChecksums("PersistentVolumeClaim/mysql/data", expected, expected))
+	assert.Equal(t, []Diff{
+		{Object: "pvc", Field: "data/ib_logfile0", Expected: expected["data/ib_logfile0"], Actual: missing},
+		{Object: "pvc", Field: "data/ibdata1", Expected: expected["data/ibdata1"], Actual: actual["data/ibdata1"]},
+		{Object: "pvc", Field: "data/new", Expected: missing, Actual: expected["empty"]},
+	}, CompareChecksums("pvc", expected, actual))
+}
+
+func TestCompareResources(t *testing.T) {
+	pvc := func(namespace, class, volume string) map[string]interface{} {
+		return map[string]interface{}{
+			"kind": "PersistentVolumeClaim",
+			"metadata": map[string]interface{}{
+				"name":            "mysql-data",
+				"namespace":       namespace,
+				"uid":             volume,
+				"resourceVersion": "42",
+				"annotations": map[string]interface{}{
+					"pv.kubernetes.io/bind-completed": "yes",
+				},
+			},
+			"spec": map[string]interface{}{
+				"storageClassName": class,
+				"volumeName":       volume,
+				"accessModes":      []interface{}{"ReadWriteOnce"},
+			},
+			"status": map[string]interface{}{"phase": "Bound"},
+		}
+	}
+	expected := NormalizeResource(pvc("mysql", "px-db", "pvc-1"), map[string]string{"mysql": "mysql-restored"},
+		map[string]string{"px-db": "px-db-repl3"})
+	assert.Empty(t, CompareResources("pvc", expected, NormalizeResource(pvc("mysql-restored", "px-db-repl3", "pvc-2"), nil, nil)))
+	assert.Equal(t, []Diff{
+		{Object: "pvc", Field: "spec.storageClassName", Expected: "px-db-repl3", Actual: "px-db"},
+	}, CompareResources("pvc", expected, NormalizeResource(pvc("mysql-restored", "px-db", "pvc-2"), nil, nil)))
+
+	deployment := func(image string, ports ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"kind":     "Deployment",
+			"metadata": map[string]interface{}{"name": "mysql", "namespace": "mysql"},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"image": image, "ports": ports},
+						},
+					},
+				},
+			},
+		}
+	}
+	assert.Equal(t, []Diff{
+		{Object: "deployment", Field: "spec.template.spec.containers[0].image", Expected: "mysql:5.7", Actual: "mysql:8.0"},
+		{Object: "deployment", Field: "spec.template.spec.containers[0].ports[1]", Expected: "33060", Actual: missing},
+	}, CompareResources("deployment", deployment("mysql:5.7", int64(3306), int64(33060)), deployment("mysql:8.0", int64(3306))))
+
+	secret := func(password string) map[string]interface{} {
+		return map[string]interface{}{
+			"kind": "Secret",
+			"data": map[string]interface{}{"password": password},
+		}
+	}
+	assert.Equal(t, []Diff{
+		{Object: "secret", Field: "data.password", Expected: redacted, Actual: redacted},
+	}, CompareResources("secret", secret("c2VjcmV0"), secret("b3RoZXI=")))
+}
@@ -0,0 +1,149 @@
+package revertutils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+// Action is the undo action of a fault injected by a driver
+type Action struct {
+	// Key pairs the action with the driver call undoing the fault, e.g. maintenance/<node>
+	Key string
+	// Description is reported when the action is replayed
+	Description string
+	// Scope is the test or longevity event the fault was injected in
+	Scope  string
+	revert func() error
+}
+
+// Failure is an action which failed to revert its fault
+type Failure struct {
+	Action *Action
+	Cause  error
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("failed to revert [%s] injected in [%s]. Cause: %v", f.Action.Description, f.Action.Scope, f.Cause)
+}
+
+// Registry keeps the undo actions of the faults which are still injected
+type Registry struct {
+	sync.Mutex
+	scope   string
+	actions []*Action
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+var defaultRegistry = NewRegistry()
+
+// BeginScope starts a test or longevity event. The actions registered from now on belong to the scope
+func (r *Registry) BeginScope(scope string) {
+	r.Lock()
+	defer r.Unlock()
+	r.scope = scope
+}
+
+// Register records the undo action of a fault. Registering a key which is already pending replaces
+// its action, e.g. a network error changed twice is reverted once.
+func (r *Registry) Register(key, description string, revert func() error) {
+	r.Lock()
+	defer r.Unlock()
+	r.remove(key)
+	r.actions = append(r.actions, &Action{Key: key, Description: description, Scope: r.scope, revert: revert})
+}
+
+// Resolve drops the action of the given key once its fault was reverted by the caller
+func (r *Registry) Resolve(key string) {
+	r.Lock()
+	defer r.Unlock()
+	r.remove(key)
+}
+
+// Pending returns the actions which were not resolved yet, the latest first
+func (r *Registry) Pending() []*Action {
+	r.Lock()
+	defer r.Unlock()
+	pending := make([]*Action, 0, len(r.actions))
+	for i := len(r.actions) - 1; i >= 0; i-- {
+		pending = append(pending, r.actions[i])
+	}
+	return pending
+}
+
+// Replay reverts the pending faults in LIFO order and returns the actions which failed. Every action is
+// replayed once, the ones which failed are not retried by a later replay.
+func (r *Registry) Replay() []*Failure {
+	return r.ReplayPrefix("")
+}
+
+// ReplayPrefix reverts the pending faults whose key starts with the given prefix in LIFO order and returns
+// the actions which failed, e.g. the network partitions with prefix partition/
+func (r *Registry) ReplayPrefix(prefix string) []*Failure {
+	var failures []*Failure
+	for {
+		var action *Action
+		r.Lock()
+		for i := len(r.actions) - 1; i >= 0; i-- {
+			if strings.HasPrefix(r.actions[i].Key, prefix) {
+				action = r.actions[i]
+				r.actions = append(r.actions[:i], r.actions[i+1:]...)
+				break
+			}
+		}
+		r.Unlock()
+		if action == nil {
+			return failures
+		}
+
+		log.Infof("Reverting [%s] injected in [%s]", action.Description, action.Scope)
+		if err := action.revert(); err != nil {
+			failures = append(failures, &Failure{Action: action, Cause: err})
+		}
+	}
+}
+
+func (r *Registry) remove(key string) {
+	for i := len(r.actions) - 1; i >= 0; i-- {
+		if r.actions[i].Key == key {
+			r.actions = append(r.actions[:i], r.actions[i+1:]...)
+			return
+		}
+	}
+}
+
+// BeginScope starts a test or longevity event in the default registry
+func BeginScope(scope string) {
+	defaultRegistry.BeginScope(scope)
+}
+
+// Register records the undo action of a fault in the default registry
+func Register(key, description string, revert func() error) {
+	defaultRegistry.Register(key, description, revert)
+}
+
+// Resolve drops the action of the given key from the default registry
+func Resolve(key string) {
+	defaultRegistry.Resolve(key)
+}
+
+// Pending returns the actions of the default registry which were not resolved yet
+func Pending() []*Action {
+	return defaultRegistry.Pending()
+}
+
+// Replay reverts the pending faults of the default registry in LIFO order
+func Replay() []*Failure {
+	return defaultRegistry.Replay()
+}
+
+// ReplayPrefix reverts the pending faults of the default registry whose key starts with the given prefix
+func ReplayPrefix(prefix string) []*Failure {
+	return defaultRegistry.ReplayPrefix(prefix)
+}
@@ -0,0 +1,65 @@
+package revertutils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	r := NewRegistry()
+	var reverted []string
+	revert := func(key string, err error) func() error {
+		return func() error {
+			reverted = append(reverted, key)
+			// the driver call undoing the fault resolves its own key while replayed
+			r.Resolve(key)
+			return err
+		}
+	}
+
+	r.BeginScope("test-1")
+	r.Register("maintenance/node-1", "enter maintenance", revert("maintenance/node-1", nil))
+	r.Register("cordon/node-2", "cordon", revert("cordon/node-2", fmt.Errorf("node not found")))
+	r.BeginScope("test-2")
+	r.Register("netem/node-3", "network delay", revert("netem/node-3", nil))
+	r.Register("netem/node-3", "network loss", revert("netem/node-3", nil))
+	r.Register("stop/node-4", "stop driver", revert("stop/node-4", nil))
+	r.Resolve("stop/node-4")
+
+	pending := r.Pending()
+	assert.Len(t, pending, 3)
+	assert.Equal(t, "network loss", pending[0].Description, "re-registering a key replaces its action")
+	assert.Equal(t, "test-2", pending[0].Scope)
+
+	failures := r.Replay()
+	assert.Equal(t, []string{"netem/node-3", "cordon/node-2", "maintenance/node-1"}, reverted, "faults are reverted in LIFO order")
+	if assert.Len(t, failures, 1) {
+		assert.Equal(t, "cordon/node-2", failures[0].Action.Key)
+		assert.Equal(t, "test-1", failures[0].Action.Scope)
+	}
+	assert.Empty(t, r.Pending())
+	assert.Empty(t, r.Replay(), "failed actions are not replayed again")
+}
+
+func TestReplayPrefix(t *testing.T) {
+	r := NewRegistry()
+	var reverted []string
+	revert := func(key string) func() error {
+		return func() error {
+			reverted = append(reverted, key)
+			return nil
+		}
+	}
+
+	r.Register("partition/A", "partition A", revert("partition/A"))
+	r.Register("diskfault/node-1/sdb", "disk fault", revert("diskfault/node-1/sdb"))
+	r.Register("partition/B", "partition B", revert("partition/B"))
+
+	assert.Empty(t, r.ReplayPrefix("partition/"))
+	assert.Equal(t, []string{"partition/B", "partition/A"}, reverted)
+	if pending := r.Pending(); assert.Len(t, pending, 1) {
+		assert.Equal(t, "diskfault/node-1/sdb", pending[0].Key, "actions of other prefixes stay pending")
+	}
+}
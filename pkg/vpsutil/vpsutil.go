@@ -2,15 +2,31 @@ package vpsutil
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/libopenstorage/openstorage/api"
 	"github.com/portworx/talisman/pkg/apis/portworx/v1beta1"
 	talisman_v1beta2 "github.com/portworx/talisman/pkg/apis/portworx/v1beta2"
 	"github.com/portworx/torpedo/drivers/scheduler"
-	"github.com/portworx/torpedo/tests"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// TopologyZoneKey is the label key used to look up the zone of a replica's node
+	TopologyZoneKey = "topology.kubernetes.io/zone"
+	// TopologyRegionKey is the label key used to look up the region of a replica's node
+	TopologyRegionKey = "topology.kubernetes.io/region"
+	// PxTopologyZoneKey is the node label key the zone of a portworx node is read from
+	PxTopologyZoneKey = "topology.portworx.io/zone"
+	// PxTopologyRegionKey is the node label key the region of a portworx node is read from
+	PxTopologyRegionKey = "topology.portworx.io/region"
+	// PxRackKey is the portworx node label key holding the rack of a node
+	PxRackKey = "rack"
+	// PlacementStrategyParam is the storage class parameter referring to a VolumePlacementStrategy
+	PlacementStrategyParam = "placement_strategy"
+)
+
 type VolumePlaceMentStrategyTestCase interface {
 	TestName() string
 	DeployVPS() error
@@ -70,7 +86,7 @@ func ValidateVolumeAntiAffinityByNode(vols []*api.Volume, volumeLabelKey string,
 		nodeList := getNodePlacement(vol)
 		labelValue := getVolumeLabelsValue(vol, volumeLabelKey)
 		for _, node := range nodeList {
-			if tests.Contains(volToNodeMap[labelValue], node) {
+			if contains(volToNodeMap[labelValue], node) {
 				return fmt.Errorf("failed to validate vps deployment, expecting vol to be place on unique node but vol %v is duplicated on node %v ... data: %v", labelValue, node, volToNodeMap)
 			}
 			volToNodeMap[labelValue] = append(volToNodeMap[labelValue], node)
@@ -93,7 +109,7 @@ func ValidateVolumeAffinityByNode(vols []*api.Volume, label string) error {
 		nodeList := getNodePlacement(vol)
 		labelValue := getVolumeLabelsValue(vol, label)
 		for _, node := range nodeList {
-			if tests.Contains(volToNodeMap[labelValue], node) {
+			if contains(volToNodeMap[labelValue], node) {
 				continue
 			}
 			volToNodeMap[labelValue] = append(volToNodeMap[labelValue], node)
@@ -107,3 +123,253 @@ func ValidateVolumeAffinityByNode(vols []*api.Volume, label string) error {
 	}
 	return nil
 }
+
+// ReplicaTopology holds the labels of the nodes and pools replicas can be placed on, used to validate
+// replica placement against topology and VolumePlacementStrategy rules
+type ReplicaTopology struct {
+	// NodeLabels is a map of volume driver node ID to the labels of that node
+	NodeLabels map[string]map[string]string
+	// PoolLabels is a map of pool UUID to the labels of that pool
+	PoolLabels map[string]map[string]string
+}
+
+// placementParams are the storage class parameters constraining where the replicas of a volume are placed
+var placementParams = []string{PlacementStrategyParam, api.SpecNodes, api.SpecZones, api.SpecRacks, api.SpecRack}
+
+// IsReplicaPlacementConstrained returns true if a VolumePlacementStrategy or a placement parameter of the
+// storage class constrains where the replicas of the volume are placed
+func IsReplicaPlacementConstrained(vol *api.Volume, params map[string]string) bool {
+	if vol.GetSpec().GetPlacementStrategy() != nil {
+		return true
+	}
+	for _, param := range placementParams {
+		if params[param] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateUniqueReplicaNodes validates that the replicas in each replica set of the volume are placed on unique nodes
+func ValidateUniqueReplicaNodes(vol *api.Volume) error {
+	for _, replicaSet := range vol.GetReplicaSets() {
+		var seenNodes []string
+		for _, nodeID := range replicaSet.Nodes {
+			if contains(seenNodes, nodeID) {
+				return fmt.Errorf("volume %s has more than one replica on node %s in replica set %d: %v",
+					vol.GetLocator().GetName(), nodeID, replicaSet.Id, replicaSet.Nodes)
+			}
+			seenNodes = append(seenNodes, nodeID)
+		}
+	}
+	return nil
+}
+
+// ValidateReplicaSpreadByTopology validates that the replicas in each replica set of the volume are placed on unique
+// nodes, and are spread across as many distinct values of the topologyKey as the cluster allows. Nodes that do not
+// carry the topologyKey label are ignored for the spread check.
+func ValidateReplicaSpreadByTopology(vol *api.Volume, topology ReplicaTopology, topologyKey string) error {
+	availableDomains := make(map[string]bool)
+	for _, labels := range topology.NodeLabels {
+		if value, ok := labels[topologyKey]; ok && value != "" {
+			availableDomains[value] = true
+		}
+	}
+
+	for _, replicaSet := range vol.GetReplicaSets() {
+		var seenNodes []string
+		usedDomains := make(map[string]bool)
+		labeledReplicas := 0
+		for _, nodeID := range replicaSet.Nodes {
+			if contains(seenNodes, nodeID) {
+				return fmt.Errorf("volume %s has more than one replica on node %s in replica set %d: %v",
+					vol.GetLocator().GetName(), nodeID, replicaSet.Id, replicaSet.Nodes)
+			}
+			seenNodes = append(seenNodes, nodeID)
+			if value, ok := topology.NodeLabels[nodeID][topologyKey]; ok && value != "" {
+				usedDomains[value] = true
+				labeledReplicas++
+			}
+		}
+
+		expectedDomains := labeledReplicas
+		if len(availableDomains) < expectedDomains {
+			expectedDomains = len(availableDomains)
+		}
+		if len(usedDomains) < expectedDomains {
+			return fmt.Errorf("volume %s replica set %d is placed on %d distinct [%s] values %v, expected %d out of %d available",
+				vol.GetLocator().GetName(), replicaSet.Id, len(usedDomains), topologyKey, mapKeys(usedDomains), expectedDomains, len(availableDomains))
+		}
+	}
+	return nil
+}
+
+// ValidateReplicaPlacementRules validates the replicas of the volume honor the required replica affinity and
+// anti-affinity rules of the given VolumePlacementStrategy. Preferred rules are best effort and are not validated.
+func ValidateReplicaPlacementRules(vol *api.Volume, vps *talisman_v1beta2.VolumePlacementStrategy, topology ReplicaTopology) error {
+	for _, rule := range vps.Spec.ReplicaAffinity {
+		if !isRequired(rule.Enforcement) {
+			continue
+		}
+		for _, replicaSet := range vol.GetReplicaSets() {
+			if err := validateReplicaRule(vol, replicaSet, rule, topology, true); err != nil {
+				return fmt.Errorf("vps %s: %v", vps.Name, err)
+			}
+		}
+	}
+
+	for _, rule := range vps.Spec.ReplicaAntiAffinity {
+		if !isRequired(rule.Enforcement) {
+			continue
+		}
+		for _, replicaSet := range vol.GetReplicaSets() {
+			if err := validateReplicaRule(vol, replicaSet, rule, topology, false); err != nil {
+				return fmt.Errorf("vps %s: %v", vps.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateReplicaRule(vol *api.Volume, replicaSet *api.ReplicaSet, rule *talisman_v1beta2.ReplicaPlacementSpec,
+	topology ReplicaTopology, affinity bool) error {
+	affectedReplicas := len(replicaSet.Nodes)
+	if rule.AffectedReplicas > 0 && int(rule.AffectedReplicas) < affectedReplicas {
+		affectedReplicas = int(rule.AffectedReplicas)
+	}
+
+	if len(rule.MatchExpressions) > 0 {
+		matchingReplicas := 0
+		for i, nodeID := range replicaSet.Nodes {
+			labels := replicaLabels(topology, nodeID, replicaSet.PoolUuids, i)
+			matched, skip := matchExpressions(rule.MatchExpressions, labels)
+			if skip {
+				return nil
+			}
+			if matched {
+				matchingReplicas++
+			}
+		}
+		if affinity && matchingReplicas < affectedReplicas {
+			return fmt.Errorf("volume %s has %d replicas matching replica affinity expressions %v, expected at least %d",
+				vol.GetLocator().GetName(), matchingReplicas, describeExpressions(rule.MatchExpressions), affectedReplicas)
+		}
+		if !affinity && matchingReplicas > len(replicaSet.Nodes)-affectedReplicas {
+			return fmt.Errorf("volume %s has %d replicas matching replica anti-affinity expressions %v",
+				vol.GetLocator().GetName(), matchingReplicas, describeExpressions(rule.MatchExpressions))
+		}
+	}
+
+	if rule.TopologyKey != "" {
+		domains := make(map[string]bool)
+		for _, nodeID := range replicaSet.Nodes {
+			if value, ok := topology.NodeLabels[nodeID][rule.TopologyKey]; ok {
+				domains[value] = true
+			}
+		}
+		if affinity && len(domains) > 1 {
+			return fmt.Errorf("volume %s replicas are spread across [%s] values %v, expected a single value due to replica affinity",
+				vol.GetLocator().GetName(), rule.TopologyKey, mapKeys(domains))
+		}
+		if !affinity && len(domains) < affectedReplicas {
+			return fmt.Errorf("volume %s replicas are placed on %d distinct [%s] values %v, expected %d due to replica anti-affinity",
+				vol.GetLocator().GetName(), len(domains), rule.TopologyKey, mapKeys(domains), affectedReplicas)
+		}
+	}
+	return nil
+}
+
+// replicaLabels merges the labels of the node hosting a replica with the labels of its pool
+func replicaLabels(topology ReplicaTopology, nodeID string, poolUUIDs []string, index int) map[string]string {
+	labels := make(map[string]string)
+	for k, v := range topology.NodeLabels[nodeID] {
+		labels[k] = v
+	}
+	if index < len(poolUUIDs) {
+		for k, v := range topology.PoolLabels[poolUUIDs[index]] {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// matchExpressions returns true if all expressions match the given labels. skip is returned true if any expression
+// refers to a templated value (e.g ${pvc.labels.app}) which can't be evaluated without the pvc
+func matchExpressions(expressions []*v1beta1.LabelSelectorRequirement, labels map[string]string) (matched bool, skip bool) {
+	for _, expression := range expressions {
+		for _, value := range expression.Values {
+			if strings.Contains(value, "${") {
+				return false, true
+			}
+		}
+		labelValue, exists := labels[expression.Key]
+		switch expression.Operator {
+		case v1beta1.LabelSelectorOpIn:
+			if !exists || !contains(expression.Values, labelValue) {
+				return false, false
+			}
+		case v1beta1.LabelSelectorOpNotIn:
+			if exists && contains(expression.Values, labelValue) {
+				return false, false
+			}
+		case v1beta1.LabelSelectorOpExists:
+			if !exists {
+				return false, false
+			}
+		case v1beta1.LabelSelectorOpDoesNotExist:
+			if exists {
+				return false, false
+			}
+		case v1beta1.LabelSelectorOpGt, v1beta1.LabelSelectorOpLt:
+			if !exists || len(expression.Values) != 1 {
+				return false, false
+			}
+			actual, err := strconv.ParseInt(labelValue, 10, 64)
+			if err != nil {
+				return false, false
+			}
+			expected, err := strconv.ParseInt(expression.Values[0], 10, 64)
+			if err != nil {
+				return false, false
+			}
+			if expression.Operator == v1beta1.LabelSelectorOpGt && actual <= expected {
+				return false, false
+			}
+			if expression.Operator == v1beta1.LabelSelectorOpLt && actual >= expected {
+				return false, false
+			}
+		default:
+			return false, true
+		}
+	}
+	return true, false
+}
+
+func describeExpressions(expressions []*v1beta1.LabelSelectorRequirement) []string {
+	var out []string
+	for _, expression := range expressions {
+		out = append(out, fmt.Sprintf("%s %s %v", expression.Key, expression.Operator, expression.Values))
+	}
+	return out
+}
+
+func isRequired(enforcement v1beta1.EnforcementType) bool {
+	return enforcement == "" || enforcement == v1beta1.EnforcementRequired
+}
+
+func mapKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func contains(list []string, item string) bool {
+	for _, value := range list {
+		if value == item {
+			return true
+		}
+	}
+	return false
+}
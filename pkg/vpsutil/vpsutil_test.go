@@ -0,0 +1,170 @@
+package vpsutil
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/portworx/talisman/pkg/apis/portworx/v1beta1"
+	talisman_v1beta2 "github.com/portworx/talisman/pkg/apis/portworx/v1beta2"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newVolume(replicaNodes []string, poolUUIDs []string) *api.Volume {
+	return &api.Volume{
+		Locator:     &api.VolumeLocator{Name: "vol"},
+		ReplicaSets: []*api.ReplicaSet{{Nodes: replicaNodes, PoolUuids: poolUUIDs}},
+	}
+}
+
+var testTopology = ReplicaTopology{
+	NodeLabels: map[string]map[string]string{
+		"n1": {TopologyZoneKey: "z1", PxRackKey: "r1"},
+		"n2": {TopologyZoneKey: "z1", PxRackKey: "r2"},
+		"n3": {TopologyZoneKey: "z2", PxRackKey: "r3"},
+		"n4": {},
+	},
+	PoolLabels: map[string]map[string]string{
+		"p1": {"media": "ssd"},
+		"p2": {"media": "hdd"},
+		"p3": {"media": "ssd"},
+	},
+}
+
+func TestValidateReplicaSpreadByTopology(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []string
+		wantErr bool
+	}{
+		{name: "spread across zones", nodes: []string{"n1", "n3"}},
+		{name: "same zone while another is available", nodes: []string{"n1", "n2"}, wantErr: true},
+		{name: "more replicas than zones", nodes: []string{"n1", "n2", "n3"}},
+		{name: "unlabeled node is ignored", nodes: []string{"n1", "n4"}},
+		{name: "two replicas on one node", nodes: []string{"n1", "n1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateReplicaSpreadByTopology(newVolume(tt.nodes, nil), testTopology, TopologyZoneKey)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateUniqueReplicaNodes(t *testing.T) {
+	assert.NoError(t, ValidateUniqueReplicaNodes(newVolume([]string{"n1", "n2"}, nil)))
+	assert.Error(t, ValidateUniqueReplicaNodes(newVolume([]string{"n1", "n1"}, nil)), "two replicas on one node")
+}
+
+func TestIsReplicaPlacementConstrained(t *testing.T) {
+	vol := newVolume([]string{"n1"}, nil)
+	assert.False(t, IsReplicaPlacementConstrained(vol, map[string]string{"repl": "2"}))
+	assert.True(t, IsReplicaPlacementConstrained(vol, map[string]string{PlacementStrategyParam: "vps"}))
+	assert.True(t, IsReplicaPlacementConstrained(vol, map[string]string{api.SpecZones: "z1"}))
+	assert.True(t, IsReplicaPlacementConstrained(vol, map[string]string{api.SpecRacks: "r1,r2"}))
+
+	vol.Spec = &api.VolumeSpec{PlacementStrategy: &api.VolumePlacementStrategy{}}
+	assert.True(t, IsReplicaPlacementConstrained(vol, nil), "placement strategy of the volume spec")
+}
+
+func replicaRule(enforcement v1beta1.EnforcementType, topologyKey string, expressions []*v1beta1.LabelSelectorRequirement) []*talisman_v1beta2.ReplicaPlacementSpec {
+	return []*talisman_v1beta2.ReplicaPlacementSpec{{CommonPlacementSpec: talisman_v1beta2.CommonPlacementSpec{
+		Enforcement:      enforcement,
+		TopologyKey:      topologyKey,
+		MatchExpressions: expressions,
+	}}}
+}
+
+func TestValidateReplicaPlacementRules(t *testing.T) {
+	ssd := []*v1beta1.LabelSelectorRequirement{{Key: "media", Operator: v1beta1.LabelSelectorOpIn, Values: []string{"ssd"}}}
+	tests := []struct {
+		name    string
+		spec    talisman_v1beta2.VolumePlacementSpec
+		nodes   []string
+		pools   []string
+		wantErr bool
+	}{
+		{
+			name:  "affinity to ssd pools",
+			spec:  talisman_v1beta2.VolumePlacementSpec{ReplicaAffinity: replicaRule("", "", ssd)},
+			nodes: []string{"n1", "n3"},
+			pools: []string{"p1", "p3"},
+		},
+		{
+			name:    "affinity to ssd pools violated",
+			spec:    talisman_v1beta2.VolumePlacementSpec{ReplicaAffinity: replicaRule("", "", ssd)},
+			nodes:   []string{"n1", "n2"},
+			pools:   []string{"p1", "p2"},
+			wantErr: true,
+		},
+		{
+			name:  "preferred affinity is not validated",
+			spec:  talisman_v1beta2.VolumePlacementSpec{ReplicaAffinity: replicaRule(v1beta1.EnforcementPreferred, "", ssd)},
+			nodes: []string{"n1", "n2"},
+			pools: []string{"p1", "p2"},
+		},
+		{
+			name:  "anti-affinity by rack",
+			spec:  talisman_v1beta2.VolumePlacementSpec{ReplicaAntiAffinity: replicaRule("", PxRackKey, nil)},
+			nodes: []string{"n1", "n2"},
+		},
+		{
+			name:    "anti-affinity by zone violated",
+			spec:    talisman_v1beta2.VolumePlacementSpec{ReplicaAntiAffinity: replicaRule("", TopologyZoneKey, nil)},
+			nodes:   []string{"n1", "n2"},
+			wantErr: true,
+		},
+		{
+			name:    "affinity by zone violated",
+			spec:    talisman_v1beta2.VolumePlacementSpec{ReplicaAffinity: replicaRule("", TopologyZoneKey, nil)},
+			nodes:   []string{"n1", "n3"},
+			wantErr: true,
+		},
+		{
+			name: "templated values are skipped",
+			spec: talisman_v1beta2.VolumePlacementSpec{ReplicaAffinity: replicaRule("", "", []*v1beta1.LabelSelectorRequirement{
+				{Key: "app", Operator: v1beta1.LabelSelectorOpIn, Values: []string{"${pvc.labels.app}"}}})},
+			nodes: []string{"n1", "n2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vps := &talisman_v1beta2.VolumePlacementStrategy{ObjectMeta: v1.ObjectMeta{Name: "vps"}, Spec: tt.spec}
+			err := ValidateReplicaPlacementRules(newVolume(tt.nodes, tt.pools), vps, testTopology)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	labels := map[string]string{"media": "ssd", "iops": "500"}
+	tests := []struct {
+		name        string
+		expression  *v1beta1.LabelSelectorRequirement
+		wantMatched bool
+		wantSkip    bool
+	}{
+		{name: "in", expression: &v1beta1.LabelSelectorRequirement{Key: "media", Operator: v1beta1.LabelSelectorOpIn, Values: []string{"ssd"}}, wantMatched: true},
+		{name: "not in", expression: &v1beta1.LabelSelectorRequirement{Key: "media", Operator: v1beta1.LabelSelectorOpNotIn, Values: []string{"ssd"}}},
+		{name: "exists", expression: &v1beta1.LabelSelectorRequirement{Key: "iops", Operator: v1beta1.LabelSelectorOpExists}, wantMatched: true},
+		{name: "does not exist", expression: &v1beta1.LabelSelectorRequirement{Key: "rack", Operator: v1beta1.LabelSelectorOpDoesNotExist}, wantMatched: true},
+		{name: "gt", expression: &v1beta1.LabelSelectorRequirement{Key: "iops", Operator: v1beta1.LabelSelectorOpGt, Values: []string{"100"}}, wantMatched: true},
+		{name: "lt", expression: &v1beta1.LabelSelectorRequirement{Key: "iops", Operator: v1beta1.LabelSelectorOpLt, Values: []string{"100"}}},
+		{name: "templated", expression: &v1beta1.LabelSelectorRequirement{Key: "app", Operator: v1beta1.LabelSelectorOpIn, Values: []string{"${pvc.labels.app}"}}, wantSkip: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, skip := matchExpressions([]*v1beta1.LabelSelectorRequirement{tt.expression}, labels)
+			assert.Equal(t, tt.wantMatched, matched)
+			assert.Equal(t, tt.wantSkip, skip)
+		})
+	}
+}
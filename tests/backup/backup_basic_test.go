@@ -9,6 +9,7 @@ import (
 	"github.com/portworx/torpedo/drivers"
 	"github.com/portworx/torpedo/drivers/backup"
 	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/objectstore"
 	"github.com/portworx/torpedo/drivers/scheduler"
 	"github.com/portworx/torpedo/pkg/aetosutil"
 	"github.com/portworx/torpedo/pkg/log"
@@ -36,6 +37,8 @@ func getGlobalBucketName(provider string) string {
 		return globalAzureBucketName
 	case drivers.ProviderGke:
 		return globalGCPBucketName
+	case drivers.ProviderNfs:
+		return globalNFSSubPath
 	default:
 		return globalAWSBucketName
 	}
@@ -140,6 +143,10 @@ var _ = BeforeSuite(func() {
 			globalGCPBucketName = fmt.Sprintf("%s-%s", globalGCPBucketPrefix, bucketNameSuffix)
 			CreateBucket(provider, globalGCPBucketName)
 			log.Infof("Bucket created with name - %s", globalGCPBucketName)
+		case drivers.ProviderNfs:
+			globalNFSSubPath = fmt.Sprintf("%s-%s", globalNFSSubPathPrefix, bucketNameSuffix)
+			CreateBucket(provider, globalNFSSubPath)
+			log.Infof("NFS sub path created with name - %s", globalNFSSubPath)
 		}
 	}
 	lockedBucketNameSuffix, present := os.LookupEnv("LOCKED_BUCKET_NAME")
@@ -259,6 +266,11 @@ var _ = AfterSuite(func() {
 		case drivers.ProviderGke:
 			DeleteBucket(provider, globalGCPBucketName)
 			log.Infof("Bucket deleted - %s", globalGCPBucketName)
+		case drivers.ProviderNfs:
+			DeleteBucket(provider, globalNFSSubPath)
+			log.Infof("NFS sub path deleted - %s", globalNFSSubPath)
+			err := objectstore.StopNFSHelper()
+			dash.VerifySafely(err, nil, "Stopping the NFS helper pod")
 		}
 	}
 
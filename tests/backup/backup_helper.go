@@ -23,13 +23,18 @@ import (
 	api "github.com/portworx/px-backup-api/pkg/apis/v1"
 	"github.com/portworx/sched-ops/k8s/apps"
 	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/sched-ops/k8s/dynamic"
 	"github.com/portworx/sched-ops/k8s/operator"
 	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers"
 	"github.com/portworx/torpedo/drivers/backup"
+	"github.com/portworx/torpedo/drivers/objectstore"
 	"github.com/portworx/torpedo/drivers/scheduler/k8s"
+	"github.com/portworx/torpedo/pkg/kdmputils"
 	"github.com/portworx/torpedo/pkg/log"
 	. "github.com/portworx/torpedo/tests"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 const (
@@ -60,6 +65,7 @@ const (
 	globalAWSBucketPrefix                     = "global-aws"
 	globalAzureBucketPrefix                   = "global-azure"
 	globalGCPBucketPrefix                     = "global-gcp"
+	globalNFSSubPathPrefix                    = "global-nfs"
 	globalAWSLockedBucketPrefix               = "global-aws-locked"
 	globalAzureLockedBucketPrefix             = "global-azure-locked"
 	globalGCPLockedBucketPrefix               = "global-gcp-locked"
@@ -80,6 +86,9 @@ const (
 	jobDeleteRetryTime                        = 10 * time.Second
 	podStatusTimeOut                          = 20 * time.Minute
 	podStatusRetryTime                        = 30 * time.Second
+	dataMoverObserveInterval                  = 5 * time.Second
+	dataMoverCleanupTimeout                   = 10 * time.Minute
+	dataMoverCleanupRetryTime                 = 30 * time.Second
 )
 
 var (
@@ -89,6 +98,7 @@ var (
 	globalAWSBucketName         string
 	globalAzureBucketName       string
 	globalGCPBucketName         string
+	globalNFSSubPath            string
 	globalAWSLockedBucketName   string
 	globalAzureLockedBucketName string
 	globalGCPLockedBucketName   string
@@ -778,20 +788,23 @@ func CleanupCloudSettingsAndClusters(backupLocationMap map[string]string, credNa
 			_, err = task.DoRetryWithTimeout(backupLocationDeleteStatusCheck, cloudAccountDeleteTimeout, cloudAccountDeleteRetryTime)
 			Inst().Dash.VerifySafely(err, nil, fmt.Sprintf("Verifying backup location deletion status %s", bkpLocationName))
 		}
-		err := DeleteCloudCredential(credName, orgID, cloudCredUID)
-		Inst().Dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying deletion of cloud cred [%s]", credName))
-		cloudCredDeleteStatus := func() (interface{}, bool, error) {
-			status, err := IsCloudCredPresent(credName, ctx, orgID)
-			if err != nil {
-				return "", true, fmt.Errorf("cloud cred %s still present with error %v", credName, err)
-			}
-			if status {
-				return "", true, fmt.Errorf("cloud cred %s is not deleted yet", credName)
+		// NFS backup locations have no cloud credential
+		if credName != "" {
+			err := DeleteCloudCredential(credName, orgID, cloudCredUID)
+			Inst().Dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying deletion of cloud cred [%s]", credName))
+			cloudCredDeleteStatus := func() (interface{}, bool, error) {
+				status, err := IsCloudCredPresent(credName, ctx, orgID)
+				if err != nil {
+					return "", true, fmt.Errorf("cloud cred %s still present with error %v", credName, err)
+				}
+				if status {
+					return "", true, fmt.Errorf("cloud cred %s is not deleted yet", credName)
+				}
+				return "", false, nil
 			}
-			return "", false, nil
+			_, err = task.DoRetryWithTimeout(cloudCredDeleteStatus, cloudAccountDeleteTimeout, cloudAccountDeleteRetryTime)
+			Inst().Dash.VerifySafely(err, nil, fmt.Sprintf("Deleting cloud cred %s", credName))
 		}
-		_, err = task.DoRetryWithTimeout(cloudCredDeleteStatus, cloudAccountDeleteTimeout, cloudAccountDeleteRetryTime)
-		Inst().Dash.VerifySafely(err, nil, fmt.Sprintf("Deleting cloud cred %s", credName))
 	}
 	err := DeleteCluster(SourceClusterName, orgID, ctx)
 	Inst().Dash.VerifySafely(err, nil, fmt.Sprintf("Deleting cluster %s", SourceClusterName))
@@ -1139,48 +1152,7 @@ func DeletePodWithLabelInNamespace(namespace string, label map[string]string) er
 
 // backupSuccessCheck inspects backup task
 func backupSuccessCheck(backupName string, orgID string, retryDuration time.Duration, retryInterval time.Duration, ctx context.Context) error {
-	bkpUid, err := Inst().Backup.GetBackupUID(ctx, backupName, orgID)
-	if err != nil {
-		return err
-	}
-	backupInspectRequest := &api.BackupInspectRequest{
-		Name:  backupName,
-		Uid:   bkpUid,
-		OrgId: orgID,
-	}
-	statusesExpected := [...]api.BackupInfo_StatusInfo_Status{
-		api.BackupInfo_StatusInfo_Success,
-	}
-	statusesUnexpected := [...]api.BackupInfo_StatusInfo_Status{
-		api.BackupInfo_StatusInfo_Invalid,
-		api.BackupInfo_StatusInfo_Aborted,
-		api.BackupInfo_StatusInfo_Failed,
-	}
-	backupSuccessCheckFunc := func() (interface{}, bool, error) {
-		resp, err := Inst().Backup.InspectBackup(ctx, backupInspectRequest)
-		if err != nil {
-			return "", false, err
-		}
-		actual := resp.GetBackup().GetStatus().Status
-		reason := resp.GetBackup().GetStatus().Reason
-		for _, status := range statusesExpected {
-			if actual == status {
-				return "", false, nil
-			}
-		}
-		for _, status := range statusesUnexpected {
-			if actual == status {
-				return "", false, fmt.Errorf("backup status for [%s] expected was [%s] but got [%s] because of [%s]", backupName, statusesExpected, actual, reason)
-			}
-		}
-		return "", true, fmt.Errorf("backup status for [%s] expected was [%s] but got [%s] because of [%s]", backupName, statusesExpected, actual, reason)
-
-	}
-	_, err = task.DoRetryWithTimeout(backupSuccessCheckFunc, retryDuration, retryInterval)
-	if err != nil {
-		return err
-	}
-	return nil
+	return backup.CheckBackupSuccess(ctx, Inst().Backup, backupName, orgID, retryDuration, retryInterval)
 }
 
 // restoreSuccessCheck inspects restore task
@@ -1350,20 +1322,176 @@ func CreateCustomRestoreWithPVCs(restoreName string, backupName string, namespac
 
 // GetOrdinalScheduleBackupName returns the name of the schedule backup at the specified ordinal position for the given schedule
 func GetOrdinalScheduleBackupName(ctx context.Context, scheduleName string, ordinal int, orgID string) (string, error) {
-	if ordinal < 1 {
-		return "", fmt.Errorf("the provided ordinal value [%d] for schedule backups with schedule name [%s] is invalid. valid values range from 1", ordinal, scheduleName)
+	return backup.GetOrdinalScheduleBackupName(ctx, Inst().Backup, scheduleName, ordinal, orgID)
+}
+
+// NewScheduleRetentionValidator returns a validator of the backups of the given schedule retained by px-backup
+// and in the given bucket of the provider
+func NewScheduleRetentionValidator(provider string, bucketName string, scheduleName string, retain int64, locked bool) (*backup.RetentionValidator, error) {
+	var storeName string
+	switch provider {
+	case drivers.ProviderAws:
+		storeName = objectstore.S3DriverName
+	case drivers.ProviderAzure:
+		storeName = objectstore.AzureDriverName
+	case drivers.ProviderGke:
+		storeName = objectstore.GCSDriverName
+	case drivers.ProviderNfs:
+		storeName = objectstore.NFSDriverName
+	default:
+		return nil, fmt.Errorf("no objectstore driver for provider [%s]", provider)
+	}
+	store, err := objectstore.GetDriver(storeName)
+	if err != nil {
+		return nil, err
 	}
-	allScheduleBackupNames, err := Inst().Backup.GetAllScheduleBackupNames(ctx, scheduleName, orgID)
+	return backup.NewRetentionValidator(Inst().Backup, store, bucketName, orgID, scheduleName, retain, locked), nil
+}
+
+// WaitForNextScheduleBackup waits for the schedule to take a backup after the given number of backups and
+// for that backup to succeed, and returns its name
+func WaitForNextScheduleBackup(ctx context.Context, scheduleName string, backupCount int, orgID string, timeout time.Duration) (string, error) {
+	nextScheduleBackup, err := task.DoRetryWithTimeout(func() (interface{}, bool, error) {
+		allScheduleBackupNames, err := Inst().Backup.GetAllScheduleBackupNames(ctx, scheduleName, orgID)
+		if err != nil {
+			return "", true, err
+		}
+		if len(allScheduleBackupNames) <= backupCount {
+			return "", true, fmt.Errorf("schedule [%s] has taken %d backups, waiting for backup %d", scheduleName, len(allScheduleBackupNames), backupCount+1)
+		}
+		return allScheduleBackupNames[backupCount], false, nil
+	}, timeout, time.Minute)
 	if err != nil {
 		return "", err
 	}
-	if len(allScheduleBackupNames) == 0 {
-		return "", fmt.Errorf("no backups were found for the schedule [%s]", scheduleName)
+	nextScheduleBackupName := nextScheduleBackup.(string)
+	err = backupSuccessCheck(nextScheduleBackupName, orgID, maxWaitPeriodForBackupCompletionInMinutes*time.Minute, 30*time.Second, ctx)
+	if err != nil {
+		return "", err
 	}
-	if ordinal > len(allScheduleBackupNames) {
-		return "", fmt.Errorf("schedule backups with schedule name [%s] have not been created up to the provided ordinal value [%d]", scheduleName, ordinal)
+	return nextScheduleBackupName, nil
+}
+
+// CreateBackupWithType creates a backup of the namespaces of the given type, so a generic backup forces the
+// volumes through the KDMP data mover, and waits for it to succeed. It returns the DataExports and the jobs
+// of the data mover seen while the backup ran.
+func CreateBackupWithType(backupName string, clusterName string, bLocation string, bLocationUID string,
+	namespaces []string, labelSelectors map[string]string, orgID string, uid string,
+	backupType api.BackupCreateRequest_BackupType, ctx context.Context) (*kdmputils.Observer, error) {
+	backupDriver := Inst().Backup
+	bkpCreateRequest := &api.BackupCreateRequest{
+		CreateMetadata: &api.CreateMetadata{
+			Name:  backupName,
+			OrgId: orgID,
+		},
+		BackupLocationRef: &api.ObjectRef{
+			Name: bLocation,
+			Uid:  bLocationUID,
+		},
+		Cluster:        clusterName,
+		Namespaces:     namespaces,
+		LabelSelectors: labelSelectors,
+		ClusterRef: &api.ObjectRef{
+			Name: clusterName,
+			Uid:  uid,
+		},
+		BackupType: backupType,
+	}
+	_, err := backupDriver.CreateBackup(ctx, bkpCreateRequest)
+	if err != nil {
+		return nil, err
+	}
+	backupUid, err := backupDriver.GetBackupUID(ctx, backupName, orgID)
+	if err != nil {
+		return nil, err
+	}
+	backupInspectRequest := &api.BackupInspectRequest{
+		Name:  backupName,
+		Uid:   backupUid,
+		OrgId: orgID,
+	}
+	observer := kdmputils.NewObserver(namespaces)
+	backupObserveFunc := func() (interface{}, bool, error) {
+		exports, jobs, err := listDataMoverObjects()
+		if err != nil {
+			log.Warnf("Failed to list the objects of the data mover. Err: %v", err)
+		} else {
+			observer.Observe(exports, jobs)
+		}
+		resp, err := backupDriver.InspectBackup(ctx, backupInspectRequest)
+		if err != nil {
+			return "", true, err
+		}
+		status := resp.GetBackup().GetStatus()
+		switch status.GetStatus() {
+		case api.BackupInfo_StatusInfo_Success:
+			return "", false, nil
+		case api.BackupInfo_StatusInfo_Invalid, api.BackupInfo_StatusInfo_Aborted, api.BackupInfo_StatusInfo_Failed:
+			return "", false, fmt.Errorf("backup [%s] is [%s] because of [%s]", backupName, status.GetStatus(), status.GetReason())
+		}
+		return "", true, fmt.Errorf("backup [%s] is [%s], waiting for it to succeed", backupName, status.GetStatus())
+	}
+	_, err = task.DoRetryWithTimeout(backupObserveFunc, maxWaitPeriodForBackupCompletionInMinutes*time.Minute, dataMoverObserveInterval)
+	if err != nil {
+		return observer, err
+	}
+	log.Infof("Backup [%s] of type [%s] created successfully", backupName, backupType)
+	return observer, nil
+}
+
+// ValidateGenericBackup checks that every volume of the backup was backed up by the KDMP data mover and
+// succeeded, and waits for the DataExports and the jobs of the data mover to be cleaned up
+func ValidateGenericBackup(backupName string, orgID string, observer *kdmputils.Observer, ctx context.Context) error {
+	backupUid, err := Inst().Backup.GetBackupUID(ctx, backupName, orgID)
+	if err != nil {
+		return err
+	}
+	resp, err := Inst().Backup.InspectBackup(ctx, &api.BackupInspectRequest{
+		Name:  backupName,
+		Uid:   backupUid,
+		OrgId: orgID,
+	})
+	if err != nil {
+		return err
+	}
+	volumes := resp.GetBackup().GetVolumes()
+	if err := kdmputils.ValidateVolumeDrivers(volumes, kdmputils.GenericDriverName); err != nil {
+		return fmt.Errorf("backup [%s] is not generic. Err: %v", backupName, err)
+	}
+	if err := observer.Validate(volumes); err != nil {
+		return err
+	}
+	log.Infof("Backup [%s] went through DataExports %v and jobs %v", backupName, observer.DataExports(), observer.Jobs())
+	dataMoverCleanupFunc := func() (interface{}, bool, error) {
+		exports, jobs, err := listDataMoverObjects()
+		if err != nil {
+			return "", true, err
+		}
+		if leftovers := observer.Leftovers(exports, jobs); len(leftovers) > 0 {
+			return "", true, fmt.Errorf("objects %v of the data mover are not cleaned up yet after backup [%s]", leftovers, backupName)
+		}
+		return "", false, nil
+	}
+	_, err = task.DoRetryWithTimeout(dataMoverCleanupFunc, dataMoverCleanupTimeout, dataMoverCleanupRetryTime)
+	return err
+}
+
+// listDataMoverObjects returns the DataExports and the jobs of the KDMP data mover in all the namespaces
+func listDataMoverObjects() ([]unstructured.Unstructured, []batchv1.Job, error) {
+	exports, err := dynamic.Instance().ListObjects(&metav1.ListOptions{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: kdmputils.DataExportAPIVersion,
+			Kind:       kdmputils.DataExportKind,
+		},
+	}, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list DataExports. Err: %v", err)
+	}
+	jobs, err := batch.Instance().ListAllJobs("", metav1.ListOptions{LabelSelector: kdmputils.DriverNameLabel})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list the jobs of the data mover. Err: %v", err)
 	}
-	return allScheduleBackupNames[ordinal-1], nil
+	return exports.Items, jobs.Items, nil
 }
 
 // GetFirstScheduleBackupName returns the name of the first schedule backup for the given schedule
@@ -2026,3 +2154,95 @@ func MapToKeyValueString(m map[string]string) string {
 	}
 	return strings.Join(pairs, ",")
 }
+
+// NewRBACUserPrincipals creates users with the px-backup application user role, and groups with the same role
+// each with a new member, and returns them as principals of an RBAC matrix. The operations of a group run as its
+// member. The principals register the source and destination clusters, so they can restore what is shared.
+func NewRBACUserPrincipals(userNames []string, groupNames []string) ([]backup.RBACPrincipal, error) {
+	principals := make([]backup.RBACPrincipal, 0)
+	newPrincipal := func(userName string, groupName string) (backup.RBACPrincipal, error) {
+		principal := backup.RBACPrincipal{Name: userName}
+		err := backup.AddUser(userName, "FirstName", "LastName", fmt.Sprintf("%s@cnbu.com", userName), commonPassword)
+		if err != nil {
+			return principal, fmt.Errorf("failed to create user [%s]. Err: %v", userName, err)
+		}
+		err = backup.AddRoleToUser(userName, backup.ApplicationUser, "Adding role to RBAC matrix user")
+		if err != nil {
+			return principal, fmt.Errorf("failed to add role to user [%s]. Err: %v", userName, err)
+		}
+		principal.ID, err = backup.FetchIDOfUser(userName)
+		if err != nil {
+			return principal, fmt.Errorf("failed to fetch the id of user [%s]. Err: %v", userName, err)
+		}
+		if groupName != "" {
+			err = backup.AddGroup(groupName)
+			if err != nil {
+				return principal, fmt.Errorf("failed to create group [%s]. Err: %v", groupName, err)
+			}
+			err = backup.AddRoleToGroup(groupName, backup.ApplicationUser, "Adding role to RBAC matrix group")
+			if err != nil {
+				return principal, fmt.Errorf("failed to add role to group [%s]. Err: %v", groupName, err)
+			}
+			err = backup.AddGroupToUser(userName, groupName)
+			if err != nil {
+				return principal, fmt.Errorf("failed to add user [%s] to group [%s]. Err: %v", userName, groupName, err)
+			}
+			principal = backup.RBACPrincipal{Name: groupName, ID: groupName, Group: true}
+		}
+		principal.Ctx, err = backup.GetNonAdminCtx(userName, commonPassword)
+		if err != nil {
+			return principal, fmt.Errorf("failed to fetch the ctx of user [%s]. Err: %v", userName, err)
+		}
+		err = CreateSourceAndDestClusters(orgID, "", "", principal.Ctx)
+		if err != nil {
+			return principal, fmt.Errorf("failed to register the clusters for user [%s]. Err: %v", userName, err)
+		}
+		return principal, nil
+	}
+	for _, userName := range userNames {
+		principal, err := newPrincipal(userName, "")
+		if err != nil {
+			return nil, err
+		}
+		principals = append(principals, principal)
+	}
+	for _, groupName := range groupNames {
+		principal, err := newPrincipal(fmt.Sprintf("%s-member", groupName), groupName)
+		if err != nil {
+			return nil, err
+		}
+		principals = append(principals, principal)
+	}
+	return principals, nil
+}
+
+// RegisterRBACRestoreOperation registers on the engine the restore of backups to the destination cluster, allowed
+// from restorable access. Each restore maps the namespaces of the backup to new ones, which are appended to
+// restoredNamespaces, and is deleted once it succeeded.
+func RegisterRBACRestoreOperation(e *backup.RBACEngine, adminCtx context.Context, restoredNamespaces *[]string) {
+	restores := 0
+	e.RegisterOperation(backup.BackupKind, backup.RBACOperation{
+		Name:      "Restore",
+		MinAccess: backup.RestorableAccess,
+		Run: func(ctx context.Context, r backup.RBACResource) error {
+			resp, err := Inst().Backup.InspectBackup(adminCtx, &api.BackupInspectRequest{OrgId: r.OrgID, Name: r.Name, Uid: r.UID})
+			if err != nil {
+				return fmt.Errorf("failed to inspect backup [%s] as admin. Err: %v", r.Name, err)
+			}
+			restores++
+			restoreName := fmt.Sprintf("%s-rbac-%d-%v", restoreNamePrefix, restores, time.Now().Unix())
+			namespaceMapping := make(map[string]string)
+			for _, namespace := range resp.GetBackup().GetNamespaces() {
+				namespaceMapping[namespace] = fmt.Sprintf("%s-rbac%d", namespace, restores)
+			}
+			err = CreateRestore(restoreName, r.Name, namespaceMapping, destinationClusterName, r.OrgID, ctx, make(map[string]string))
+			if err != nil {
+				return err
+			}
+			for _, namespace := range namespaceMapping {
+				*restoredNamespaces = append(*restoredNamespaces, namespace)
+			}
+			return DeleteRestore(restoreName, r.OrgID, ctx)
+		},
+	})
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/pborman/uuid"
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"github.com/portworx/torpedo/drivers/backup"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/pkg/log"
+	. "github.com/portworx/torpedo/tests"
+)
+
+// This testcase forces a generic backup of every namespace through the KDMP data mover, verifies the
+// DataExports and the jobs of the data mover and their cleanup, and compares the restored data
+var _ = Describe("{GenericBackupWithKDMP}", func() {
+	var (
+		contexts          []*scheduler.Context
+		appContexts       []*scheduler.Context
+		bkpNamespaces     []string
+		clusterUid        string
+		clusterStatus     api.ClusterInfo_StatusInfo_Status
+		cloudCredName     string
+		cloudCredUID      string
+		backupLocationUID string
+		bkpLocationName   string
+		backupLocationMap map[string]string
+		backupNames       map[string]string
+		restoreNames      []string
+		labelSelectors    map[string]string
+		dataSnapshot      *BackupDataSnapshot
+	)
+
+	JustBeforeEach(func() {
+		StartTorpedoTest("GenericBackupWithKDMP", "Generic backup and restore of every namespace through the KDMP data mover", nil, 0)
+		bkpNamespaces = make([]string, 0)
+		backupLocationMap = make(map[string]string)
+		backupNames = make(map[string]string)
+		restoreNames = make([]string, 0)
+		labelSelectors = make(map[string]string)
+		log.InfoD("Deploy applications")
+		contexts = make([]*scheduler.Context, 0)
+		for i := 0; i < Inst().GlobalScaleFactor; i++ {
+			taskName := fmt.Sprintf("%s-%d", taskNamePrefix, i)
+			appContexts = ScheduleApplications(taskName)
+			contexts = append(contexts, appContexts...)
+			for _, ctx := range appContexts {
+				ctx.ReadinessTimeout = appReadinessTimeout
+				namespace := GetAppNamespace(ctx, taskName)
+				bkpNamespaces = append(bkpNamespaces, namespace)
+			}
+		}
+	})
+	It("Generic backup and restore through the KDMP data mover", func() {
+		Step("Validate applications", func() {
+			ValidateApplications(contexts)
+		})
+
+		Step("Creating backup location and cloud setting", func() {
+			log.InfoD("Creating backup location and cloud setting")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			for _, provider := range getProviders() {
+				cloudCredName = fmt.Sprintf("%s-%s-%v", "cred", provider, time.Now().Unix())
+				bkpLocationName = fmt.Sprintf("%s-%s-bl", provider, getGlobalBucketName(provider))
+				cloudCredUID = uuid.New()
+				backupLocationUID = uuid.New()
+				backupLocationMap[backupLocationUID] = bkpLocationName
+				err := CreateCloudCredential(provider, cloudCredName, cloudCredUID, orgID, ctx)
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying creation of cloud credential named [%s] for org [%s] with [%s] as provider", cloudCredName, orgID, provider))
+				err = CreateBackupLocation(provider, bkpLocationName, backupLocationUID, cloudCredName, cloudCredUID, getGlobalBucketName(provider), orgID, "")
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Creating backup location %s", bkpLocationName))
+			}
+		})
+
+		Step("Registering cluster for backup", func() {
+			log.InfoD("Registering cluster for backup")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			err = CreateSourceAndDestClusters(orgID, "", "", ctx)
+			dash.VerifyFatal(err, nil, "Creating source and destination cluster")
+			clusterStatus, err = Inst().Backup.GetClusterStatus(orgID, SourceClusterName, ctx)
+			log.FailOnError(err, fmt.Sprintf("Fetching [%s] cluster status", SourceClusterName))
+			dash.VerifyFatal(clusterStatus, api.ClusterInfo_StatusInfo_Online, fmt.Sprintf("Verifying if [%s] cluster is online", SourceClusterName))
+			clusterUid, err = Inst().Backup.GetClusterUID(ctx, orgID, SourceClusterName)
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Fetching [%s] cluster uid", SourceClusterName))
+		})
+
+		Step("Scaling down the applications and capturing the contents of their volumes and their resources", func() {
+			log.InfoD("Scaling down the applications and capturing the contents of their volumes and their resources")
+			var err error
+			dataSnapshot, err = CaptureBackupDataSnapshot(contexts)
+			log.FailOnError(err, "Capturing the data of the applications before the backup")
+		})
+
+		Step("Taking a generic backup of every namespace", func() {
+			log.InfoD("Taking a generic backup of every namespace")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			for _, namespace := range bkpNamespaces {
+				backupName := fmt.Sprintf("%s-generic-%s-%v", BackupNamePrefix, namespace, time.Now().Unix())
+				observer, err := CreateBackupWithType(backupName, SourceClusterName, bkpLocationName, backupLocationUID, []string{namespace},
+					labelSelectors, orgID, clusterUid, api.BackupCreateRequest_Generic, ctx)
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying generic backup [%s] of namespace [%s]", backupName, namespace))
+				backupNames[namespace] = backupName
+				err = ValidateGenericBackup(backupName, orgID, observer, ctx)
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying the data mover objects of backup [%s] and their cleanup", backupName))
+			}
+		})
+
+		Step("Restoring the generic backups and comparing the restored data", func() {
+			log.InfoD("Restoring the generic backups and comparing the restored data")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			namespaceMapping := make(map[string]string)
+			for namespace, backupName := range backupNames {
+				restoreName := fmt.Sprintf("%s-%s", restoreNamePrefix, backupName)
+				namespaceMapping[namespace] = namespace
+				err = CreateRestore(restoreName, backupName, map[string]string{namespace: namespace}, destinationClusterName, orgID, ctx, make(map[string]string))
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Creating restore [%s]", restoreName))
+				restoreNames = append(restoreNames, restoreName)
+			}
+			SetDestinationKubeConfig()
+			defer func() {
+				err := SetSourceKubeConfig()
+				log.FailOnError(err, "Switching context to source cluster")
+			}()
+			err = ValidateRestoredData(dataSnapshot, namespaceMapping, make(map[string]string))
+			dash.VerifyFatal(err, nil, "Verifying the data restored from the generic backups")
+		})
+	})
+
+	JustAfterEach(func() {
+		defer EndPxBackupTorpedoTest(contexts)
+		ctx, err := backup.GetAdminCtxFromSecret()
+		log.FailOnError(err, "Fetching px-central-admin ctx")
+		for _, restoreName := range restoreNames {
+			err = DeleteRestore(restoreName, orgID, ctx)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting restore [%s]", restoreName))
+		}
+		for _, backupName := range backupNames {
+			backupUID, err := Inst().Backup.GetBackupUID(ctx, backupName, orgID)
+			log.FailOnError(err, "Failed while trying to get backup UID for - [%s]", backupName)
+			_, err = DeleteBackup(backupName, backupUID, orgID, ctx)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting backup [%s]", backupName))
+		}
+		err = ResumeBackupApplications(dataSnapshot)
+		dash.VerifySafely(err, nil, "Scaling the applications back up after the backup")
+		log.InfoD("Deleting the deployed apps after the testcase")
+		opts := make(map[string]bool)
+		opts[SkipClusterScopedObjects] = true
+		ValidateAndDestroy(contexts, opts)
+
+		log.InfoD("Deleting backup location, cloud creds and clusters")
+		CleanupCloudSettingsAndClusters(backupLocationMap, cloudCredName, cloudCredUID, ctx)
+	})
+})
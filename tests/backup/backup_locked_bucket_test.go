@@ -412,3 +412,152 @@ var _ = Describe("{LockedBucketResizeOnRestoredVolume}", func() {
 		CleanupCloudSettingsAndClusters(BackupLocationMap, credName, CloudCredUID, ctx)
 	})
 })
+
+// This testcase verifies that schedules retain as many backups as their policy in px-backup and in locked buckets
+var _ = Describe("{ScheduleBackupRetentionInLockedBucket}", func() {
+	var (
+		contexts          []*scheduler.Context
+		appContexts       []*scheduler.Context
+		bkpNamespaces     []string
+		scheduleNames     []string
+		credName          string
+		cloudCredUID      string
+		clusterStatus     api.ClusterInfo_StatusInfo_Status
+		backupLocationMap map[string]string
+		validators        map[string]*backup.RetentionValidator
+		periodicPolicy    string
+	)
+	const retainCount = 2
+	labelSelectors := make(map[string]string)
+
+	JustBeforeEach(func() {
+		StartTorpedoTest("ScheduleBackupRetentionInLockedBucket", "Verify the backups retained by schedules in locked buckets", nil, 0)
+		bkpNamespaces = make([]string, 0)
+		scheduleNames = make([]string, 0)
+		backupLocationMap = make(map[string]string)
+		validators = make(map[string]*backup.RetentionValidator)
+		periodicPolicy = fmt.Sprintf("%s-%v", "periodic", time.Now().Unix())
+		log.InfoD("Deploy applications")
+		contexts = make([]*scheduler.Context, 0)
+		for i := 0; i < Inst().GlobalScaleFactor; i++ {
+			taskName := fmt.Sprintf("%s-%d", taskNamePrefix, i)
+			appContexts = ScheduleApplications(taskName)
+			contexts = append(contexts, appContexts...)
+			for _, ctx := range appContexts {
+				ctx.ReadinessTimeout = appReadinessTimeout
+				namespace := GetAppNamespace(ctx, taskName)
+				bkpNamespaces = append(bkpNamespaces, namespace)
+			}
+		}
+	})
+	It("Verify schedule backup retention in locked buckets", func() {
+		providers := getProviders()
+		Step("Validate applications", func() {
+			ValidateApplications(contexts)
+		})
+
+		Step("Creating cloud credentials", func() {
+			log.InfoD("Creating cloud credentials")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			for _, provider := range providers {
+				credName = fmt.Sprintf("%s-%s-%v", "cred", provider, time.Now().Unix())
+				cloudCredUID = uuid.New()
+				err := CreateCloudCredential(provider, credName, cloudCredUID, orgID, ctx)
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying creation of cloud credential named [%s] for org [%s] with [%s] as provider", credName, orgID, provider))
+			}
+		})
+
+		Step("Creating locked buckets and backup locations", func() {
+			log.InfoD("Creating locked buckets and backup locations")
+			modes := [2]string{"GOVERNANCE", "COMPLIANCE"}
+			for _, provider := range providers {
+				for _, mode := range modes {
+					bucketName := fmt.Sprintf("%s-%s-%s", provider, getGlobalLockedBucketName(provider), strings.ToLower(mode))
+					backupLocation := fmt.Sprintf("%s-%s-%s-retention", provider, getGlobalLockedBucketName(provider), strings.ToLower(mode))
+					err := CreateS3Bucket(bucketName, true, 3, mode)
+					log.FailOnError(err, "Unable to create locked s3 bucket %s", bucketName)
+					backupLocationUID := uuid.New()
+					backupLocationMap[backupLocationUID] = backupLocation
+					err = CreateBackupLocation(provider, backupLocation, backupLocationUID, credName, cloudCredUID, bucketName, orgID, "")
+					dash.VerifyFatal(err, nil, fmt.Sprintf("Creating backup location %s", backupLocation))
+					scheduleName := fmt.Sprintf("%s-%s", BackupNamePrefix, backupLocation)
+					validator, err := NewScheduleRetentionValidator(provider, bucketName, scheduleName, retainCount, true)
+					log.FailOnError(err, "Creating retention validator of schedule %s", scheduleName)
+					validators[backupLocationUID] = validator
+				}
+			}
+		})
+
+		Step("Register cluster for backup", func() {
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			err = CreateSourceAndDestClusters(orgID, "", "", ctx)
+			dash.VerifyFatal(err, nil, "Creating source and destination cluster")
+			clusterStatus, err = Inst().Backup.GetClusterStatus(orgID, SourceClusterName, ctx)
+			log.FailOnError(err, fmt.Sprintf("Fetching [%s] cluster status", SourceClusterName))
+			dash.VerifyFatal(clusterStatus, api.ClusterInfo_StatusInfo_Online, fmt.Sprintf("Verifying if [%s] cluster is online", SourceClusterName))
+		})
+
+		Step("Creating schedule policy", func() {
+			log.InfoD("Creating schedule policy retaining %d backups", retainCount)
+			periodicSchedulePolicyInfo := Inst().Backup.CreateIntervalSchedulePolicy(retainCount, 15, 0)
+			err := Inst().Backup.BackupSchedulePolicy(periodicPolicy, uuid.New(), orgID, periodicSchedulePolicyInfo)
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Creating periodic schedule policy %s", periodicPolicy))
+		})
+
+		Step("Creating schedule backups to locked buckets", func() {
+			log.InfoD("Creating schedule backups to locked buckets")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			schPolicyUid, err := Inst().Backup.GetSchedulePolicyUid(orgID, ctx, periodicPolicy)
+			log.FailOnError(err, "Fetching uid of schedule policy %s", periodicPolicy)
+			for backupLocationUID, backupLocationName := range backupLocationMap {
+				scheduleName := fmt.Sprintf("%s-%s", BackupNamePrefix, backupLocationName)
+				err = CreateScheduleBackup(scheduleName, SourceClusterName, backupLocationName, backupLocationUID, bkpNamespaces,
+					labelSelectors, orgID, "", "", "", "", periodicPolicy, schPolicyUid, ctx)
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Creating schedule backup %s", scheduleName))
+				scheduleNames = append(scheduleNames, scheduleName)
+				err = validators[backupLocationUID].Validate(ctx)
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying retention of schedule %s", scheduleName))
+			}
+		})
+
+		Step("Verifying retention over the lifetime of the schedules", func() {
+			log.InfoD("Verifying retention over the lifetime of the schedules")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			for backupCount := 1; backupCount <= retainCount+1; backupCount++ {
+				for backupLocationUID, backupLocationName := range backupLocationMap {
+					scheduleName := fmt.Sprintf("%s-%s", BackupNamePrefix, backupLocationName)
+					backupName, err := WaitForNextScheduleBackup(ctx, scheduleName, backupCount, orgID, 20*time.Minute)
+					dash.VerifyFatal(err, nil, fmt.Sprintf("Waiting for backup %d of schedule %s", backupCount+1, scheduleName))
+					log.InfoD("Schedule %s took backup %s", scheduleName, backupName)
+					err = validators[backupLocationUID].Validate(ctx)
+					dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying retention of schedule %s after %d backups", scheduleName, backupCount+1))
+				}
+			}
+		})
+	})
+
+	JustAfterEach(func() {
+		defer EndPxBackupTorpedoTest(contexts)
+		ctx, err := backup.GetAdminCtxFromSecret()
+		log.FailOnError(err, "Fetching px-central-admin ctx")
+		for _, scheduleName := range scheduleNames {
+			scheduleUid, err := GetScheduleUID(scheduleName, orgID, ctx)
+			log.FailOnError(err, "Error while getting schedule uid %v", scheduleName)
+			err = DeleteSchedule(scheduleName, scheduleUid, orgID)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting backup schedule %s", scheduleName))
+		}
+		err = Inst().Backup.DeleteBackupSchedulePolicy(orgID, []string{periodicPolicy})
+		dash.VerifySafely(err, nil, fmt.Sprintf("Deleting schedule policy %s", periodicPolicy))
+		log.InfoD("Deleting the deployed apps after the testcase")
+		opts := make(map[string]bool)
+		opts[SkipClusterScopedObjects] = true
+		ValidateAndDestroy(contexts, opts)
+
+		log.InfoD("Deleting backup location, cloud creds and clusters")
+		CleanupCloudSettingsAndClusters(backupLocationMap, credName, cloudCredUID, ctx)
+	})
+})
@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/pborman/uuid"
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/torpedo/drivers"
+	"github.com/portworx/torpedo/drivers/backup"
+	"github.com/portworx/torpedo/drivers/objectstore"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/pkg/log"
+	. "github.com/portworx/torpedo/tests"
+)
+
+// This testcase takes backups of the same applications to an NFS and an S3 backup location, verifies the
+// files of the NFS backup on the export and restores both backups
+var _ = Describe("{BackupAndRestoreWithNFSAndS3Locations}", func() {
+	var (
+		contexts           []*scheduler.Context
+		appContexts        []*scheduler.Context
+		nfsServerContext   *scheduler.Context
+		bkpNamespaces      []string
+		clusterUid         string
+		clusterStatus      api.ClusterInfo_StatusInfo_Status
+		cloudCredName      string
+		cloudCredUID       string
+		nfsSubPath         string
+		s3BucketName       string
+		backupLocationMap  map[string]string
+		backupLocationUID  map[string]string
+		backupNames        map[string]string
+		restoredNamespaces []string
+		restoreNames       []string
+		labelSelectors     map[string]string
+		dataSnapshot       *BackupDataSnapshot
+	)
+	providers := []string{drivers.ProviderNfs, drivers.ProviderAws}
+
+	JustBeforeEach(func() {
+		StartTorpedoTest("BackupAndRestoreWithNFSAndS3Locations", "Backup and restore with NFS and S3 backup locations", nil, 0)
+		bkpNamespaces = make([]string, 0)
+		backupLocationMap = make(map[string]string)
+		backupLocationUID = make(map[string]string)
+		restoredNamespaces = make([]string, 0)
+		backupNames = make(map[string]string)
+		restoreNames = make([]string, 0)
+		labelSelectors = make(map[string]string)
+
+		log.InfoD("Deploying the NFS server")
+		var err error
+		nfsServerContext, err = DeployNFSServer(fmt.Sprintf("%s-nfs-server", taskNamePrefix))
+		log.FailOnError(err, "Deploying the NFS server")
+
+		log.InfoD("Deploy applications")
+		contexts = make([]*scheduler.Context, 0)
+		for i := 0; i < Inst().GlobalScaleFactor; i++ {
+			taskName := fmt.Sprintf("%s-%d", taskNamePrefix, i)
+			appContexts = ScheduleApplications(taskName)
+			contexts = append(contexts, appContexts...)
+			for _, ctx := range appContexts {
+				ctx.ReadinessTimeout = appReadinessTimeout
+				namespace := GetAppNamespace(ctx, taskName)
+				bkpNamespaces = append(bkpNamespaces, namespace)
+			}
+		}
+	})
+	It("Backup and restore with NFS and S3 backup locations", func() {
+		Step("Validate applications", func() {
+			ValidateApplications(contexts)
+		})
+
+		Step("Creating NFS sub path and S3 bucket", func() {
+			log.InfoD("Creating NFS sub path and S3 bucket")
+			nfsSubPath = fmt.Sprintf("%s-%v", BucketNamePrefix, time.Now().Unix())
+			CreateBucket(drivers.ProviderNfs, nfsSubPath)
+			s3BucketName = fmt.Sprintf("%s-s3-%v", BucketNamePrefix, time.Now().Unix())
+			CreateBucket(drivers.ProviderAws, s3BucketName)
+		})
+
+		Step("Creating cloud credentials and backup locations", func() {
+			log.InfoD("Creating cloud credentials and backup locations")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			cloudCredName = fmt.Sprintf("%s-%s-%v", "cred", drivers.ProviderAws, time.Now().Unix())
+			cloudCredUID = uuid.New()
+			err = CreateCloudCredential(drivers.ProviderAws, cloudCredName, cloudCredUID, orgID, ctx)
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying creation of cloud credential named [%s] for org [%s] with [%s] as provider", cloudCredName, orgID, drivers.ProviderAws))
+			for _, provider := range providers {
+				bucketName := s3BucketName
+				if provider == drivers.ProviderNfs {
+					bucketName = nfsSubPath
+				}
+				backupLocationName := fmt.Sprintf("%s-%s-bl", provider, bucketName)
+				backupLocationUID[provider] = uuid.New()
+				backupLocationMap[backupLocationUID[provider]] = backupLocationName
+				err = CreateBackupLocation(provider, backupLocationName, backupLocationUID[provider], cloudCredName, cloudCredUID, bucketName, orgID, "")
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Creating backup location %s", backupLocationName))
+			}
+		})
+
+		Step("Registering cluster for backup", func() {
+			log.InfoD("Registering cluster for backup")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			err = CreateSourceAndDestClusters(orgID, "", "", ctx)
+			dash.VerifyFatal(err, nil, "Creating source and destination cluster")
+			clusterStatus, err = Inst().Backup.GetClusterStatus(orgID, SourceClusterName, ctx)
+			log.FailOnError(err, fmt.Sprintf("Fetching [%s] cluster status", SourceClusterName))
+			dash.VerifyFatal(clusterStatus, api.ClusterInfo_StatusInfo_Online, fmt.Sprintf("Verifying if [%s] cluster is online", SourceClusterName))
+			clusterUid, err = Inst().Backup.GetClusterUID(ctx, orgID, SourceClusterName)
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Fetching [%s] cluster uid", SourceClusterName))
+		})
+
+		Step("Scaling down the applications and capturing the contents of their volumes and their resources", func() {
+			log.InfoD("Scaling down the applications and capturing the contents of their volumes and their resources")
+			var err error
+			dataSnapshot, err = CaptureBackupDataSnapshot(contexts)
+			log.FailOnError(err, "Capturing the data of the applications before the backup")
+		})
+
+		Step("Taking backups to the NFS and S3 backup locations", func() {
+			log.InfoD("Taking backups to the NFS and S3 backup locations")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			for _, provider := range providers {
+				backupLocationName := backupLocationMap[backupLocationUID[provider]]
+				backupName := fmt.Sprintf("%s-%s", BackupNamePrefix, backupLocationName)
+				err = CreateBackup(backupName, SourceClusterName, backupLocationName, backupLocationUID[provider], bkpNamespaces,
+					labelSelectors, orgID, clusterUid, "", "", "", "", ctx)
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying [%s] backup creation", backupName))
+				backupNames[provider] = backupName
+			}
+		})
+
+		Step("Verifying the files of the backup on the NFS export", func() {
+			log.InfoD("Verifying the files of the backup on the NFS export")
+			backupName := backupNames[drivers.ProviderNfs]
+			files, err := GetNFSBackupFiles(nfsSubPath, backupName, orgID)
+			log.FailOnError(err, "Listing the files of backup %s on the NFS export", backupName)
+			log.Infof("Files of backup %s on the NFS export: %v", backupName, files)
+			dash.VerifyFatal(len(files) > 0, true, fmt.Sprintf("Verifying backup %s has files under NFS sub path %s", backupName, nfsSubPath))
+		})
+
+		Step("Restoring the NFS and S3 backups and comparing the restored data", func() {
+			log.InfoD("Restoring the NFS and S3 backups and comparing the restored data")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			for provider, backupName := range backupNames {
+				restoreName := fmt.Sprintf("%s-%s", RestoreNamePrefix, backupName)
+				namespaceMapping := make(map[string]string)
+				for _, namespace := range bkpNamespaces {
+					namespaceMapping[namespace] = fmt.Sprintf("%s-%s", namespace, provider)
+					restoredNamespaces = append(restoredNamespaces, namespaceMapping[namespace])
+				}
+				err = CreateRestore(restoreName, backupName, namespaceMapping, destinationClusterName, orgID, ctx, make(map[string]string))
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Creating restore [%s]", restoreName))
+				restoreNames = append(restoreNames, restoreName)
+
+				SetDestinationKubeConfig()
+				err = ValidateRestoredData(dataSnapshot, namespaceMapping, make(map[string]string))
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying the data restored by [%s]", restoreName))
+				err = SetSourceKubeConfig()
+				log.FailOnError(err, "Switching context to source cluster")
+			}
+		})
+	})
+
+	JustAfterEach(func() {
+		defer EndPxBackupTorpedoTest(contexts)
+		ctx, err := backup.GetAdminCtxFromSecret()
+		log.FailOnError(err, "Fetching px-central-admin ctx")
+		for _, restoreName := range restoreNames {
+			err = DeleteRestore(restoreName, orgID, ctx)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting restore [%s]", restoreName))
+		}
+		for _, backupName := range backupNames {
+			backupUID, err := Inst().Backup.GetBackupUID(ctx, backupName, orgID)
+			log.FailOnError(err, "Failed while trying to get backup UID for - [%s]", backupName)
+			_, err = DeleteBackup(backupName, backupUID, orgID, ctx)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting backup [%s]", backupName))
+			err = DeleteBackupAndWait(backupName, ctx)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Waiting for the deletion of backup [%s]", backupName))
+		}
+		log.InfoD("Deleting the restored namespaces on the destination cluster")
+		SetDestinationKubeConfig()
+		for _, namespace := range restoredNamespaces {
+			err = core.Instance().DeleteNamespace(namespace)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting restored namespace %s", namespace))
+		}
+		err = SetSourceKubeConfig()
+		log.FailOnError(err, "Switching context to source cluster")
+		err = ResumeBackupApplications(dataSnapshot)
+		dash.VerifySafely(err, nil, "Scaling the applications back up after the backup")
+		log.InfoD("Deleting the deployed apps after the testcase")
+		opts := make(map[string]bool)
+		opts[SkipClusterScopedObjects] = true
+		ValidateAndDestroy(contexts, opts)
+
+		log.InfoD("Deleting backup locations, cloud creds and clusters")
+		CleanupCloudSettingsAndClusters(backupLocationMap, cloudCredName, cloudCredUID, ctx)
+		DeleteBucket(drivers.ProviderNfs, nfsSubPath)
+		DeleteBucket(drivers.ProviderAws, s3BucketName)
+		err = objectstore.StopNFSHelper()
+		dash.VerifySafely(err, nil, "Stopping the NFS helper pod")
+		if nfsServerContext != nil {
+			ValidateAndDestroy([]*scheduler.Context{nfsServerContext}, opts)
+			// the next test deploys its own server
+			os.Unsetenv(objectstore.EnvNFSServerAddr)
+		}
+	})
+})
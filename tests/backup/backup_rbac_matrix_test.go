@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/pborman/uuid"
+	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/torpedo/drivers/backup"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/pkg/log"
+	. "github.com/portworx/torpedo/tests"
+)
+
+// This testcase shares a cloud credential, a backup location, a cluster, a backup, a schedule policy and a rule
+// with users and a group at different access levels, and verifies every operation of every principal on every
+// object is allowed or denied as declared in the RBAC matrix
+var _ = Describe("{BackupRBACMatrix}", func() {
+	var (
+		contexts           []*scheduler.Context
+		appContexts        []*scheduler.Context
+		bkpNamespaces      []string
+		clusterUid         string
+		cloudCredName      string
+		cloudCredUID       string
+		backupLocationUID  string
+		bkpLocationName    string
+		backupLocationMap  map[string]string
+		backupName         string
+		schedulePolicyName string
+		ruleNames          []string
+		users              []string
+		groups             []string
+		principals         []backup.RBACPrincipal
+		restoredNamespaces []string
+	)
+
+	JustBeforeEach(func() {
+		StartTorpedoTest("BackupRBACMatrix", "Verify the operations of users and groups on shared px-backup objects", nil, 0)
+		bkpNamespaces = make([]string, 0)
+		backupLocationMap = make(map[string]string)
+		ruleNames = make([]string, 0)
+		restoredNamespaces = make([]string, 0)
+		timestamp := time.Now().Unix()
+		users = []string{
+			fmt.Sprintf("rbac-viewer-%v", timestamp),
+			fmt.Sprintf("rbac-restorer-%v", timestamp),
+			fmt.Sprintf("rbac-owner-%v", timestamp),
+			fmt.Sprintf("rbac-stranger-%v", timestamp),
+		}
+		groups = []string{fmt.Sprintf("rbac-group-%v", timestamp)}
+		log.InfoD("Deploy applications")
+		contexts = make([]*scheduler.Context, 0)
+		for i := 0; i < Inst().GlobalScaleFactor; i++ {
+			taskName := fmt.Sprintf("%s-%d", taskNamePrefix, i)
+			appContexts = ScheduleApplications(taskName)
+			contexts = append(contexts, appContexts...)
+			for _, ctx := range appContexts {
+				ctx.ReadinessTimeout = appReadinessTimeout
+				namespace := GetAppNamespace(ctx, taskName)
+				bkpNamespaces = append(bkpNamespaces, namespace)
+			}
+		}
+	})
+	It("Verify the RBAC matrix of shared px-backup objects", func() {
+		Step("Validate applications", func() {
+			ValidateApplications(contexts)
+		})
+
+		Step("Creating backup location and cloud setting", func() {
+			log.InfoD("Creating backup location and cloud setting")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			for _, provider := range getProviders() {
+				cloudCredName = fmt.Sprintf("%s-%s-%v", "cred", provider, time.Now().Unix())
+				bkpLocationName = fmt.Sprintf("%s-%s-bl", provider, getGlobalBucketName(provider))
+				cloudCredUID = uuid.New()
+				backupLocationUID = uuid.New()
+				backupLocationMap[backupLocationUID] = bkpLocationName
+				err := CreateCloudCredential(provider, cloudCredName, cloudCredUID, orgID, ctx)
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying creation of cloud credential named [%s] for org [%s] with [%s] as provider", cloudCredName, orgID, provider))
+				err = CreateBackupLocation(provider, bkpLocationName, backupLocationUID, cloudCredName, cloudCredUID, getGlobalBucketName(provider), orgID, "")
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Creating backup location %s", bkpLocationName))
+			}
+		})
+
+		Step("Creating schedule policy and rules", func() {
+			log.InfoD("Creating schedule policy and rules")
+			schedulePolicyName = fmt.Sprintf("%s-%v", "rbac-periodic", time.Now().Unix())
+			schedulePolicyInfo := Inst().Backup.CreateIntervalSchedulePolicy(5, 15, 2)
+			err := Inst().Backup.BackupSchedulePolicy(schedulePolicyName, uuid.New(), orgID, schedulePolicyInfo)
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Creating schedule policy %s", schedulePolicyName))
+			for _, appCtx := range contexts {
+				_, ruleName, err := Inst().Backup.CreateRuleForBackup(appCtx.App.Key, orgID, "pre")
+				log.FailOnError(err, "Creating pre rule for app %s", appCtx.App.Key)
+				if ruleName != "" {
+					ruleNames = append(ruleNames, ruleName)
+				}
+			}
+		})
+
+		Step("Registering cluster for backup", func() {
+			log.InfoD("Registering cluster for backup")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			err = CreateSourceAndDestClusters(orgID, "", "", ctx)
+			dash.VerifyFatal(err, nil, "Creating source and destination cluster")
+			clusterStatus, err := Inst().Backup.GetClusterStatus(orgID, SourceClusterName, ctx)
+			log.FailOnError(err, fmt.Sprintf("Fetching [%s] cluster status", SourceClusterName))
+			dash.VerifyFatal(clusterStatus, api.ClusterInfo_StatusInfo_Online, fmt.Sprintf("Verifying if [%s] cluster is online", SourceClusterName))
+			clusterUid, err = Inst().Backup.GetClusterUID(ctx, orgID, SourceClusterName)
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Fetching [%s] cluster uid", SourceClusterName))
+		})
+
+		Step("Taking backup of applications", func() {
+			log.InfoD("Taking backup of applications")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			backupName = fmt.Sprintf("%s-rbac-%v", BackupNamePrefix, time.Now().Unix())
+			err = CreateBackup(backupName, SourceClusterName, bkpLocationName, backupLocationUID, bkpNamespaces,
+				make(map[string]string), orgID, clusterUid, "", "", "", "", ctx)
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying backup [%s] creation", backupName))
+		})
+
+		Step("Creating users and groups", func() {
+			log.InfoD("Creating users %v and groups %v", users, groups)
+			var err error
+			principals, err = NewRBACUserPrincipals(users, groups)
+			dash.VerifyFatal(err, nil, "Creating the principals of the RBAC matrix")
+		})
+
+		Step("Verifying the RBAC matrix", func() {
+			log.InfoD("Verifying the RBAC matrix")
+			ctx, err := backup.GetAdminCtxFromSecret()
+			log.FailOnError(err, "Fetching px-central-admin ctx")
+			backupUID, err := Inst().Backup.GetBackupUID(ctx, backupName, orgID)
+			log.FailOnError(err, "Fetching uid of backup %s", backupName)
+			schedulePolicyUid, err := Inst().Backup.GetSchedulePolicyUid(orgID, ctx, schedulePolicyName)
+			log.FailOnError(err, "Fetching uid of schedule policy %s", schedulePolicyName)
+			viewer, restorer, owner, group := users[0], users[1], users[2], groups[0]
+			credential := backup.RBACResource{Kind: backup.CloudCredentialKind, Name: cloudCredName, UID: cloudCredUID, OrgID: orgID}
+			location := backup.RBACResource{Kind: backup.BackupLocationKind, Name: bkpLocationName, UID: backupLocationUID, OrgID: orgID}
+			cluster := backup.RBACResource{Kind: backup.ClusterKind, Name: SourceClusterName, UID: clusterUid, OrgID: orgID}
+			bkp := backup.RBACResource{Kind: backup.BackupKind, Name: backupName, UID: backupUID, OrgID: orgID}
+			policy := backup.RBACResource{Kind: backup.SchedulePolicyKind, Name: schedulePolicyName, UID: schedulePolicyUid, OrgID: orgID}
+			// The cluster is shared before the backup, whose share then sets the access of the group to it
+			m := &backup.RBACMatrix{
+				Principals: principals,
+				Resources:  []backup.RBACResource{credential, location, cluster, bkp, policy},
+			}
+			m.Grant(owner, credential.Key(), backup.ViewAccess).
+				Grant(owner, location.Key(), backup.RestorableAccess).
+				Grant(group, location.Key(), backup.ViewAccess).
+				Grant(group, cluster.Key(), backup.ViewAccess).
+				Grant(viewer, bkp.Key(), backup.ViewAccess).
+				Grant(restorer, bkp.Key(), backup.RestorableAccess).
+				Grant(owner, bkp.Key(), backup.FullAccess).
+				Grant(group, bkp.Key(), backup.RestorableAccess).
+				Grant(viewer, policy.Key(), backup.ViewAccess).
+				Grant(owner, policy.Key(), backup.FullAccess)
+			for _, ruleName := range ruleNames {
+				ruleUid, err := Inst().Backup.GetRuleUid(orgID, ctx, ruleName)
+				log.FailOnError(err, "Fetching uid of rule %s", ruleName)
+				rule := backup.RBACResource{Kind: backup.RuleKind, Name: ruleName, UID: ruleUid, OrgID: orgID}
+				m.Resources = append(m.Resources, rule)
+				m.Grant(owner, rule.Key(), backup.ViewAccess).
+					Grant(group, rule.Key(), backup.FullAccess)
+			}
+			e := backup.NewRBACEngine(Inst().Backup, ctx)
+			RegisterRBACRestoreOperation(e, ctx, &restoredNamespaces)
+			report, err := e.Run(m)
+			dash.VerifyFatal(err, nil, "Sharing the objects of the RBAC matrix")
+			log.Infof("RBAC matrix report:\n%s", report)
+			dash.VerifyFatal(report.Err(), nil, "Verifying the operations of the RBAC matrix")
+		})
+	})
+
+	JustAfterEach(func() {
+		defer EndPxBackupTorpedoTest(contexts)
+		ctx, err := backup.GetAdminCtxFromSecret()
+		log.FailOnError(err, "Fetching px-central-admin ctx")
+		// The backup, the schedule policy and the rules are deleted by the principals with full access to them
+		if backupUID, err := Inst().Backup.GetBackupUID(ctx, backupName, orgID); err == nil {
+			_, err = DeleteBackup(backupName, backupUID, orgID, ctx)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting backup [%s]", backupName))
+		}
+		if _, err := Inst().Backup.GetSchedulePolicyUid(orgID, ctx, schedulePolicyName); err == nil {
+			err = Inst().Backup.DeleteBackupSchedulePolicy(orgID, []string{schedulePolicyName})
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting schedule policy [%s]", schedulePolicyName))
+		}
+		for _, ruleName := range ruleNames {
+			if _, err := Inst().Backup.GetRuleUid(orgID, ctx, ruleName); err == nil {
+				err = Inst().Backup.DeleteRuleForBackup(orgID, ruleName)
+				dash.VerifySafely(err, nil, fmt.Sprintf("Deleting rule [%s]", ruleName))
+			}
+		}
+		log.InfoD("Deleting the restored namespaces %v", restoredNamespaces)
+		SetDestinationKubeConfig()
+		for _, namespace := range restoredNamespaces {
+			err = core.Instance().DeleteNamespace(namespace)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting restored namespace [%s]", namespace))
+		}
+		err = SetSourceKubeConfig()
+		log.FailOnError(err, "Switching context to source cluster")
+
+		log.InfoD("Deleting registered clusters for the principals")
+		for _, principal := range principals {
+			err = DeleteCluster(SourceClusterName, orgID, principal.Ctx)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting cluster %s of %s", SourceClusterName, principal.Name))
+			err = DeleteCluster(destinationClusterName, orgID, principal.Ctx)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting cluster %s of %s", destinationClusterName, principal.Name))
+		}
+		members := make([]string, 0)
+		for _, group := range groups {
+			members = append(members, fmt.Sprintf("%s-member", group))
+		}
+		err = backup.DeleteMultipleGroups(groups)
+		dash.VerifySafely(err, nil, fmt.Sprintf("Deleting groups %v", groups))
+		err = backup.DeleteMultipleUsers(append(users, members...))
+		dash.VerifySafely(err, nil, fmt.Sprintf("Deleting users %v", users))
+
+		log.InfoD("Deleting the deployed apps after the testcase")
+		opts := make(map[string]bool)
+		opts[SkipClusterScopedObjects] = true
+		ValidateAndDestroy(contexts, opts)
+
+		log.InfoD("Deleting backup location, cloud creds and clusters")
+		CleanupCloudSettingsAndClusters(backupLocationMap, cloudCredName, cloudCredUID, ctx)
+	})
+})
@@ -39,6 +39,8 @@ var _ = Describe("{BasicSelectiveRestore}", func() {
 		providers         []string
 		backupLocationMap map[string]string
 		labelSelectors    map[string]string
+		dataSnapshot      *BackupDataSnapshot
+		namespaceMapping  map[string]string
 	)
 	JustBeforeEach(func() {
 		backupName = fmt.Sprintf("%s-%v", BackupNamePrefix, time.Now().Unix())
@@ -100,6 +102,12 @@ var _ = Describe("{BasicSelectiveRestore}", func() {
 			clusterUid, err = Inst().Backup.GetClusterUID(ctx, orgID, SourceClusterName)
 			dash.VerifyFatal(err, nil, fmt.Sprintf("Fetching [%s] cluster uid", SourceClusterName))
 		})
+		Step("Scaling down the applications and capturing the contents of their volumes and their resources", func() {
+			log.InfoD("Scaling down the applications and capturing the contents of their volumes and their resources")
+			var err error
+			dataSnapshot, err = CaptureBackupDataSnapshot(contexts)
+			log.FailOnError(err, "Capturing the data of the applications before the backup")
+		})
 		Step("Taking backup of multiple namespaces", func() {
 			log.InfoD(fmt.Sprintf("Taking backup of multiple namespaces [%v]", bkpNamespaces))
 			ctx, err := backup.GetAdminCtxFromSecret()
@@ -112,16 +120,26 @@ var _ = Describe("{BasicSelectiveRestore}", func() {
 			log.InfoD("Selecting random backed-up apps and restoring them")
 			selectedBkpNamespaces, err := GetSubsetOfSlice(bkpNamespaces, len(bkpNamespaces)/2)
 			log.FailOnError(err, "Getting a subset of backed-up namespaces")
-			selectedBkpNamespaceMapping := make(map[string]string)
+			namespaceMapping = make(map[string]string)
 			for _, namespace := range selectedBkpNamespaces {
-				selectedBkpNamespaceMapping[namespace] = namespace
+				namespaceMapping[namespace] = namespace
 			}
 			log.InfoD("Selected application namespaces to restore: [%v]", selectedBkpNamespaces)
 			ctx, err := backup.GetAdminCtxFromSecret()
 			log.FailOnError(err, "Fetching px-central-admin ctx")
-			err = CreateRestore(restoreName, backupName, selectedBkpNamespaceMapping, destinationClusterName, orgID, ctx, make(map[string]string))
+			err = CreateRestore(restoreName, backupName, namespaceMapping, destinationClusterName, orgID, ctx, make(map[string]string))
 			dash.VerifyFatal(err, nil, fmt.Sprintf("Creating restore [%s]", restoreName))
 		})
+		Step("Comparing the restored data with the backed up data", func() {
+			log.InfoD("Comparing the restored data with the backed up data")
+			SetDestinationKubeConfig()
+			defer func() {
+				err := SetSourceKubeConfig()
+				log.FailOnError(err, "Switching context to source cluster")
+			}()
+			err := ValidateRestoredData(dataSnapshot, namespaceMapping, make(map[string]string))
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying the data restored by [%s]", restoreName))
+		})
 	})
 	JustAfterEach(func() {
 		defer EndPxBackupTorpedoTest(contexts)
@@ -129,6 +147,8 @@ var _ = Describe("{BasicSelectiveRestore}", func() {
 		log.FailOnError(err, "Fetching px-central-admin ctx")
 		opts := make(map[string]bool)
 		opts[SkipClusterScopedObjects] = true
+		err = ResumeBackupApplications(dataSnapshot)
+		dash.VerifySafely(err, nil, "Scaling the applications back up after the backup")
 		log.InfoD("Deleting deployed applications")
 		ValidateAndDestroy(contexts, opts)
 		backupDriver := Inst().Backup
@@ -239,6 +239,24 @@ func asgKillANodeAndValidate(storageDriverNodes []node.Node) {
 	rand.Seed(time.Now().Unix())
 	nodeToKill := storageDriverNodes[rand.Intn(len(storageDriverNodes))]
 
+	// the replacement node takes over the volume driver node ID and the pools of the deleted node
+	before := node.TakeInventory()
+	expectedChanges := []node.TopologyEvent{
+		{Type: node.NodeRemoved, Node: nodeToKill.Name},
+		{Type: node.NodeAdded},
+		{Type: node.NodeIDMoved, From: nodeToKill.Name},
+	}
+	for pool := range before[nodeToKill.Name].Pools {
+		expectedChanges = append(expectedChanges, node.TopologyEvent{Type: node.PoolAdded, Pool: pool})
+	}
+	if before[nodeToKill.Name].IsStorage() {
+		expectedChanges = append(expectedChanges, node.TopologyEvent{Type: node.NodeBecameStorage})
+	}
+	stopTopologyLog := node.OnTopologyChange(func(e node.TopologyEvent) {
+		log.InfoD("Node registry topology change: %s", e)
+	})
+	defer stopTopologyLog()
+
 	stepLog := fmt.Sprintf("Deleting node [%v]", nodeToKill.Name)
 	Step(stepLog, func() {
 		log.InfoD(stepLog)
@@ -280,11 +298,12 @@ func asgKillANodeAndValidate(storageDriverNodes []node.Node) {
 		time.Sleep(10 * time.Minute)
 	})
 
-	err := Inst().S.RefreshNodeRegistry()
-	log.FailOnError(err, "Verify node registry refresh")
-
-	err = Inst().V.RefreshDriverEndpoints()
-	log.FailOnError(err, "Verify driver end points refresh")
+	stepLog = fmt.Sprintf("Validate node [%v] is replaced by a node taking over its storage", nodeToKill.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		err := ValidateTopologyChange(before, expectedChanges...)
+		dash.VerifyFatal(err, nil, fmt.Sprintf("Validate topology change after deleting node [%s]", nodeToKill.Name))
+	})
 
 	stepLog = fmt.Sprintf("Validate number of storage nodes after killing node [%v]", nodeToKill.Name)
 	Step(stepLog, func() {
@@ -19,6 +19,7 @@ import (
 	//"github.com/portworx/torpedo/drivers/scheduler/spec"
 	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/portworx/torpedo/pkg/asyncdr"
 	"github.com/portworx/torpedo/pkg/testrailuttils"
 	. "github.com/portworx/torpedo/tests"
 
@@ -148,6 +149,120 @@ var _ = Describe("{MigrateDeployment}", func() {
 	})
 })
 
+// This test migrates applications with migration schedules, fails them over to the destination cluster and
+// fails them back to the source cluster
+var _ = Describe("{MigrationScheduleFailoverFailback}", func() {
+	var testrailID = 0
+	var runID int
+
+	var kubeConfigWritten bool
+	BeforeEach(func() {
+		if !kubeConfigWritten {
+			// Write kubeconfig files after reading from the config maps created by torpedo deploy script
+			WriteKubeconfigToFiles()
+			kubeConfigWritten = true
+		}
+		wantAllAfterSuiteActions = false
+	})
+	JustBeforeEach(func() {
+		StartTorpedoTest("MigrationScheduleFailoverFailback", "Failover and failback of applications migrated with migration schedules", nil, testrailID)
+		runID = testrailuttils.AddRunsToMilestone(testrailID)
+	})
+	var (
+		contexts              []*scheduler.Context
+		namespaceContexts     = make(map[string]*scheduler.Context)
+		taskNamePrefix        = "async-dr-failover"
+		schedulePolicyName    = "async-dr-failover-policy"
+		migrationSchedules    = make(map[string]string)
+		failbackMigrations    []*storkapi.Migration
+		includeResourcesFlag  = true
+		startApplicationsFlag = false
+	)
+
+	It("has to migrate apps with migration schedules, fail them over and fail them back", func() {
+		Step("Deploy applications and pair clusters", func() {
+			SetSourceKubeConfig()
+			for i := 0; i < Inst().GlobalScaleFactor; i++ {
+				taskName := fmt.Sprintf("%s-%d", taskNamePrefix, i)
+				appContexts := ScheduleApplications(taskName)
+				contexts = append(contexts, appContexts...)
+				ValidateApplications(contexts)
+				for _, ctx := range appContexts {
+					ctx.ReadinessTimeout = appReadinessTimeout
+					namespace := GetAppNamespace(ctx, taskName)
+					namespaceContexts[namespace] = ctx
+					err := ScheduleValidateClusterPair(ctx, false, true, defaultClusterPairDir, false)
+					log.FailOnError(err, "Failed to pair clusters for namespace %s", namespace)
+				}
+			}
+		})
+
+		Step("Migrate applications with migration schedules", func() {
+			_, err := asyncdr.CreateSchedulePolicy(schedulePolicyName, 15)
+			log.FailOnError(err, "Failed to create schedule policy %s", schedulePolicyName)
+			start := time.Now()
+			for namespace := range namespaceContexts {
+				scheduleName := migrationKey + "schedule-" + namespace
+				_, err := asyncdr.CreateMigrationSchedule(scheduleName, namespace, defaultClusterPairName, schedulePolicyName, &includeResourcesFlag, &startApplicationsFlag)
+				log.FailOnError(err, "Failed to create migration schedule %s in namespace %s", scheduleName, namespace)
+				migrationSchedules[namespace] = scheduleName
+			}
+			for namespace, scheduleName := range migrationSchedules {
+				err := asyncdr.WaitForScheduledMigration(scheduleName, namespace, start, migrationRetryTimeout)
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying migration of schedule %s in namespace %s", scheduleName, namespace))
+			}
+		})
+
+		Step("Fail over and fail back applications", func() {
+			for namespace, ctx := range namespaceContexts {
+				cfg, err := NewAsyncDRConfig(ctx, namespace, migrationSchedules[namespace])
+				log.FailOnError(err, "Failed to get the async DR config of namespace %s", namespace)
+				err = asyncdr.Failover(cfg)
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying failover of namespace %s", namespace))
+				mig, err := asyncdr.Failback(cfg, migrationKey+"failback-"+namespace)
+				if mig != nil {
+					failbackMigrations = append(failbackMigrations, mig)
+				}
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying failback of namespace %s", namespace))
+			}
+			ValidateApplications(contexts)
+		})
+
+		Step("teardown migration schedules", func() {
+			for namespace, scheduleName := range migrationSchedules {
+				err := storkops.Instance().DeleteMigrationSchedule(scheduleName, namespace)
+				dash.VerifySafely(err, nil, fmt.Sprintf("Deleting migration schedule %s in namespace %s", scheduleName, namespace))
+			}
+			err := storkops.Instance().DeleteSchedulePolicy(schedulePolicyName)
+			dash.VerifySafely(err, nil, fmt.Sprintf("Deleting schedule policy %s", schedulePolicyName))
+		})
+
+		Step("teardown all applications on both clusters", func() {
+			opts := map[string]bool{
+				SkipClusterScopedObjects:                    true,
+				scheduler.OptionsWaitForResourceLeakCleanup: true,
+				scheduler.OptionsWaitForDestroy:             true,
+			}
+			for _, ctx := range contexts {
+				TearDownContext(ctx, opts)
+			}
+			SetDestinationKubeConfig()
+			for _, mig := range failbackMigrations {
+				err := DeleteAndWaitForMigrationDeletion(mig.Name, mig.Namespace)
+				dash.VerifySafely(err, nil, fmt.Sprintf("Deleting migration %s in namespace %s", mig.Name, mig.Namespace))
+			}
+			for _, ctx := range contexts {
+				TearDownContext(ctx, opts)
+			}
+			SetSourceKubeConfig()
+		})
+	})
+	JustAfterEach(func() {
+		defer EndTorpedoTest()
+		AfterEachTest(contexts, testrailID, runID)
+	})
+})
+
 func WriteKubeconfigToFiles() {
 	kubeconfigs := os.Getenv("KUBECONFIGS")
 	Expect(kubeconfigs).NotTo(Equal(""),
@@ -89,13 +89,23 @@ var _ = Describe("{Longevity}", func() {
 		Trashcan:               TriggerTrashcan,
 		KVDBFailover:           TriggerKVDBFailover,
 		ValidateDeviceMapper:   TriggerValidateDeviceMapperCleanup,
+		ReplicaPlacement:       TriggerValidateReplicaPlacement,
+		EncryptionKeyRotation:  TriggerEncryptionKeyRotation,
+		DiskFault:              TriggerDiskFault,
+		CPUPressure:            TriggerCPUPressure,
+		MemoryPressure:         TriggerMemoryPressure,
+		DiskFillPressure:       TriggerDiskFillPressure,
+		ClockSkew:              TriggerClockSkew,
+		NodeOSUpgrade:          TriggerNodeOSUpgrade,
 		AsyncDR:                TriggerAsyncDR,
 		AsyncDRVolumeOnly:      TriggerAsyncDRVolumeOnly,
+		MetroDRDomainFailover:  TriggerMetroDRDomainFailover,
 		StorkApplicationBackup: TriggerStorkApplicationBackup,
 		StorkAppBkpVolResize:   TriggerStorkAppBkpVolResize,
 		StorkAppBkpHaUpdate:    TriggerStorkAppBkpHaUpdate,
 		StorkAppBkpPxRestart:   TriggerStorkAppBkpPxRestart,
 		StorkAppBkpPoolResize:  TriggerStorkAppBkpPoolResize,
+		StorkApplicationClone:  TriggerStorkApplicationClone,
 		RestartKvdbVolDriver:   TriggerRestartKvdbVolDriver,
 		HAIncreaseAndReboot:    TriggerHAIncreaseAndReboot,
 		AddDiskAndReboot:       TriggerPoolAddDiskAndReboot,
@@ -357,6 +367,7 @@ func populateDisruptiveTriggers() {
 		AddDiskAndReboot:                true,
 		ResizeDiskAndReboot:             true,
 		VolumeCreatePxRestart:           true,
+		MetroDRDomainFailover:           true,
 	}
 }
 
@@ -619,14 +630,24 @@ func populateIntervals() {
 	triggerInterval[Trashcan] = make(map[int]time.Duration)
 	triggerInterval[KVDBFailover] = make(map[int]time.Duration)
 	triggerInterval[ValidateDeviceMapper] = make(map[int]time.Duration)
+	triggerInterval[ReplicaPlacement] = make(map[int]time.Duration)
+	triggerInterval[EncryptionKeyRotation] = make(map[int]time.Duration)
+	triggerInterval[DiskFault] = make(map[int]time.Duration)
+	triggerInterval[CPUPressure] = make(map[int]time.Duration)
+	triggerInterval[MemoryPressure] = make(map[int]time.Duration)
+	triggerInterval[DiskFillPressure] = make(map[int]time.Duration)
+	triggerInterval[ClockSkew] = make(map[int]time.Duration)
+	triggerInterval[NodeOSUpgrade] = make(map[int]time.Duration)
 	triggerInterval[AsyncDR] = make(map[int]time.Duration)
 	triggerInterval[ConfluentAsyncDR] = make(map[int]time.Duration)
 	triggerInterval[AsyncDRVolumeOnly] = make(map[int]time.Duration)
+	triggerInterval[MetroDRDomainFailover] = make(map[int]time.Duration)
 	triggerInterval[StorkApplicationBackup] = make(map[int]time.Duration)
 	triggerInterval[StorkAppBkpVolResize] = make(map[int]time.Duration)
 	triggerInterval[StorkAppBkpHaUpdate] = make(map[int]time.Duration)
 	triggerInterval[StorkAppBkpPxRestart] = make(map[int]time.Duration)
 	triggerInterval[StorkAppBkpPoolResize] = make(map[int]time.Duration)
+	triggerInterval[StorkApplicationClone] = make(map[int]time.Duration)
 	triggerInterval[HAIncreaseAndReboot] = make(map[int]time.Duration)
 	triggerInterval[AddDrive] = make(map[int]time.Duration)
 	triggerInterval[AddDiskAndReboot] = make(map[int]time.Duration)
@@ -787,6 +808,17 @@ func populateIntervals() {
 	triggerInterval[AsyncDRVolumeOnly][2] = 24 * baseInterval
 	triggerInterval[AsyncDRVolumeOnly][1] = 27 * baseInterval
 
+	triggerInterval[MetroDRDomainFailover][10] = 1 * baseInterval
+	triggerInterval[MetroDRDomainFailover][9] = 3 * baseInterval
+	triggerInterval[MetroDRDomainFailover][8] = 6 * baseInterval
+	triggerInterval[MetroDRDomainFailover][7] = 9 * baseInterval
+	triggerInterval[MetroDRDomainFailover][6] = 12 * baseInterval
+	triggerInterval[MetroDRDomainFailover][5] = 15 * baseInterval
+	triggerInterval[MetroDRDomainFailover][4] = 18 * baseInterval
+	triggerInterval[MetroDRDomainFailover][3] = 21 * baseInterval
+	triggerInterval[MetroDRDomainFailover][2] = 24 * baseInterval
+	triggerInterval[MetroDRDomainFailover][1] = 27 * baseInterval
+
 	triggerInterval[StorkApplicationBackup][10] = 1 * baseInterval
 	triggerInterval[StorkApplicationBackup][9] = 3 * baseInterval
 	triggerInterval[StorkApplicationBackup][8] = 6 * baseInterval
@@ -842,6 +874,17 @@ func populateIntervals() {
 	triggerInterval[StorkAppBkpPoolResize][2] = 24 * baseInterval
 	triggerInterval[StorkAppBkpPoolResize][1] = 27 * baseInterval
 
+	triggerInterval[StorkApplicationClone][10] = 1 * baseInterval
+	triggerInterval[StorkApplicationClone][9] = 3 * baseInterval
+	triggerInterval[StorkApplicationClone][8] = 6 * baseInterval
+	triggerInterval[StorkApplicationClone][7] = 9 * baseInterval
+	triggerInterval[StorkApplicationClone][6] = 12 * baseInterval
+	triggerInterval[StorkApplicationClone][5] = 15 * baseInterval
+	triggerInterval[StorkApplicationClone][4] = 18 * baseInterval
+	triggerInterval[StorkApplicationClone][3] = 21 * baseInterval
+	triggerInterval[StorkApplicationClone][2] = 24 * baseInterval
+	triggerInterval[StorkApplicationClone][1] = 27 * baseInterval
+
 	baseInterval = 60 * time.Minute
 
 	triggerInterval[AppTasksDown][10] = 1 * baseInterval
@@ -1270,6 +1313,94 @@ func populateIntervals() {
 	triggerInterval[ValidateDeviceMapper][2] = 24 * baseInterval
 	triggerInterval[ValidateDeviceMapper][1] = 27 * baseInterval
 
+	triggerInterval[ReplicaPlacement][10] = 1 * baseInterval
+	triggerInterval[ReplicaPlacement][9] = 3 * baseInterval
+	triggerInterval[ReplicaPlacement][8] = 6 * baseInterval
+	triggerInterval[ReplicaPlacement][7] = 9 * baseInterval
+	triggerInterval[ReplicaPlacement][6] = 12 * baseInterval
+	triggerInterval[ReplicaPlacement][5] = 15 * baseInterval
+	triggerInterval[ReplicaPlacement][4] = 18 * baseInterval
+	triggerInterval[ReplicaPlacement][3] = 21 * baseInterval
+	triggerInterval[ReplicaPlacement][2] = 24 * baseInterval
+	triggerInterval[ReplicaPlacement][1] = 27 * baseInterval
+
+	triggerInterval[EncryptionKeyRotation][10] = 1 * baseInterval
+	triggerInterval[EncryptionKeyRotation][9] = 3 * baseInterval
+	triggerInterval[EncryptionKeyRotation][8] = 6 * baseInterval
+	triggerInterval[EncryptionKeyRotation][7] = 9 * baseInterval
+	triggerInterval[EncryptionKeyRotation][6] = 12 * baseInterval
+	triggerInterval[EncryptionKeyRotation][5] = 15 * baseInterval
+	triggerInterval[EncryptionKeyRotation][4] = 18 * baseInterval
+	triggerInterval[EncryptionKeyRotation][3] = 21 * baseInterval
+	triggerInterval[EncryptionKeyRotation][2] = 24 * baseInterval
+	triggerInterval[EncryptionKeyRotation][1] = 27 * baseInterval
+
+	triggerInterval[DiskFault][10] = 1 * baseInterval
+	triggerInterval[DiskFault][9] = 3 * baseInterval
+	triggerInterval[DiskFault][8] = 6 * baseInterval
+	triggerInterval[DiskFault][7] = 9 * baseInterval
+	triggerInterval[DiskFault][6] = 12 * baseInterval
+	triggerInterval[DiskFault][5] = 15 * baseInterval
+	triggerInterval[DiskFault][4] = 18 * baseInterval
+	triggerInterval[DiskFault][3] = 21 * baseInterval
+	triggerInterval[DiskFault][2] = 24 * baseInterval
+	triggerInterval[DiskFault][1] = 27 * baseInterval
+
+	triggerInterval[CPUPressure][10] = 1 * baseInterval
+	triggerInterval[CPUPressure][9] = 3 * baseInterval
+	triggerInterval[CPUPressure][8] = 6 * baseInterval
+	triggerInterval[CPUPressure][7] = 9 * baseInterval
+	triggerInterval[CPUPressure][6] = 12 * baseInterval
+	triggerInterval[CPUPressure][5] = 15 * baseInterval
+	triggerInterval[CPUPressure][4] = 18 * baseInterval
+	triggerInterval[CPUPressure][3] = 21 * baseInterval
+	triggerInterval[CPUPressure][2] = 24 * baseInterval
+	triggerInterval[CPUPressure][1] = 27 * baseInterval
+
+	triggerInterval[MemoryPressure][10] = 1 * baseInterval
+	triggerInterval[MemoryPressure][9] = 3 * baseInterval
+	triggerInterval[MemoryPressure][8] = 6 * baseInterval
+	triggerInterval[MemoryPressure][7] = 9 * baseInterval
+	triggerInterval[MemoryPressure][6] = 12 * baseInterval
+	triggerInterval[MemoryPressure][5] = 15 * baseInterval
+	triggerInterval[MemoryPressure][4] = 18 * baseInterval
+	triggerInterval[MemoryPressure][3] = 21 * baseInterval
+	triggerInterval[MemoryPressure][2] = 24 * baseInterval
+	triggerInterval[MemoryPressure][1] = 27 * baseInterval
+
+	triggerInterval[DiskFillPressure][10] = 1 * baseInterval
+	triggerInterval[DiskFillPressure][9] = 3 * baseInterval
+	triggerInterval[DiskFillPressure][8] = 6 * baseInterval
+	triggerInterval[DiskFillPressure][7] = 9 * baseInterval
+	triggerInterval[DiskFillPressure][6] = 12 * baseInterval
+	triggerInterval[DiskFillPressure][5] = 15 * baseInterval
+	triggerInterval[DiskFillPressure][4] = 18 * baseInterval
+	triggerInterval[DiskFillPressure][3] = 21 * baseInterval
+	triggerInterval[DiskFillPressure][2] = 24 * baseInterval
+	triggerInterval[DiskFillPressure][1] = 27 * baseInterval
+
+	triggerInterval[ClockSkew][10] = 1 * baseInterval
+	triggerInterval[ClockSkew][9] = 3 * baseInterval
+	triggerInterval[ClockSkew][8] = 6 * baseInterval
+	triggerInterval[ClockSkew][7] = 9 * baseInterval
+	triggerInterval[ClockSkew][6] = 12 * baseInterval
+	triggerInterval[ClockSkew][5] = 15 * baseInterval
+	triggerInterval[ClockSkew][4] = 18 * baseInterval
+	triggerInterval[ClockSkew][3] = 21 * baseInterval
+	triggerInterval[ClockSkew][2] = 24 * baseInterval
+	triggerInterval[ClockSkew][1] = 27 * baseInterval
+
+	triggerInterval[NodeOSUpgrade][10] = 1 * baseInterval
+	triggerInterval[NodeOSUpgrade][9] = 3 * baseInterval
+	triggerInterval[NodeOSUpgrade][8] = 6 * baseInterval
+	triggerInterval[NodeOSUpgrade][7] = 9 * baseInterval
+	triggerInterval[NodeOSUpgrade][6] = 12 * baseInterval
+	triggerInterval[NodeOSUpgrade][5] = 15 * baseInterval
+	triggerInterval[NodeOSUpgrade][4] = 18 * baseInterval
+	triggerInterval[NodeOSUpgrade][3] = 21 * baseInterval
+	triggerInterval[NodeOSUpgrade][2] = 24 * baseInterval
+	triggerInterval[NodeOSUpgrade][1] = 27 * baseInterval
+
 	triggerInterval[AddDrive][10] = 1 * baseInterval
 	triggerInterval[AddDrive][9] = 2 * baseInterval
 	triggerInterval[AddDrive][8] = 3 * baseInterval
@@ -1320,14 +1451,24 @@ func populateIntervals() {
 	triggerInterval[RelaxedReclaim][0] = 0
 	triggerInterval[KVDBFailover][0] = 0
 	triggerInterval[ValidateDeviceMapper][0] = 0
+	triggerInterval[ReplicaPlacement][0] = 0
+	triggerInterval[EncryptionKeyRotation][0] = 0
+	triggerInterval[DiskFault][0] = 0
+	triggerInterval[CPUPressure][0] = 0
+	triggerInterval[MemoryPressure][0] = 0
+	triggerInterval[DiskFillPressure][0] = 0
+	triggerInterval[ClockSkew][0] = 0
+	triggerInterval[NodeOSUpgrade][0] = 0
 	triggerInterval[AsyncDR][0] = 0
 	triggerInterval[ConfluentAsyncDR][0] = 0
 	triggerInterval[AsyncDRVolumeOnly][0] = 0
+	triggerInterval[MetroDRDomainFailover][0] = 0
 	triggerInterval[StorkApplicationBackup][0] = 0
 	triggerInterval[StorkAppBkpVolResize][0] = 0
 	triggerInterval[StorkAppBkpHaUpdate][0] = 0
 	triggerInterval[StorkAppBkpPxRestart][0] = 0
 	triggerInterval[StorkAppBkpPoolResize][0] = 0
+	triggerInterval[StorkApplicationClone][0] = 0
 	triggerInterval[HAIncreaseAndReboot][0] = 0
 	triggerInterval[AddDrive][0] = 0
 	triggerInterval[AddDiskAndReboot][0] = 0
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/pkg/log"
+	"github.com/portworx/torpedo/pkg/testrailuttils"
+	. "github.com/portworx/torpedo/tests"
+)
+
+var _ = Describe("{MetroDRDomainFailover}", func() {
+	var testrailID = 0
+	var runID int
+
+	var kubeConfigWritten bool
+	BeforeEach(func() {
+		if !kubeConfigWritten {
+			// Write kubeconfig files after reading from the config maps created by torpedo deploy script
+			WriteKubeconfigToFiles()
+			kubeConfigWritten = true
+		}
+		wantAllAfterSuiteActions = false
+	})
+	JustBeforeEach(func() {
+		StartTorpedoTest("MetroDRDomainFailover", "Fail over applications of a stretched cluster to the destination cluster in the surviving cluster domain", nil, testrailID)
+		runID = testrailuttils.AddRunsToMilestone(testrailID)
+	})
+	var (
+		contexts       []*scheduler.Context
+		taskNamePrefix = "metro-dr-failover"
+	)
+
+	It("has to take down the cluster domain of the source cluster and fail over the applications to the destination cluster", func() {
+		topology, err := GetMetroDRTopology()
+		log.FailOnError(err, "Failed to get the cluster domains of the stretched cluster")
+		domain, err := GetMetroDRSourceDomain(topology)
+		log.FailOnError(err, "Failed to get the cluster domain of the source cluster")
+		surviving := topology.SurvivingDomains(domain)
+		log.InfoD("Cluster domains %v, witness nodes %d", topology.DomainNames(), len(topology.Witness))
+		protected, err := GetMetroDRProtectedNodes()
+		log.FailOnError(err, "Failed to get the nodes running torpedo, stork and the control planes")
+		stoppable := false
+		for _, d := range topology.DomainsWithout(protected) {
+			stoppable = stoppable || d == domain
+		}
+		if !stoppable {
+			Skip(fmt.Sprintf("Cluster domain %s of the source cluster holds some of the nodes %v running torpedo, stork or a control plane", domain, protected))
+		}
+
+		Step("Deploy applications and migrate them to the destination cluster through sync DR cluster pairs", func() {
+			SetSourceKubeConfig()
+			for i := 0; i < Inst().GlobalScaleFactor; i++ {
+				taskName := fmt.Sprintf("%s-%d", taskNamePrefix, i)
+				appContexts := ScheduleApplications(taskName)
+				contexts = append(contexts, appContexts...)
+				ValidateApplications(contexts)
+				for _, ctx := range appContexts {
+					err := ScheduleValidateSyncDRClusterPair(ctx, defaultClusterPairDir)
+					log.FailOnError(err, "Failed to pair clusters for sync DR for app %s", ctx.App.Key)
+					err = MigrateSyncDRResources(ctx, fmt.Sprintf("%s-%s", taskName, ctx.App.Key))
+					log.FailOnError(err, "Failed to migrate the resources of app %s", ctx.App.Key)
+				}
+			}
+		})
+
+		Step(fmt.Sprintf("Take down cluster domain %s and fail over the applications to %v", domain, surviving), func() {
+			err := StopClusterDomain(topology, domain)
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying cluster domain %s is down", domain))
+			err = FailoverMetroDRApps(contexts, topology, domain)
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying applications fail over to cluster domains %v", surviving))
+		})
+
+		Step(fmt.Sprintf("Fail back the applications and bring back cluster domain %s", domain), func() {
+			err := FailbackMetroDRApps(contexts)
+			dash.VerifyFatal(err, nil, "Verifying applications are deactivated on the destination cluster")
+			err = StartClusterDomain(topology, domain)
+			dash.VerifyFatal(err, nil, fmt.Sprintf("Verifying cluster domain %s is back", domain))
+			ValidateApplications(contexts)
+		})
+
+		Step("teardown all applications", func() {
+			SetDestinationKubeConfig()
+			for _, ctx := range contexts {
+				err := core.Instance().DeleteNamespace(ctx.ScheduleOptions.Namespace)
+				dash.VerifySafely(err, nil, fmt.Sprintf("Deleting migrated namespace %s on the destination cluster", ctx.ScheduleOptions.Namespace))
+			}
+			err := SetSourceKubeConfig()
+			log.FailOnError(err, "Switching context to source cluster")
+			opts := map[string]bool{
+				SkipClusterScopedObjects:                    true,
+				scheduler.OptionsWaitForResourceLeakCleanup: true,
+				scheduler.OptionsWaitForDestroy:             true,
+			}
+			for _, ctx := range contexts {
+				TearDownContext(ctx, opts)
+			}
+		})
+	})
+	JustAfterEach(func() {
+		defer EndTorpedoTest()
+		AfterEachTest(contexts, testrailID, runID)
+	})
+})
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/log"
+	. "github.com/portworx/torpedo/tests"
+)
+
+const (
+	authMatrixNamespace = "torpedo-auth"
+	shortTokenExpiry    = 1 * time.Minute
+)
+
+// Validate the volume operations allowed for each PX-Security role
+var _ = Describe("{PxSecurityAuthMatrix}", func() {
+	JustBeforeEach(func() {
+		StartTorpedoTest("PxSecurityAuthMatrix", "Validate volume operations for admin, user, view-only, namespace scoped and non-owner tokens", nil, 0)
+	})
+	var contexts []*scheduler.Context
+
+	stepLog := "has to run volume create, inspect, snapshot and delete under each role"
+	It(stepLog, func() {
+		if !IsPxSecurityEnabled() {
+			Skip("PX-Security is not enabled on the cluster")
+		}
+		log.InfoD(stepLog)
+		for _, err := range GetAuthMatrixErrors(DefaultAuthMatrix(authMatrixNamespace)) {
+			log.Errorf("%v", err)
+			dash.VerifySafely(err, nil, "volume operation outcome matches the authorization matrix?")
+		}
+	})
+	JustAfterEach(func() {
+		defer EndTorpedoTest()
+		AfterEachTest(contexts)
+	})
+})
+
+// Validate tokens are rejected once they expire
+var _ = Describe("{PxSecurityTokenExpiry}", func() {
+	JustBeforeEach(func() {
+		StartTorpedoTest("PxSecurityTokenExpiry", "Validate an expired token is rejected by the volume driver", nil, 0)
+	})
+	var contexts []*scheduler.Context
+
+	stepLog := fmt.Sprintf("has to reject a user token after %v", shortTokenExpiry)
+	It(stepLog, func() {
+		if !IsPxSecurityEnabled() {
+			Skip("PX-Security is not enabled on the cluster")
+		}
+		log.InfoD(stepLog)
+		identity := volume.AuthIdentity{Name: "torpedo-expiry", Roles: []string{volume.AuthRoleUser}}
+		err := ValidateTokenExpiry(identity, shortTokenExpiry)
+		dash.VerifyFatal(err, nil, "expired token is rejected?")
+	})
+	JustAfterEach(func() {
+		defer EndTorpedoTest()
+		AfterEachTest(contexts)
+	})
+})
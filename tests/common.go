@@ -10,9 +10,15 @@ import (
 	"math/rand"
 	"net/http"
 	"regexp"
+	"sort"
 
 	"github.com/portworx/torpedo/pkg/aetosutil"
+	"github.com/portworx/torpedo/pkg/alertutils"
+	"github.com/portworx/torpedo/pkg/applicationbackup"
+	"github.com/portworx/torpedo/pkg/asyncdr"
 	"github.com/portworx/torpedo/pkg/log"
+	"github.com/portworx/torpedo/pkg/metrodr"
+	"github.com/portworx/torpedo/pkg/revertutils"
 	"github.com/portworx/torpedo/pkg/units"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
@@ -33,6 +39,8 @@ import (
 
 	storageapi "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
 
@@ -51,17 +59,23 @@ import (
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
 	api "github.com/portworx/px-backup-api/pkg/apis/v1"
+	"github.com/portworx/sched-ops/k8s/apps"
 	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/portworx/sched-ops/k8s/talisman"
 	"github.com/portworx/sched-ops/task"
 	"github.com/portworx/torpedo/drivers"
 	"github.com/portworx/torpedo/drivers/backup"
 	"github.com/portworx/torpedo/drivers/monitor"
 	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/objectstore"
 	torpedovolume "github.com/portworx/torpedo/drivers/volume"
 	"github.com/portworx/torpedo/pkg/jirautils"
 	"github.com/portworx/torpedo/pkg/osutils"
 	"github.com/portworx/torpedo/pkg/pureutils"
+	"github.com/portworx/torpedo/pkg/restoreutils"
 	"github.com/portworx/torpedo/pkg/testrailuttils"
+	"github.com/portworx/torpedo/pkg/vpsutil"
 	appsapi "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
@@ -77,6 +91,10 @@ import (
 	_ "github.com/portworx/torpedo/drivers/node/gke"
 	// import vsphere driver to invoke it's init
 	_ "github.com/portworx/torpedo/drivers/node/vsphere"
+	// import redfish driver to invoke it's init
+	_ "github.com/portworx/torpedo/drivers/node/redfish"
+	// import libvirt driver to invoke it's init
+	_ "github.com/portworx/torpedo/drivers/node/libvirt"
 	// import ibm driver to invoke it's init
 	_ "github.com/portworx/torpedo/drivers/node/ibm"
 	// import oracle driver to invoke it's init
@@ -129,6 +147,7 @@ const (
 	monitorDriverCliFlag                 = "monitor-driver"
 	storageDriverCliFlag                 = "storage-driver"
 	backupCliFlag                        = "backup-driver"
+	objectStoreCliFlag                   = "objectstore-driver"
 	specDirCliFlag                       = "spec-dir"
 	appListCliFlag                       = "app-list"
 	secureAppsCliFlag                    = "secure-apps"
@@ -150,6 +169,8 @@ const (
 	csiGenericDriverConfigMapFlag        = "csi-generic-driver-config-map"
 	licenseExpiryTimeoutHoursFlag        = "license_expiry_timeout_hours"
 	meteringIntervalMinsFlag             = "metering_interval_mins"
+	validateReplicaPlacementFlag         = "validate-replica-placement"
+	validateAlertsFlag                   = "validate-alerts"
 	SourceClusterName                    = "source-cluster"
 	destinationClusterName               = "destination-cluster"
 	backupLocationNameConst              = "tp-blocation"
@@ -219,6 +240,8 @@ const (
 	SchedulePolicyAllName             = "schedule-policy-all"
 	SchedulePolicyScaleName           = "schedule-policy-scale"
 	BucketNamePrefix                  = "tp-backup-bucket"
+	// nfsServerAppKey is the spec of the in-cluster NFS server used as backup location
+	nfsServerAppKey = "nfs-server"
 )
 
 const (
@@ -271,6 +294,26 @@ const (
 	pxctlCDListCmd = "pxctl cd list"
 )
 
+const (
+	encryptionParam      = "secure"
+	encryptionSecretName = "px/secret-name"
+	encryptionSecretNS   = "px/secret-namespace"
+	encryptionMarkerFile = ".torpedo-encryption-marker"
+)
+
+// serviceAccountNamespaceFile holds the namespace of the pod torpedo runs in, when it runs in the cluster
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+const (
+	volumeChecksumPodPrefix = "torpedo-checksum-"
+	volumeChecksumMountPath = "/data"
+)
+
+const (
+	authTokenExpiry = 1 * time.Hour
+	authVolumeSize  = 1 * 1024 * 1024 * 1024
+)
+
 var pxRuntimeOpts string
 var PxBackupVersion string
 
@@ -301,6 +344,7 @@ var (
 	beTrue        = gomega.BeTrue
 	beNumerically = gomega.BeNumerically
 	k8sCore       = core.Instance()
+	k8sApps       = apps.Instance()
 )
 
 // Backup vars
@@ -542,6 +586,12 @@ func ValidateContext(ctx *scheduler.Context, errChan ...*chan error) {
 			}
 		})
 
+		if Inst().ValidateReplicaPlacement && !ctx.SkipVolumeValidation {
+			Step(fmt.Sprintf("validate replica placement of %s app's volumes", ctx.App.Key), func() {
+				ValidateReplicaPlacement(ctx, errChan...)
+			})
+		}
+
 		Step("Validate Px pod restart count", func() {
 			ValidatePxPodRestartCount(ctx, errChan...)
 		})
@@ -862,6 +912,400 @@ func ValidateVolumes(ctx *scheduler.Context, errChan ...*chan error) {
 	})
 }
 
+// GetEncryptedVolumes returns the encrypted volumes of the given context along with the parameters of all its volumes
+func GetEncryptedVolumes(ctx *scheduler.Context) ([]*volume.Volume, map[string]map[string]string, error) {
+	vols, err := Inst().S.GetVolumes(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	volParams, err := Inst().S.GetVolumeParameters(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var encrypted []*volume.Volume
+	for _, vol := range vols {
+		if secure, _ := strconv.ParseBool(volParams[vol.ID][encryptionParam]); secure {
+			encrypted = append(encrypted, vol)
+		}
+	}
+	return encrypted, volParams, nil
+}
+
+// WriteEncryptionMarker writes a unique plaintext marker to the given volume through a pod using it and returns the marker
+func WriteEncryptionMarker(vol *volume.Volume) (string, error) {
+	marker := fmt.Sprintf("torpedo-encryption-marker-%s", GenerateUUID())
+	cmd := fmt.Sprintf("echo %s > %s && sync", marker, encryptionMarkerFile)
+	if _, err := runInVolumeMount(vol, cmd); err != nil {
+		return "", err
+	}
+	return marker, nil
+}
+
+// ValidateEncryptionMarker validates the app still reads the marker previously written to the given volume
+func ValidateEncryptionMarker(vol *volume.Volume, marker string) error {
+	out, err := runInVolumeMount(vol, fmt.Sprintf("cat %s", encryptionMarkerFile))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(out) != marker {
+		return fmt.Errorf("volume [%s] returned marker [%s], expected [%s]", vol.Name, strings.TrimSpace(out), marker)
+	}
+	return nil
+}
+
+// runInVolumeMount runs the given shell command from the mount path of the volume in a running pod using it
+func runInVolumeMount(vol *volume.Volume, cmd string) (string, error) {
+	pods, err := k8sCore.GetPodsUsingPVC(vol.Name, vol.Namespace)
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		var podVolume string
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == vol.Name {
+				podVolume = v.Name
+			}
+		}
+		for _, container := range pod.Spec.Containers {
+			for _, mount := range container.VolumeMounts {
+				if mount.Name != podVolume || mount.ReadOnly {
+					continue
+				}
+				return k8sCore.RunCommandInPod([]string{"sh", "-c", fmt.Sprintf("cd %s && %s", mount.MountPath, cmd)},
+					pod.Name, container.Name, pod.Namespace)
+			}
+		}
+	}
+	return "", fmt.Errorf("no running pod mounts volume [%s] in namespace [%s]", vol.Name, vol.Namespace)
+}
+
+// AuthMatrixEntry is the expected outcome of the volume operations run under an identity when PX-Security is enabled
+type AuthMatrixEntry struct {
+	Identity volume.AuthIdentity
+	// Owner, when set, owns the volume the identity inspects, snapshots and deletes, and shares it with read
+	// access to its groups. Otherwise the identity runs them against its own volume, or one owned by the admin
+	// if it cannot create volumes.
+	Owner       *volume.AuthIdentity
+	CanCreate   bool
+	CanInspect  bool
+	CanSnapshot bool
+	CanDelete   bool
+}
+
+// IsPxSecurityEnabled returns true if PX-Security is enabled on the StorageCluster
+func IsPxSecurityEnabled() bool {
+	if stc, err := Inst().V.GetDriver(); err == nil {
+		return stc.Spec.Security != nil && stc.Spec.Security.Enabled
+	}
+	return false
+}
+
+// DefaultAuthMatrix returns the authorization matrix of the built in roles, of a namespace scoped identity using a
+// volume shared with its namespace group, and of a user using a volume owned by another user
+func DefaultAuthMatrix(namespace string) []AuthMatrixEntry {
+	return []AuthMatrixEntry{
+		{
+			Identity:    volume.AuthIdentity{Name: "torpedo-admin", Roles: []string{volume.AuthRoleAdmin}},
+			CanCreate:   true,
+			CanInspect:  true,
+			CanSnapshot: true,
+			CanDelete:   true,
+		},
+		{
+			Identity:    volume.AuthIdentity{Name: "torpedo-user", Roles: []string{volume.AuthRoleUser}},
+			CanCreate:   true,
+			CanInspect:  true,
+			CanSnapshot: true,
+			CanDelete:   true,
+		},
+		{
+			Identity: volume.AuthIdentity{Name: "torpedo-viewer", Roles: []string{volume.AuthRoleView}},
+		},
+		{
+			// read access through the namespace group allows inspect and snapshot but not delete
+			Identity: volume.AuthIdentity{
+				Name:   fmt.Sprintf("torpedo-%s-member", namespace),
+				Roles:  []string{volume.AuthRoleUser},
+				Groups: []string{namespace},
+			},
+			Owner: &volume.AuthIdentity{
+				Name:   fmt.Sprintf("torpedo-%s-owner", namespace),
+				Roles:  []string{volume.AuthRoleUser},
+				Groups: []string{namespace},
+			},
+			CanCreate:   true,
+			CanInspect:  true,
+			CanSnapshot: true,
+		},
+		{
+			Identity:  volume.AuthIdentity{Name: "torpedo-other-user", Roles: []string{volume.AuthRoleUser}},
+			Owner:     &volume.AuthIdentity{Name: "torpedo-user", Roles: []string{volume.AuthRoleUser}},
+			CanCreate: true,
+		},
+	}
+}
+
+// GetAuthMatrixErrors runs volume create, inspect, snapshot and delete under the identity of every entry
+// and returns an error for every operation whose outcome differs from the expected one
+func GetAuthMatrixErrors(entries []AuthMatrixEntry) []error {
+	adminToken, err := Inst().V.GenerateToken(volume.AuthIdentity{Name: "torpedo-admin", Roles: []string{volume.AuthRoleAdmin}}, authTokenExpiry)
+	if err != nil {
+		return []error{err}
+	}
+
+	var authErrors []error
+	check := func(identity volume.AuthIdentity, op string, expected bool, err error) {
+		if err != nil && !isAuthDenied(err) {
+			authErrors = append(authErrors, fmt.Errorf("%s as [%s] failed with a non authorization error: %v", op, identity.Name, err))
+			return
+		}
+		if allowed := err == nil; allowed != expected {
+			authErrors = append(authErrors, fmt.Errorf("%s as [%s] with roles %v: expected allowed=%t, got allowed=%t (%v)",
+				op, identity.Name, identity.Roles, expected, allowed, err))
+		}
+	}
+	deleteAsAdmin := func(volID string) {
+		if err := Inst().V.DeleteVolumeWithToken(adminToken, volID); err != nil {
+			log.Warnf("Failed to delete volume [%s]: %v", volID, err)
+		}
+	}
+
+	for _, entry := range entries {
+		identity := entry.Identity
+		log.InfoD("Validating volume operations as [%s] with roles %v and groups %v", identity.Name, identity.Roles, identity.Groups)
+		token, err := Inst().V.GenerateToken(identity, authTokenExpiry)
+		if err != nil {
+			authErrors = append(authErrors, err)
+			continue
+		}
+
+		volName := fmt.Sprintf("auth-%s-%s", identity.Name, GenerateUUID()[:8])
+		volID, err := Inst().V.CreateVolumeWithToken(token, volName, authVolumeSize, 1, nil)
+		check(identity, "create volume", entry.CanCreate, err)
+		if err != nil || entry.Owner != nil {
+			if err == nil {
+				deleteAsAdmin(volID)
+			}
+			// run the remaining operations against a volume the identity does not own
+			ownerName, ownerToken, ownerGroups := "admin", adminToken, []string(nil)
+			if entry.Owner != nil {
+				ownerName, ownerGroups = entry.Owner.Name, entry.Owner.Groups
+				if ownerToken, err = Inst().V.GenerateToken(*entry.Owner, authTokenExpiry); err != nil {
+					authErrors = append(authErrors, err)
+					continue
+				}
+			}
+			volName = fmt.Sprintf("auth-%s-%s", ownerName, GenerateUUID()[:8])
+			if volID, err = Inst().V.CreateVolumeWithToken(ownerToken, volName, authVolumeSize, 1, ownerGroups); err != nil {
+				authErrors = append(authErrors, fmt.Errorf("failed to create volume [%s] as [%s]: %v", volName, ownerName, err))
+				continue
+			}
+		}
+
+		_, err = Inst().V.InspectVolumeWithToken(token, volID)
+		check(identity, "inspect volume", entry.CanInspect, err)
+
+		snapID, err := Inst().V.CreateSnapshotWithToken(token, volID, volName+"-snap")
+		check(identity, "create snapshot", entry.CanSnapshot, err)
+		if err == nil {
+			deleteAsAdmin(snapID)
+		}
+
+		err = Inst().V.DeleteVolumeWithToken(token, volID)
+		check(identity, "delete volume", entry.CanDelete, err)
+		if err != nil {
+			deleteAsAdmin(volID)
+		}
+	}
+	return authErrors
+}
+
+// ValidateTokenExpiry validates that a token of the given identity is accepted until it expires and rejected afterwards
+func ValidateTokenExpiry(identity volume.AuthIdentity, expiry time.Duration) error {
+	token, err := Inst().V.GenerateToken(identity, expiry)
+	if err != nil {
+		return err
+	}
+	volID, err := Inst().V.CreateVolumeWithToken(token, fmt.Sprintf("auth-expiry-%s", GenerateUUID()[:8]), authVolumeSize, 1, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create volume as [%s] before token expiry: %v", identity.Name, err)
+	}
+	// the token is only checked against the expiry with a second granularity
+	time.Sleep(expiry + 5*time.Second)
+	_, inspectErr := Inst().V.InspectVolumeWithToken(token, volID)
+	if err := Inst().V.DeleteVolume(volID); err != nil {
+		log.Warnf("Failed to delete volume [%s]: %v", volID, err)
+	}
+	if inspectErr == nil {
+		return fmt.Errorf("expired token of [%s] was accepted", identity.Name)
+	}
+	if status.Code(inspectErr) != codes.Unauthenticated {
+		return fmt.Errorf("expected expired token of [%s] to be unauthenticated, got: %v", identity.Name, inspectErr)
+	}
+	return nil
+}
+
+// isAuthDenied returns true if the error is an authorization failure
+func isAuthDenied(err error) bool {
+	switch status.Code(err) {
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return true
+	}
+	return false
+}
+
+// alertResourceTypes are the resource types whose alarms are checked for unexpected alerts
+var alertResourceTypes = []opsapi.ResourceType{
+	opsapi.ResourceType_RESOURCE_TYPE_CLUSTER,
+	opsapi.ResourceType_RESOURCE_TYPE_NODE,
+	opsapi.ResourceType_RESOURCE_TYPE_POOL,
+	opsapi.ResourceType_RESOURCE_TYPE_DRIVE,
+	opsapi.ResourceType_RESOURCE_TYPE_VOLUME,
+}
+
+// GetAlertErrors returns an error for every expected alert that was not raised between startTime and endTime,
+// and for every alarm raised in that window that none of the expectations account for
+func GetAlertErrors(startTime, endTime time.Time, expected []alertutils.Expectation) []error {
+	var alerts []*opsapi.Alert
+	for _, resourceType := range alertResourceTypes {
+		resp, err := Inst().V.GetAlertsUsingResourceTypeByTime(resourceType, startTime, endTime)
+		if err != nil {
+			return []error{fmt.Errorf("failed to get %v alerts: %v", resourceType, err)}
+		}
+		alerts = append(alerts, resp.GetAlerts()...)
+	}
+
+	var alertErrors []error
+	missing, unexpected := alertutils.Diff(expected, alerts)
+	for _, e := range missing {
+		alertErrors = append(alertErrors, fmt.Errorf("expected alert [%s] was not raised between [%s] and [%s]",
+			e, startTime.Format(time.RFC1123), endTime.Format(time.RFC1123)))
+	}
+	for _, alert := range unexpected {
+		alertErrors = append(alertErrors, fmt.Errorf("unexpected alarm on %v [%s]: %s",
+			alert.GetResource(), alert.GetResourceId(), alert.GetMessage()))
+	}
+	return alertErrors
+}
+
+// ValidateReplicaPlacement is the ginkgo spec for validating that the replicas of an app's volumes are spread across
+// zones and racks and honor the VolumePlacementStrategy of their storage class
+func ValidateReplicaPlacement(ctx *scheduler.Context, errChan ...*chan error) {
+	context("For validation of an app's volume replica placement", func() {
+		stepLog := fmt.Sprintf("validate replica placement of %s app's volumes", ctx.App.Key)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			for _, err := range GetReplicaPlacementErrors(ctx) {
+				processError(err, errChan...)
+			}
+		})
+	})
+}
+
+// GetReplicaPlacementErrors returns the replica placement violations of all the volumes of the given context
+func GetReplicaPlacementErrors(ctx *scheduler.Context) []error {
+	var placementErrors []error
+	vols, err := Inst().S.GetVolumes(ctx)
+	if err != nil {
+		return []error{err}
+	}
+	volParams, err := Inst().S.GetVolumeParameters(ctx)
+	if err != nil {
+		return []error{err}
+	}
+
+	topology := getReplicaTopology()
+	for _, vol := range vols {
+		if err := validateVolumeReplicaPlacement(vol, volParams[vol.ID], topology); err != nil {
+			log.Errorf("Replica placement validation failed for volume [%s] of app [%s]: %v", vol.Name, ctx.App.Key, err)
+			placementErrors = append(placementErrors, err)
+		}
+	}
+	return placementErrors
+}
+
+func validateVolumeReplicaPlacement(vol *volume.Volume, params map[string]string, topology vpsutil.ReplicaTopology) error {
+	isPureVol, err := Inst().V.IsPureVolume(vol)
+	if err != nil {
+		return err
+	}
+	if isPureVol {
+		log.Debugf("Skipping replica placement validation for pure volume [%s]", vol.Name)
+		return nil
+	}
+
+	apiVol, err := Inst().V.InspectVolume(vol.ID)
+	if err != nil {
+		return err
+	}
+	replicaSets, err := Inst().V.GetReplicaSets(vol)
+	if err != nil {
+		return err
+	}
+	apiVol.ReplicaSets = replicaSets
+
+	if err = vpsutil.ValidateUniqueReplicaNodes(apiVol); err != nil {
+		return err
+	}
+	// replicas are spread across zones and racks on a best effort basis, and only when nothing else
+	// constrains their placement
+	if !vpsutil.IsReplicaPlacementConstrained(apiVol, params) {
+		for _, topologyKey := range []string{vpsutil.TopologyZoneKey, vpsutil.PxRackKey} {
+			if err = vpsutil.ValidateReplicaSpreadByTopology(apiVol, topology, topologyKey); err != nil {
+				log.Warnf("Replicas of volume [%s] are not spread by [%s]: %v", vol.Name, topologyKey, err)
+			}
+		}
+	}
+
+	if vpsName, ok := params[vpsutil.PlacementStrategyParam]; ok && vpsName != "" {
+		vps, err := talisman.Instance().GetVolumePlacementStrategy(vpsName)
+		if err != nil {
+			return fmt.Errorf("failed to get volume placement strategy [%s] of volume [%s]. Err: %v", vpsName, vol.Name, err)
+		}
+		if err = vpsutil.ValidateReplicaPlacementRules(apiVol, vps, topology); err != nil {
+			return err
+		}
+	}
+	log.Infof("Validated replica placement of volume [%s] with replica sets %v", vol.Name, replicaSets)
+	return nil
+}
+
+// getReplicaTopology builds the node and pool labels of storage nodes in the node registry
+func getReplicaTopology() vpsutil.ReplicaTopology {
+	topology := vpsutil.ReplicaTopology{
+		NodeLabels: make(map[string]map[string]string),
+		PoolLabels: make(map[string]map[string]string),
+	}
+	for _, n := range node.GetStorageNodes() {
+		labels := make(map[string]string)
+		for k, v := range n.NodeLabels {
+			labels[k] = v
+		}
+		// the zone and region of a node come from the portworx topology labels. They stand in for the
+		// kubernetes topology labels on nodes which do not carry those
+		if n.TopologyZone != "" {
+			labels[vpsutil.PxTopologyZoneKey] = n.TopologyZone
+			if _, ok := labels[vpsutil.TopologyZoneKey]; !ok {
+				labels[vpsutil.TopologyZoneKey] = n.TopologyZone
+			}
+		}
+		if n.TopologyRegion != "" {
+			labels[vpsutil.PxTopologyRegionKey] = n.TopologyRegion
+			if _, ok := labels[vpsutil.TopologyRegionKey]; !ok {
+				labels[vpsutil.TopologyRegionKey] = n.TopologyRegion
+			}
+		}
+		topology.NodeLabels[n.VolDriverNodeID] = labels
+		for _, pool := range n.Pools {
+			topology.PoolLabels[pool.Uuid] = pool.Labels
+		}
+	}
+	return topology
+}
+
 // ValidatePureSnapshotsSDK is the ginkgo spec for validating Pure direct access volume snapshots using API for a context
 func ValidatePureSnapshotsSDK(ctx *scheduler.Context, errChan ...*chan error) {
 	context("For validation of an app's volumes", func() {
@@ -1320,6 +1764,336 @@ func ValidateRestoredApplications(contexts []*scheduler.Context, volumeParameter
 	}
 }
 
+// BackupDataSnapshot holds the volume contents and the resources of applications captured before a backup,
+// to be compared with the restored applications
+type BackupDataSnapshot struct {
+	// Checksums of the files of each volume by namespace/pvc
+	Checksums map[string]map[string]string
+	// Resources of the applications converted to unstructured, keyed by restoreutils.ResourceKey
+	Resources map[string]map[string]interface{}
+	// Replicas of the workloads scaled down for the capture, keyed by restoreutils.ResourceKey
+	Replicas map[string]int32
+}
+
+// CaptureBackupDataSnapshot scales down the workloads of the given applications so they stop writing to their
+// volumes, then captures the checksums of the files of the volumes and the resources. The workloads stay scaled
+// down so the backup holds the captured data, and are scaled back up by ResumeBackupApplications.
+func CaptureBackupDataSnapshot(contexts []*scheduler.Context) (*BackupDataSnapshot, error) {
+	snapshot := &BackupDataSnapshot{
+		Checksums: make(map[string]map[string]string),
+		Resources: make(map[string]map[string]interface{}),
+		Replicas:  make(map[string]int32),
+	}
+	for _, ctx := range contexts {
+		namespaces := make(map[string]bool)
+		for _, spec := range ctx.App.SpecList {
+			if _, namespace, _ := backupResourceOf(spec); namespace != "" {
+				namespaces[namespace] = true
+			}
+		}
+		for namespace := range namespaces {
+			replicas, err := scaleDownWorkloads(namespace)
+			if err != nil {
+				return snapshot, err
+			}
+			for key, count := range replicas {
+				snapshot.Replicas[key] = count
+			}
+		}
+	}
+	for _, ctx := range contexts {
+		vols, err := Inst().S.GetVolumes(ctx)
+		if err != nil {
+			return snapshot, err
+		}
+		for _, vol := range vols {
+			checksums, err := getVolumeChecksums(vol.Name, vol.Namespace)
+			if err != nil {
+				return snapshot, err
+			}
+			snapshot.Checksums[vol.Namespace+"/"+vol.Name] = checksums
+		}
+		for _, spec := range ctx.App.SpecList {
+			kind, namespace, name := backupResourceOf(spec)
+			if kind == "" {
+				continue
+			}
+			resource, err := getBackupResource(kind, namespace, name)
+			if err != nil {
+				return snapshot, err
+			}
+			snapshot.Resources[restoreutils.ResourceKey(kind, namespace, name)] = resource
+		}
+	}
+	log.Infof("Captured the contents of %d volumes and %d resources", len(snapshot.Checksums), len(snapshot.Resources))
+	return snapshot, nil
+}
+
+// ResumeBackupApplications scales the workloads scaled down by CaptureBackupDataSnapshot back to their replicas
+func ResumeBackupApplications(snapshot *BackupDataSnapshot) error {
+	if snapshot == nil {
+		return nil
+	}
+	for key, replicas := range snapshot.Replicas {
+		parts := strings.SplitN(key, "/", 3)
+		if len(parts) != 3 {
+			return fmt.Errorf("invalid workload key [%s]", key)
+		}
+		if err := scaleWorkload(parts[0], parts[1], parts[2], replicas); err != nil {
+			return err
+		}
+	}
+	log.Infof("Scaled %d workloads back up", len(snapshot.Replicas))
+	return nil
+}
+
+// ValidateRestoredData compares the volume contents and the resources of the restored applications in the
+// current cluster with the snapshot captured before the backup, mapping their namespaces and storage classes
+// the way the restore does. Only the namespaces of the mapping are compared, unless it is empty. The restored
+// workloads are scaled down first, as the backed up ones were, so they do not write to their volumes.
+func ValidateRestoredData(snapshot *BackupDataSnapshot, namespaceMapping, storageClassMapping map[string]string) error {
+	restoredNamespace := func(namespace string) (string, bool) {
+		if len(namespaceMapping) == 0 {
+			return namespace, true
+		}
+		mapped, ok := namespaceMapping[namespace]
+		return mapped, ok
+	}
+
+	scaledDown := make(map[string]bool)
+	for key := range snapshot.Checksums {
+		namespace, ok := restoredNamespace(strings.SplitN(key, "/", 2)[0])
+		if !ok || scaledDown[namespace] {
+			continue
+		}
+		if _, err := scaleDownWorkloads(namespace); err != nil {
+			return err
+		}
+		scaledDown[namespace] = true
+	}
+
+	var diffs []restoreutils.Diff
+	for key, expected := range snapshot.Checksums {
+		parts := strings.SplitN(key, "/", 2)
+		namespace, ok := restoredNamespace(parts[0])
+		if !ok {
+			continue
+		}
+		var actual map[string]string
+		// the restored volumes may still be provisioning
+		_, err := task.DoRetryWithTimeout(func() (interface{}, bool, error) {
+			var err error
+			actual, err = getVolumeChecksums(parts[1], namespace)
+			return nil, err != nil, err
+		}, defaultTimeout, defaultRetryInterval)
+		if err != nil {
+			return fmt.Errorf("failed to get the contents of restored volume [%s/%s]. Err: %v", namespace, parts[1], err)
+		}
+		diffs = append(diffs, restoreutils.CompareChecksums(fmt.Sprintf("PersistentVolumeClaim/%s/%s", namespace, parts[1]), expected, actual)...)
+	}
+	for key, resource := range snapshot.Resources {
+		if _, ok := restoredNamespace((&unstructured.Unstructured{Object: resource}).GetNamespace()); !ok {
+			continue
+		}
+		expected := restoreutils.NormalizeResource(resource, namespaceMapping, storageClassMapping)
+		restored := &unstructured.Unstructured{Object: expected}
+		actual, err := getBackupResource(restored.GetKind(), restored.GetNamespace(), restored.GetName())
+		if err != nil {
+			return fmt.Errorf("failed to get restored resource of [%s]. Err: %v", key, err)
+		}
+		restoredKey := restoreutils.ResourceKey(restored.GetKind(), restored.GetNamespace(), restored.GetName())
+		diffs = append(diffs, restoreutils.CompareResources(restoredKey, expected, restoreutils.NormalizeResource(actual, nil, nil))...)
+	}
+
+	if len(diffs) > 0 {
+		var lines []string
+		for _, diff := range diffs {
+			lines = append(lines, diff.String())
+		}
+		sort.Strings(lines)
+		return fmt.Errorf("restored data differs from the backed up data:\n%s", strings.Join(lines, "\n"))
+	}
+	log.Infof("Restored data of %d volumes and %d resources matches the backed up data", len(snapshot.Checksums), len(snapshot.Resources))
+	return nil
+}
+
+// scaleDownWorkloads scales the deployments and statefulsets of the namespace to zero and waits for their pods
+// to be gone. It returns the replicas of the workloads it scaled down, keyed by restoreutils.ResourceKey.
+func scaleDownWorkloads(namespace string) (map[string]int32, error) {
+	replicas := make(map[string]int32)
+	deployments, err := k8sApps.ListDeployments(namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range deployments.Items {
+		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas == 0 {
+			continue
+		}
+		replicas[restoreutils.ResourceKey("Deployment", namespace, deployment.Name)] = *deployment.Spec.Replicas
+		if err := scaleWorkload("Deployment", namespace, deployment.Name, 0); err != nil {
+			return nil, err
+		}
+	}
+	statefulSets, err := k8sApps.ListStatefulSets(namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, statefulSet := range statefulSets.Items {
+		if statefulSet.Spec.Replicas == nil || *statefulSet.Spec.Replicas == 0 {
+			continue
+		}
+		replicas[restoreutils.ResourceKey("StatefulSet", namespace, statefulSet.Name)] = *statefulSet.Spec.Replicas
+		if err := scaleWorkload("StatefulSet", namespace, statefulSet.Name, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = task.DoRetryWithTimeout(func() (interface{}, bool, error) {
+		pods, err := k8sCore.GetPods(namespace, nil)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, pod := range pods.Items {
+			for _, v := range pod.Spec.Volumes {
+				if v.PersistentVolumeClaim != nil && !strings.HasPrefix(pod.Name, volumeChecksumPodPrefix) {
+					return nil, true, fmt.Errorf("pod [%s/%s] still uses volume [%s]", namespace, pod.Name, v.PersistentVolumeClaim.ClaimName)
+				}
+			}
+		}
+		return nil, false, nil
+	}, defaultTimeout, defaultRetryInterval)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Scaled down %d workloads of namespace [%s]", len(replicas), namespace)
+	return replicas, nil
+}
+
+// scaleWorkload sets the replicas of the given deployment or statefulset
+func scaleWorkload(kind, namespace, name string, replicas int32) error {
+	switch kind {
+	case "Deployment":
+		deployment, err := k8sApps.GetDeployment(name, namespace)
+		if err != nil {
+			return err
+		}
+		deployment.Spec.Replicas = &replicas
+		_, err = k8sApps.UpdateDeployment(deployment)
+		return err
+	case "StatefulSet":
+		statefulSet, err := k8sApps.GetStatefulSet(name, namespace)
+		if err != nil {
+			return err
+		}
+		statefulSet.Spec.Replicas = &replicas
+		_, err = k8sApps.UpdateStatefulSet(statefulSet)
+		return err
+	}
+	return fmt.Errorf("workloads of kind [%s] are not scaled", kind)
+}
+
+// getVolumeChecksums returns the checksums of the files of the given volume by path. The volume is mounted read
+// only by a short lived pod, so its applications are expected to be scaled down.
+func getVolumeChecksums(pvcName, namespace string) (map[string]string, error) {
+	pod, err := k8sCore.CreatePod(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: volumeChecksumPodPrefix,
+			Namespace:    namespace,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{{
+				Name:    "checksum",
+				Image:   "busybox",
+				Command: []string{"sleep", "infinity"},
+				VolumeMounts: []corev1.VolumeMount{{
+					Name:      "data",
+					MountPath: volumeChecksumMountPath,
+					ReadOnly:  true,
+				}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: pvcName,
+						ReadOnly:  true,
+					},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := k8sCore.DeletePod(pod.Name, namespace, true); err != nil {
+			log.Warnf("failed to delete pod [%s/%s]. Err: %v", namespace, pod.Name, err)
+		}
+	}()
+	if err := k8sCore.ValidatePod(pod, defaultTimeout, defaultRetryInterval); err != nil {
+		return nil, err
+	}
+	out, err := k8sCore.RunCommandInPod([]string{"sh", "-c", fmt.Sprintf("cd %s && %s", volumeChecksumMountPath, restoreutils.ChecksumCommand)},
+		pod.Name, "checksum", namespace)
+	if err != nil {
+		return nil, err
+	}
+	return restoreutils.ParseChecksums(out)
+}
+
+// backupResourceOf returns the kind, namespace and name of the app specs whose restored copy is compared
+// by ValidateRestoredData, or an empty kind for the others
+func backupResourceOf(spec interface{}) (string, string, string) {
+	switch obj := spec.(type) {
+	case *appsapi.Deployment:
+		return "Deployment", obj.Namespace, obj.Name
+	case *appsapi.StatefulSet:
+		return "StatefulSet", obj.Namespace, obj.Name
+	case *corev1.ConfigMap:
+		return "ConfigMap", obj.Namespace, obj.Name
+	case *corev1.Secret:
+		return "Secret", obj.Namespace, obj.Name
+	case *corev1.Service:
+		return "Service", obj.Namespace, obj.Name
+	case *corev1.PersistentVolumeClaim:
+		return "PersistentVolumeClaim", obj.Namespace, obj.Name
+	}
+	return "", "", ""
+}
+
+// getBackupResource returns the given resource of the current cluster converted to unstructured
+func getBackupResource(kind, namespace, name string) (map[string]interface{}, error) {
+	var obj interface{}
+	var err error
+	switch kind {
+	case "Deployment":
+		obj, err = k8sApps.GetDeployment(name, namespace)
+	case "StatefulSet":
+		obj, err = k8sApps.GetStatefulSet(name, namespace)
+	case "ConfigMap":
+		obj, err = k8sCore.GetConfigMap(name, namespace)
+	case "Secret":
+		obj, err = k8sCore.GetSecret(name, namespace)
+	case "Service":
+		obj, err = k8sCore.GetService(name, namespace)
+	case "PersistentVolumeClaim":
+		obj, err = k8sCore.GetPersistentVolumeClaim(name, namespace)
+	default:
+		return nil, fmt.Errorf("resources of kind [%s] are not compared", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resource, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	// typed objects fetched from the API server have no kind
+	resource["kind"] = kind
+	return resource, nil
+}
+
 func ValidateFastpathVolume(ctx *scheduler.Context, expectedStatus opsapi.FastpathStatus) error {
 	appVolumes, err := Inst().S.GetVolumes(ctx)
 	if err != nil {
@@ -1852,10 +2626,12 @@ func CollectSupport() {
 		nodes := node.GetWorkerNodes()
 		dash.VerifyFatal(len(nodes) > 0, true, "Worker nodes found ?")
 
+		var pxNodes []node.Node
 		for _, n := range nodes {
 			if !n.IsStorageDriverInstalled {
 				continue
 			}
+			pxNodes = append(pxNodes, n)
 			Step(fmt.Sprintf("save all useful logs on node %s", n.SchedulerNodeName), func() {
 				log.Infof("save all useful logs on node %s", n.SchedulerNodeName)
 
@@ -1875,24 +2651,37 @@ func CollectSupport() {
 
 				Inst().V.CollectDiags(n, r, volume.DiagOps{})
 
-				journalCmd := fmt.Sprintf("journalctl -l > %s/all_journal_%v.log", Inst().BundleLocation, time.Now().Format(time.RFC3339))
-				runCmd(journalCmd, n)
-
-				runCmd(fmt.Sprintf("journalctl -lu portworx* > %s/portworx.log", Inst().BundleLocation), n)
-
 				Inst().S.SaveSchedulerLogsToFile(n, Inst().BundleLocation)
+			})
+		}
 
-				runCmd(fmt.Sprintf("dmesg -T > %s/dmesg.log", Inst().BundleLocation), n)
-
-				runCmd(fmt.Sprintf("lsblk > %s/lsblk.log", Inst().BundleLocation), n)
-
-				runCmd(fmt.Sprintf("cat /proc/mounts > %s/mounts.log", Inst().BundleLocation), n)
-
+		Step("save journal, kernel, block device and mount logs on all nodes", func() {
+			cmds := []string{
+				fmt.Sprintf("journalctl -l > %s/all_journal_%v.log", Inst().BundleLocation, time.Now().Format(time.RFC3339)),
+				fmt.Sprintf("journalctl -lu portworx* > %s/portworx.log", Inst().BundleLocation),
+				fmt.Sprintf("dmesg -T > %s/dmesg.log", Inst().BundleLocation),
+				fmt.Sprintf("lsblk > %s/lsblk.log", Inst().BundleLocation),
+				fmt.Sprintf("cat /proc/mounts > %s/mounts.log", Inst().BundleLocation),
 				// this is a small tweak especially for providers like openshift, aws where oci-mon saves this file
 				// with root read permissions only but collect support bundle is a non-root user
-				runCmd(fmt.Sprintf("chmod 755 %s/oci.log", Inst().BundleLocation), n)
+				fmt.Sprintf("chmod 755 %s/oci.log", Inst().BundleLocation),
+			}
+			results, err := Inst().N.RunCommandOnNodes(pxNodes, strings.Join(cmds, "; "), node.RunCommandOpts{
+				ConnectionOpts: node.ConnectionOpts{
+					Timeout:         defaultCmdTimeout,
+					TimeBeforeRetry: defaultCmdRetryInterval,
+					Sudo:            true,
+				},
 			})
-		}
+			if err != nil && results == nil {
+				log.Warnf("failed to save logs on nodes. err: %v", err)
+			}
+			for _, result := range results {
+				if result.Err != nil {
+					log.Warnf("failed to save logs on node %s. err: %v", result.Node.Name, result.Err)
+				}
+			}
+		})
 	})
 }
 
@@ -1958,6 +2747,35 @@ func PerformSystemCheck() {
 	})
 }
 
+// RestoredContexts returns copies of the contexts of applications with their objects moved to the namespaces
+// they were restored or cloned to, so the scheduler driver can validate the restored applications
+func RestoredContexts(contexts []*scheduler.Context, namespaceMapping map[string]string) ([]*scheduler.Context, error) {
+	restoredContexts := make([]*scheduler.Context, 0)
+	for _, ctx := range contexts {
+		restoredCtx := ctx.DeepCopy()
+		restoredCtx.ScheduleOptions = ctx.ScheduleOptions
+		restoredCtx.SkipVolumeValidation = ctx.SkipVolumeValidation
+		restoredCtx.SkipClusterScopedObject = ctx.SkipClusterScopedObject
+		restoredCtx.ReadinessTimeout = ctx.ReadinessTimeout
+		for i, spec := range restoredCtx.App.SpecList {
+			if obj, ok := spec.(runtime.Object); ok {
+				restoredCtx.App.SpecList[i] = obj.DeepCopyObject()
+			}
+		}
+		// namespaces which are not restored elsewhere keep their objects
+		mapping := map[string]string{ctx.ScheduleOptions.Namespace: ctx.ScheduleOptions.Namespace}
+		for source, destination := range namespaceMapping {
+			mapping[source] = destination
+		}
+		restoredCtx.ScheduleOptions.Namespace = mapping[ctx.ScheduleOptions.Namespace]
+		if err := ChangeNamespaces([]*scheduler.Context{restoredCtx}, mapping); err != nil {
+			return nil, err
+		}
+		restoredContexts = append(restoredContexts, restoredCtx)
+	}
+	return restoredContexts, nil
+}
+
 // ChangeNamespaces updates the namespace in supplied in-memory contexts.
 // It does not apply changes on scheduler
 func ChangeNamespaces(contexts []*scheduler.Context,
@@ -2117,6 +2935,7 @@ func ValidateVolumeParametersGetErr(volParam map[string]map[string]string) error
 
 // AfterEachTest runs collect support bundle after each test when it fails
 func AfterEachTest(contexts []*scheduler.Context, ids ...int) {
+	ReplayReverts()
 	testStatus := "Pass"
 	ginkgoTestDescr := ginkgo.CurrentGinkgoTestDescription()
 	if ginkgoTestDescr.Failed {
@@ -2142,6 +2961,14 @@ func AfterEachTest(contexts []*scheduler.Context, ids ...int) {
 	}
 }
 
+// ReplayReverts reverts the faults a test or longevity event left injected, the latest first
+func ReplayReverts() {
+	for _, failure := range revertutils.Replay() {
+		log.Errorf("%v", failure)
+		dash.VerifySafely(failure, nil, fmt.Sprintf("revert [%s] injected in [%s]?", failure.Action.Description, failure.Action.Scope))
+	}
+}
+
 // SetClusterContext sets context to clusterConfigPath
 func SetClusterContext(clusterConfigPath string) error {
 	err := Inst().S.SetConfig(clusterConfigPath)
@@ -2153,87 +2980,403 @@ func SetClusterContext(clusterConfigPath string) error {
 		return fmt.Errorf("Failed to switch to context. RefreshNodeRegistry Error: [%v]", err)
 	}
 
-	err = Inst().V.RefreshDriverEndpoints()
-	if err != nil {
-		return fmt.Errorf("Failed to switch to context. RefreshDriverEndpoints Error: [%v]", err)
+	err = Inst().V.RefreshDriverEndpoints()
+	if err != nil {
+		return fmt.Errorf("Failed to switch to context. RefreshDriverEndpoints Error: [%v]", err)
+	}
+	return nil
+}
+
+// SetSourceKubeConfig sets current context to the kubeconfig passed as source to the torpedo test
+func SetSourceKubeConfig() error {
+	sourceClusterConfigPath, err := GetSourceClusterConfigPath()
+	if err != nil {
+		return err
+	}
+	SetClusterContext(sourceClusterConfigPath)
+	return nil
+}
+
+// SetDestinationKubeConfig sets current context to the kubeconfig passed as destination to the torpedo test
+func SetDestinationKubeConfig() {
+	destClusterConfigPath, err := GetDestinationClusterConfigPath()
+	expect(err).NotTo(haveOccurred())
+	SetClusterContext(destClusterConfigPath)
+}
+
+// ScheduleValidateClusterPair Schedule a clusterpair by creating a yaml file and validate it
+func ScheduleValidateClusterPair(ctx *scheduler.Context, skipStorage, resetConfig bool, clusterPairDir string, reverse bool) error {
+	var kubeConfigPath string
+	var err error
+	if reverse {
+		SetSourceKubeConfig()
+		// get the kubeconfig path to get the correct pairing info
+		kubeConfigPath, err = GetSourceClusterConfigPath()
+		if err != nil {
+			return err
+		}
+	} else {
+		SetDestinationKubeConfig()
+		// get the kubeconfig path to get the correct pairing info
+		kubeConfigPath, err = GetDestinationClusterConfigPath()
+		if err != nil {
+			return err
+		}
+	}
+
+	pairInfo, err := Inst().V.GetClusterPairingInfo(kubeConfigPath, "", IsEksCluster(), reverse)
+	if err != nil {
+		log.Errorf("Error writing to clusterpair.yml: %v", err)
+		return err
+	}
+
+	err = CreateClusterPairFile(pairInfo, skipStorage, resetConfig, clusterPairDir, kubeConfigPath)
+	if err != nil {
+		log.Errorf("Error creating cluster Spec: %v", err)
+		return err
+	}
+	err = Inst().S.RescanSpecs(Inst().SpecDir, Inst().V.String())
+	if err != nil {
+		log.Errorf("Unable to parse spec dir: %v", err)
+		return err
+	}
+
+	// Set the correct cluster context to apply the cluster pair spec
+	if reverse {
+		SetDestinationKubeConfig()
+	} else {
+		SetSourceKubeConfig()
+	}
+
+	err = Inst().S.AddTasks(ctx,
+		scheduler.ScheduleOptions{AppKeys: []string{clusterPairDir}})
+	if err != nil {
+		log.Errorf("Failed to schedule Cluster Pair Specs: %v", err)
+		return err
+	}
+
+	err = Inst().S.WaitForRunning(ctx, defaultTimeout, defaultRetryInterval)
+	if err != nil {
+		log.Errorf("Error waiting to get cluster pair in ready state: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// NewAsyncDRConfig returns the asynchronous DR of the namespace, whose cluster pairs are scheduled in the
+// context with ScheduleValidateClusterPair
+func NewAsyncDRConfig(ctx *scheduler.Context, namespace, migrationSchedule string) (asyncdr.DRConfig, error) {
+	sourceKubeConfigPath, err := GetSourceClusterConfigPath()
+	if err != nil {
+		return asyncdr.DRConfig{}, err
+	}
+	destinationKubeConfigPath, err := GetDestinationClusterConfigPath()
+	if err != nil {
+		return asyncdr.DRConfig{}, err
+	}
+	return asyncdr.DRConfig{
+		Namespace:                 namespace,
+		ClusterPair:               remotePairName,
+		MigrationSchedule:         migrationSchedule,
+		SourceKubeConfigPath:      sourceKubeConfigPath,
+		DestinationKubeConfigPath: destinationKubeConfigPath,
+		SetSourceKubeConfig:       SetSourceKubeConfig,
+		SetDestinationKubeConfig: func() error {
+			SetDestinationKubeConfig()
+			return nil
+		},
+		PairClusters: func(reverse bool) error {
+			return ScheduleValidateClusterPair(ctx, false, true, defaultClusterPairDir, reverse)
+		},
+		Timeout: migrationRetryTimeout,
+	}, nil
+}
+
+// ScheduleValidateSyncDRClusterPair pairs the source with the destination cluster of a stretched storage cluster
+// for sync DR. The pair is made without storage options as both clusters share the storage cluster.
+func ScheduleValidateSyncDRClusterPair(ctx *scheduler.Context, clusterPairDir string) error {
+	err := ScheduleValidateClusterPair(ctx, true, true, clusterPairDir, false)
+	if err != nil {
+		return err
+	}
+	pair, err := storkops.Instance().GetClusterPair(remotePairName, ctx.ScheduleOptions.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster pair %s in namespace %s. Err: %v", remotePairName, ctx.ScheduleOptions.Namespace, err)
+	}
+	return metrodr.ValidateSyncDRClusterPair(pair)
+}
+
+// GetMetroDRTopology returns the layout of the nodes of the stretched cluster across its cluster domains, from the
+// nodes of the volume driver in all the clusters sharing it, and validates the volume driver knows all the domains.
+// The nodes of the current cluster are the ones of the node registry.
+func GetMetroDRTopology() (*metrodr.Topology, error) {
+	domainLabel := os.Getenv(metrodr.EnvClusterDomainLabel)
+	if domainLabel == "" {
+		return nil, fmt.Errorf("node label of the cluster domains not provided as env var: %s", metrodr.EnvClusterDomainLabel)
+	}
+	driverNodes, err := Inst().V.GetDriverNodes()
+	if err != nil {
+		return nil, err
+	}
+	topology, err := metrodr.NewTopologyFromDriverNodes(driverNodes, domainLabel, os.Getenv(metrodr.EnvWitnessDomain))
+	if err != nil {
+		return nil, err
+	}
+	registered := func(nodes []node.Node) {
+		for i, n := range nodes {
+			if registeredNode, err := node.GetNodeByName(n.Name); err == nil {
+				nodes[i] = registeredNode
+			}
+		}
+	}
+	for _, nodes := range topology.Domains {
+		registered(nodes)
+	}
+	registered(topology.Witness)
+
+	states, err := Inst().V.GetClusterDomains()
+	if err != nil {
+		return nil, err
+	}
+	err = metrodr.ValidateDomainStates(states, topology.DomainNames(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range topology.Witness {
+		err = Inst().V.WaitDriverUpOnNode(n, Inst().DriverStartTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("witness node %s is not up. Err: %v", n.Name, err)
+		}
+	}
+	return topology, nil
+}
+
+// GetMetroDRProtectedNodes returns the names of the nodes running torpedo, stork or the control plane of the source
+// and the destination clusters. Taking one of them down with its cluster domain would take down the test or the
+// failover. It leaves the clients on the source cluster.
+func GetMetroDRProtectedNodes() ([]string, error) {
+	protected := make(map[string]bool)
+	collect := func() error {
+		nodes, err := k8sCore.GetNodes()
+		if err != nil {
+			return err
+		}
+		for _, n := range nodes.Items {
+			if k8sCore.IsNodeMaster(n) {
+				protected[n.Name] = true
+			}
+		}
+		pods, err := k8sCore.GetPods(pxNamespace, map[string]string{"name": "stork"})
+		if err != nil {
+			return err
+		}
+		for _, pod := range pods.Items {
+			if pod.Spec.NodeName != "" {
+				protected[pod.Spec.NodeName] = true
+			}
+		}
+		return nil
+	}
+
+	// torpedo runs in a pod named after its hostname when it runs in the cluster
+	if namespace, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		pod, err := k8sCore.GetPodByName(hostname, strings.TrimSpace(string(namespace)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the torpedo pod %s. Err: %v", hostname, err)
+		}
+		protected[pod.Spec.NodeName] = true
+	}
+	if err := collect(); err != nil {
+		return nil, err
+	}
+	SetDestinationKubeConfig()
+	err := collect()
+	if switchErr := SetSourceKubeConfig(); err == nil {
+		err = switchErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range protected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetMetroDRSourceDomain returns the cluster domain holding all the nodes of the source cluster, which the
+// applications fail over from through the sync DR cluster pair
+func GetMetroDRSourceDomain(topology *metrodr.Topology) (string, error) {
+	var sourceNodes []string
+	for _, n := range node.GetNodes() {
+		sourceNodes = append(sourceNodes, n.Name)
 	}
-	return nil
+	for _, domain := range topology.DomainNames() {
+		if topology.HoldsAll(domain, sourceNodes) {
+			return domain, nil
+		}
+	}
+	return "", fmt.Errorf("nodes of the source cluster are not in a single cluster domain of %v", topology.DomainNames())
 }
 
-// SetSourceKubeConfig sets current context to the kubeconfig passed as source to the torpedo test
-func SetSourceKubeConfig() error {
-	sourceClusterConfigPath, err := GetSourceClusterConfigPath()
+// StopClusterDomain powers off all the nodes of the cluster domain and deactivates the domain, so the applications
+// fail over to the surviving domains. Witness nodes are never in a domain which runs applications and stay up.
+func StopClusterDomain(topology *metrodr.Topology, domain string) error {
+	nodes, ok := topology.Domains[domain]
+	if !ok {
+		return fmt.Errorf("cluster domain %s not found in %v", domain, topology.DomainNames())
+	}
+	for _, n := range nodes {
+		log.Infof("Powering off node %s of cluster domain %s", n.Name, domain)
+		err := Inst().N.PowerOffVM(n)
+		if err != nil {
+			return fmt.Errorf("failed to power off node %s of cluster domain %s. Err: %v", n.Name, domain, err)
+		}
+	}
+	err := Inst().V.DeactivateClusterDomain(domain)
 	if err != nil {
 		return err
 	}
-	SetClusterContext(sourceClusterConfigPath)
-	return nil
+	return waitForClusterDomainStates(topology.SurvivingDomains(domain), []string{domain})
 }
 
-// SetDestinationKubeConfig sets current context to the kubeconfig passed as destination to the torpedo test
-func SetDestinationKubeConfig() {
-	destClusterConfigPath, err := GetDestinationClusterConfigPath()
-	expect(err).NotTo(haveOccurred())
-	SetClusterContext(destClusterConfigPath)
-}
-
-// ScheduleValidateClusterPair Schedule a clusterpair by creating a yaml file and validate it
-func ScheduleValidateClusterPair(ctx *scheduler.Context, skipStorage, resetConfig bool, clusterPairDir string, reverse bool) error {
-	var kubeConfigPath string
-	var err error
-	if reverse {
-		SetSourceKubeConfig()
-		// get the kubeconfig path to get the correct pairing info
-		kubeConfigPath, err = GetSourceClusterConfigPath()
+// StartClusterDomain powers on all the nodes of the cluster domain, waits for the volume driver to be up on them and
+// activates the domain. Only the nodes of the current cluster are checked with the scheduler.
+func StartClusterDomain(topology *metrodr.Topology, domain string) error {
+	nodes, ok := topology.Domains[domain]
+	if !ok {
+		return fmt.Errorf("cluster domain %s not found in %v", domain, topology.DomainNames())
+	}
+	for _, n := range nodes {
+		log.Infof("Powering on node %s of cluster domain %s", n.Name, domain)
+		err := Inst().N.PowerOnVM(n)
+		if err != nil {
+			return fmt.Errorf("failed to power on node %s of cluster domain %s. Err: %v", n.Name, domain, err)
+		}
+	}
+	for _, n := range nodes {
+		err := Inst().N.TestConnection(n, node.ConnectionOpts{
+			Timeout:         15 * time.Minute,
+			TimeBeforeRetry: 10 * time.Second,
+		})
 		if err != nil {
 			return err
 		}
-	} else {
-		SetDestinationKubeConfig()
-		// get the kubeconfig path to get the correct pairing info
-		kubeConfigPath, err = GetDestinationClusterConfigPath()
+		if _, err := node.GetNodeByName(n.Name); err == nil {
+			err = Inst().S.IsNodeReady(n)
+			if err != nil {
+				return err
+			}
+		}
+		err = Inst().V.WaitDriverUpOnNode(n, Inst().DriverStartTimeout)
 		if err != nil {
 			return err
 		}
 	}
-
-	pairInfo, err := Inst().V.GetClusterPairingInfo(kubeConfigPath, "", IsEksCluster(), reverse)
+	err := Inst().V.ActivateClusterDomain(domain)
 	if err != nil {
-		log.Errorf("Error writing to clusterpair.yml: %v", err)
 		return err
 	}
+	return waitForClusterDomainStates(topology.DomainNames(), nil)
+}
 
-	err = CreateClusterPairFile(pairInfo, skipStorage, resetConfig, clusterPairDir, kubeConfigPath)
+// waitForClusterDomainStates waits for the volume driver to report the active and the inactive cluster domains
+func waitForClusterDomainStates(active, inactive []string) error {
+	_, err := task.DoRetryWithTimeout(func() (interface{}, bool, error) {
+		states, err := Inst().V.GetClusterDomains()
+		if err != nil {
+			return nil, true, err
+		}
+		err = metrodr.ValidateDomainStates(states, active, inactive)
+		return nil, err != nil, err
+	}, defaultTimeout, defaultRetryInterval)
+	return err
+}
+
+// FailoverMetroDRApps activates the applications of the namespaces migrated through their sync DR cluster pairs on
+// the destination cluster once the cluster domain of the source cluster is down, and validates they run in the
+// surviving cluster domains. It leaves the clients on the destination cluster.
+func FailoverMetroDRApps(contexts []*scheduler.Context, topology *metrodr.Topology, failedDomain string) error {
+	destinationKubeConfigPath, err := GetDestinationClusterConfigPath()
 	if err != nil {
-		log.Errorf("Error creating cluster Spec: %v", err)
 		return err
 	}
-	err = Inst().S.RescanSpecs(Inst().SpecDir, Inst().V.String())
+	SetDestinationKubeConfig()
+	for _, namespace := range contextNamespaces(contexts) {
+		err = asyncdr.ActivateMigrations(namespace, destinationKubeConfigPath)
+		if err != nil {
+			return fmt.Errorf("failed to activate the applications of namespace %s on the destination cluster. Err: %v", namespace, err)
+		}
+	}
+	err = applicationbackup.ValidateRestoredApplications(Inst().S, contexts, defaultTimeout, defaultRetryInterval)
 	if err != nil {
-		log.Errorf("Unable to parse spec dir: %v", err)
 		return err
 	}
+	return ValidateAppsInClusterDomains(contexts, topology, topology.SurvivingDomains(failedDomain))
+}
 
-	// Set the correct cluster context to apply the cluster pair spec
-	if reverse {
-		SetDestinationKubeConfig()
-	} else {
-		SetSourceKubeConfig()
-	}
-
-	err = Inst().S.AddTasks(ctx,
-		scheduler.ScheduleOptions{AppKeys: []string{clusterPairDir}})
+// FailbackMetroDRApps deactivates the applications failed over to the destination cluster, so they run again on the
+// source cluster once its cluster domain is back. It leaves the clients on the source cluster.
+func FailbackMetroDRApps(contexts []*scheduler.Context) error {
+	destinationKubeConfigPath, err := GetDestinationClusterConfigPath()
 	if err != nil {
-		log.Errorf("Failed to schedule Cluster Pair Specs: %v", err)
 		return err
 	}
+	SetDestinationKubeConfig()
+	for _, namespace := range contextNamespaces(contexts) {
+		err = asyncdr.DeactivateMigrations(namespace, destinationKubeConfigPath)
+		if err != nil {
+			break
+		}
+	}
+	if switchErr := SetSourceKubeConfig(); err == nil {
+		err = switchErr
+	}
+	return err
+}
 
-	err = Inst().S.WaitForRunning(ctx, defaultTimeout, defaultRetryInterval)
+// MigrateSyncDRResources migrates the resources of the namespace of the context through its sync DR cluster pair,
+// without starting the applications on the destination cluster
+func MigrateSyncDRResources(ctx *scheduler.Context, migrationName string) error {
+	mig, err := storkops.Instance().CreateMigration(metrodr.NewSyncDRMigration(migrationName, ctx.ScheduleOptions.Namespace, remotePairName))
 	if err != nil {
-		log.Errorf("Error waiting to get cluster pair in ready state: %v", err)
-		return err
+		return fmt.Errorf("failed to create migration %s in namespace %s. Err: %v", migrationName, ctx.ScheduleOptions.Namespace, err)
+	}
+	return asyncdr.WaitForMigration([]*storkapi.Migration{mig})
+}
+
+// contextNamespaces returns the sorted namespaces of the contexts
+func contextNamespaces(contexts []*scheduler.Context) []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, ctx := range contexts {
+		if !seen[ctx.ScheduleOptions.Namespace] {
+			seen[ctx.ScheduleOptions.Namespace] = true
+			namespaces = append(namespaces, ctx.ScheduleOptions.Namespace)
+		}
 	}
+	sort.Strings(namespaces)
+	return namespaces
+}
 
+// ValidateAppsInClusterDomains returns an error if any of the applications runs on nodes out of the given domains
+func ValidateAppsInClusterDomains(contexts []*scheduler.Context, topology *metrodr.Topology, domains []string) error {
+	for _, ctx := range contexts {
+		appNodes, err := Inst().S.GetNodesForApp(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get the nodes of app %s. Err: %v", ctx.App.Key, err)
+		}
+		err = topology.ValidateAppNodes(appNodes, domains)
+		if err != nil {
+			return fmt.Errorf("app %s: %v", ctx.App.Key, err)
+		}
+	}
 	return nil
 }
 
@@ -2944,6 +4087,8 @@ func CreateBackupLocation(provider, name, uid, credName, credUID, bucketName, or
 		err = CreateS3BackupLocation(name, uid, credName, credUID, bucketName, orgID, encryptionKey)
 	case drivers.ProviderAzure:
 		err = CreateAzureBackupLocation(name, uid, credName, CloudCredUID, bucketName, orgID)
+	case drivers.ProviderNfs:
+		err = CreateNFSBackupLocation(name, uid, bucketName, orgID, encryptionKey)
 	}
 	return err
 }
@@ -3040,6 +4185,9 @@ func CreateCloudCredential(provider, credName string, uid, orgID string, ctx con
 				},
 			},
 		}
+	case drivers.ProviderNfs:
+		log.Infof("NFS backup locations do not need a cloud credential, skipping the creation of [%s]", credName)
+		return nil
 	default:
 		return fmt.Errorf("provider [%s] not supported for creating cloud credential", provider)
 	}
@@ -3161,16 +4309,143 @@ func CreateAzureBackupLocation(name string, uid string, cloudCred string, cloudC
 	return nil
 }
 
+// CreateNFSBackupLocation creates a backup location storing the backups under the given sub path of the NFS
+// export configured in the environment. NFS backup locations have no cloud credential.
+func CreateNFSBackupLocation(name string, uid string, subPath string, orgID string, encryptionKey string) error {
+	backupDriver := Inst().Backup
+	config, err := objectstore.GetNFSConfig()
+	if err != nil {
+		return err
+	}
+	bLocationCreateReq := &api.BackupLocationCreateRequest{
+		CreateMetadata: &api.CreateMetadata{
+			Name:  name,
+			OrgId: orgID,
+			Uid:   uid,
+		},
+		BackupLocation: &api.BackupLocationInfo{
+			Path:          config.Path,
+			EncryptionKey: encryptionKey,
+			Type:          api.BackupLocationInfo_NFS,
+			Config: &api.BackupLocationInfo_NfsConfig{
+				NfsConfig: &api.NFSConfig{
+					ServerAddr:  config.ServerAddr,
+					SubPath:     subPath,
+					MountOption: config.MountOption,
+				},
+			},
+		},
+	}
+	ctx, err := backup.GetAdminCtxFromSecret()
+	if err != nil {
+		return err
+	}
+	_, err = backupDriver.CreateBackupLocation(ctx, bLocationCreateReq)
+	if err != nil {
+		return fmt.Errorf("failed to create NFS backup location [%s]. Err: %v", name, err)
+	}
+	return nil
+}
+
+// GetNFSBackupFiles returns the files of the given backup stored under the sub path of the NFS export, read
+// through a helper pod mounting the export
+func GetNFSBackupFiles(subPath string, backupName string, orgID string) ([]string, error) {
+	ctx, err := backup.GetAdminCtxFromSecret()
+	if err != nil {
+		return nil, err
+	}
+	backupUID, err := Inst().Backup.GetBackupUID(ctx, backupName, orgID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := Inst().Backup.InspectBackup(ctx, &api.BackupInspectRequest{
+		Name:  backupName,
+		Uid:   backupUID,
+		OrgId: orgID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	backupPath := strings.Trim(resp.GetBackup().GetBackupPath(), "/")
+	if backupPath == "" {
+		return nil, fmt.Errorf("backup [%s] has no backup path", backupName)
+	}
+	d, err := objectstore.GetDriver(objectstore.NFSDriverName)
+	if err != nil {
+		return nil, err
+	}
+	files, err := d.ListFilesInBucket(subPath)
+	if err != nil {
+		return nil, err
+	}
+	var backupFiles []string
+	for _, file := range files {
+		if strings.HasPrefix(file, backupPath+"/") {
+			backupFiles = append(backupFiles, file)
+		}
+	}
+	return backupFiles, nil
+}
+
+// DeployNFSServer deploys the in-cluster NFS server of the nfs-server spec and points the NFS backup locations
+// and the nfs objectstore driver at its export, unless an NFS server is already configured in the environment.
+// The server is exposed through a load balancer, so that the backups it holds can be restored on the destination
+// cluster. Clusters without load balancers need an external NFS server configured with NFS_SERVER_ADDR.
+// It returns the context of the server, nil if it was not deployed.
+func DeployNFSServer(taskName string) (*scheduler.Context, error) {
+	if os.Getenv(objectstore.EnvNFSServerAddr) != "" {
+		log.Infof("Using the NFS server [%s] configured in the environment", os.Getenv(objectstore.EnvNFSServerAddr))
+		return nil, nil
+	}
+	contexts, err := Inst().S.Schedule(taskName, scheduler.ScheduleOptions{AppKeys: []string{nfsServerAppKey}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule [%s]. Err: %v", nfsServerAppKey, err)
+	}
+	ctx := contexts[0]
+	if err := Inst().S.WaitForRunning(ctx, defaultTimeout, defaultRetryInterval); err != nil {
+		return ctx, fmt.Errorf("failed to wait for [%s] to run. Err: %v", nfsServerAppKey, err)
+	}
+	namespace := GetAppNamespace(ctx, taskName)
+	// the export is mounted by the kubelets of both clusters, which can neither resolve the name of the service
+	// nor reach its cluster IP from the destination cluster
+	addr, err := task.DoRetryWithTimeout(func() (interface{}, bool, error) {
+		svc, err := k8sCore.GetService(nfsServerAppKey, namespace)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				return ingress.IP, false, nil
+			}
+			if ingress.Hostname != "" {
+				return ingress.Hostname, false, nil
+			}
+		}
+		return nil, true, fmt.Errorf("service [%s/%s] has no load balancer address yet", namespace, nfsServerAppKey)
+	}, defaultTimeout, defaultRetryInterval)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to get the load balancer address of the NFS server, set %s to an NFS server "+
+			"reachable from the source and destination clusters. Err: %v", objectstore.EnvNFSServerAddr, err)
+	}
+	log.Infof("Deployed NFS server [%s/%s] at [%s]", namespace, nfsServerAppKey, addr)
+	os.Setenv(objectstore.EnvNFSServerAddr, addr.(string))
+	os.Setenv(objectstore.EnvNFSPath, "/")
+	if os.Getenv(objectstore.EnvNFSMountOption) == "" {
+		os.Setenv(objectstore.EnvNFSMountOption, "nfsvers=4.1")
+	}
+	return ctx, nil
+}
+
 // GetProvider validates and return object store provider
 func GetProvider() string {
 	provider, ok := os.LookupEnv("OBJECT_STORE_PROVIDER")
-	expect(ok).To(beTrue(), fmt.Sprintf("No environment variable 'PROVIDER' supplied. Valid values are: %s, %s, %s",
-		drivers.ProviderAws, drivers.ProviderAzure, drivers.ProviderGke))
+	expect(ok).To(beTrue(), fmt.Sprintf("No environment variable 'PROVIDER' supplied. Valid values are: %s, %s, %s, %s",
+		drivers.ProviderAws, drivers.ProviderAzure, drivers.ProviderGke, drivers.ProviderNfs))
 	switch provider {
-	case drivers.ProviderAws, drivers.ProviderAzure, drivers.ProviderGke:
+	case drivers.ProviderAws, drivers.ProviderAzure, drivers.ProviderGke, drivers.ProviderNfs:
 	default:
-		fail(fmt.Sprintf("Valid values for 'PROVIDER' environment variables are: %s, %s, %s",
-			drivers.ProviderAws, drivers.ProviderAzure, drivers.ProviderGke))
+		fail(fmt.Sprintf("Valid values for 'PROVIDER' environment variables are: %s, %s, %s, %s",
+			drivers.ProviderAws, drivers.ProviderAzure, drivers.ProviderGke, drivers.ProviderNfs))
 	}
 	return provider
 }
@@ -3404,6 +4679,28 @@ func SetScheduledBackupInterval(interval time.Duration, triggerType string) {
 	}
 }
 
+// GetCloudsnapBackupLocation returns a backup location with the S3 credentials from the environment,
+// used to inspect the cloudsnaps uploaded by the volume driver. Any S3 compatible objectstore such as
+// a local minio works by pointing S3_ENDPOINT to it. Returns nil if S3_ENDPOINT is not set.
+func GetCloudsnapBackupLocation() *storkapi.BackupLocation {
+	if os.Getenv("S3_ENDPOINT") == "" {
+		return nil
+	}
+	id, secret, endpoint, s3Region, disableSSLBool := s3utils.GetAWSDetailsFromEnv()
+	return &storkapi.BackupLocation{
+		Location: storkapi.BackupLocationItem{
+			Type: storkapi.BackupLocationS3,
+			S3Config: &storkapi.S3Config{
+				Endpoint:        endpoint,
+				AccessKeyID:     id,
+				SecretAccessKey: secret,
+				Region:          s3Region,
+				DisableSSL:      disableSSLBool,
+			},
+		},
+	}
+}
+
 // DeleteS3Bucket deletes bucket in S3
 func DeleteS3Bucket(bucketName string) {
 	id, secret, endpoint, s3Region, disableSSLBool := s3utils.GetAWSDetailsFromEnv()
@@ -3457,16 +4754,26 @@ func DeleteAzureBucket(bucketName string) {
 		fmt.Sprintf("Failed to delete container. Error: [%v]", err))
 }
 
+// bucketDrivers maps the cloud providers to the objectstore drivers managing their buckets
+var bucketDrivers = map[string]string{
+	drivers.ProviderAws:   objectstore.S3DriverName,
+	drivers.ProviderAzure: objectstore.AzureDriverName,
+	drivers.ProviderGke:   objectstore.GCSDriverName,
+	drivers.ProviderNfs:   objectstore.NFSDriverName,
+}
+
 // DeleteBucket deletes bucket from the cloud
 func DeleteBucket(provider string, bucketName string) {
 	Step(fmt.Sprintf("Delete bucket [%s]", bucketName), func() {
-		switch provider {
-		// TODO(stgleb): PTX-2359 Add DeleteAzureBucket
-		case drivers.ProviderAws:
-			DeleteS3Bucket(bucketName)
-		case drivers.ProviderAzure:
-			DeleteAzureBucket(bucketName)
+		driverName, ok := bucketDrivers[provider]
+		if !ok {
+			return
 		}
+		d, err := objectstore.GetDriver(driverName)
+		expect(err).NotTo(haveOccurred())
+		err = d.DeleteBucket(bucketName)
+		expect(err).NotTo(haveOccurred(),
+			fmt.Sprintf("Failed to delete bucket [%v] with objectstore driver [%s]. Error: [%v]", bucketName, driverName, err))
 	})
 }
 
@@ -3737,12 +5044,15 @@ func ValidateReplFactorUpdate(v *volume.Volume, expaectedReplFactor int64) error
 // CreateBucket creates bucket on the appropriate cloud platform
 func CreateBucket(provider string, bucketName string) {
 	Step(fmt.Sprintf("Create bucket [%s]", bucketName), func() {
-		switch provider {
-		case drivers.ProviderAws:
-			CreateS3Bucket(bucketName, false, 0, "")
-		case drivers.ProviderAzure:
-			CreateAzureBucket(bucketName)
+		driverName, ok := bucketDrivers[provider]
+		if !ok {
+			return
 		}
+		d, err := objectstore.GetDriver(driverName)
+		expect(err).NotTo(haveOccurred())
+		err = d.CreateBucket(bucketName, objectstore.BucketOpts{})
+		expect(err).NotTo(haveOccurred(),
+			fmt.Sprintf("Failed to create bucket [%v] with objectstore driver [%s]. Error: [%v]", bucketName, driverName, err))
 	})
 }
 
@@ -3910,13 +5220,15 @@ type Torpedo struct {
 	JobName                             string
 	JobType                             string
 	PortworxPodRestartCheck             bool
+	ValidateReplicaPlacement            bool
+	ValidateAlerts                      bool
 }
 
 // ParseFlags parses command line flags
 func ParseFlags() {
 	var err error
 
-	var s, m, n, v, backupDriverName, specDir, logLoc, logLevel, appListCSV, secureAppsCSV, repl1AppsCSV, provisionerName, configMapName string
+	var s, m, n, v, backupDriverName, objectStoreDriverName, specDir, logLoc, logLevel, appListCSV, secureAppsCSV, repl1AppsCSV, provisionerName, configMapName string
 	var schedulerDriver scheduler.Driver
 	var volumeDriver volume.Driver
 	var nodeDriver node.Driver
@@ -3939,6 +5251,8 @@ func ParseFlags() {
 	var hyperConverged bool
 	var enableDash bool
 	var pxPodRestartCheck bool
+	var replicaPlacementCheck bool
+	var alertsCheck bool
 
 	// TODO: We rely on the customAppConfig map to be passed into k8s.go and stored there.
 	// We modify this map from the tests and expect that the next RescanSpecs will pick up the new custom configs.
@@ -3968,6 +5282,7 @@ func ParseFlags() {
 	flag.StringVar(&torpedoJobName, torpedoJobNameFlag, defaultTorpedoJob, "Name of the torpedo job")
 	flag.StringVar(&torpedoJobType, torpedoJobTypeFlag, defaultTorpedoJobType, "Type of torpedo job")
 	flag.StringVar(&backupDriverName, backupCliFlag, "", "Name of the backup driver to use")
+	flag.StringVar(&objectStoreDriverName, objectStoreCliFlag, "", "Name of the objectstore driver to use: s3, azure, gcs or file")
 	flag.StringVar(&specDir, specDirCliFlag, defaultSpecsRoot, "Root directory containing the application spec files")
 	flag.StringVar(&logLoc, logLocationCliFlag, defaultLogLocation,
 		"Path to save logs/artifacts upon failure. Default: /mnt/torpedo_support_dir")
@@ -4024,6 +5339,8 @@ func ParseFlags() {
 	flag.StringVar(&testProduct, testProductFlag, "PxEnp", "Portworx product under test")
 	flag.StringVar(&pxRuntimeOpts, "px-runtime-opts", "", "comma separated list of run time options for cluster update")
 	flag.BoolVar(&pxPodRestartCheck, failOnPxPodRestartCount, false, "Set it true for px pods restart check during test")
+	flag.BoolVar(&replicaPlacementCheck, validateReplicaPlacementFlag, false, "Set it true to validate volume replica placement against topology and VPS rules during app validation")
+	flag.BoolVar(&alertsCheck, validateAlertsFlag, false, "Set it true to validate the alerts raised while longevity triggers run against the ones they declare")
 	flag.Parse()
 
 	log.SetLoglevel(logLevel)
@@ -4107,6 +5424,12 @@ func ParseFlags() {
 				log.Infof("Backup driver found %v", backupDriver)
 			}
 		}
+		if objectStoreDriverName != "" {
+			if err = objectstore.Select(objectStoreDriverName); err != nil {
+				log.Fatalf("cannot find objectstore driver for %s. Err: %v\n", objectStoreDriverName, err)
+			}
+			log.Infof("Objectstore driver name %s", objectStoreDriverName)
+		}
 		dash = aetosutil.Get()
 		if enableDash && !isDashboardReachable() {
 			enableDash = false
@@ -4229,6 +5552,8 @@ func ParseFlags() {
 				JobName:                             torpedoJobName,
 				JobType:                             torpedoJobType,
 				PortworxPodRestartCheck:             pxPodRestartCheck,
+				ValidateReplicaPlacement:            replicaPlacementCheck,
+				ValidateAlerts:                      alertsCheck,
 			}
 		})
 	}
@@ -4673,6 +5998,43 @@ func RebootNodeAndWait(n node.Node) error {
 
 }
 
+// ValidateTopologyChange refreshes the node registry and the volume driver endpoints and validates the
+// topology changed from the given inventory by exactly the expected events. Empty fields of an expected
+// event match any value, e.g. a PoolResized event without From and To matches any resize of the pool.
+func ValidateTopologyChange(before node.Inventory, expected ...node.TopologyEvent) error {
+	if err := Inst().S.RefreshNodeRegistry(); err != nil {
+		return err
+	}
+	if err := Inst().V.RefreshDriverEndpoints(); err != nil {
+		return err
+	}
+	actual := node.DiffInventories(before, node.TakeInventory())
+	log.Infof("Topology changes: %v", actual)
+
+	matches := func(e, a node.TopologyEvent) bool {
+		return e.Type == a.Type && (e.Node == "" || e.Node == a.Node) && (e.Pool == "" || e.Pool == a.Pool) &&
+			(e.From == "" || e.From == a.From) && (e.To == "" || e.To == a.To)
+	}
+	unmatched := append([]node.TopologyEvent(nil), actual...)
+	for _, e := range expected {
+		found := false
+		for i, a := range unmatched {
+			if matches(e, a) {
+				unmatched = append(unmatched[:i], unmatched[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("expected topology change [%s] did not happen, changes: %v", e, actual)
+		}
+	}
+	if len(unmatched) > 0 {
+		return fmt.Errorf("unexpected topology changes: %v", unmatched)
+	}
+	return nil
+}
+
 // GetNodeWithGivenPoolID returns node having pool id
 func GetNodeWithGivenPoolID(poolID string) (*node.Node, error) {
 	if err := Inst().V.RefreshDriverEndpoints(); err != nil {
@@ -4961,6 +6323,7 @@ func GetCloudDriveDeviceSpecs() ([]string, error) {
 func StartTorpedoTest(testName, testDescription string, tags map[string]string, testRepoID int) {
 	TestLogger = CreateLogger(fmt.Sprintf("%s.log", testName))
 	log.SetTorpedoFileOutput(TestLogger)
+	revertutils.BeginScope(testName)
 	if tags == nil {
 		tags = make(map[string]string, 0)
 	}
@@ -5010,6 +6373,7 @@ func EnableAutoFSTrim() {
 
 // EndTorpedoTest ends the logging for torpedo test
 func EndTorpedoTest() {
+	ReplayReverts()
 	CloseLogger(TestLogger)
 	dash.TestCaseEnd()
 }
@@ -5615,6 +6979,132 @@ func WaitForPoolOffline(n node.Node) error {
 	return err
 }
 
+// WaitForPoolStatus waits till the given pool is online, or till it leaves the online status when online is false
+func WaitForPoolStatus(n node.Node, poolUUID string, online bool, timeout time.Duration) error {
+	t := func() (interface{}, bool, error) {
+		poolsStatus, err := Inst().V.GetNodePoolsStatus(n)
+		if err != nil {
+			return nil, true, err
+		}
+		status, ok := poolsStatus[poolUUID]
+		if !ok {
+			return nil, true, fmt.Errorf("pool [%s] not found on node [%s]", poolUUID, n.Name)
+		}
+		if (status == "Online") != online {
+			return nil, true, fmt.Errorf("pool [%s] on node [%s] has status [%s], expected online: %v", poolUUID, n.Name, status, online)
+		}
+		return nil, false, nil
+	}
+	_, err := task.DoRetryWithTimeout(t, timeout, 30*time.Second)
+	return err
+}
+
+// GetPoolDrive returns a random storage node with one of its pools and a drive of that pool
+func GetPoolDrive() (node.Node, string, string, error) {
+	stNodes := node.GetStorageNodes()
+	rand.Shuffle(len(stNodes), func(i, j int) { stNodes[i], stNodes[j] = stNodes[j], stNodes[i] })
+	for _, n := range stNodes {
+		poolDrives, err := Inst().V.GetPoolDrives(&n)
+		if err != nil {
+			return node.Node{}, "", "", err
+		}
+		for _, pool := range n.StoragePools {
+			drives := poolDrives[fmt.Sprintf("%d", pool.ID)]
+			if len(drives) > 0 {
+				return n, pool.Uuid, drives[0], nil
+			}
+		}
+	}
+	return node.Node{}, "", "", fmt.Errorf("no storage node with pool drives found")
+}
+
+// GetFaultablePoolDrive returns a random storage node with a pool and a pool or journal drive of that node disk
+// faults can be injected on. A fault on the journal drive is reported against the first pool of the node.
+func GetFaultablePoolDrive() (node.Node, string, string, error) {
+	stNodes := node.GetStorageNodes()
+	rand.Shuffle(len(stNodes), func(i, j int) { stNodes[i], stNodes[j] = stNodes[j], stNodes[i] })
+	opts := node.ConnectionOpts{Timeout: defaultTimeout, TimeBeforeRetry: defaultRetryInterval}
+	for _, n := range stNodes {
+		poolDrives, err := Inst().V.GetPoolDrives(&n)
+		if err != nil {
+			return node.Node{}, "", "", err
+		}
+		journal := getJournalDrive(n)
+		for i, pool := range n.StoragePools {
+			drives := poolDrives[fmt.Sprintf("%d", pool.ID)]
+			if i == 0 && journal != "" {
+				drives = append(drives, journal)
+			}
+			for _, drive := range drives {
+				ok, err := Inst().N.CanInjectDiskFault(n, drive, opts)
+				if err != nil {
+					return node.Node{}, "", "", err
+				}
+				if ok {
+					return n, pool.Uuid, drive, nil
+				}
+			}
+		}
+	}
+	return node.Node{}, "", "", fmt.Errorf("no storage node with a device-mapper linear pool or journal drive found")
+}
+
+// AddFaultableDrive wraps an unused raw drive of a random storage node with a device-mapper linear device
+// and adds the wrapped device to Portworx, so disk faults can be injected on it
+func AddFaultableDrive() (node.Node, string, error) {
+	stNodes := node.GetStorageNodes()
+	rand.Shuffle(len(stNodes), func(i, j int) { stNodes[i], stNodes[j] = stNodes[j], stNodes[i] })
+	systemOpts := node.SystemctlOpts{
+		ConnectionOpts: node.ConnectionOpts{
+			Timeout:         defaultTimeout,
+			TimeBeforeRetry: defaultRetryInterval,
+		},
+		Action: "start",
+	}
+	for _, n := range stNodes {
+		if isCloudDrive, err := IsCloudDriveInitialised(n); err != nil || isCloudDrive {
+			continue
+		}
+		blockDrives, err := Inst().N.GetBlockDrives(n, systemOpts)
+		if err != nil {
+			return node.Node{}, "", err
+		}
+		for _, drv := range blockDrives {
+			if strings.Contains(drv.Path, "pxd") || drv.MountPoint != "" || drv.FSType != "" || drv.Type != "disk" {
+				continue
+			}
+			wrapped, err := Inst().N.WrapDrive(n, drv.Path, systemOpts.ConnectionOpts)
+			if err != nil {
+				return node.Node{}, "", err
+			}
+			if err = Inst().V.AddBlockDrives(&n, []string{wrapped}); err != nil {
+				return node.Node{}, "", err
+			}
+			return n, wrapped, nil
+		}
+	}
+	return node.Node{}, "", fmt.Errorf("no storage node with an unused raw drive found")
+}
+
+// getJournalDrive returns the journal drive of the given node from the StorageCluster spec. Node
+// specs are matched by node name only, and an empty string is returned for "auto" journals.
+func getJournalDrive(n node.Node) string {
+	stc, err := Inst().V.GetDriver()
+	if err != nil {
+		return ""
+	}
+	storage := stc.Spec.Storage
+	for _, nodeSpec := range stc.Spec.Nodes {
+		if nodeSpec.Selector.NodeName == n.Name && nodeSpec.Storage != nil {
+			storage = nodeSpec.Storage
+		}
+	}
+	if storage == nil || storage.JournalDevice == nil || !strings.HasPrefix(*storage.JournalDevice, "/dev/") {
+		return ""
+	}
+	return *storage.JournalDevice
+}
+
 func GetPoolIDFromPoolUUID(poolUuid string) (int32, error) {
 	nodesPresent := node.GetStorageNodes()
 	for _, each := range nodesPresent {
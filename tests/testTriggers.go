@@ -15,9 +15,11 @@ import (
 	"time"
 
 	apapi "github.com/libopenstorage/autopilot-api/pkg/apis/autopilot/v1alpha1"
+	"github.com/portworx/torpedo/pkg/alertutils"
 	"github.com/portworx/torpedo/pkg/applicationbackup"
 	"github.com/portworx/torpedo/pkg/aututils"
 	"github.com/portworx/torpedo/pkg/log"
+	"github.com/portworx/torpedo/pkg/revertutils"
 	"github.com/portworx/torpedo/pkg/units"
 	"gopkg.in/natefinch/lumberjack.v2"
 
@@ -32,6 +34,7 @@ import (
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/task"
 
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	storage "github.com/portworx/sched-ops/k8s/storage"
@@ -108,6 +111,9 @@ const (
 	pxVersionError = "ERROR GETTING PX VERSION"
 )
 
+// storkAdminNamespace is the default admin namespace of stork, the only namespace ApplicationClones are created in
+const storkAdminNamespace = "kube-system"
+
 var longevityLogger *lumberjack.Logger
 
 // EmailRecipients list of email IDs to send email to
@@ -266,6 +272,38 @@ func GenerateUUID() string {
 	return strings.TrimSpace(string(uuidbyte))
 }
 
+// triggerAlertExpectations declares the alerts each trigger is expected to raise. Any other alarm raised
+// while the trigger runs is reported as unexpected when alert validation is enabled.
+var triggerAlertExpectations = map[string][]alertutils.Expectation{
+	HAIncrease:     {alertutils.VolumeHAUpdate},
+	HADecrease:     {alertutils.VolumeHAUpdate},
+	RebootNode:     {alertutils.NodeDown},
+	CrashNode:      {alertutils.NodeDown},
+	PoolResizeDisk: {alertutils.PoolExpand},
+}
+
+// validateTriggerAlerts reports the missing and unexpected alerts of the trigger in the event outcome
+func validateTriggerAlerts(event *EventRecord, trigger string) {
+	validateAlerts(event, trigger, triggerAlertExpectations[trigger])
+}
+
+// validateAlerts reports the alerts missing from the given expectations, and the alarms none of them
+// accounts for, in the event outcome
+func validateAlerts(event *EventRecord, trigger string, expected []alertutils.Expectation) {
+	if !Inst().ValidateAlerts {
+		return
+	}
+	startTime, err := time.Parse(time.RFC1123, event.Start)
+	if err != nil {
+		UpdateOutcome(event, err)
+		return
+	}
+	log.InfoD("Validating alerts raised by %s since %s", trigger, event.Start)
+	for _, err := range GetAlertErrors(startTime, time.Now(), expected) {
+		UpdateOutcome(event, err)
+	}
+}
+
 // UpdateOutcome updates outcome based on error
 func UpdateOutcome(event *EventRecord, err error) {
 
@@ -383,10 +421,35 @@ const (
 	KVDBFailover = "kvdbFailover"
 	// ValidateDeviceMapper validate device mapper cleanup
 	ValidateDeviceMapper = "validateDeviceMapper"
+	// ReplicaPlacement validates volume replica placement against topology and VPS rules
+	ReplicaPlacement = "replicaPlacement"
+
+	// EncryptionKeyRotation rotates the encryption keys of secure volumes and validates the apps still read their data
+	EncryptionKeyRotation = "encryptionKeyRotation"
+
+	// DiskFault injects device-mapper faults on a pool drive and validates the pool and volume state transitions
+	DiskFault = "diskFault"
+
+	// CPUPressure burns the CPU of a storage node and validates Portworx and kvdb stay healthy
+	CPUPressure = "cpuPressure"
+
+	// MemoryPressure allocates the memory of a storage node and validates Portworx and kvdb recover
+	MemoryPressure = "memoryPressure"
+
+	// DiskFillPressure fills /var/lib/osd on a storage node and validates Portworx and kvdb recover
+	DiskFillPressure = "diskFillPressure"
+
+	// ClockSkew shifts the clock of a storage node and validates Portworx and kvdb recover
+	ClockSkew = "clockSkew"
+
+	// NodeOSUpgrade upgrades the kernel/OS packages of the storage nodes one at a time and validates Portworx and apps recover
+	NodeOSUpgrade = "nodeOSUpgrade"
 	// AsyncDR runs Async DR between two clusters
 	AsyncDR = "asyncdr"
 	// ConfluentAsyncDR runs Async DR between two clusters for Confluent kafka CRD
 	ConfluentAsyncDR = "ConfluentAsyncDR"
+	// MetroDRDomainFailover takes down a cluster domain of a stretched cluster and validates the apps fail over
+	MetroDRDomainFailover = "metroDRDomainFailover"
 	// AsyncDR Volume Only runs Async DR volume only migration between two clusters
 
 	AsyncDRVolumeOnly = "asyncdrvolumeonly"
@@ -400,6 +463,8 @@ const (
 	StorkAppBkpPxRestart = "storkappbkppxrestart"
 	// stork application backup runs with pool resize
 	StorkAppBkpPoolResize = "storkappbkppoolresize"
+	// stork application clone clones applications to new namespaces with stork
+	StorkApplicationClone = "storkapplicationclone"
 	// HAIncreaseAndReboot performs repl-add
 	HAIncreaseAndReboot = "haIncreaseAndReboot"
 	// AddDrive performs drive add for on-prem cluster
@@ -469,9 +534,11 @@ func TriggerCoreChecker(contexts *[]*scheduler.Context, recordChan *chan *EventR
 func startLongevityTest(testName string) {
 	longevityLogger = CreateLogger(fmt.Sprintf("%s-%s.log", testName, time.Now().Format(time.RFC3339)))
 	log.SetTorpedoFileOutput(longevityLogger)
+	revertutils.BeginScope(fmt.Sprintf("%s-%s", testName, time.Now().Format(time.RFC3339)))
 	dash.TestCaseBegin(testName, fmt.Sprintf("validating %s in longevity cluster", testName), "", nil)
 }
 func endLongevityTest() {
+	ReplayReverts()
 	dash.TestCaseEnd()
 	CloseLogger(longevityLogger)
 }
@@ -754,6 +821,7 @@ func TriggerHAIncrease(contexts *[]*scheduler.Context, recordChan *chan *EventRe
 		event.End = time.Now().Format(time.RFC1123)
 		*recordChan <- event
 	}()
+	defer validateTriggerAlerts(event, HAIncrease)
 	setMetrics(*event)
 
 	expReplMap := make(map[*volume.Volume]int64)
@@ -901,6 +969,7 @@ func TriggerHADecrease(contexts *[]*scheduler.Context, recordChan *chan *EventRe
 		event.End = time.Now().Format(time.RFC1123)
 		*recordChan <- event
 	}()
+	defer validateTriggerAlerts(event, HADecrease)
 	setMetrics(*event)
 
 	expReplMap := make(map[*volume.Volume]int64)
@@ -1359,6 +1428,7 @@ func TriggerRebootNodes(contexts *[]*scheduler.Context, recordChan *chan *EventR
 		event.End = time.Now().Format(time.RFC1123)
 		*recordChan <- event
 	}()
+	defer validateTriggerAlerts(event, RebootNode)
 
 	setMetrics(*event)
 	stepLog := "get all nodes and reboot one by one"
@@ -1634,6 +1704,7 @@ func TriggerCrashNodes(contexts *[]*scheduler.Context, recordChan *chan *EventRe
 		event.End = time.Now().Format(time.RFC1123)
 		*recordChan <- event
 	}()
+	defer validateTriggerAlerts(event, CrashNode)
 	stepLog := "get all nodes and crash one by one"
 	Step(stepLog, func() {
 		log.InfoD(stepLog)
@@ -2183,6 +2254,10 @@ func TriggerCloudSnapShot(contexts *[]*scheduler.Context, recordChan *chan *Even
 						log.InfoD("Got error while getting volume snapshot status :%v", err.Error())
 					}
 					UpdateOutcome(event, err)
+
+					if cloudsnapLocation := GetCloudsnapBackupLocation(); err == nil && cloudsnapLocation != nil {
+						validateCloudsnapLifecycle(event, ctx, v, appNamespace, snapStatuses, cloudsnapLocation)
+					}
 				}
 
 			}
@@ -2193,6 +2268,61 @@ func TriggerCloudSnapShot(contexts *[]*scheduler.Context, recordChan *chan *Even
 
 }
 
+// validateCloudsnapLifecycle validates the cloudsnaps of the volume are present in the objectstore, then deletes the
+// latest scheduled cloudsnap and validates its objects are garbage collected from the objectstore
+func validateCloudsnapLifecycle(event *EventRecord, ctx *scheduler.Context, v *volume.Volume, namespace string,
+	snapStatuses map[storkv1.SchedulePolicyType][]*storkv1.ScheduledVolumeSnapshotStatus, cloudsnapLocation *storkv1.BackupLocation) {
+	params, err := Inst().S.GetVolumeParameters(ctx)
+	if err != nil {
+		UpdateOutcome(event, err)
+		return
+	}
+	stepLog := fmt.Sprintf("validate cloudsnaps of volume %s in the objectstore", v.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		err = Inst().V.ValidateCloudsnapInObjectstore(v.ID, params[v.ID], cloudsnapLocation)
+		UpdateOutcome(event, err)
+	})
+	if err != nil {
+		return
+	}
+
+	// no incremental cloudsnap depends on the latest one, so its objects can be garbage collected right away
+	var latest *storkv1.ScheduledVolumeSnapshotStatus
+	for _, statuses := range snapStatuses {
+		for _, snapStatus := range statuses {
+			if snapStatus.Status == snapv1.VolumeSnapshotConditionReady &&
+				(latest == nil || snapStatus.CreationTimestamp.After(latest.CreationTimestamp.Time)) {
+				latest = snapStatus
+			}
+		}
+	}
+	if latest == nil {
+		return
+	}
+	stepLog = fmt.Sprintf("delete cloudsnap %s of volume %s and validate it is garbage collected", latest.Name, v.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		snapData, err := Inst().S.GetSnapShotData(ctx, latest.Name, namespace)
+		if err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+		if snapData.Spec.PortworxSnapshot == nil {
+			UpdateOutcome(event, fmt.Errorf("volumesnapshotdata %s does not have portworx volume source set", snapData.Metadata.Name))
+			return
+		}
+		cloudsnapID := snapData.Spec.PortworxSnapshot.SnapshotID
+		err = Inst().S.DeleteSnapShot(ctx, latest.Name, namespace)
+		if err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+		err = Inst().V.ValidateCloudsnapDeleted(cloudsnapID, params[v.ID], cloudsnapLocation)
+		UpdateOutcome(event, err)
+	})
+}
+
 // TriggerVolumeDelete delete the volumes
 func TriggerVolumeDelete(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
 	defer ginkgo.GinkgoRecover()
@@ -4040,6 +4170,7 @@ func TriggerPoolResizeDisk(contexts *[]*scheduler.Context, recordChan *chan *Eve
 		event.End = time.Now().Format(time.RFC1123)
 		*recordChan <- event
 	}()
+	defer validateTriggerAlerts(event, PoolResizeDisk)
 
 	setMetrics(*event)
 
@@ -5639,90 +5770,53 @@ func TriggerValidateDeviceMapperCleanup(contexts *[]*scheduler.Context, recordCh
 	updateMetrics(*event)
 }
 
-// TriggerAddDrive performs add drive operation
-func TriggerAddDrive(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+// TriggerValidateReplicaPlacement validates replicas of all the volumes are spread across zones and racks
+// and honor the VolumePlacementStrategy of their storage class
+func TriggerValidateReplicaPlacement(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
 	defer ginkgo.GinkgoRecover()
 	defer endLongevityTest()
-	startLongevityTest(AddDrive)
+	startLongevityTest(ReplicaPlacement)
 	event := &EventRecord{
 		Event: Event{
 			ID:   GenerateUUID(),
-			Type: AddDrive,
+			Type: ReplicaPlacement,
 		},
 		Start:   time.Now().Format(time.RFC1123),
 		Outcome: []error{},
 	}
-
 	defer func() {
 		event.End = time.Now().Format(time.RFC1123)
 		*recordChan <- event
 	}()
 
 	setMetrics(*event)
-	stepLog := fmt.Sprintf("Perform add drive on all the worker nodes")
-	Step(stepLog, func() {
 
-		storageNodes := node.GetStorageNodes()
-
-		isCloudDrive, err := IsCloudDriveInitialised(storageNodes[0])
-		UpdateOutcome(event, err)
-		systemOpts := node.SystemctlOpts{
-			ConnectionOpts: node.ConnectionOpts{
-				Timeout:         defaultTimeout,
-				TimeBeforeRetry: defaultRetryInterval,
-			},
-			Action: "start",
-		}
-		if err == nil && !isCloudDrive {
-			for _, storageNode := range storageNodes {
-				log.InfoD("Get Block drives to add for node %s", storageNode.Name)
-				blockDrives, err := Inst().N.GetBlockDrives(storageNode, systemOpts)
-				UpdateOutcome(event, err)
-
-				drvPaths := make([]string, 5)
-
-				for _, drv := range blockDrives {
-					if drv.MountPoint == "" && drv.FSType == "" && drv.Type == "disk" {
-						drvPaths = append(drvPaths, drv.Path)
-						break
-					}
-				}
-
-				err = Inst().V.AddBlockDrives(&storageNode, drvPaths)
-				if err != nil && strings.Contains(err.Error(), "no block drives available to add") {
-					log.Warn(err.Error())
-					continue
-				}
-
-				UpdateOutcome(event, err)
+	stepLog := "Validate replica placement of all the app volumes"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, ctx := range *contexts {
+			if ctx.SkipVolumeValidation {
+				continue
 			}
-
-			for _, ctx := range *contexts {
-				stepLog = fmt.Sprintf("validating context after add drive on storage nodes")
-				Step(stepLog, func() {
-					log.InfoD(stepLog)
-					errorChan := make(chan error, errorChannelSize)
-					ctx.SkipVolumeValidation = true
-					ValidateContext(ctx, &errorChan)
-					for err := range errorChan {
-						UpdateOutcome(event, err)
-					}
-				})
+			log.Infof("Validating replica placement of app [%s]", ctx.App.Key)
+			for _, err := range GetReplicaPlacementErrors(ctx) {
+				UpdateOutcome(event, err)
 			}
 		}
-
 	})
 	updateMetrics(*event)
 }
 
-// TriggerAsyncDR triggers Async DR
-func TriggerAsyncDR(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
-	log.Infof("Async DR triggered at: %v", time.Now())
+// TriggerEncryptionKeyRotation rotates the per volume or cluster wide secret of the encrypted volumes
+// and validates the data written before the rotation is still read by the apps after a restart
+func TriggerEncryptionKeyRotation(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
 	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(EncryptionKeyRotation)
 	event := &EventRecord{
 		Event: Event{
 			ID:   GenerateUUID(),
-			Type: AsyncDR,
+			Type: EncryptionKeyRotation,
 		},
 		Start:   time.Now().Format(time.RFC1123),
 		Outcome: []error{},
@@ -5734,85 +5828,97 @@ func TriggerAsyncDR(contexts *[]*scheduler.Context, recordChan *chan *EventRecor
 
 	setMetrics(*event)
 
-	chaosLevel := ChaosMap[AsyncDR]
-	var (
-		migrationNamespaces   []string
-		taskNamePrefix        = "async-dr-mig"
-		allMigrations         []*storkapi.Migration
-		includeResourcesFlag  = true
-		startApplicationsFlag = false
-	)
-
-	Step(fmt.Sprintf("Deploy applications for migration, with frequency: %v", chaosLevel), func() {
-
-		// Write kubeconfig files after reading from the config maps created by torpedo deploy script
-		err := asyncdr.WriteKubeconfigToFiles()
-		if err != nil {
-			log.Errorf("Failed to write kubeconfig: %v", err)
-		}
-
-		err = SetSourceKubeConfig()
+	// a secret may be shared by several volumes, which are all rotated along with the first one
+	rotatedSecrets := make(map[string]bool)
+	for _, ctx := range *contexts {
+		vols, params, err := GetEncryptedVolumes(ctx)
 		if err != nil {
-			log.Errorf("Failed to Set source kubeconfig: %v", err)
-		}
-		UpdateOutcome(event, err)
-		for i := 0; i < Inst().GlobalScaleFactor; i++ {
-			taskName := fmt.Sprintf("%s-%d-%s", taskNamePrefix, i, time.Now().Format("15h03m05s"))
-			log.Infof("Task name %s\n", taskName)
-			appContexts := ScheduleApplications(taskName)
-			*contexts = append(*contexts, appContexts...)
-			ValidateApplications(*contexts)
-			for _, ctx := range appContexts {
-				// Override default App readiness time out of 5 mins with 10 mins
-				ctx.ReadinessTimeout = appReadinessTimeout
-				namespace := GetAppNamespace(ctx, taskName)
-				migrationNamespaces = append(migrationNamespaces, namespace)
-			}
-			Step("Create cluster pair between source and destination clusters", func() {
-				// Set cluster context to cluster where torpedo is running
-				ScheduleValidateClusterPair(appContexts[0], false, true, defaultClusterPairDir, false)
-			})
+			UpdateOutcome(event, err)
+			continue
 		}
-
-		log.Infof("Migration Namespaces: %v", migrationNamespaces)
-
-	})
-
-	time.Sleep(5 * time.Minute)
-	log.Info("Start migration")
-
-	for i, currMigNamespace := range migrationNamespaces {
-		migrationName := migrationKey + fmt.Sprintf("%d", i) + time.Now().Format("15h03m05s")
-		currMig, err := asyncdr.CreateMigration(migrationName, currMigNamespace, asyncdr.DefaultClusterPairName, currMigNamespace, &includeResourcesFlag, &startApplicationsFlag)
-		if err != nil {
-			UpdateOutcome(event, fmt.Errorf("failed to create migration: %s in namespace %s. Error: [%v]", migrationKey, currMigNamespace, err))
-		} else {
-			allMigrations = append(allMigrations, currMig)
+		if len(vols) == 0 {
+			continue
 		}
-	}
+		markers := make(map[string]string)
+		stepLog := fmt.Sprintf("rotate encryption keys of %s app's volumes", ctx.App.Key)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			for _, vol := range vols {
+				marker, err := WriteEncryptionMarker(vol)
+				if err != nil {
+					UpdateOutcome(event, err)
+					continue
+				}
+				markers[vol.ID] = marker
+				err = Inst().V.ValidateVolumeEncryption(vol, params[vol.ID], marker)
+				UpdateOutcome(event, err)
 
-	// Validate all migrations
-	for _, mig := range allMigrations {
-		err := storkops.Instance().ValidateMigration(mig.Name, mig.Namespace, migrationRetryTimeout, migrationRetryInterval)
-		if err != nil {
-			UpdateOutcome(event, fmt.Errorf("failed to validate migration: %s in namespace %s. Error: [%v]", mig.Name, mig.Namespace, err))
-		} else {
-			UpdateOutcome(event, err)
-		}
+				secret := params[vol.ID][encryptionSecretNS] + "/" + params[vol.ID][encryptionSecretName]
+				if rotatedSecrets[secret] {
+					continue
+				}
+				rotatedSecrets[secret] = true
+				err = Inst().V.RotateEncryptionKey(vol, params[vol.ID], Inst().SecretType)
+				UpdateOutcome(event, err)
+			}
+		})
+		stepLog = fmt.Sprintf("restart %s app and validate it reads its data with the rotated keys", ctx.App.Key)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			err := Inst().S.DeleteTasks(ctx, nil)
+			if err != nil {
+				PrintDescribeContext(ctx)
+				UpdateOutcome(event, err)
+				return
+			}
+			errorChan := make(chan error, errorChannelSize)
+			ctx.SkipVolumeValidation = true
+			ValidateContext(ctx, &errorChan)
+			ctx.SkipVolumeValidation = false
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+			for _, vol := range vols {
+				marker, ok := markers[vol.ID]
+				if !ok {
+					continue
+				}
+				err = ValidateEncryptionMarker(vol, marker)
+				UpdateOutcome(event, err)
+				err = Inst().V.ValidateVolumeEncryption(vol, params[vol.ID], marker)
+				UpdateOutcome(event, err)
+			}
+		})
 	}
 	updateMetrics(*event)
 }
 
-// TriggerAsyncDRVolumeOnly triggers Async DR
-func TriggerAsyncDRVolumeOnly(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
-	defer endLongevityTest()
-	startLongevityTest(AsyncDRVolumeOnly)
+var (
+	// diskFaultTypes are injected in turn by TriggerDiskFault
+	diskFaultTypes = []node.DiskFaultType{node.DiskFaultDelay, node.DiskFaultFlakey, node.DiskFaultReadOnly, node.DiskFaultError}
+	diskFaultCount = 0
+)
+
+const (
+	// diskFaultDuration reverts a disk fault on the node itself if the trigger does not revert it first
+	diskFaultDuration = 30 * time.Minute
+	// diskFaultObserveTime is the time the apps run on a drive with latency or intermittent errors
+	diskFaultObserveTime = 5 * time.Minute
+	// poolStatusTimeout is the time a pool gets to change its status after a disk fault is injected or reverted
+	poolStatusTimeout = 15 * time.Minute
+)
+
+// TriggerDiskFault injects a delay, flakey, read-only or error fault on a pool drive of a storage node.
+// Apps have to keep running with latency and intermittent errors, and the pool has to go offline when
+// the drive fails its writes. Once reverted the pool and the apps have to recover.
+func TriggerDiskFault(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
 	defer ginkgo.GinkgoRecover()
-	log.InfoD("Volume Only Async DR triggered at: %v", time.Now())
+	defer endLongevityTest()
+	startLongevityTest(DiskFault)
 	event := &EventRecord{
 		Event: Event{
 			ID:   GenerateUUID(),
-			Type: AsyncDRVolumeOnly,
+			Type: DiskFault,
 		},
 		Start:   time.Now().Format(time.RFC1123),
 		Outcome: []error{},
@@ -5824,29 +5930,655 @@ func TriggerAsyncDRVolumeOnly(contexts *[]*scheduler.Context, recordChan *chan *
 
 	setMetrics(*event)
 
-	chaosLevel := ChaosMap[AsyncDRVolumeOnly]
-	var (
-		migrationNamespaces   []string
-		taskNamePrefix        = "adr-vonly"
-		allMigrations         []*storkapi.Migration
-		includeResourcesFlag  = false
-		startApplicationsFlag = false
-	)
-
-	Step(fmt.Sprintf("Deploy applications for volume-only migration, with frequency: %v", chaosLevel), func() {
-
-		// Write kubeconfig files after reading from the config maps created by torpedo deploy script
-		err := asyncdr.WriteKubeconfigToFiles()
-		if err != nil {
-			log.Errorf("Failed to write kubeconfig: %v", err)
+	faultType := diskFaultTypes[diskFaultCount%len(diskFaultTypes)]
+	diskFaultCount++
+	// faults are only injected on pool or journal drives Portworx uses through a device-mapper linear device
+	stNode, poolUUID, drive, err := GetFaultablePoolDrive()
+	if err != nil {
+		log.InfoD("No faultable drive found, wrapping an unused raw drive: %v", err)
+		if _, _, err = AddFaultableDrive(); err == nil {
+			stNode, poolUUID, drive, err = GetFaultablePoolDrive()
 		}
+	}
+	if err != nil {
+		log.InfoD("Skipping %s event, no drive disk faults can be injected on: %v", DiskFault, err)
+		return
+	}
 
-		err = SetSourceKubeConfig()
-		if err != nil {
-			log.Errorf("Failed to Set source kubeconfig: %v", err)
+	// only a failing drive raises drive failure alarms and takes its pool offline, a delayed or
+	// flakey drive must not raise any alarm
+	var expectedAlerts []alertutils.Expectation
+	if faultType == node.DiskFaultError || faultType == node.DiskFaultReadOnly {
+		expectedAlerts = []alertutils.Expectation{
+			alertutils.DriveFailure.ForResource(drive),
+			alertutils.PoolOffline.ForResource(poolUUID),
 		}
-		UpdateOutcome(event, err)
-		for i := 0; i < Inst().GlobalScaleFactor; i++ {
+	}
+	defer validateAlerts(event, DiskFault, expectedAlerts)
+
+	var fault *node.DiskFault
+	stepLog := fmt.Sprintf("inject %s fault on drive [%s] of pool [%s] on node [%s]", faultType, drive, poolUUID, stNode.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		fault, err = Inst().N.InjectDiskFault(stNode, drive, node.DiskFaultOpts{
+			Type:     faultType,
+			Duration: diskFaultDuration,
+			ConnectionOpts: node.ConnectionOpts{
+				Timeout:         defaultTimeout,
+				TimeBeforeRetry: defaultRetryInterval,
+			},
+		})
+		UpdateOutcome(event, err)
+	})
+	if fault == nil {
+		return
+	}
+	defer func() {
+		if err := fault.Revert(); err != nil {
+			UpdateOutcome(event, err)
+		}
+	}()
+
+	stepLog = fmt.Sprintf("validate pool [%s] and apps under %s fault", poolUUID, faultType)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		switch faultType {
+		case node.DiskFaultError, node.DiskFaultReadOnly:
+			err = WaitForPoolStatus(stNode, poolUUID, false, poolStatusTimeout)
+			UpdateOutcome(event, err)
+		default:
+			time.Sleep(diskFaultObserveTime)
+			for _, ctx := range *contexts {
+				errorChan := make(chan error, errorChannelSize)
+				ValidateContext(ctx, &errorChan)
+				for err := range errorChan {
+					UpdateOutcome(event, err)
+				}
+			}
+		}
+	})
+
+	stepLog = fmt.Sprintf("revert %s fault and validate pool [%s] and apps recover", faultType, poolUUID)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		err = fault.Revert()
+		UpdateOutcome(event, err)
+		if err != nil {
+			return
+		}
+		if WaitForPoolStatus(stNode, poolUUID, true, poolStatusTimeout) != nil {
+			log.InfoD("Pool [%s] is still not online, recovering %s on node [%s]", poolUUID, Inst().V.String(), stNode.Name)
+			err = Inst().V.RecoverDriver(stNode)
+			UpdateOutcome(event, err)
+			err = Inst().V.WaitDriverUpOnNode(stNode, Inst().DriverStartTimeout)
+			UpdateOutcome(event, err)
+			err = WaitForPoolStatus(stNode, poolUUID, true, poolStatusTimeout)
+			UpdateOutcome(event, err)
+		}
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+	updateMetrics(*event)
+}
+
+const (
+	// resourcePressureDuration bounds every resource pressure applied by the triggers
+	resourcePressureDuration = 10 * time.Minute
+	// resourcePressureSettleTime is the time a node runs under pressure before it is validated
+	resourcePressureSettleTime = 3 * time.Minute
+	// clockSkewShift is the shift applied to the clock of a node by the clock skew trigger
+	clockSkewShift = 5 * time.Minute
+)
+
+// TriggerCPUPressure burns all the cores of a storage node
+func TriggerCPUPressure(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	triggerResourcePressure(contexts, recordChan, CPUPressure, node.ResourcePressureOpts{Type: node.PressureCPU})
+}
+
+// TriggerMemoryPressure allocates the memory of a storage node up to OOM
+func TriggerMemoryPressure(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	triggerResourcePressure(contexts, recordChan, MemoryPressure, node.ResourcePressureOpts{Type: node.PressureMemory, MemoryPercent: 100})
+}
+
+// TriggerDiskFillPressure fills /var/lib/osd of a storage node
+func TriggerDiskFillPressure(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	triggerResourcePressure(contexts, recordChan, DiskFillPressure, node.ResourcePressureOpts{Type: node.PressureDiskFill})
+}
+
+// TriggerClockSkew moves the clock of a storage node forward
+func TriggerClockSkew(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	triggerResourcePressure(contexts, recordChan, ClockSkew, node.ResourcePressureOpts{Type: node.PressureClockSkew, ClockSkew: clockSkewShift})
+}
+
+// triggerResourcePressure puts a random storage node under the given pressure. Portworx on the node may
+// go down, but the kvdb has to keep its quorum and the node has to recover once the pressure is reverted.
+func triggerResourcePressure(contexts *[]*scheduler.Context, recordChan *chan *EventRecord, trigger string, opts node.ResourcePressureOpts) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(trigger)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: trigger,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+
+	stNodes := node.GetStorageNodes()
+	if len(stNodes) < 2 {
+		UpdateOutcome(event, fmt.Errorf("at least 2 storage nodes are required for %s, found %d", trigger, len(stNodes)))
+		return
+	}
+	rand.Shuffle(len(stNodes), func(i, j int) { stNodes[i], stNodes[j] = stNodes[j], stNodes[i] })
+	stNode, peer := stNodes[0], stNodes[1]
+
+	opts.Duration = resourcePressureDuration
+	opts.ConnectionOpts = node.ConnectionOpts{
+		Timeout:         defaultTimeout,
+		TimeBeforeRetry: defaultRetryInterval,
+	}
+	var pressure *node.ResourcePressure
+	stepLog := fmt.Sprintf("apply %s pressure on node [%s] for %v", opts.Type, stNode.Name, opts.Duration)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		var err error
+		pressure, err = Inst().N.ApplyResourcePressure(stNode, opts)
+		UpdateOutcome(event, err)
+	})
+	if pressure == nil {
+		return
+	}
+	defer func() {
+		if err := pressure.Revert(); err != nil {
+			UpdateOutcome(event, err)
+		}
+	}()
+
+	stepLog = fmt.Sprintf("validate %s and kvdb under %s pressure on node [%s]", Inst().V.String(), opts.Type, stNode.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		time.Sleep(resourcePressureSettleTime)
+		if err := Inst().V.WaitDriverUpOnNode(stNode, defaultTimeout); err != nil {
+			log.InfoD("%s is down on node [%s] under %s pressure: %v", Inst().V.String(), stNode.Name, opts.Type, err)
+		}
+		err := validateKvdbQuorum(peer)
+		UpdateOutcome(event, err)
+	})
+
+	stepLog = fmt.Sprintf("revert %s pressure and validate node [%s] and apps recover", opts.Type, stNode.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		err := pressure.Revert()
+		UpdateOutcome(event, err)
+		err = Inst().V.WaitDriverUpOnNode(stNode, Inst().DriverStartTimeout)
+		UpdateOutcome(event, err)
+		err = validateKvdbQuorum(peer)
+		UpdateOutcome(event, err)
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+	updateMetrics(*event)
+}
+
+// validateKvdbQuorum validates the majority of the kvdb members are healthy, as seen from the given node
+func validateKvdbQuorum(n node.Node) error {
+	t := func() (interface{}, bool, error) {
+		members, err := Inst().V.GetKvdbMembers(n)
+		if err != nil {
+			return nil, true, err
+		}
+		healthy := 0
+		for _, m := range members {
+			if m.IsHealthy {
+				healthy++
+			}
+		}
+		if healthy <= len(members)/2 {
+			return nil, true, fmt.Errorf("kvdb lost quorum, %d of %d members are healthy", healthy, len(members))
+		}
+		return nil, false, nil
+	}
+	_, err := task.DoRetryWithTimeout(t, defaultTimeout, defaultRetryInterval)
+	return err
+}
+
+const (
+	// nodeOSUpgradePackagesEnv lists the packages installed by the node OS upgrade trigger, separated by
+	// commas. The trigger is skipped when it is not set
+	nodeOSUpgradePackagesEnv = "NODE_OS_UPGRADE_PACKAGES"
+	// nodeOSUpgradeRebootTimeout is how long a node has to come back after rebooting into its upgrade
+	nodeOSUpgradeRebootTimeout = 20 * time.Minute
+	// nodeOSUpgradeInstallTimeout bounds the package installation on a node
+	nodeOSUpgradeInstallTimeout = 30 * time.Minute
+)
+
+// TriggerNodeOSUpgrade installs the packages of NODE_OS_UPGRADE_PACKAGES on the storage nodes one at a time,
+// reboots each node into them and validates Portworx, kvdb and the apps recover before moving to the next node
+func TriggerNodeOSUpgrade(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(NodeOSUpgrade)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: NodeOSUpgrade,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+
+	var packages []string
+	for _, p := range strings.Split(os.Getenv(nodeOSUpgradePackagesEnv), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			packages = append(packages, p)
+		}
+	}
+	if len(packages) == 0 {
+		log.InfoD("Skipping %s event, no packages to install given in %s", NodeOSUpgrade, nodeOSUpgradePackagesEnv)
+		return
+	}
+	stNodes := node.GetStorageNodes()
+	if len(stNodes) < 2 {
+		UpdateOutcome(event, fmt.Errorf("at least 2 storage nodes are required for %s, found %d", NodeOSUpgrade, len(stNodes)))
+		return
+	}
+
+	for i, n := range stNodes {
+		peer := stNodes[(i+1)%len(stNodes)]
+		upgraded := false
+		stepLog := fmt.Sprintf("upgrade OS of node [%s] with packages %v", n.Name, packages)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			kernel, err := Inst().N.UpgradeNodeOS(n, node.OSUpgradeOpts{
+				Packages:      packages,
+				RebootTimeout: nodeOSUpgradeRebootTimeout,
+				ConnectionOpts: node.ConnectionOpts{
+					Timeout:         nodeOSUpgradeInstallTimeout,
+					TimeBeforeRetry: defaultRetryInterval,
+				},
+			})
+			UpdateOutcome(event, err)
+			if err == nil {
+				upgraded = true
+				event.Event.Type += fmt.Sprintf("<br>%s: %s", n.Name, kernel)
+				log.InfoD("Node [%s] is running kernel %s", n.Name, kernel)
+			}
+		})
+		if !upgraded {
+			// the next nodes are not upgraded while this one may still be down
+			break
+		}
+
+		stepLog = fmt.Sprintf("validate %s and apps recover after OS upgrade of node [%s]", Inst().V.String(), n.Name)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			err := Inst().S.IsNodeReady(n)
+			UpdateOutcome(event, err)
+			err = Inst().V.WaitDriverUpOnNode(n, Inst().DriverStartTimeout)
+			UpdateOutcome(event, err)
+			err = validateKvdbQuorum(peer)
+			UpdateOutcome(event, err)
+			for _, ctx := range *contexts {
+				errorChan := make(chan error, errorChannelSize)
+				ValidateContext(ctx, &errorChan)
+				for err := range errorChan {
+					UpdateOutcome(event, err)
+				}
+			}
+		})
+	}
+	updateMetrics(*event)
+}
+
+// TriggerAddDrive performs add drive operation
+func TriggerAddDrive(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(AddDrive)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: AddDrive,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+	stepLog := fmt.Sprintf("Perform add drive on all the worker nodes")
+	Step(stepLog, func() {
+
+		storageNodes := node.GetStorageNodes()
+
+		isCloudDrive, err := IsCloudDriveInitialised(storageNodes[0])
+		UpdateOutcome(event, err)
+		systemOpts := node.SystemctlOpts{
+			ConnectionOpts: node.ConnectionOpts{
+				Timeout:         defaultTimeout,
+				TimeBeforeRetry: defaultRetryInterval,
+			},
+			Action: "start",
+		}
+		if err == nil && !isCloudDrive {
+			for _, storageNode := range storageNodes {
+				log.InfoD("Get Block drives to add for node %s", storageNode.Name)
+				blockDrives, err := Inst().N.GetBlockDrives(storageNode, systemOpts)
+				UpdateOutcome(event, err)
+
+				drvPaths := make([]string, 5)
+
+				for _, drv := range blockDrives {
+					if drv.MountPoint == "" && drv.FSType == "" && drv.Type == "disk" {
+						drvPaths = append(drvPaths, drv.Path)
+						break
+					}
+				}
+
+				err = Inst().V.AddBlockDrives(&storageNode, drvPaths)
+				if err != nil && strings.Contains(err.Error(), "no block drives available to add") {
+					log.Warn(err.Error())
+					continue
+				}
+
+				UpdateOutcome(event, err)
+			}
+
+			for _, ctx := range *contexts {
+				stepLog = fmt.Sprintf("validating context after add drive on storage nodes")
+				Step(stepLog, func() {
+					log.InfoD(stepLog)
+					errorChan := make(chan error, errorChannelSize)
+					ctx.SkipVolumeValidation = true
+					ValidateContext(ctx, &errorChan)
+					for err := range errorChan {
+						UpdateOutcome(event, err)
+					}
+				})
+			}
+		}
+
+	})
+	updateMetrics(*event)
+}
+
+// TriggerMetroDRDomainFailover powers off all the nodes of a cluster domain of a stretched cluster, validates the
+// apps fail over to the surviving domains and brings the domain back. Domains holding the nodes running torpedo,
+// stork or a control plane, or all the nodes of the source cluster, are never taken down.
+func TriggerMetroDRDomainFailover(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(MetroDRDomainFailover)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: MetroDRDomainFailover,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+
+	topology, err := GetMetroDRTopology()
+	if err != nil {
+		UpdateOutcome(event, err)
+		return
+	}
+	protected, err := GetMetroDRProtectedNodes()
+	if err != nil {
+		UpdateOutcome(event, err)
+		return
+	}
+	// the apps of the trigger are not paired with another cluster, so they only fail over within the source cluster
+	sourceDomain, _ := GetMetroDRSourceDomain(topology)
+	var domains []string
+	for _, d := range topology.DomainsWithout(protected) {
+		if d != sourceDomain {
+			domains = append(domains, d)
+		}
+	}
+	if len(domains) == 0 {
+		log.InfoD("Skipping %s, every cluster domain of %v holds the source cluster or some of the nodes %v running torpedo, stork or a control plane",
+			MetroDRDomainFailover, topology.DomainNames(), protected)
+		return
+	}
+	domain := domains[rand.Intn(len(domains))]
+	surviving := topology.SurvivingDomains(domain)
+	event.Event.Type += fmt.Sprintf("<br>domain: %s", domain)
+
+	stepLog := fmt.Sprintf("power off the nodes of cluster domain [%s]", domain)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		err = StopClusterDomain(topology, domain)
+		UpdateOutcome(event, err)
+	})
+
+	if err == nil {
+		stepLog = fmt.Sprintf("validate apps fail over to cluster domains %v", surviving)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			for _, ctx := range *contexts {
+				errorChan := make(chan error, errorChannelSize)
+				ValidateContext(ctx, &errorChan)
+				for err := range errorChan {
+					UpdateOutcome(event, err)
+				}
+			}
+			err := ValidateAppsInClusterDomains(*contexts, topology, surviving)
+			UpdateOutcome(event, err)
+		})
+	}
+
+	stepLog = fmt.Sprintf("bring back cluster domain [%s]", domain)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		err := StartClusterDomain(topology, domain)
+		UpdateOutcome(event, err)
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+	updateMetrics(*event)
+}
+
+// TriggerAsyncDR triggers Async DR
+func TriggerAsyncDR(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	log.Infof("Async DR triggered at: %v", time.Now())
+	defer ginkgo.GinkgoRecover()
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: AsyncDR,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+
+	chaosLevel := ChaosMap[AsyncDR]
+	var (
+		migrationNamespaces   []string
+		namespaceContexts     = make(map[string]*scheduler.Context)
+		migratedNamespaces    []string
+		taskNamePrefix        = "async-dr-mig"
+		allMigrations         []*storkapi.Migration
+		includeResourcesFlag  = true
+		startApplicationsFlag = false
+	)
+
+	Step(fmt.Sprintf("Deploy applications for migration, with frequency: %v", chaosLevel), func() {
+
+		// Write kubeconfig files after reading from the config maps created by torpedo deploy script
+		err := asyncdr.WriteKubeconfigToFiles()
+		if err != nil {
+			log.Errorf("Failed to write kubeconfig: %v", err)
+		}
+
+		err = SetSourceKubeConfig()
+		if err != nil {
+			log.Errorf("Failed to Set source kubeconfig: %v", err)
+		}
+		UpdateOutcome(event, err)
+		for i := 0; i < Inst().GlobalScaleFactor; i++ {
+			taskName := fmt.Sprintf("%s-%d-%s", taskNamePrefix, i, time.Now().Format("15h03m05s"))
+			log.Infof("Task name %s\n", taskName)
+			appContexts := ScheduleApplications(taskName)
+			*contexts = append(*contexts, appContexts...)
+			ValidateApplications(*contexts)
+			for _, ctx := range appContexts {
+				// Override default App readiness time out of 5 mins with 10 mins
+				ctx.ReadinessTimeout = appReadinessTimeout
+				namespace := GetAppNamespace(ctx, taskName)
+				migrationNamespaces = append(migrationNamespaces, namespace)
+				namespaceContexts[namespace] = ctx
+			}
+			Step("Create cluster pair between source and destination clusters", func() {
+				// Set cluster context to cluster where torpedo is running
+				ScheduleValidateClusterPair(appContexts[0], false, true, defaultClusterPairDir, false)
+			})
+		}
+
+		log.Infof("Migration Namespaces: %v", migrationNamespaces)
+
+	})
+
+	time.Sleep(5 * time.Minute)
+	log.Info("Start migration")
+
+	for i, currMigNamespace := range migrationNamespaces {
+		migrationName := migrationKey + fmt.Sprintf("%d", i) + time.Now().Format("15h03m05s")
+		currMig, err := asyncdr.CreateMigration(migrationName, currMigNamespace, asyncdr.DefaultClusterPairName, currMigNamespace, &includeResourcesFlag, &startApplicationsFlag)
+		if err != nil {
+			UpdateOutcome(event, fmt.Errorf("failed to create migration: %s in namespace %s. Error: [%v]", migrationKey, currMigNamespace, err))
+		} else {
+			allMigrations = append(allMigrations, currMig)
+		}
+	}
+
+	// Validate all migrations
+	for _, mig := range allMigrations {
+		err := storkops.Instance().ValidateMigration(mig.Name, mig.Namespace, migrationRetryTimeout, migrationRetryInterval)
+		if err != nil {
+			UpdateOutcome(event, fmt.Errorf("failed to validate migration: %s in namespace %s. Error: [%v]", mig.Name, mig.Namespace, err))
+		} else {
+			UpdateOutcome(event, err)
+			migratedNamespaces = append(migratedNamespaces, mig.Namespace)
+		}
+	}
+
+	Step("Fail over the migrated applications and fail them back", func() {
+		for _, namespace := range migratedNamespaces {
+			cfg, err := NewAsyncDRConfig(namespaceContexts[namespace], namespace, "")
+			if err != nil {
+				UpdateOutcome(event, err)
+				continue
+			}
+			err = asyncdr.Failover(cfg)
+			if err != nil {
+				UpdateOutcome(event, fmt.Errorf("failover of namespace %s failed. Error: [%v]", namespace, err))
+				continue
+			}
+			mig, err := asyncdr.Failback(cfg, migrationKey+"failback-"+time.Now().Format("15h03m05s"))
+			if err != nil {
+				UpdateOutcome(event, fmt.Errorf("failback of namespace %s failed. Error: [%v]", namespace, err))
+			}
+			if mig != nil {
+				SetDestinationKubeConfig()
+				err = asyncdr.DeleteAndWaitForMigrationDeletion(mig.Name, mig.Namespace)
+				UpdateOutcome(event, err)
+			}
+		}
+		err := SetSourceKubeConfig()
+		UpdateOutcome(event, err)
+	})
+	updateMetrics(*event)
+}
+
+// TriggerAsyncDRVolumeOnly triggers Async DR
+func TriggerAsyncDRVolumeOnly(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer endLongevityTest()
+	startLongevityTest(AsyncDRVolumeOnly)
+	defer ginkgo.GinkgoRecover()
+	log.InfoD("Volume Only Async DR triggered at: %v", time.Now())
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: AsyncDRVolumeOnly,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+
+	chaosLevel := ChaosMap[AsyncDRVolumeOnly]
+	var (
+		migrationNamespaces   []string
+		taskNamePrefix        = "adr-vonly"
+		allMigrations         []*storkapi.Migration
+		includeResourcesFlag  = false
+		startApplicationsFlag = false
+	)
+
+	Step(fmt.Sprintf("Deploy applications for volume-only migration, with frequency: %v", chaosLevel), func() {
+
+		// Write kubeconfig files after reading from the config maps created by torpedo deploy script
+		err := asyncdr.WriteKubeconfigToFiles()
+		if err != nil {
+			log.Errorf("Failed to write kubeconfig: %v", err)
+		}
+
+		err = SetSourceKubeConfig()
+		if err != nil {
+			log.Errorf("Failed to Set source kubeconfig: %v", err)
+		}
+		UpdateOutcome(event, err)
+		for i := 0; i < Inst().GlobalScaleFactor; i++ {
 			taskName := fmt.Sprintf("%s-%d", taskNamePrefix, i)
 			log.InfoD("Task name %s\n", taskName)
 			appContexts := ScheduleApplications(taskName)
@@ -5924,10 +6656,11 @@ func TriggerStorkApplicationBackup(contexts *[]*scheduler.Context, recordChan *c
 	chaosLevel := ChaosMap[StorkApplicationBackup]
 
 	var (
-		s3SecretName     = "s3secret"
-		backupNamespaces []string
-		timeout          = 5 * time.Minute
-		taskNamePrefix   = "stork-app-backup"
+		s3SecretName      = "s3secret"
+		backupNamespaces  []string
+		namespaceContexts = make(map[string][]*scheduler.Context)
+		timeout           = 5 * time.Minute
+		taskNamePrefix    = "stork-app-backup"
 	)
 
 	Step(fmt.Sprintf("Deploy applications for backup, with frequency: %v", chaosLevel), func() {
@@ -5952,6 +6685,7 @@ func TriggerStorkApplicationBackup(contexts *[]*scheduler.Context, recordChan *c
 			for _, ctx := range appContexts {
 				namespace := GetAppNamespace(ctx, taskName)
 				backupNamespaces = append(backupNamespaces, namespace)
+				namespaceContexts[namespace] = append(namespaceContexts[namespace], ctx)
 			}
 		}
 		log.Infof("Backup applications, present in namespaces - %v", backupNamespaces)
@@ -5965,9 +6699,10 @@ func TriggerStorkApplicationBackup(contexts *[]*scheduler.Context, recordChan *c
 				UpdateOutcome(event, fmt.Errorf("backup location creation failed with %v", err))
 				return
 			}
-			_, bkp_create_err := applicationbackup.CreateApplicationBackup(backupname, currbkNamespace, currBackupLocation)
+			bkp, bkp_create_err := applicationbackup.CreateApplicationBackup(backupname, currbkNamespace, currBackupLocation)
 			if bkp_create_err != nil {
 				UpdateOutcome(event, fmt.Errorf("backup creation failed with %v", bkp_create_err))
+				return
 			}
 			bkp_comp_err := applicationbackup.WaitForAppBackupCompletion(backupname, currbkNamespace, timeout)
 			if bkp_comp_err != nil {
@@ -5975,11 +6710,46 @@ func TriggerStorkApplicationBackup(contexts *[]*scheduler.Context, recordChan *c
 				return
 			}
 			log.InfoD("backup successful, backup name - %v, backup location - %v", backupname, backuplocationname)
+			restoreAndValidateStorkAppBackup(event, bkp, namespaceContexts[currbkNamespace], timeout)
 		}
 		updateMetrics(*event)
 	})
 }
 
+// restoreAndValidateStorkAppBackup restores a stork application backup over the applications it backed up and
+// validates the restored applications. Restores outside the admin namespace of stork can only restore to the
+// namespace of the backup.
+func restoreAndValidateStorkAppBackup(event *EventRecord, bkp *storkv1.ApplicationBackup, appContexts []*scheduler.Context, timeout time.Duration) {
+	restoreName := fmt.Sprintf("%s-restore", bkp.Name)
+	namespaceMapping := map[string]string{bkp.Namespace: bkp.Namespace}
+	log.InfoD("Restoring backup %s in namespace %s", bkp.Name, bkp.Namespace)
+	_, err := applicationbackup.CreateApplicationRestore(restoreName, bkp, namespaceMapping, storkv1.ApplicationRestoreReplacePolicyDelete)
+	if err != nil {
+		UpdateOutcome(event, fmt.Errorf("restore creation failed with %v", err))
+		return
+	}
+	defer func() {
+		err := storkops.Instance().DeleteApplicationRestore(restoreName, bkp.Namespace)
+		UpdateOutcome(event, err)
+	}()
+	err = applicationbackup.WaitForAppRestoreCompletion(restoreName, bkp.Namespace, timeout)
+	if err != nil {
+		UpdateOutcome(event, fmt.Errorf("restore %s failed with %v", restoreName, err))
+		return
+	}
+	restoredContexts, err := RestoredContexts(appContexts, namespaceMapping)
+	if err != nil {
+		UpdateOutcome(event, err)
+		return
+	}
+	err = applicationbackup.ValidateRestoredApplications(Inst().S, restoredContexts, timeout, defaultRetryInterval)
+	if err != nil {
+		UpdateOutcome(event, fmt.Errorf("validation of restore %s failed with %v", restoreName, err))
+		return
+	}
+	log.InfoD("restore successful, restore name - %v", restoreName)
+}
+
 func TriggerStorkAppBkpVolResize(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
 	defer endLongevityTest()
 	startLongevityTest(StorkAppBkpVolResize)
@@ -6046,7 +6816,7 @@ func TriggerStorkAppBkpVolResize(contexts *[]*scheduler.Context, recordChan *cha
 					UpdateOutcome(event, fmt.Errorf("backup location creation failed with %v", err))
 					return
 				}
-				_, bkp_create_err := applicationbackup.CreateApplicationBackup(backupname, currbkNamespace, currBackupLocation)
+				bkp, bkp_create_err := applicationbackup.CreateApplicationBackup(backupname, currbkNamespace, currBackupLocation)
 				if bkp_create_err != nil {
 					UpdateOutcome(event, fmt.Errorf("backup creation failed with %v", bkp_create_err))
 					return
@@ -6074,6 +6844,7 @@ func TriggerStorkAppBkpVolResize(contexts *[]*scheduler.Context, recordChan *cha
 					return
 				}
 				log.InfoD("backup successful and volume resize injected during backup successfully, backup name - %v, backup location - %v", backupname, backuplocationname)
+				restoreAndValidateStorkAppBackup(event, bkp, []*scheduler.Context{ctx}, timeout)
 			}
 			updateMetrics(*event)
 		}
@@ -6305,6 +7076,7 @@ func TriggerStorkAppBkpPxRestart(contexts *[]*scheduler.Context, recordChan *cha
 					return
 				}
 				log.InfoD("backup successful and px restart injected during backup successfully, backup name - %v, backup location - %v", bkp.Name, currBackupLocation.Name)
+				restoreAndValidateStorkAppBackup(event, bkp, []*scheduler.Context{ctx}, timeout)
 			}
 		}
 		updateMetrics(*event)
@@ -6422,13 +7194,115 @@ func TriggerStorkAppBkpPoolResize(contexts *[]*scheduler.Context, recordChan *ch
 					return
 				}
 				log.InfoD("backup successful and pool resize injected during backup successfully, backup name - %v, backup location - %v", bkp.Name, currBackupLocation.Name)
+				restoreAndValidateStorkAppBackup(event, bkp, []*scheduler.Context{ctx}, timeout)
+
+			}
+		}
+		updateMetrics(*event)
+	})
+}
+
+// TriggerStorkApplicationClone clones the namespaces of the applications with a stork ApplicationClone and
+// validates the cloned applications run in the clone namespaces
+func TriggerStorkApplicationClone(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer endLongevityTest()
+	startLongevityTest(StorkApplicationClone)
+	defer ginkgo.GinkgoRecover()
+	log.InfoD("Stork Application Clone triggered at: %v", time.Now())
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: StorkApplicationClone,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+	setMetrics(*event)
+	chaosLevel := ChaosMap[StorkApplicationClone]
+
+	var (
+		cloneNamespaces   []string
+		namespaceContexts = make(map[string][]*scheduler.Context)
+		timeout           = 5 * time.Minute
+		taskNamePrefix    = "stork-app-clone"
+	)
+
+	Step(fmt.Sprintf("Deploy applications and clone them to new namespaces, with frequency: %v", chaosLevel), func() {
 
+		// Write kubeconfig files after reading from the config maps created by torpedo deploy script
+		err := asyncdr.WriteKubeconfigToFiles()
+		if err != nil {
+			UpdateOutcome(event, fmt.Errorf("unable to write kubeconfigs, getting error %v", err))
+			return
+		}
+		err = SetSourceKubeConfig()
+		if err != nil {
+			UpdateOutcome(event, fmt.Errorf("getting error in setting source kubeconfig %v", err))
+			return
+		}
+		for i := 0; i < Inst().GlobalScaleFactor; i++ {
+			taskName := fmt.Sprintf("%s-%d", taskNamePrefix, i)
+			log.Infof("Task name %s\n", taskName)
+			appContexts := ScheduleApplications(taskName)
+			*contexts = append(*contexts, appContexts...)
+			ValidateApplications(*contexts)
+			for _, ctx := range appContexts {
+				namespace := GetAppNamespace(ctx, taskName)
+				if _, ok := namespaceContexts[namespace]; !ok {
+					cloneNamespaces = append(cloneNamespaces, namespace)
+				}
+				namespaceContexts[namespace] = append(namespaceContexts[namespace], ctx)
 			}
 		}
+		log.Infof("Clone applications, present in namespaces - %v", cloneNamespaces)
+		for _, namespace := range cloneNamespaces {
+			cloneAndValidateStorkApps(event, namespace, namespaceContexts[namespace], timeout)
+		}
 		updateMetrics(*event)
 	})
 }
 
+// cloneAndValidateStorkApps clones the applications of the namespace to a new namespace with stork, validates
+// the cloned applications and deletes them
+func cloneAndValidateStorkApps(event *EventRecord, namespace string, appContexts []*scheduler.Context, timeout time.Duration) {
+	cloneName := fmt.Sprintf("%s-clone-%s", namespace, time.Now().Format("15h03m05s"))
+	namespaceMapping := map[string]string{namespace: cloneName}
+	log.InfoD("Cloning applications of namespace %s to namespace %s", namespace, cloneName)
+	_, err := applicationbackup.CreateApplicationClone(cloneName, storkAdminNamespace, namespace, cloneName,
+		storkv1.ApplicationCloneReplacePolicyDelete)
+	if err != nil {
+		UpdateOutcome(event, fmt.Errorf("clone creation failed with %v", err))
+		return
+	}
+	defer func() {
+		err := storkops.Instance().DeleteApplicationClone(cloneName, storkAdminNamespace)
+		UpdateOutcome(event, err)
+		// the clone leaves the cloned applications behind
+		err = core.Instance().DeleteNamespace(cloneName)
+		UpdateOutcome(event, err)
+	}()
+	err = applicationbackup.WaitForAppCloneCompletion(cloneName, storkAdminNamespace, timeout)
+	if err != nil {
+		UpdateOutcome(event, fmt.Errorf("clone %s failed with %v", cloneName, err))
+		return
+	}
+	clonedContexts, err := RestoredContexts(appContexts, namespaceMapping)
+	if err != nil {
+		UpdateOutcome(event, err)
+		return
+	}
+	err = applicationbackup.ValidateRestoredApplications(Inst().S, clonedContexts, timeout, defaultRetryInterval)
+	if err != nil {
+		UpdateOutcome(event, fmt.Errorf("validation of clone %s failed with %v", cloneName, err))
+		return
+	}
+	log.InfoD("clone successful, clone name - %v", cloneName)
+}
+
 func TriggerConfluentAsyncDR(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
 	defer endLongevityTest()
 	startLongevityTest(ConfluentAsyncDR)